@@ -25,11 +25,39 @@ const (
 	BookingStatusMissed    = "missed"
 	BookingStatusNoShow    = "no_show"
 
+	// Booking series statuses
+	SeriesStatusActive    = "active"
+	SeriesStatusCancelled = "cancelled"
+
+	// Phạm vi áp dụng khi hủy/dời một buổi trong chuỗi lịch
+	SeriesScopeThis      = "this"
+	SeriesScopeFollowing = "following"
+
+	// Waitlist entry statuses
+	WaitlistStatusWaiting   = "waiting"
+	WaitlistStatusOffered   = "offered"
+	WaitlistStatusBooked    = "booked"
+	WaitlistStatusExpired   = "expired"
+	WaitlistStatusCancelled = "cancelled"
+
+	// Slot hold statuses (slot được giữ tạm cho người trong waitlist)
+	SlotHoldStatusActive   = "active"
+	SlotHoldStatusClaimed  = "claimed"
+	SlotHoldStatusExpired  = "expired"
+	SlotHoldStatusReleased = "released"
+
+	// Group session statuses
+	GroupSessionStatusScheduled = "scheduled"
+	GroupSessionStatusCancelled = "cancelled"
+	GroupSessionStatusCompleted = "completed"
+
 	// Payment statuses
 	PaymentStatusPending  = "pending"
 	PaymentStatusPaid     = "paid"
 	PaymentStatusRefunded = "refunded"
 	PaymentStatusFailed   = "failed"
+	// Đã hoàn một phần số tiền thanh toán (ví dụ huỷ muộn theo chính sách hoàn tiền)
+	PaymentStatusPartiallyRefunded = "partially_refunded"
 
 	// Transaction statuses
 	TransactionStatusPending    = "pending"
@@ -39,6 +67,32 @@ const (
 	TransactionStatusRefunded   = "refunded"
 	TransactionStatusCancelled  = "cancelled"
 
+	// Transaction types
+	TransactionTypePayment = "payment"
+	TransactionTypeRefund  = "refund"
+
+	// Cancellation actors (dùng cho chính sách hoàn tiền)
+	CancelledByUser   = "user"
+	CancelledByExpert = "expert"
+	CancelledBySystem = "system"
+
+	// Payout ledger entry types
+	LedgerEntryCredit = "credit"
+	LedgerEntryDebit  = "debit"
+
+	// Payout statement statuses
+	PayoutStatusPending = "pending"
+	PayoutStatusPaid    = "paid"
+
+	// Coupon discount types
+	DiscountTypePercentage = "percentage"
+	DiscountTypeFixed      = "fixed"
+
+	// Coupon redemption statuses
+	RedemptionStatusApplied  = "applied"  // Đã áp dụng khi đặt lịch, chờ thanh toán
+	RedemptionStatusRedeemed = "redeemed" // Giao dịch thanh toán đã hoàn tất
+	RedemptionStatusReleased = "released" // Booking bị hủy trước khi thanh toán, trả lại lượt dùng
+
 	// Job statuses
 	JobStatusPending    = "pending"
 	JobStatusProcessing = "processing"
@@ -46,15 +100,6 @@ const (
 	JobStatusFailed     = "failed"
 	JobStatusRetrying   = "retrying"
 
-	// Days of week (0 = Sunday, 6 = Saturday)
-	DaySunday    = 0
-	DayMonday    = 1
-	DayTuesday   = 2
-	DayWednesday = 3
-	DayThursday  = 4
-	DayFriday    = 5
-	DaySaturday  = 6
-
 	// Default currency
 	CurrencyVND = "VND"
 	CurrencyUSD = "USD"
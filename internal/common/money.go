@@ -0,0 +1,53 @@
+package common
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// currencyMinorDigits là số chữ số thập phân của đơn vị tiền nhỏ nhất (VND không có xu, USD có cent)
+var currencyMinorDigits = map[string]int{
+	CurrencyVND: 0,
+	CurrencyUSD: 2,
+}
+
+// IsSupportedCurrency kiểm tra mã tiền tệ ISO 4217 có được hệ thống hỗ trợ không
+func IsSupportedCurrency(currency string) bool {
+	_, ok := currencyMinorDigits[currency]
+	return ok
+}
+
+// NormalizeCurrency chuẩn hoá mã tiền tệ, rỗng thì mặc định VND
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return CurrencyVND
+	}
+	return currency
+}
+
+// MinorUnitFactor trả về 10^digits của tiền tệ (1 với VND, 100 với USD)
+func MinorUnitFactor(currency string) float64 {
+	return math.Pow10(currencyMinorDigits[currency])
+}
+
+// ToMinorUnits đổi số tiền dạng thập phân sang đơn vị nhỏ nhất, làm tròn về số nguyên gần nhất
+func ToMinorUnits(amount float64, currency string) int64 {
+	return int64(math.Round(amount * MinorUnitFactor(currency)))
+}
+
+// FromMinorUnits đổi số tiền ở đơn vị nhỏ nhất về dạng thập phân để hiển thị/gửi gateway
+func FromMinorUnits(minor int64, currency string) float64 {
+	return float64(minor) / MinorUnitFactor(currency)
+}
+
+// FormatMinorUnits định dạng số tiền ở đơn vị nhỏ nhất với đúng số chữ số thập phân của tiền tệ (vd: 150000 VND, 12.50 USD)
+func FormatMinorUnits(minor int64, currency string) string {
+	return strconv.FormatFloat(FromMinorUnits(minor, currency), 'f', currencyMinorDigits[currency], 64)
+}
+
+// PercentOfMinor tính percentage% của một số tiền ở đơn vị nhỏ nhất, làm tròn về số nguyên gần nhất
+func PercentOfMinor(minor int64, percentage float64) int64 {
+	return int64(math.Round(float64(minor) * percentage / 100))
+}
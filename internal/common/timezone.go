@@ -0,0 +1,72 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // Nhúng tzdata để LoadLocation chạy được trên image không có /usr/share/zoneinfo
+)
+
+// DefaultTimeZone là múi giờ IANA mặc định cho user/expert chưa cấu hình múi giờ
+const DefaultTimeZone = "Asia/Ho_Chi_Minh"
+
+var locationCache sync.Map // map[string]*time.Location
+
+// ValidateTimeZone kiểm tra tên múi giờ IANA (vd "Asia/Ho_Chi_Minh", "Europe/Berlin") có hợp lệ không
+func ValidateTimeZone(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("time zone is required")
+	}
+	if _, err := LoadLocation(name); err != nil {
+		return fmt.Errorf("invalid time zone %q", name)
+	}
+	return nil
+}
+
+// LoadLocation nạp múi giờ IANA, có cache vì time.LoadLocation đọc tzdata mỗi lần gọi
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
+// LocationOrDefault trả về múi giờ theo tên, rỗng hoặc không hợp lệ thì dùng DefaultTimeZone
+func LocationOrDefault(name string) *time.Location {
+	if name = strings.TrimSpace(name); name != "" {
+		if loc, err := LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	if loc, err := LoadLocation(DefaultTimeZone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// FormatInZone định dạng t theo múi giờ của người nhận, kèm tên múi giờ (vd "15:04 02/01/2006 (GMT+7)")
+func FormatInZone(t time.Time, timeZone, layout string) string {
+	local := t.In(LocationOrDefault(timeZone))
+	_, offset := local.Zone()
+	return fmt.Sprintf("%s (%s)", local.Format(layout), gmtOffset(offset))
+}
+
+func gmtOffset(offsetSeconds int) string {
+	sign := "+"
+	if offsetSeconds < 0 {
+		sign = "-"
+		offsetSeconds = -offsetSeconds
+	}
+	hours, minutes := offsetSeconds/3600, (offsetSeconds%3600)/60
+	if minutes == 0 {
+		return fmt.Sprintf("GMT%s%d", sign, hours)
+	}
+	return fmt.Sprintf("GMT%s%d:%02d", sign, hours, minutes)
+}
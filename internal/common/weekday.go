@@ -0,0 +1,45 @@
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+// Weekday là quy ước ngày trong tuần duy nhất của hệ thống, trùng với time.Weekday (0 = Chủ nhật, 6 = Thứ 7).
+// Cột day_of_week của giờ làm việc, bộ sinh slot và bước kiểm tra khi đặt lịch đều dùng kiểu này.
+type Weekday int
+
+const (
+	DaySunday    Weekday = Weekday(time.Sunday)
+	DayMonday    Weekday = Weekday(time.Monday)
+	DayTuesday   Weekday = Weekday(time.Tuesday)
+	DayWednesday Weekday = Weekday(time.Wednesday)
+	DayThursday  Weekday = Weekday(time.Thursday)
+	DayFriday    Weekday = Weekday(time.Friday)
+	DaySaturday  Weekday = Weekday(time.Saturday)
+)
+
+// WeekdayOf trả về ngày trong tuần của t theo múi giờ t đang mang; muốn theo múi giờ expert thì truyền t.In(loc)
+func WeekdayOf(t time.Time) Weekday {
+	return Weekday(t.Weekday())
+}
+
+// Valid kiểm tra w nằm trong 0..6
+func (w Weekday) Valid() bool {
+	return w >= DaySunday && w <= DaySaturday
+}
+
+// ValidateWeekday trả về lỗi khi day_of_week ngoài 0..6
+func ValidateWeekday(w Weekday) error {
+	if !w.Valid() {
+		return fmt.Errorf("invalid day_of_week %d: must be between 0 (Sunday) and 6 (Saturday)", int(w))
+	}
+	return nil
+}
+
+func (w Weekday) String() string {
+	if !w.Valid() {
+		return fmt.Sprintf("Weekday(%d)", int(w))
+	}
+	return time.Weekday(w).String()
+}
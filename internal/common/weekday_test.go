@@ -0,0 +1,46 @@
+package common
+
+import (
+	"testing"
+	"testing/quick"
+	"time"
+	_ "time/tzdata"
+)
+
+var weekdayTestZones = []string{"UTC", "Asia/Ho_Chi_Minh", "America/New_York", "Europe/London", "Australia/Sydney", "Pacific/Apia", "Pacific/Kiritimati", "America/St_Johns"}
+
+func TestWeekdayRoundTripsWithTimeWeekday(t *testing.T) {
+	property := func(unix int64, zone uint8) bool {
+		loc, err := time.LoadLocation(weekdayTestZones[int(zone)%len(weekdayTestZones)])
+		if err != nil {
+			t.Fatalf("LoadLocation: %v", err)
+		}
+		// Giới hạn trong khoảng ±130 năm quanh 1970 để năm không tràn khi đổi múi giờ
+		at := time.Unix(unix%(130*365*24*3600), 0).In(loc)
+		w := WeekdayOf(at)
+		return w.Valid() &&
+			time.Weekday(w) == at.Weekday() &&
+			WeekdayOf(at) == Weekday(time.Weekday(w)) &&
+			w.String() == at.Weekday().String()
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWeekdayConstantsMatchTimeWeekday(t *testing.T) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		w := Weekday(d)
+		if !w.Valid() || ValidateWeekday(w) != nil {
+			t.Errorf("Weekday(%v) must be valid", d)
+		}
+		if time.Weekday(w) != d || w.String() != d.String() {
+			t.Errorf("Weekday(%v) = %v, does not round-trip", d, w)
+		}
+	}
+	for _, w := range []Weekday{-1, 7} {
+		if w.Valid() || ValidateWeekday(w) == nil {
+			t.Errorf("Weekday(%d) must be invalid", int(w))
+		}
+	}
+}
@@ -7,10 +7,11 @@ import (
 	"cbs_backend/global"
 	"cbs_backend/internal/kafka"
 	"cbs_backend/internal/service/email"
+	"cbs_backend/utils/cache"
 	// emailservice "cbs_backend/internal/service"
 )
 
-func InitKafka() {
+func InitKafka(redis *cache.RedisCache) {
 	cfg := global.ConfigConection.KafkaCF
 
 	if len(cfg.Brokers) == 0 {
@@ -30,8 +31,9 @@ func InitKafka() {
 	// New way:
 	emailService := email.NewEmailManager(global.DB, global.Log)
 
-	// 3. Initialize EventHandler với EmailService
+	// 3. Initialize EventHandler với EmailService, kèm BookingCache để xoá cache slot khi lịch trống đổi
 	handler := kafka.NewEventHandlerWithEmailService(emailService) // ← Fix: Truyền emailService
+	handler.WithBookingCache(cache.NewRedisBookingCache(redis, global.Log))
 
 	// 4. Initialize Consumer
 	consumer, err := kafka.NewConsumer(
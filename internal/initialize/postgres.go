@@ -5,12 +5,17 @@ import (
 	"log"
 
 	"cbs_backend/global"
+	"cbs_backend/internal/common"
 	entityLog "cbs_backend/internal/modules/activity_logs/entity"
 	entityBooking "cbs_backend/internal/modules/bookings/entity"
 	entityConsultation "cbs_backend/internal/modules/consultation_review/entity"
+	entityExchangeRate "cbs_backend/internal/modules/exchange_rates/entity"
 	entityExpert "cbs_backend/internal/modules/experts/entity"
+	entityInvoice "cbs_backend/internal/modules/invoices/entity"
 	entityTemplate "cbs_backend/internal/modules/notification_template/entity"
 	entityPayment "cbs_backend/internal/modules/payment_transactions/entity"
+	entityPayout "cbs_backend/internal/modules/payouts/entity"
+	entityPromotion "cbs_backend/internal/modules/promotions/entity"
 	entityNotification "cbs_backend/internal/modules/system_notification/entity"
 	entitySystem "cbs_backend/internal/modules/system_setting/entity"
 	entityUser "cbs_backend/internal/modules/users/entity"
@@ -93,10 +98,16 @@ func MigrateDatabase(db *gorm.DB) error {
 		&entityUser.User{},
 		&entitySystem.SystemSetting{},
 		&entityTemplate.NotificationTemplate{},
+		&entityPromotion.Coupon{},
+		&entityExchangeRate.ExchangeRate{},
+		&entityExpert.PlatformHoliday{},
 	}
 
 	userDependentTables := []interface{}{
 		&entityExpert.ExpertProfile{},
+		&entityExpert.PricingConfig{},
+		&entityExpert.ExpertHolidayOptIn{},
+		&entityExpert.ExpertWorkingHourOverride{},
 		&entityUser.UserToken{},
 		&entityUser.UserSession{},
 		&entityNotification.SystemNotification{},
@@ -104,13 +115,24 @@ func MigrateDatabase(db *gorm.DB) error {
 	}
 
 	bookingRelatedTables := []interface{}{
+		&entityBooking.BookingSeries{},
+		&entityBooking.GroupSession{},
 		&entityBooking.ConsultationBooking{},
+		&entityBooking.WaitlistEntry{},
+		&entityBooking.SlotHold{},
+		&entityBooking.CalendarFeed{},
 	}
 
 	bookingDependentTables := []interface{}{
 		&entityBooking.BookingStatusHistory{},
 		&entityConsultation.ConsultationReview{},
 		&entityPayment.PaymentTransaction{},
+		&entityPayment.PaymentWebhookEvent{},
+		&entityPayout.PayoutStatement{},
+		&entityPayout.PayoutLedgerEntry{},
+		&entityPromotion.CouponRedemption{},
+		&entityInvoice.Invoice{},
+		&entityInvoice.InvoiceSequence{},
 	}
 
 	// Execute migrations in order
@@ -124,6 +146,13 @@ func MigrateDatabase(db *gorm.DB) error {
 		{"Booking dependent tables", bookingDependentTables},
 	}
 
+	if err := migrateWorkingHourWeekdays(db); err != nil {
+		return err
+	}
+	if err := dropBookingPaymentStatusCheck(db); err != nil {
+		return err
+	}
+
 	for _, group := range migrationGroups {
 		log.Printf("📋 Migrating %s...", group.name)
 
@@ -135,6 +164,10 @@ func MigrateDatabase(db *gorm.DB) error {
 		}
 	}
 
+	if err := migrateMoneyToMinorUnits(db); err != nil {
+		return err
+	}
+
 	// Create indexes for better performance
 	// if err := CreateIndexes(db); err != nil {
 	// 	log.Printf("⚠️  Warning: Failed to create some indexes: %v", err)
@@ -144,6 +177,114 @@ func MigrateDatabase(db *gorm.DB) error {
 	return nil
 }
 
+// migrateMoneyToMinorUnits chuyển các cột tiền dạng decimal cũ sang cột *_minor (số nguyên theo đơn vị nhỏ nhất).
+// Chạy sau AutoMigrate: cột mới đã tồn tại, dữ liệu cũ được backfill rồi cột cũ bị xoá nên lần chạy sau sẽ bỏ qua.
+func migrateMoneyToMinorUnits(db *gorm.DB) error {
+	columns := []struct {
+		model     interface{}
+		table     string
+		oldColumn string
+		newColumn string
+	}{
+		{&entityExpert.ExpertProfile{}, "tbl_expert_profiles", "consultation_fee", "consultation_fee_minor"},
+		{&entityExpert.PricingConfig{}, "tbl_pricing_configs", "base_price", "base_price_minor"},
+		{&entityBooking.ConsultationBooking{}, "tbl_consultation_bookings", "consultation_fee", "consultation_fee_minor"},
+		{&entityPayment.PaymentTransaction{}, "tbl_payment_transactions", "amount", "amount_minor"},
+		{&entityPayout.PayoutLedgerEntry{}, "tbl_expert_payout_ledger", "gross_amount", "gross_amount_minor"},
+		{&entityPayout.PayoutLedgerEntry{}, "tbl_expert_payout_ledger", "commission_amount", "commission_amount_minor"},
+		{&entityPayout.PayoutLedgerEntry{}, "tbl_expert_payout_ledger", "net_amount", "net_amount_minor"},
+		{&entityPayout.PayoutStatement{}, "tbl_expert_payout_statements", "gross_amount", "gross_amount_minor"},
+		{&entityPayout.PayoutStatement{}, "tbl_expert_payout_statements", "commission_amount", "commission_amount_minor"},
+		{&entityPayout.PayoutStatement{}, "tbl_expert_payout_statements", "net_amount", "net_amount_minor"},
+		{&entityPromotion.Coupon{}, "tbl_coupons", "max_discount_amount", "max_discount_amount_minor"},
+		{&entityPromotion.Coupon{}, "tbl_coupons", "min_booking_amount", "min_booking_amount_minor"},
+		{&entityPromotion.CouponRedemption{}, "tbl_coupon_redemptions", "original_amount", "original_amount_minor"},
+		{&entityPromotion.CouponRedemption{}, "tbl_coupon_redemptions", "discount_amount", "discount_amount_minor"},
+		{&entityPromotion.CouponRedemption{}, "tbl_coupon_redemptions", "final_amount", "final_amount_minor"},
+	}
+
+	// discount_value của coupon tách theo loại mã: mã phần trăm giữ nguyên %, mã cố định đổi sang đơn vị nhỏ nhất
+	if db.Migrator().HasColumn(&entityPromotion.Coupon{}, "discount_value") {
+		log.Printf("💱 Migrating tbl_coupons.discount_value...")
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(`UPDATE tbl_coupons SET
+				discount_percentage = CASE WHEN discount_type = 'percentage' THEN discount_value END,
+				discount_amount_minor = CASE WHEN discount_type = 'fixed'
+					THEN ROUND(discount_value * CASE currency WHEN 'USD' THEN 100 ELSE 1 END) END`).Error; err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&entityPromotion.Coupon{}, "discount_value")
+		})
+		if err != nil {
+			return fmt.Errorf("failed to migrate tbl_coupons.discount_value: %w", err)
+		}
+	}
+
+	for _, c := range columns {
+		if !db.Migrator().HasColumn(c.model, c.oldColumn) {
+			continue
+		}
+		log.Printf("💱 Migrating %s.%s to %s...", c.table, c.oldColumn, c.newColumn)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			backfill := fmt.Sprintf(
+				"UPDATE %s SET %s = ROUND(%s * CASE currency WHEN 'USD' THEN 100 ELSE 1 END) WHERE %s IS NOT NULL",
+				c.table, c.newColumn, c.oldColumn, c.oldColumn)
+			if err := tx.Exec(backfill).Error; err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(c.model, c.oldColumn)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to migrate %s.%s: %w", c.table, c.oldColumn, err)
+		}
+	}
+	return nil
+}
+
+// migrateWorkingHourWeekdays sửa dữ liệu day_of_week về quy ước common.Weekday (0 = Chủ nhật .. 6 = Thứ 7).
+// API và bộ sinh slot luôn dùng 0..6, riêng bước kiểm tra khi đặt lịch trước đây đọc theo 1..7 (Chủ nhật = 1, Thứ 7 = 7).
+// Giá trị 7 chỉ có nghĩa ở quy ước cũ đó (Thứ 7) nên được đổi về 6; dòng 7 chỉ có thể tồn tại ở bảng tạo trước khi có
+// check constraint BETWEEN 0 AND 6, vì constraint này đã có sẵn và chặn mọi lần ghi 7 sau đó.
+// Giá trị 1..6 không phân biệt được hai quy ước (1 là Thứ 2 theo API nhưng là Chủ nhật theo bước kiểm tra cũ) nên giữ
+// nguyên theo quy ước của API; expert có dòng 7 được ghi log để tự rà lại các dòng còn lại của mình.
+// Chạy trước AutoMigrate; lần chạy sau không còn dòng nào để cập nhật.
+func migrateWorkingHourWeekdays(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&entityExpert.ExpertWorkingHour{}) {
+		return nil
+	}
+	var expertIDs []string
+	if err := db.Model(&entityExpert.ExpertWorkingHour{}).
+		Where("day_of_week = ?", 7).
+		Distinct().
+		Pluck("expert_profile_id", &expertIDs).Error; err != nil {
+		return fmt.Errorf("failed to find legacy working hour weekdays: %w", err)
+	}
+	if len(expertIDs) == 0 {
+		return nil
+	}
+	result := db.Model(&entityExpert.ExpertWorkingHour{}).
+		Where("day_of_week = ?", 7).
+		Update("day_of_week", common.DaySaturday)
+	if result.Error != nil {
+		return fmt.Errorf("failed to migrate working hour weekdays: %w", result.Error)
+	}
+	log.Printf("📅 Migrated %d working hours from day_of_week 7 to 6 (Saturday)", result.RowsAffected)
+	log.Printf("⚠️ Experts %v used the legacy 1..7 weekday encoding; their other working hours (1..6) were kept as-is and may be one day off", expertIDs)
+	return nil
+}
+
+// dropBookingPaymentStatusCheck xoá check constraint cũ của payment_status để AutoMigrate tạo lại với giá trị
+// partially_refunded; AutoMigrate không sửa check constraint đã tồn tại.
+func dropBookingPaymentStatusCheck(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&entityBooking.ConsultationBooking{}) {
+		return nil
+	}
+	if err := db.Exec("ALTER TABLE tbl_consultation_bookings DROP CONSTRAINT IF EXISTS chk_tbl_consultation_bookings_payment_status").Error; err != nil {
+		return fmt.Errorf("failed to drop booking payment status check: %w", err)
+	}
+	return nil
+}
+
 // func CreateIndexes(db *gorm.DB) error {
 // 	log.Println("📇 Creating database indexes...")
 
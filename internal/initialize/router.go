@@ -45,6 +45,10 @@ func InitRouter() *gin.Engine {
 	ExpertMainGroup := routerAll.RouterGroupApp.Expert
 	BookingMainGroup := routerAll.RouterGroupApp.Booking
 	DashBoardMainGroup := routerAll.RouterGroupApp.Dashboard
+	PaymentMainGroup := routerAll.RouterGroupApp.Payment
+	PayoutMainGroup := routerAll.RouterGroupApp.Payout
+	PromotionMainGroup := routerAll.RouterGroupApp.Promotion
+	CurrencyMainGroup := routerAll.RouterGroupApp.Currency
 	// Nhóm route chính (có thể đặt prefix như /api)
 	apiGroup := r.Group("")
 	{
@@ -52,6 +56,10 @@ func InitRouter() *gin.Engine {
 		UserMainGroup.InitUserRouter(apiGroup) // Khởi tạo route user
 		ExpertMainGroup.InitExpertRouter(apiGroup)
 		BookingMainGroup.InitBookingRouter(apiGroup)
+		PaymentMainGroup.InitPaymentRouter(apiGroup)
+		PayoutMainGroup.InitPayoutRouter(apiGroup)
+		PromotionMainGroup.InitPromotionRouter(apiGroup)
+		CurrencyMainGroup.InitCurrencyRouter(apiGroup)
 	}
 
 	return r
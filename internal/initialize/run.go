@@ -39,8 +39,8 @@ func Run() *gin.Engine {
 	redis := InitRedis()
 	global.Log.Info("✅ Redis initialized")
 
-	// Step 4: Init Kafka
-	InitKafka()
+	// Step 4: Init Kafka (consumer dùng Redis để xoá cache slot)
+	InitKafka(redis)
 
 	// Step 5: Init Services
 	InitServices(db, redis, global.Log)
@@ -62,6 +62,7 @@ func InitWorker() {
 	maxWorkers := 5 // Có thể lấy từ config
 	emailSvc := email.NewEmailManager(global.DB, global.Log)
 	WorkerScheduler = worker.NewWorkerScheduler(global.DB, maxWorkers, emailSvc, global.Redis)
+	WorkerScheduler.SetPaymentWindow(global.ConfigConection.PaymentCF.PaymentWindow)
 
 	if err := WorkerScheduler.Start(); err != nil {
 		global.Log.Fatal("❌ Failed to start worker scheduler", zap.Error(err))
@@ -1,11 +1,22 @@
 package initialize
 
 import (
+	"fmt"
+
+	"cbs_backend/global"
 	"cbs_backend/internal/modules/bookings"
 	"cbs_backend/internal/modules/dashboard"
+	exchangerates "cbs_backend/internal/modules/exchange_rates"
 	"cbs_backend/internal/modules/experts"
+	"cbs_backend/internal/modules/invoices"
+	paymenttransactions "cbs_backend/internal/modules/payment_transactions"
+	"cbs_backend/internal/modules/payouts"
+	"cbs_backend/internal/modules/promotions"
 	"cbs_backend/internal/modules/users"
 	"cbs_backend/internal/service/email"
+	"cbs_backend/internal/service/interfaces"
+	"cbs_backend/internal/service/payment"
+	"cbs_backend/pkg/configs"
 	"cbs_backend/utils/cache"
 
 	"github.com/bsm/redislock"
@@ -32,7 +43,50 @@ func InitServices(
 	users.InitUserService(db, userCache, log)
 	// 4. Experts
 	experts.InitExpertService(db, expertCache, log)
-	//5.Booking
+	// Tỷ giá: dashboard/booking dùng để quy đổi doanh thu đa tiền tệ
+	exchangerates.InitExchangeRateService(db, log)
+	//5.Booking (promotion phải khởi tạo trước vì booking/payment ghi nhận lượt dùng mã)
+	promotions.InitPromotionService(db, log)
 	bookings.InitBookingService(db, bookingCache, log, redisLocker)
 	dashboard.InitDashboardService(db, log)
+	//6.Payment (payout/invoice phải khởi tạo trước vì payment ghi sổ payout khi hoàn tiền và xuất hoá đơn khi thanh toán xong)
+	paymentCfg := global.ConfigConection.PaymentCF
+	if paymentCfg.WebhookSecret == "" {
+		log.Warn("⚠️ PAYMENT_WEBHOOK_SECRET is not set, all payment webhooks will be rejected")
+	}
+	payouts.InitPayoutService(db, log, paymentCfg.CommissionPercentage)
+	invoices.InitInvoiceService(db, log, paymentCfg)
+	gateways, err := paymentGateways(paymentCfg, global.ConfigConection.SMTPCF.BaseURL, log)
+	if err != nil {
+		log.Fatal("❌ Invalid payment gateway configuration", zap.Error(err))
+	}
+	paymenttransactions.InitPaymentService(db, log, paymentCfg, gateways...)
+}
+
+// paymentGateways dựng danh sách gateway theo config; fake gateway chỉ có khi bật cờ dev/test.
+// Chỉ lỗi khi PAYMENT_DEFAULT_GATEWAY trỏ tới gateway chưa đăng ký. Không cấu hình gateway nào
+// thì server vẫn chạy, chức năng thanh toán bị tắt và CreatePayment trả về ErrPaymentsDisabled.
+func paymentGateways(cfg *configs.PaymentConfig, baseURL string, log *zap.Logger) ([]interfaces.PaymentGateway, error) {
+	var gateways []interfaces.PaymentGateway
+	if cfg.EnableFakeGateway {
+		log.Warn("⚠️ Fake payment gateway is enabled, never turn on PAYMENT_ENABLE_FAKE_GATEWAY in production")
+		gateways = append(gateways, payment.NewFakeGateway(baseURL, cfg.FakeAutoComplete))
+	}
+	if cfg.DefaultGateway != "" && !hasPaymentGateway(gateways, cfg.DefaultGateway) {
+		return nil, fmt.Errorf("PAYMENT_DEFAULT_GATEWAY %q is not a registered payment gateway", cfg.DefaultGateway)
+	}
+	if len(gateways) == 0 {
+		log.Warn("⚠️ No payment gateway is configured, payments are disabled")
+	}
+	return gateways, nil
+}
+
+// hasPaymentGateway kiểm tra gateway mặc định đã được đăng ký
+func hasPaymentGateway(gateways []interfaces.PaymentGateway, name string) bool {
+	for _, gw := range gateways {
+		if gw.Name() == name {
+			return true
+		}
+	}
+	return false
 }
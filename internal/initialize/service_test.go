@@ -0,0 +1,70 @@
+package initialize
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	paymenttransactions "cbs_backend/internal/modules/payment_transactions"
+	"cbs_backend/internal/modules/payment_transactions/dtopayments"
+	"cbs_backend/internal/service/payment"
+	"cbs_backend/pkg/configs"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestPaymentGatewaysFakeFlag(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       configs.PaymentConfig
+		wantNames []string
+		wantErr   bool
+	}{
+		{"fake enabled as default", configs.PaymentConfig{EnableFakeGateway: true, DefaultGateway: payment.FakeGatewayName}, []string{payment.FakeGatewayName}, false},
+		{"fake enabled without default", configs.PaymentConfig{EnableFakeGateway: true}, []string{payment.FakeGatewayName}, false},
+		{"fake default without flag", configs.PaymentConfig{DefaultGateway: payment.FakeGatewayName}, nil, true},
+		{"unknown default", configs.PaymentConfig{EnableFakeGateway: true, DefaultGateway: "stripe"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateways, err := paymentGateways(&tt.cfg, "http://localhost", zap.NewNop())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("paymentGateways() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(gateways) != len(tt.wantNames) {
+				t.Fatalf("paymentGateways() returned %d gateways, want %d", len(gateways), len(tt.wantNames))
+			}
+			for i, gw := range gateways {
+				if gw.Name() != tt.wantNames[i] {
+					t.Errorf("gateway[%d] = %q, want %q", i, gw.Name(), tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPaymentGatewaysNoneConfigured(t *testing.T) {
+	cfg := &configs.PaymentConfig{}
+	gateways, err := paymentGateways(cfg, "http://localhost", zap.NewNop())
+	if err != nil {
+		t.Fatalf("paymentGateways() with default settings must not fail startup, got %v", err)
+	}
+	if len(gateways) != 0 {
+		t.Fatalf("paymentGateways() returned %d gateways, want none", len(gateways))
+	}
+
+	// Không có gateway thì CreatePayment báo thanh toán đang tắt trước khi chạm tới database
+	svc := paymenttransactions.NewPaymentService(nil, zap.NewNop(), cfg, gateways...)
+	_, err = svc.CreatePayment(context.Background(), dtopayments.CreatePaymentRequest{
+		BookingID: uuid.NewString(),
+		UserID:    uuid.NewString(),
+	})
+	if !errors.Is(err, paymenttransactions.ErrPaymentsDisabled) {
+		t.Fatalf("CreatePayment() error = %v, want ErrPaymentsDisabled", err)
+	}
+}
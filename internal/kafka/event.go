@@ -43,6 +43,17 @@ type BookingEvent struct {
 	EventData map[string]interface{} `json:"event_data"`
 }
 
+// SlotAvailabilityChangedEvent báo lịch trống của expert vừa đổi (booking, giờ làm việc, lịch nghỉ, ngày nghỉ...)
+// để consumer xoá lưới slot đã cache của các ngày bị ảnh hưởng
+type SlotAvailabilityChangedEvent struct {
+	EventType string     `json:"event_type"`     // "slot_availability_changed"
+	ExpertID  string     `json:"expert_id"`      // Rỗng: mọi expert (vd ngày nghỉ toàn hệ thống)
+	From      *time.Time `json:"from,omitempty"` // Khoảng thời gian bị ảnh hưởng; nil: mọi ngày
+	To        *time.Time `json:"to,omitempty"`
+	Reason    string     `json:"reason"`
+	ChangedAt time.Time  `json:"changed_at"`
+}
+
 // Event structs - Định nghĩa các struct để parse events
 type BookingCreatedEvent struct {
 	EventType          string    `json:"event_type"`
@@ -53,6 +64,7 @@ type BookingCreatedEvent struct {
 	DoctorSpecialty    []string  `json:"doctor_specialty"`
 	ConsultationDate   string    `json:"consultation_date"`
 	ConsultationTime   string    `json:"consultation_time"`
+	TimeZone           string    `json:"time_zone"` // Múi giờ của người nhận dùng để format ngày/giờ tư vấn
 	Duration           int       `json:"duration"`
 	ConsultationType   string    `json:"consultation_type"`
 	Location           string    `json:"location"`
@@ -76,6 +88,7 @@ type BookingConfirmEvent struct {
 	DoctorSpecialty    []string  `json:"doctor_specialty"`
 	ConsultationDate   string    `json:"consultation_date"`
 	ConsultationTime   string    `json:"consultation_time"`
+	TimeZone           string    `json:"time_zone"` // Múi giờ của người nhận dùng để format ngày/giờ tư vấn
 	Duration           int       `json:"duration"`
 	ConsultationType   string    `json:"consultation_type"`
 	Location           string    `json:"location"`
@@ -99,6 +112,10 @@ type BookingCancelledEvent struct {
 	DoctorSpecialty   []string  `json:"doctor_specialty"`    // Chuyên khoa
 	ConsultationDate  string    `json:"consultation_date"`   // Ngày tư vấn
 	ConsultationTime  string    `json:"consultation_time"`   // Giờ tư vấn
+	TimeZone          string    `json:"time_zone"`           // Múi giờ của user, ngày/giờ ở trên format theo múi giờ này
+	ExpertDate        string    `json:"expert_date"`         // Ngày tư vấn theo múi giờ của expert
+	ExpertTime        string    `json:"expert_time"`         // Giờ tư vấn theo múi giờ của expert
+	ExpertTimeZone    string    `json:"expert_time_zone"`    // Múi giờ của expert
 	Duration          int       `json:"duration"`            // Thời lượng tư vấn (phút)
 	ConsultationType  string    `json:"consultation_type"`   // Loại tư vấn
 	Location          string    `json:"location"`            // Địa điểm (hoặc "online")
@@ -114,6 +131,34 @@ type BookingCancelledEvent struct {
 	CancelledAt       time.Time `json:"cancelled_at"`        // Thời gian huỷ
 }
 
+// Booking Reject Event: expert từ chối booking pending, kèm các khung giờ trống gợi ý cho người dùng
+type BookingRejectedEvent struct {
+	EventType         string          `json:"event_type"` // "booking_rejected"
+	BookingID         string          `json:"booking_id"`
+	UserID            string          `json:"user_id"`
+	ExpertID          string          `json:"expert_id"`
+	DoctorName        string          `json:"doctor_name"`
+	ConsultationDate  string          `json:"consultation_date"`
+	ConsultationTime  string          `json:"consultation_time"`
+	TimeZone          string          `json:"time_zone"` // Múi giờ của user, cả khung giờ gợi ý cũng format theo múi giờ này
+	Duration          int             `json:"duration"`
+	ConsultationType  string          `json:"consultation_type"`
+	Email             string          `json:"email"`
+	FullName          string          `json:"full_name"`
+	RejectionReason   string          `json:"rejection_reason"`
+	RefundAmount      float64         `json:"refund_amount"`
+	RefundProcessDays int             `json:"refund_process_days"`
+	AlternativeSlots  []SuggestedSlot `json:"alternative_slots"`
+	RejectedAt        time.Time       `json:"rejected_at"`
+}
+
+// SuggestedSlot là khung giờ trống được gợi ý, đã format sẵn để hiển thị trong email
+type SuggestedSlot struct {
+	Date      string `json:"date"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
 // Hàm tạo BookingConfirmEvent
 func CreateBookingConfirmEvent(
 	userID, bookingID, expertID, email, fullName, doctorName string, doctorSpecialty []string,
@@ -2,6 +2,8 @@ package kafka
 
 import (
 	"cbs_backend/internal/service/interfaces"
+	"cbs_backend/utils/cache"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -16,6 +18,7 @@ import (
 
 type EventHandler struct {
 	emailService interfaces.EmailService
+	bookingCache cache.BookingCache // Xoá lưới slot đã cache khi lịch trống của expert đổi; nil thì bỏ qua
 }
 
 // Constructors
@@ -31,6 +34,12 @@ func NewEventHandlerWithEmailService(emailService interfaces.EmailService) *Even
 	}
 }
 
+// WithBookingCache gắn cache booking để consumer xoá lưới slot khi nhận slot_availability_changed
+func (h *EventHandler) WithBookingCache(bookingCache cache.BookingCache) *EventHandler {
+	h.bookingCache = bookingCache
+	return h
+}
+
 // =============================================================================
 // MAIN MESSAGE HANDLER
 // =============================================================================
@@ -153,6 +162,10 @@ func (h *EventHandler) handleBookingEvent(data []byte) error {
 			return h.handleBookingUpdated(data)
 		case "booking_cancelled":
 			return h.handleBookingCancelled(data)
+		case "booking_rejected":
+			return h.handleBookingRejected(data)
+		case "slot_availability_changed":
+			return h.handleSlotAvailabilityChanged(data)
 		default:
 			log.Printf("⚠️ Unknown booking event type: %s", eventType)
 		}
@@ -215,10 +228,43 @@ func (h *EventHandler) handleBookingConfirmed(data []byte) error {
 }
 
 func (h *EventHandler) handleBookingUpdated(data []byte) error {
-	log.Printf("🔄 Booking updated event received")
-	// TODO: Implement booking updated logic
+	var event BookingEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		log.Printf("❌ Failed to unmarshal booking updated event: %v", err)
+		return err
+	}
+
+	action, _ := event.EventData["action"].(string)
+	log.Printf("🔄 Booking updated: %s (%s) for user %s, expert %s", event.BookingID, action, event.UserID, event.ExpertID)
+	// TODO: Implement booking updated notification
+	return nil
+}
+
+// handleSlotAvailabilityChanged xoá lưới slot đã cache của các ngày bị ảnh hưởng
+func (h *EventHandler) handleSlotAvailabilityChanged(data []byte) error {
+	var event SlotAvailabilityChangedEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		log.Printf("❌ Failed to unmarshal slot availability changed event: %v", err)
+		return err
+	}
+	if h.bookingCache == nil {
+		return nil
+	}
+
+	var days []string
+	if event.From != nil && event.To != nil {
+		days = cache.SlotGridDaysAround(*event.From, *event.To)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.bookingCache.InvalidateSlotGrids(ctx, event.ExpertID, days); err != nil {
+		log.Printf("❌ Failed to invalidate slot grids for expert %q: %v", event.ExpertID, err)
+		return err
+	}
+	log.Printf("🗑️ Slot grids invalidated for expert %q (%s, %d days)", event.ExpertID, event.Reason, len(days))
 	return nil
 }
+
 func (h *EventHandler) handleBookingCancelled(data []byte) error {
 	var event BookingCancelledEvent
 	if err := json.Unmarshal(data, &event); err != nil {
@@ -231,6 +277,16 @@ func (h *EventHandler) handleBookingCancelled(data []byte) error {
 
 	notificationData := h.createBookingCancelNotificationData(event)
 
+	// Expert nhận ngày/giờ theo múi giờ của expert (event cũ không có thì dùng như user)
+	expertDate := event.ConsultationDate
+	expertNotificationData := h.createBookingCancelNotificationData(event)
+	if event.ExpertDate != "" {
+		expertDate = event.ExpertDate
+		expertNotificationData["consultation_date"] = event.ExpertDate
+		expertNotificationData["consultation_time"] = event.ExpertTime
+		expertNotificationData["time_zone"] = event.ExpertTimeZone
+	}
+
 	// Notification for User
 	notificationForUser := NotificationEvent{
 		UserID:        event.UserID,
@@ -250,8 +306,8 @@ func (h *EventHandler) handleBookingCancelled(data []byte) error {
 		RecipientType: "expert",
 		Type:          "booking_cancelled",
 		Title:         "Lịch hẹn bị huỷ",
-		Message:       fmt.Sprintf("Người dùng %s đã huỷ lịch hẹn vào %s.", event.FullName, event.ConsultationDate),
-		Data:          notificationData,
+		Message:       fmt.Sprintf("Người dùng %s đã huỷ lịch hẹn vào %s.", event.FullName, expertDate),
+		Data:          expertNotificationData,
 		CreatedAt:     time.Now(),
 	}
 
@@ -266,6 +322,48 @@ func (h *EventHandler) handleBookingCancelled(data []byte) error {
 	return nil
 }
 
+func (h *EventHandler) handleBookingRejected(data []byte) error {
+	var event BookingRejectedEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		log.Printf("❌ Failed to unmarshal booking rejected event: %v", err)
+		return err
+	}
+
+	log.Printf("🚫 Booking rejected: %s by expert %s (user: %s)", event.BookingID, event.ExpertID, event.UserID)
+
+	slots := make([]interface{}, 0, len(event.AlternativeSlots))
+	for _, slot := range event.AlternativeSlots {
+		slots = append(slots, map[string]interface{}{
+			"date":       slot.Date,
+			"start_time": slot.StartTime,
+			"end_time":   slot.EndTime,
+		})
+	}
+
+	notification := NotificationEvent{
+		UserID:        event.UserID,
+		RecipientID:   event.UserID,
+		RecipientType: "user",
+		Type:          "booking_rejected",
+		Title:         "Lịch hẹn bị từ chối",
+		Message:       fmt.Sprintf("Chuyên gia %s đã từ chối lịch hẹn vào %s.", event.DoctorName, event.ConsultationDate),
+		Data: map[string]interface{}{
+			"booking_id":          event.BookingID,
+			"doctor_name":         event.DoctorName,
+			"consultation_date":   event.ConsultationDate,
+			"consultation_time":   event.ConsultationTime,
+			"time_zone":           event.TimeZone,
+			"rejection_reason":    event.RejectionReason,
+			"refund_amount":       event.RefundAmount,
+			"refund_process_days": event.RefundProcessDays,
+			"alternative_slots":   slots,
+		},
+		CreatedAt: time.Now(),
+	}
+
+	return PublishNotificationEvent(notification)
+}
+
 func (h *EventHandler) handleBookingNotification(event NotificationEvent) error {
 	log.Printf("📧 Processing booking notification for user %s: %s", event.UserID, event.Type)
 
@@ -295,6 +393,7 @@ func (h *EventHandler) createBookingNotificationData(event BookingCreatedEvent)
 		"doctor_specialty":    event.DoctorSpecialty,
 		"consultation_date":   event.ConsultationDate,
 		"consultation_time":   event.ConsultationTime,
+		"time_zone":           event.TimeZone,
 		"duration":            event.Duration,
 		"consultation_type":   event.ConsultationType,
 		"location":            event.Location,
@@ -317,6 +416,7 @@ func (h *EventHandler) createBookingConfirmNotificationData(event BookingConfirm
 		"doctor_specialty":    event.DoctorSpecialty,
 		"consultation_date":   event.ConsultationDate,
 		"consultation_time":   event.ConsultationTime,
+		"time_zone":           event.TimeZone,
 		"duration":            event.Duration,
 		"consultation_type":   event.ConsultationType,
 		"location":            event.Location,
@@ -336,6 +436,7 @@ func (h *EventHandler) createBookingCancelNotificationData(event BookingCancelle
 		"booking_id":          event.BookingID,
 		"consultation_date":   event.ConsultationDate,
 		"consultation_time":   event.ConsultationTime,
+		"time_zone":           event.TimeZone,
 		"doctor_name":         event.DoctorName,
 		"doctor_specialty":    event.DoctorSpecialty,
 		"consultation_type":   event.ConsultationType,
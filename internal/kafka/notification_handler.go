@@ -32,6 +32,8 @@ func (h *EventHandler) handleNotificationEvent(data []byte) error {
 		return h.handleBookingConfirmationNotification(event)
 	case "booking_cancelled":
 		return h.handleBookingCancelledNotification(event) // ✅ FIXED
+	case "booking_rejected":
+		return h.handleBookingRejectedNotification(event)
 	default:
 		log.Printf("⚠️ Unknown notification type: %s", event.Type)
 	}
@@ -166,6 +168,44 @@ func (h *EventHandler) handleBookingCancelledNotification(event NotificationEven
 	return nil
 }
 
+func (h *EventHandler) handleBookingRejectedNotification(event NotificationEvent) error {
+	log.Printf("📧 Sending booking rejected email to user: %s", event.RecipientID)
+
+	data := interfaces.ConsultationRejectionData{
+		BookingID:         getString(event.Data["booking_id"]),
+		DoctorName:        getString(event.Data["doctor_name"]),
+		ConsultationDate:  getString(event.Data["consultation_date"]),
+		ConsultationTime:  getString(event.Data["consultation_time"]),
+		RejectionReason:   getString(event.Data["rejection_reason"]),
+		RefundAmount:      getFloat64(event.Data["refund_amount"]),
+		RefundProcessDays: int(getFloat64(event.Data["refund_process_days"])),
+	}
+	if slots, ok := event.Data["alternative_slots"].([]interface{}); ok {
+		for _, raw := range slots {
+			slot, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			data.AlternativeSlots = append(data.AlternativeSlots, interfaces.SuggestedSlotData{
+				Date:      getString(slot["date"]),
+				StartTime: getString(slot["start_time"]),
+				EndTime:   getString(slot["end_time"]),
+			})
+		}
+	}
+
+	if h.emailService == nil {
+		log.Printf("⚠️ EmailService is nil - running in simulation mode")
+		log.Printf("✅ [SIMULATION] Booking rejected email sent to user %s for booking %s", event.RecipientID, data.BookingID)
+		return nil
+	}
+
+	if err := h.emailService.SendConsultationBookingRejected(context.Background(), event.RecipientID, data); err != nil {
+		log.Printf("❌ Failed to send rejected email to user %s: %v", event.RecipientID, err)
+	}
+	return nil
+}
+
 // =============================================================================
 // HELPER FUNCTIONS
 // =============================================================================
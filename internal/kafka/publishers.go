@@ -108,3 +108,27 @@ func PublishBookingCancelledEvent(event BookingCancelledEvent) error {
 
 	return Publish("booking-events", data)
 }
+
+func PublishBookingRejectedEvent(event BookingRejectedEvent) error {
+	event.EventType = "booking_rejected"
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal booking rejected event: %v", err)
+	}
+
+	return Publish("booking-events", data)
+}
+
+// PublishSlotAvailabilityChangedEvent gửi lên booking-events để mọi instance dùng chung một luồng xoá cache slot
+func PublishSlotAvailabilityChangedEvent(event SlotAvailabilityChangedEvent) error {
+	event.EventType = "slot_availability_changed"
+	event.ChangedAt = time.Now()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal slot availability changed event: %v", err)
+	}
+
+	return Publish("booking-events", data)
+}
@@ -0,0 +1,262 @@
+package bookings
+
+import (
+	"cbs_backend/global"
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/internal/modules/experts/entity"
+	"cbs_backend/pkg/ical"
+	"cbs_backend/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	calendarFeedTypeUser   = "user"
+	calendarFeedTypeExpert = "expert"
+
+	calendarProdID        = "-//CBS//Consultation Booking//VI"
+	calendarFeedPath      = "/booking/v1/calendar/"
+	calendarFeedExtension = ".ics"
+	calendarTokenBytes    = 32
+	// calendarFeedLookback giữ lại các buổi vừa diễn ra để ứng dụng lịch không xoá sự kiện ngay khi hết giờ
+	calendarFeedLookback = 7 * 24 * time.Hour
+)
+
+// calendarFeedStatuses gồm cả booking đã huỷ/từ chối để ứng dụng lịch nhận STATUS:CANCELLED và gỡ sự kiện cũ
+var calendarFeedStatuses = []string{
+	common.BookingStatusPending,
+	common.BookingStatusConfirmed,
+	common.BookingStatusCompleted,
+	common.BookingStatusCancelled,
+	common.BookingStatusRejected,
+}
+
+// CreateCalendarFeed cấp token feed mới cho (user, feed_type); token cũ (nếu có) hết hiệu lực ngay
+func (bs *bookingservice) CreateCalendarFeed(ctx context.Context, req dtobookings.CreateCalendarFeedRequest) (*dtobookings.CalendarFeedResponse, error) {
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	if req.FeedType == calendarFeedTypeExpert {
+		if _, err := bs.expertProfileForUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := newCalendarToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate calendar token: %w", err)
+	}
+
+	now := time.Now()
+	feed := entityBooking.CalendarFeed{
+		UserID:        userID,
+		FeedType:      req.FeedType,
+		TokenHash:     utils.Hash(token),
+		FeedCreatedAt: now,
+	}
+	err = bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND feed_type = ?", userID, req.FeedType).
+			Delete(&entityBooking.CalendarFeed{}).Error; err != nil {
+			return fmt.Errorf("failed to rotate calendar feed: %w", err)
+		}
+		if err := tx.Create(&feed).Error; err != nil {
+			return fmt.Errorf("failed to create calendar feed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bs.logger.Info("Calendar feed issued", zap.String("user_id", req.UserID), zap.String("feed_type", req.FeedType))
+
+	return &dtobookings.CalendarFeedResponse{
+		FeedType:  req.FeedType,
+		Token:     token,
+		FeedURL:   calendarFeedURL(token),
+		CreatedAt: now,
+	}, nil
+}
+
+// RevokeCalendarFeed xoá feed để URL đã chia sẻ không còn truy cập được
+func (bs *bookingservice) RevokeCalendarFeed(ctx context.Context, req dtobookings.RevokeCalendarFeedRequest) (*dtobookings.RevokeCalendarFeedResponse, error) {
+	result := bs.db.WithContext(ctx).
+		Where("user_id = ? AND feed_type = ?", req.UserID, req.FeedType).
+		Delete(&entityBooking.CalendarFeed{})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to revoke calendar feed: %w", result.Error)
+	}
+	return &dtobookings.RevokeCalendarFeedResponse{
+		FeedType: req.FeedType,
+		Revoked:  result.RowsAffected > 0,
+	}, nil
+}
+
+// GetCalendarFeed dựng file .ics theo token trong URL. Ứng dụng lịch không gửi được header Authorization
+// nên token chính là thông tin xác thực; chỉ so khớp theo hash đã lưu.
+func (bs *bookingservice) GetCalendarFeed(ctx context.Context, token string) (*dtobookings.CalendarFile, error) {
+	token = strings.TrimSuffix(strings.TrimSpace(token), calendarFeedExtension)
+	if token == "" {
+		return nil, fmt.Errorf("calendar feed not found")
+	}
+
+	var feed entityBooking.CalendarFeed
+	if err := bs.db.WithContext(ctx).First(&feed, "token_hash = ?", utils.Hash(token)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("calendar feed not found")
+		}
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+
+	now := time.Now()
+	if err := bs.db.WithContext(ctx).Model(&feed).Update("last_accessed_at", now).Error; err != nil {
+		bs.logger.Warn("Failed to record calendar feed access", zap.String("feed_id", feed.FeedID.String()), zap.Error(err))
+	}
+
+	from := now.Add(-calendarFeedLookback)
+	var (
+		calendar ical.Calendar
+		err      error
+	)
+	switch feed.FeedType {
+	case calendarFeedTypeExpert:
+		calendar, err = bs.expertCalendar(ctx, feed.UserID, from)
+	default:
+		calendar, err = bs.userCalendar(ctx, feed.UserID, from)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtobookings.CalendarFile{
+		FileName:    "consultations.ics",
+		ContentType: "text/calendar; charset=utf-8",
+		Content:     calendar.Bytes(),
+	}, nil
+}
+
+// userCalendar gồm các buổi tư vấn người dùng đã đặt, kể cả chỗ ngồi trong buổi nhóm
+func (bs *bookingservice) userCalendar(ctx context.Context, userID uuid.UUID, from time.Time) (ical.Calendar, error) {
+	var bookings []entityBooking.ConsultationBooking
+	if err := bs.db.WithContext(ctx).
+		Where("user_id = ? AND booking_datetime >= ? AND booking_status IN ?", userID, from, calendarFeedStatuses).
+		Order("booking_datetime ASC").
+		Find(&bookings).Error; err != nil {
+		return ical.Calendar{}, fmt.Errorf("failed to get bookings: %w", err)
+	}
+
+	expertNames, err := bs.expertNames(ctx, bookings)
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+
+	calendar := ical.Calendar{ProdID: calendarProdID, Name: "Lịch tư vấn"}
+	for i := range bookings {
+		b := &bookings[i]
+		summary := fmt.Sprintf("Tư vấn với %s", expertNames[b.ExpertProfileID])
+		calendar.Events = append(calendar.Events, b.CalendarEvent(summary, calendarDescription(b)))
+	}
+	return calendar, nil
+}
+
+// expertCalendar gồm các booking 1-1 của expert và các buổi tư vấn nhóm (mỗi buổi một sự kiện thay vì từng chỗ ngồi)
+func (bs *bookingservice) expertCalendar(ctx context.Context, userID uuid.UUID, from time.Time) (ical.Calendar, error) {
+	expert, err := bs.expertProfileForUser(ctx, userID.String())
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+
+	var bookings []entityBooking.ConsultationBooking
+	if err := bs.db.WithContext(ctx).Preload("User").
+		Where("expert_profile_id = ? AND group_session_id IS NULL AND booking_datetime >= ? AND booking_status IN ?",
+			expert.ExpertProfileID, from, calendarFeedStatuses).
+		Order("booking_datetime ASC").
+		Find(&bookings).Error; err != nil {
+		return ical.Calendar{}, fmt.Errorf("failed to get bookings: %w", err)
+	}
+
+	var sessions []entityBooking.GroupSession
+	if err := bs.db.WithContext(ctx).
+		Where("expert_profile_id = ? AND session_start >= ?", expert.ExpertProfileID, from).
+		Order("session_start ASC").
+		Find(&sessions).Error; err != nil {
+		return ical.Calendar{}, fmt.Errorf("failed to get group sessions: %w", err)
+	}
+
+	calendar := ical.Calendar{ProdID: calendarProdID, Name: "Lịch tư vấn của chuyên gia"}
+	for i := range bookings {
+		b := &bookings[i]
+		summary := fmt.Sprintf("Tư vấn với %s", b.User.FullName)
+		calendar.Events = append(calendar.Events, b.CalendarEvent(summary, calendarDescription(b)))
+	}
+	for i := range sessions {
+		s := &sessions[i]
+		taken, err := groupSessionSeatsTaken(bs.db.WithContext(ctx), s.SessionID)
+		if err != nil {
+			return ical.Calendar{}, fmt.Errorf("failed to count group session seats: %w", err)
+		}
+		description := fmt.Sprintf("Buổi tư vấn nhóm: %d/%d chỗ đã đặt", taken, s.Capacity)
+		if s.Description != nil && *s.Description != "" {
+			description = *s.Description + "\n\n" + description
+		}
+		calendar.Events = append(calendar.Events, s.CalendarEvent(description))
+	}
+	return calendar, nil
+}
+
+// expertNames nạp tên hiển thị của các expert trong danh sách booking bằng một truy vấn
+func (bs *bookingservice) expertNames(ctx context.Context, bookings []entityBooking.ConsultationBooking) (map[uuid.UUID]string, error) {
+	names := make(map[uuid.UUID]string)
+	if len(bookings) == 0 {
+		return names, nil
+	}
+	ids := make([]uuid.UUID, 0, len(bookings))
+	for _, b := range bookings {
+		ids = append(ids, b.ExpertProfileID)
+	}
+
+	var experts []entity.ExpertProfile
+	if err := bs.db.WithContext(ctx).Preload("User").
+		Where("expert_profile_id IN ?", ids).
+		Find(&experts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get experts: %w", err)
+	}
+	for _, e := range experts {
+		if e.User != nil {
+			names[e.ExpertProfileID] = e.User.FullName
+		}
+	}
+	return names, nil
+}
+
+func calendarDescription(b *entityBooking.ConsultationBooking) string {
+	description := fmt.Sprintf("Mã booking: %s\nHình thức: %s", b.BookingID, b.ConsultationType)
+	if b.UserNotes != nil && *b.UserNotes != "" {
+		description += "\nGhi chú: " + *b.UserNotes
+	}
+	return description
+}
+
+func calendarFeedURL(token string) string {
+	return strings.TrimRight(global.ConfigConection.SMTPCF.BaseURL, "/") + calendarFeedPath + token + calendarFeedExtension
+}
+
+func newCalendarToken() (string, error) {
+	buf := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
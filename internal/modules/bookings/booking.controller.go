@@ -4,9 +4,11 @@ import (
 	"cbs_backend/internal/modules/bookings/dtobookings"
 	"cbs_backend/pkg/response"
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -18,6 +20,19 @@ func NewBookingController(logger *zap.Logger) *BookingController {
 	return &BookingController{Logger: logger}
 }
 
+// getUserIDFromContext lấy userID đã được AuthMiddleware gắn vào context
+func getUserIDFromContext(c *gin.Context) (string, error) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		return "", response.NewAPIError(http.StatusUnauthorized, "Unauthorized", "UserID not found in context")
+	}
+	userID, ok := userIDValue.(uuid.UUID)
+	if !ok {
+		return "", response.NewAPIError(http.StatusInternalServerError, "Internal error", "Invalid userID type")
+	}
+	return userID.String(), nil
+}
+
 func (bc *BookingController) CreateBooking(c *gin.Context) (res interface{}, err error) {
 	var req dtobookings.CreateBookingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -33,6 +48,21 @@ func (bc *BookingController) CreateBooking(c *gin.Context) (res interface{}, err
 
 	return resp, nil
 }
+func (bc *BookingController) GetPriceQuote(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.GetPriceQuoteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		bc.Logger.Error("Invalid get price quote request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get price quote request", err)
+	}
+
+	resp, err := Booking().GetPriceQuote(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Get price quote failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get price quote failed", err)
+	}
+
+	return resp, nil
+}
 func (bc *BookingController) GetUpcomingBookingsForExpert(c *gin.Context) (res interface{}, err error) {
 	var req dtobookings.GetUpcomingBookingForExpertRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -81,6 +111,24 @@ func (bc *BookingController) ConfirmBooking(c *gin.Context) (res interface{}, er
 
 	return resp, nil
 }
+func (bc *BookingController) RejectBooking(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.RejectBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid reject booking request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid reject booking request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().RejectBooking(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Reject booking failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Reject booking failed", err)
+	}
+
+	return resp, nil
+}
 func (bc *BookingController) GetAvailableSlots(c *gin.Context) (res interface{}, err error) {
 	var req dtobookings.GetAvailableSlotsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -223,3 +271,333 @@ func (bc *BookingController) SearchBookings(c *gin.Context) (res interface{}, er
 
 	return resp, nil
 }
+
+func (bc *BookingController) CreateBookingSeries(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.CreateBookingSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid create booking series request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid create booking series request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().CreateBookingSeries(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Create booking series failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Create booking series failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) GetBookingSeries(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.GetBookingSeriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		bc.Logger.Error("Invalid get booking series request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get booking series request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().GetBookingSeries(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Get booking series failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get booking series failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) CancelSeriesOccurrences(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.CancelSeriesOccurrencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid cancel series request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid cancel series request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().CancelSeriesOccurrences(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Cancel series occurrences failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Cancel series occurrences failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) RescheduleSeriesOccurrences(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.RescheduleSeriesOccurrencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid reschedule series request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid reschedule series request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().RescheduleSeriesOccurrences(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Reschedule series occurrences failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Reschedule series occurrences failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) JoinWaitlist(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid join waitlist request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid join waitlist request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().JoinWaitlist(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Join waitlist failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Join waitlist failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) GetMyWaitlist(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.GetMyWaitlistRequest
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().GetMyWaitlist(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Get waitlist failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusInternalServerError, "Get waitlist failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) LeaveWaitlist(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.LeaveWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid leave waitlist request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid leave waitlist request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().LeaveWaitlist(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Leave waitlist failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Leave waitlist failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) ClaimSlotHold(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.ClaimSlotHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid claim slot hold request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid claim slot hold request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().ClaimSlotHold(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Claim slot hold failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Claim slot hold failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) CreateGroupSession(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.CreateGroupSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid create group session request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid create group session request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().CreateGroupSession(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Create group session failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Create group session failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) ListGroupSessions(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.ListGroupSessionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		bc.Logger.Error("Invalid list group sessions request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid list group sessions request", err)
+	}
+
+	resp, err := Booking().ListGroupSessions(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("List group sessions failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "List group sessions failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) BookGroupSeat(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.BookGroupSeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid book group seat request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid book group seat request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().BookGroupSeat(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Book group seat failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Book group seat failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) GetGroupSessionAttendees(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.GetGroupSessionAttendeesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		bc.Logger.Error("Invalid get group session attendees request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get group session attendees request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().GetGroupSessionAttendees(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Get group session attendees failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get group session attendees failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) CancelGroupSession(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.CancelGroupSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid cancel group session request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid cancel group session request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().CancelGroupSession(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Cancel group session failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Cancel group session failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) CompleteGroupSession(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.CompleteGroupSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid complete group session request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid complete group session request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().CompleteGroupSession(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Complete group session failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Complete group session failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) GetExpertSchedule(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.GetExpertScheduleRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		bc.Logger.Error("Invalid get expert schedule request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get expert schedule request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().GetExpertSchedule(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Get expert schedule failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get expert schedule failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) CreateCalendarFeed(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.CreateCalendarFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid create calendar feed request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid create calendar feed request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().CreateCalendarFeed(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Create calendar feed failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Create calendar feed failed", err)
+	}
+
+	return resp, nil
+}
+
+func (bc *BookingController) RevokeCalendarFeed(c *gin.Context) (res interface{}, err error) {
+	var req dtobookings.RevokeCalendarFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bc.Logger.Error("Invalid revoke calendar feed request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid revoke calendar feed request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Booking().RevokeCalendarFeed(context.Background(), req)
+	if err != nil {
+		bc.Logger.Error("Revoke calendar feed failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Revoke calendar feed failed", err)
+	}
+
+	return resp, nil
+}
+
+// GetCalendarFeed trả file .ics thô cho ứng dụng lịch, token trong URL thay cho header Authorization
+func (bc *BookingController) GetCalendarFeed(c *gin.Context) {
+	file, err := Booking().GetCalendarFeed(context.Background(), c.Param("token"))
+	if err != nil {
+		bc.Logger.Warn("Get calendar feed failed", zap.Error(err))
+		response.ErrorResponse(c, http.StatusNotFound, "Calendar feed not found", err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", file.FileName))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, file.ContentType, file.Content)
+}
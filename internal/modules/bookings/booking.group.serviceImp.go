@@ -0,0 +1,474 @@
+package bookings
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/internal/modules/experts/entity"
+	paymenttransactions "cbs_backend/internal/modules/payment_transactions"
+	"cbs_backend/internal/modules/payment_transactions/dtopayments"
+	"cbs_backend/internal/modules/realtime"
+	entityUser "cbs_backend/internal/modules/users/entity"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	minGroupSessionCapacity = 2
+	maxGroupSessionCapacity = 100
+)
+
+// groupSeatStatuses là các trạng thái booking còn giữ chỗ trong buổi nhóm
+var groupSeatStatuses = []string{common.BookingStatusPending, common.BookingStatusConfirmed, common.BookingStatusCompleted}
+
+// CreateGroupSession cho expert mở buổi tư vấn nhóm; buổi chiếm lịch của expert như một booking thường
+func (bs *bookingservice) CreateGroupSession(ctx context.Context, req dtobookings.CreateGroupSessionRequest) (*dtobookings.GroupSessionResponse, error) {
+	now := time.Now()
+
+	// 1. Validate input
+	if strings.TrimSpace(req.Title) == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	if req.Capacity < minGroupSessionCapacity || req.Capacity > maxGroupSessionCapacity {
+		return nil, fmt.Errorf("invalid capacity: must be between %d-%d seats", minGroupSessionCapacity, maxGroupSessionCapacity)
+	}
+	if req.SeatPrice < 0 {
+		return nil, fmt.Errorf("seat price cannot be negative")
+	}
+	if req.ConsultationType != common.ConsultationTypeOnline && req.ConsultationType != common.ConsultationTypeOffline {
+		return nil, fmt.Errorf("invalid consultation type: %s", req.ConsultationType)
+	}
+
+	// 2. Chỉ expert mới mở được buổi nhóm; thời lượng và thời điểm theo quy tắc đặt lịch của expert
+	expert, err := bs.expertProfileForUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if reason := validateSlotWindow(req.SessionStart, req.DurationMinutes, expert.BookingRules(), now); reason != "" {
+		return nil, errors.New(reason)
+	}
+	currency := req.Currency
+	if currency == "" {
+		currency = expert.Currency
+	}
+	currency = common.NormalizeCurrency(currency)
+
+	// 3. Giữ slot cho cả buổi theo cùng quy tắc với booking thường (giờ làm việc, lịch nghỉ, trùng lịch)
+	reservation, err := bs.reserveSlot(ctx, slotRequest{
+		ExpertProfileID: expert.ExpertProfileID,
+		Start:           req.SessionStart,
+		DurationMinutes: req.DurationMinutes,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	defer reservation.Release(ctx)
+
+	session := &entityBooking.GroupSession{
+		ExpertProfileID:  expert.ExpertProfileID,
+		Title:            strings.TrimSpace(req.Title),
+		Description:      req.Description,
+		SessionStart:     req.SessionStart,
+		DurationMinutes:  req.DurationMinutes,
+		Capacity:         req.Capacity,
+		SeatPriceMinor:   common.ToMinorUnits(req.SeatPrice, currency),
+		Currency:         currency,
+		ConsultationType: req.ConsultationType,
+		MeetingLink:      req.MeetingLink,
+		MeetingAddress:   req.MeetingAddress,
+		SessionStatus:    common.GroupSessionStatusScheduled,
+		SessionUpdatedAt: now,
+	}
+	if err := bs.db.WithContext(ctx).Create(session).Error; err != nil {
+		return nil, fmt.Errorf("failed to create group session: %w", err)
+	}
+	reservation.Commit()
+
+	bs.logger.Info("✅ Group session created",
+		zap.String("session_id", session.SessionID.String()),
+		zap.Int("capacity", session.Capacity))
+
+	resp := toGroupSessionResponse(session, 0)
+	return &resp, nil
+}
+
+// ListGroupSessions trả về các buổi nhóm đang mở của expert trong khoảng ngày
+func (bs *bookingservice) ListGroupSessions(ctx context.Context, req dtobookings.ListGroupSessionsRequest) (*dtobookings.ListGroupSessionsResponse, error) {
+	expertID, err := uuid.Parse(req.ExpertProfileID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expert_profile_id: %w", err)
+	}
+	if req.FromDate.After(req.ToDate) {
+		return nil, fmt.Errorf("from_date cannot be after to_date")
+	}
+	sessions, err := bs.scheduledGroupSessions(ctx, expertID, req.FromDate, req.ToDate.Add(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	return &dtobookings.ListGroupSessionsResponse{Sessions: sessions}, nil
+}
+
+// BookGroupSeat đặt một chỗ trong buổi nhóm. Chỗ được xác nhận ngay vì expert đã mở buổi;
+// chỗ chưa thanh toán sẽ bị worker hủy sau hạn thanh toán như booking thường.
+func (bs *bookingservice) BookGroupSeat(ctx context.Context, req dtobookings.BookGroupSeatRequest) (*dtobookings.BookGroupSeatResponse, error) {
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+	var user entityUser.User
+	if err := bs.db.WithContext(ctx).First(&user, "user_id = ?", userID).Error; err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	var (
+		session entityBooking.GroupSession
+		booking *entityBooking.ConsultationBooking
+		taken   int64
+	)
+	err = bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Khoá buổi để đếm chỗ không bị vượt capacity khi nhiều người đặt cùng lúc
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&session, "session_id = ?", req.SessionID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("group session not found")
+			}
+			return fmt.Errorf("failed to get group session: %w", err)
+		}
+		if session.SessionStatus != common.GroupSessionStatusScheduled {
+			return fmt.Errorf("group session is %s", session.SessionStatus)
+		}
+		rules, err := bs.expertBookingRules(ctx, session.ExpertProfileID)
+		if err != nil {
+			return err
+		}
+		if time.Until(session.SessionStart) < rules.MinNotice {
+			return fmt.Errorf("cannot book less than %d minutes before the session", int(rules.MinNotice.Minutes()))
+		}
+
+		var mine int64
+		if err := tx.Model(&entityBooking.ConsultationBooking{}).
+			Where("group_session_id = ? AND user_id = ? AND booking_status IN ?", session.SessionID, userID, groupSeatStatuses).
+			Count(&mine).Error; err != nil {
+			return fmt.Errorf("failed to check existing seat: %w", err)
+		}
+		if mine > 0 {
+			return fmt.Errorf("you already have a seat in this session")
+		}
+
+		hasConflict, err := bs.helper.CheckUserConflictDB(ctx, req.UserID, session.SessionStart, session.SessionEnd())
+		if err != nil {
+			return fmt.Errorf("failed to check user booking conflicts: %w", err)
+		}
+		if hasConflict {
+			return fmt.Errorf("user has a conflicting booking at the session time")
+		}
+
+		if taken, err = groupSessionSeatsTaken(tx, session.SessionID); err != nil {
+			return err
+		}
+		if int(taken) >= session.Capacity {
+			return fmt.Errorf("group session is full")
+		}
+
+		fee := session.SeatPriceMinor
+		seat := &entityBooking.ConsultationBooking{
+			UserID:               userID,
+			ExpertProfileID:      session.ExpertProfileID,
+			BookingDatetime:      session.SessionStart,
+			DurationMinutes:      session.DurationMinutes,
+			ConsultationType:     session.ConsultationType,
+			BookingStatus:        common.BookingStatusPending,
+			UserNotes:            req.UserNotes,
+			MeetingLink:          session.MeetingLink,
+			MeetingAddress:       session.MeetingAddress,
+			ConsultationFeeMinor: &fee,
+			Currency:             session.Currency,
+			PaymentStatus:        common.PaymentStatusPending,
+			GroupSessionID:       &session.SessionID,
+		}
+		if err := tx.Create(seat).Error; err != nil {
+			return fmt.Errorf("failed to create seat booking: %w", err)
+		}
+		booking, err = ApplyBookingTransition(ctx, tx, BookingTransition{
+			BookingID: seat.BookingID,
+			Event:     BookingEventConfirm,
+			Actor:     BookingActorSystem,
+			ActorID:   SystemActorID,
+			Reason:    "Chỗ trong buổi tư vấn nhóm được xác nhận tự động",
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	taken++
+	// Số chỗ còn lại của buổi nằm trong lưới slot đã cache
+	publishBookingSlotsChanged(booking, "group_seat_booked")
+
+	go func() {
+		message := fmt.Sprintf("%s vừa đặt chỗ trong buổi \"%s\" (%d/%d chỗ)", user.FullName, session.Title, taken, session.Capacity)
+		_ = realtime.Send(session.ExpertProfileID.String(), message)
+	}()
+
+	return &dtobookings.BookGroupSeatResponse{
+		BookingID:     booking.BookingID.String(),
+		SessionID:     session.SessionID.String(),
+		SessionStart:  session.SessionStart,
+		BookingStatus: booking.BookingStatus,
+		PaymentStatus: booking.PaymentStatus,
+		SeatPrice:     session.SeatPrice(),
+		Currency:      session.Currency,
+		SeatsLeft:     session.Capacity - int(taken),
+	}, nil
+}
+
+// GetGroupSessionAttendees trả về danh sách người tham dự cho expert chủ buổi
+func (bs *bookingservice) GetGroupSessionAttendees(ctx context.Context, req dtobookings.GetGroupSessionAttendeesRequest) (*dtobookings.GetGroupSessionAttendeesResponse, error) {
+	session, err := bs.loadOwnedGroupSession(ctx, req.SessionID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var seats []entityBooking.ConsultationBooking
+	if err := bs.db.WithContext(ctx).Preload("User").
+		Where("group_session_id = ? AND booking_status IN ?", session.SessionID, groupSeatStatuses).
+		Order("booking_created_at ASC").
+		Find(&seats).Error; err != nil {
+		return nil, fmt.Errorf("failed to get attendees: %w", err)
+	}
+
+	resp := &dtobookings.GetGroupSessionAttendeesResponse{
+		SessionID: session.SessionID.String(),
+		Capacity:  session.Capacity,
+		Attendees: make([]dtobookings.GroupSessionAttendee, 0, len(seats)),
+	}
+	for _, seat := range seats {
+		resp.Attendees = append(resp.Attendees, dtobookings.GroupSessionAttendee{
+			BookingID:     seat.BookingID.String(),
+			UserID:        seat.UserID.String(),
+			FullName:      seat.User.FullName,
+			BookingStatus: seat.BookingStatus,
+			PaymentStatus: seat.PaymentStatus,
+		})
+	}
+	return resp, nil
+}
+
+// CancelGroupSession cho expert hủy cả buổi: hủy và hoàn tiền mọi chỗ còn hiệu lực rồi trả slot lại cho waitlist
+func (bs *bookingservice) CancelGroupSession(ctx context.Context, req dtobookings.CancelGroupSessionRequest) (*dtobookings.CancelGroupSessionResponse, error) {
+	session, err := bs.loadOwnedGroupSession(ctx, req.SessionID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if session.SessionStatus != common.GroupSessionStatusScheduled {
+		return nil, fmt.Errorf("cannot cancel a %s group session", session.SessionStatus)
+	}
+	expertUserID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format")
+	}
+
+	now := time.Now()
+	if err := bs.db.WithContext(ctx).Model(&entityBooking.GroupSession{}).
+		Where("session_id = ?", session.SessionID).
+		Updates(map[string]interface{}{
+			"session_status":      common.GroupSessionStatusCancelled,
+			"cancellation_reason": req.Reason,
+			"cancelled_at":        now,
+			"session_updated_at":  now,
+		}).Error; err != nil {
+		return nil, fmt.Errorf("failed to cancel group session: %w", err)
+	}
+
+	var seatIDs []uuid.UUID
+	if err := bs.db.WithContext(ctx).Model(&entityBooking.ConsultationBooking{}).
+		Where("group_session_id = ? AND booking_status IN ?", session.SessionID,
+			[]string{common.BookingStatusPending, common.BookingStatusConfirmed}).
+		Pluck("booking_id", &seatIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get seat bookings: %w", err)
+	}
+
+	resp := &dtobookings.CancelGroupSessionResponse{
+		SessionID:     session.SessionID.String(),
+		SessionStatus: common.GroupSessionStatusCancelled,
+	}
+	for _, seatID := range seatIDs {
+		seat, err := TransitionBooking(ctx, bs.db, BookingTransition{
+			BookingID: seatID,
+			Event:     BookingEventCancel,
+			Actor:     BookingActorExpert,
+			ActorID:   expertUserID,
+			Reason:    req.Reason,
+		})
+		if err != nil {
+			bs.logger.Error("❌ Failed to cancel seat booking", zap.String("booking_id", seatID.String()), zap.Error(err))
+			continue
+		}
+		resp.CancelledBookings++
+		if bs.cache != nil {
+			_ = bs.cache.DeleteBooking(ctx, seat.BookingID.String())
+		}
+
+		// Lỗi hoàn tiền chỉ log để admin xử lý tay, giống CancelBooking
+		refund, err := paymenttransactions.Payment().RefundBooking(ctx, dtopayments.RefundBookingRequest{
+			BookingID:   seat.BookingID.String(),
+			CancelledBy: common.CancelledByExpert,
+			Reason:      req.Reason,
+			CancelledAt: *seat.CancelledAt,
+		})
+		if err != nil {
+			bs.logger.Error("❌ Failed to refund cancelled seat", zap.String("booking_id", seat.BookingID.String()), zap.Error(err))
+		} else {
+			resp.RefundAmount += refund.RefundAmount
+		}
+
+		go realtime.Send(seat.UserID.String(), fmt.Sprintf("Buổi tư vấn nhóm \"%s\" đã bị hủy: %s", session.Title, req.Reason))
+	}
+
+	bs.releaseSlotToWaitlist(ctx, session.ExpertProfileID, session.SessionStart, session.DurationMinutes)
+	return resp, nil
+}
+
+// CompleteGroupSession hoàn thành mọi chỗ đã xác nhận; mỗi chỗ được ghi có doanh thu riêng và có thể đánh giá riêng
+func (bs *bookingservice) CompleteGroupSession(ctx context.Context, req dtobookings.CompleteGroupSessionRequest) (*dtobookings.CompleteGroupSessionResponse, error) {
+	session, err := bs.loadOwnedGroupSession(ctx, req.SessionID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if session.SessionStatus != common.GroupSessionStatusScheduled {
+		return nil, fmt.Errorf("cannot complete a %s group session", session.SessionStatus)
+	}
+	if time.Now().Before(session.SessionStart) {
+		return nil, fmt.Errorf("cannot complete a session that has not started")
+	}
+	expertUserID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format")
+	}
+
+	var seatIDs []uuid.UUID
+	if err := bs.db.WithContext(ctx).Model(&entityBooking.ConsultationBooking{}).
+		Where("group_session_id = ? AND booking_status = ?", session.SessionID, common.BookingStatusConfirmed).
+		Pluck("booking_id", &seatIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get seat bookings: %w", err)
+	}
+
+	resp := &dtobookings.CompleteGroupSessionResponse{
+		SessionID:     session.SessionID.String(),
+		SessionStatus: common.GroupSessionStatusCompleted,
+	}
+	for _, seatID := range seatIDs {
+		seat, err := TransitionBooking(ctx, bs.db, BookingTransition{
+			BookingID: seatID,
+			Event:     BookingEventComplete,
+			Actor:     BookingActorExpert,
+			ActorID:   expertUserID,
+		})
+		if err != nil {
+			bs.logger.Error("❌ Failed to complete seat booking", zap.String("booking_id", seatID.String()), zap.Error(err))
+			continue
+		}
+		resp.CompletedBookings++
+		go realtime.Send(seat.UserID.String(), fmt.Sprintf("Buổi tư vấn nhóm \"%s\" đã hoàn thành, hãy để lại đánh giá cho chuyên gia", session.Title))
+	}
+
+	now := time.Now()
+	if err := bs.db.WithContext(ctx).Model(&entityBooking.GroupSession{}).
+		Where("session_id = ?", session.SessionID).
+		Updates(map[string]interface{}{
+			"session_status":     common.GroupSessionStatusCompleted,
+			"session_updated_at": now,
+		}).Error; err != nil {
+		return nil, fmt.Errorf("failed to complete group session: %w", err)
+	}
+	return resp, nil
+}
+
+// scheduledGroupSessions trả về các buổi nhóm đang mở của expert bắt đầu trong [from, to)
+func (bs *bookingservice) scheduledGroupSessions(ctx context.Context, expertID uuid.UUID, from, to time.Time) ([]dtobookings.GroupSessionResponse, error) {
+	var sessions []entityBooking.GroupSession
+	if err := bs.db.WithContext(ctx).
+		Where("expert_profile_id = ? AND session_status = ? AND session_start >= ? AND session_start < ?",
+			expertID, common.GroupSessionStatusScheduled, from, to).
+		Order("session_start ASC").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get group sessions: %w", err)
+	}
+
+	result := make([]dtobookings.GroupSessionResponse, 0, len(sessions))
+	for i := range sessions {
+		taken, err := groupSessionSeatsTaken(bs.db.WithContext(ctx), sessions[i].SessionID)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, toGroupSessionResponse(&sessions[i], int(taken)))
+	}
+	return result, nil
+}
+
+func (bs *bookingservice) loadOwnedGroupSession(ctx context.Context, sessionID, userID string) (*entityBooking.GroupSession, error) {
+	var session entityBooking.GroupSession
+	if err := bs.db.WithContext(ctx).First(&session, "session_id = ?", sessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("group session not found")
+		}
+		return nil, fmt.Errorf("failed to get group session: %w", err)
+	}
+	if bs.expertUserID(ctx, session.ExpertProfileID).String() != userID {
+		return nil, fmt.Errorf("unauthorized: only the session's expert can manage it")
+	}
+	return &session, nil
+}
+
+// expertProfileForUser tìm hồ sơ expert của user đang đăng nhập
+func (bs *bookingservice) expertProfileForUser(ctx context.Context, userID string) (*entity.ExpertProfile, error) {
+	var expert entity.ExpertProfile
+	if err := bs.db.WithContext(ctx).First(&expert, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("unauthorized: user is not an expert")
+		}
+		return nil, fmt.Errorf("failed to get expert profile: %w", err)
+	}
+	return &expert, nil
+}
+
+func groupSessionSeatsTaken(db *gorm.DB, sessionID uuid.UUID) (int64, error) {
+	var taken int64
+	if err := db.Model(&entityBooking.ConsultationBooking{}).
+		Where("group_session_id = ? AND booking_status IN ?", sessionID, groupSeatStatuses).
+		Count(&taken).Error; err != nil {
+		return 0, fmt.Errorf("failed to count booked seats: %w", err)
+	}
+	return taken, nil
+}
+
+func toGroupSessionResponse(session *entityBooking.GroupSession, taken int) dtobookings.GroupSessionResponse {
+	seatsLeft := session.Capacity - taken
+	if seatsLeft < 0 {
+		seatsLeft = 0
+	}
+	return dtobookings.GroupSessionResponse{
+		SessionID:        session.SessionID.String(),
+		ExpertProfileID:  session.ExpertProfileID.String(),
+		Title:            session.Title,
+		Description:      session.Description,
+		SessionStart:     session.SessionStart,
+		SessionEnd:       session.SessionEnd(),
+		DurationMinutes:  session.DurationMinutes,
+		Capacity:         session.Capacity,
+		SeatsBooked:      taken,
+		SeatsLeft:        seatsLeft,
+		SeatPrice:        session.SeatPrice(),
+		Currency:         session.Currency,
+		ConsultationType: session.ConsultationType,
+		SessionStatus:    session.SessionStatus,
+	}
+}
@@ -0,0 +1,135 @@
+package bookings
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	entitySystem "cbs_backend/internal/modules/system_setting/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SettingKeyReschedulePolicy là key của chính sách đổi lịch trong tbl_system_settings
+const SettingKeyReschedulePolicy = "booking_reschedule_policy"
+
+// DefaultReschedulePolicy dùng khi chưa cấu hình trong system settings:
+// mỗi booking được đổi lịch tối đa 2 lần và phải đổi trước buổi tư vấn ít nhất 24h.
+func DefaultReschedulePolicy() dtobookings.ReschedulePolicy {
+	return dtobookings.ReschedulePolicy{
+		MaxReschedules:       2,
+		MinNoticeHoursBefore: 24,
+	}
+}
+
+// LoadReschedulePolicy đọc chính sách đổi lịch từ system settings, không có thì trả về mặc định
+func LoadReschedulePolicy(ctx context.Context, db *gorm.DB) (dtobookings.ReschedulePolicy, error) {
+	var setting entitySystem.SystemSetting
+	err := db.WithContext(ctx).Where("setting_key = ?", SettingKeyReschedulePolicy).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultReschedulePolicy(), nil
+	}
+	if err != nil {
+		return dtobookings.ReschedulePolicy{}, fmt.Errorf("failed to load reschedule policy: %w", err)
+	}
+
+	raw, err := json.Marshal(setting.SettingValue)
+	if err != nil {
+		return dtobookings.ReschedulePolicy{}, fmt.Errorf("failed to read reschedule policy: %w", err)
+	}
+	var policy dtobookings.ReschedulePolicy
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return dtobookings.ReschedulePolicy{}, fmt.Errorf("invalid reschedule policy setting: %w", err)
+	}
+	if policy.MaxReschedules < 0 || policy.MinNoticeHoursBefore < 0 {
+		return dtobookings.ReschedulePolicy{}, fmt.Errorf("invalid reschedule policy setting: values cannot be negative")
+	}
+	return policy, nil
+}
+
+// reschedulePolicyViolation trả về lý do booking không được đổi lịch theo policy (rỗng nếu được phép)
+func reschedulePolicyViolation(policy dtobookings.ReschedulePolicy, booking *entityBooking.ConsultationBooking, now time.Time) string {
+	if booking.RescheduleCount >= policy.MaxReschedules {
+		return fmt.Sprintf("booking has already been rescheduled %d times (limit %d)", booking.RescheduleCount, policy.MaxReschedules)
+	}
+	if booking.BookingDatetime.Sub(now).Hours() < policy.MinNoticeHoursBefore {
+		return fmt.Sprintf("bookings can only be rescheduled at least %s hours before the session", formatHours(policy.MinNoticeHoursBefore))
+	}
+	return ""
+}
+
+// rescheduleRejectedError là lý do nghiệp vụ khiến booking không được đổi lịch, phân biệt với lỗi DB
+type rescheduleRejectedError struct {
+	reason string
+}
+
+func (e *rescheduleRejectedError) Error() string {
+	return e.reason
+}
+
+// rescheduleRejectedReason trả về lý do nếu err là rescheduleRejectedError, ngược lại trả về rỗng
+func rescheduleRejectedReason(err error) string {
+	var rejected *rescheduleRejectedError
+	if errors.As(err, &rejected) {
+		return rejected.reason
+	}
+	return ""
+}
+
+// applyReschedule khoá dòng booking, kiểm tra lại trạng thái và policy trên dòng đã khoá rồi dời sang newStart
+// và đưa về pending chờ expert xác nhận lại. Dùng chung cho RescheduleBooking và đổi lịch theo chuỗi
+// để hai request đổi lịch đồng thời không cùng vượt qua giới hạn. Trả về booking sau khi dời và giờ cũ.
+func applyReschedule(ctx context.Context, tx *gorm.DB, bookingID uuid.UUID, newStart time.Time, policy dtobookings.ReschedulePolicy, now time.Time, actorID uuid.UUID, note string) (*entityBooking.ConsultationBooking, time.Time, error) {
+	var locked entityBooking.ConsultationBooking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&locked, "booking_id = ?", bookingID).Error; err != nil {
+		return nil, time.Time{}, fmt.Errorf("booking not found")
+	}
+	if locked.BookingStatus != common.BookingStatusPending && locked.BookingStatus != common.BookingStatusConfirmed {
+		return nil, time.Time{}, &rescheduleRejectedError{reason: fmt.Sprintf("cannot reschedule a %s booking", locked.BookingStatus)}
+	}
+	if newStart.Equal(locked.BookingDatetime) {
+		return nil, time.Time{}, &rescheduleRejectedError{reason: "new booking time is the same as the current one"}
+	}
+	if violation := reschedulePolicyViolation(policy, &locked, now); violation != "" {
+		return nil, time.Time{}, &rescheduleRejectedError{reason: violation}
+	}
+
+	oldDatetime := locked.BookingDatetime
+	reason := fmt.Sprintf("Đổi lịch từ %s sang %s", oldDatetime.Format("02/01/2006 15:04"), newStart.Format("02/01/2006 15:04"))
+	if note != "" {
+		reason += ": " + note
+	}
+	if err := tx.Model(&entityBooking.ConsultationBooking{}).
+		Where("booking_id = ?", bookingID).
+		Updates(map[string]interface{}{
+			"booking_datetime": newStart,
+			"reschedule_count": gorm.Expr("reschedule_count + 1"),
+		}).Error; err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to update booking: %w", err)
+	}
+	updated, err := ApplyBookingTransition(ctx, tx, BookingTransition{
+		BookingID: bookingID,
+		Event:     BookingEventReschedule,
+		Actor:     BookingActorUser,
+		ActorID:   actorID,
+		Reason:    reason,
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return updated, oldDatetime, nil
+}
+
+func formatHours(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.1f", v)
+}
@@ -0,0 +1,128 @@
+package bookings
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	maxScheduleDays      = 62
+	scheduleDateLayout   = "2006-01-02"
+	scheduleSourceWeekly = "weekly"
+	scheduleSourceDate   = "override"
+)
+
+// GetExpertSchedule dựng lịch cuối cùng của expert theo từng ngày: khung giờ làm việc (override theo ngày ưu tiên hơn
+// lịch tuần), các khoảng bận (kể cả lặp lại và ngày nghỉ toàn hệ thống) và lịch hẹn; dùng cùng nguồn dữ liệu với GetAvailableSlots.
+func (bs *bookingservice) GetExpertSchedule(ctx context.Context, req dtobookings.GetExpertScheduleRequest) (*dtobookings.ExpertScheduleResponse, error) {
+	expert, err := bs.expertProfileForUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	loc := expert.Location()
+	fromDay, err := time.ParseInLocation(scheduleDateLayout, req.FromDate, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from_date format (expecting YYYY-MM-DD): %w", err)
+	}
+	toDay, err := time.ParseInLocation(scheduleDateLayout, req.ToDate, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to_date format (expecting YYYY-MM-DD): %w", err)
+	}
+	if toDay.Before(fromDay) || toDay.After(fromDay.AddDate(0, 0, maxScheduleDays-1)) {
+		return nil, fmt.Errorf("date range must be between 1-%d days", maxScheduleDays)
+	}
+	from, to := fromDay, toDay.AddDate(0, 0, 1)
+
+	schedule, err := bs.expertWorkingSchedule(ctx, expert.ExpertProfileID, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+	unavailable, err := bs.unavailableIntervals(ctx, expert.ExpertProfileID, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var bookings []entityBooking.ConsultationBooking
+	if err := bs.db.WithContext(ctx).Preload("User").
+		Where("expert_profile_id = ? AND group_session_id IS NULL AND booking_datetime >= ? AND booking_datetime < ? AND booking_status IN ?",
+			expert.ExpertProfileID, from, to, []string{common.BookingStatusPending, common.BookingStatusConfirmed, common.BookingStatusCompleted}).
+		Order("booking_datetime ASC").
+		Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get bookings: %w", err)
+	}
+	var sessions []entityBooking.GroupSession
+	if err := bs.db.WithContext(ctx).
+		Where("expert_profile_id = ? AND session_status <> ? AND session_start >= ? AND session_start < ?",
+			expert.ExpertProfileID, common.GroupSessionStatusCancelled, from, to).
+		Order("session_start ASC").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get group sessions: %w", err)
+	}
+
+	var appointments []dtobookings.ScheduleAppointment
+	for i := range bookings {
+		b := &bookings[i]
+		appointments = append(appointments, dtobookings.ScheduleAppointment{
+			Type:      "booking",
+			ID:        b.BookingID.String(),
+			Title:     b.User.FullName,
+			StartTime: b.BookingDatetime.In(loc),
+			EndTime:   b.BookingDatetime.Add(time.Duration(b.DurationMinutes) * time.Minute).In(loc),
+			Status:    b.BookingStatus,
+		})
+	}
+	for i := range sessions {
+		s := &sessions[i]
+		appointments = append(appointments, dtobookings.ScheduleAppointment{
+			Type:      "group_session",
+			ID:        s.SessionID.String(),
+			Title:     s.Title,
+			StartTime: s.SessionStart.In(loc),
+			EndTime:   s.SessionEnd().In(loc),
+			Status:    s.SessionStatus,
+		})
+	}
+
+	res := &dtobookings.ExpertScheduleResponse{
+		ExpertProfileID: expert.ExpertProfileID.String(),
+		TimeZone:        loc.String(),
+	}
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		next := d.AddDate(0, 0, 1)
+		workingHours, overridden := schedule.On(d)
+		day := dtobookings.ExpertScheduleDay{
+			Date:           d.Format(scheduleDateLayout),
+			DayOfWeek:      common.WeekdayOf(d),
+			Source:         scheduleSourceWeekly,
+			WorkingPeriods: []dtobookings.SchedulePeriod{},
+			Unavailable:    []dtobookings.UnavailableTime{},
+			Appointments:   []dtobookings.ScheduleAppointment{},
+		}
+		if overridden {
+			day.Source = scheduleSourceDate
+		}
+		for _, wh := range workingHours {
+			day.WorkingPeriods = append(day.WorkingPeriods, dtobookings.SchedulePeriod{
+				StartTime: wh.StartTime.ToTime(d),
+				EndTime:   wh.EndTime.ToTime(d),
+			})
+		}
+		for _, u := range unavailable {
+			if u.StartDatetime.Before(next) && u.EndDatetime.After(d) {
+				u.StartDatetime, u.EndDatetime = u.StartDatetime.In(loc), u.EndDatetime.In(loc)
+				day.Unavailable = append(day.Unavailable, u)
+			}
+		}
+		for _, a := range appointments {
+			if !a.StartTime.Before(d) && a.StartTime.Before(next) {
+				day.Appointments = append(day.Appointments, a)
+			}
+		}
+		res.Days = append(res.Days, day)
+	}
+	return res, nil
+}
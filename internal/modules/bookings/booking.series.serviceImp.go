@@ -0,0 +1,509 @@
+package bookings
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/internal/modules/experts/entity"
+	paymenttransactions "cbs_backend/internal/modules/payment_transactions"
+	"cbs_backend/internal/modules/payment_transactions/dtopayments"
+	"cbs_backend/internal/modules/realtime"
+	entityUser "cbs_backend/internal/modules/users/entity"
+	"cbs_backend/utils/cache"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	minSeriesOccurrences = 2
+	maxSeriesOccurrences = 26
+	maxSeriesInterval    = 4 // tuần
+)
+
+/*
+CreateBookingSeries tạo toàn bộ các buổi của chuỗi lịch định kỳ trong một request.
+Mỗi buổi được giữ slot riêng qua reserveSlot (cùng quy tắc với CreateBooking);
+buổi bị trùng được trả về trong Conflicts thay vì làm hỏng cả request.
+Chỉ khi không buổi nào đặt được thì mới trả lỗi.
+*/
+func (bs *bookingservice) CreateBookingSeries(ctx context.Context, req dtobookings.CreateBookingSeriesRequest) (*dtobookings.BookingSeriesResponse, error) {
+	now := time.Now()
+
+	// 1. Validate input
+	if req.IntervalWeeks == 0 {
+		req.IntervalWeeks = 1
+	}
+	if req.IntervalWeeks < 1 || req.IntervalWeeks > maxSeriesInterval {
+		return nil, fmt.Errorf("invalid interval: must be between 1-%d weeks", maxSeriesInterval)
+	}
+	if req.Occurrences < minSeriesOccurrences || req.Occurrences > maxSeriesOccurrences {
+		return nil, fmt.Errorf("invalid occurrences: must be between %d-%d", minSeriesOccurrences, maxSeriesOccurrences)
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+	expertID, err := uuid.Parse(req.ExpertProfileID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expert profile ID format: %w", err)
+	}
+
+	// 2. Kiểm tra user/expert tồn tại
+	var user entityUser.User
+	if err := bs.db.WithContext(ctx).First(&user, "user_id = ?", userID).Error; err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	var expert entity.ExpertProfile
+	if err := bs.db.WithContext(ctx).First(&expert, "expert_profile_id = ?", expertID).Error; err != nil {
+		return nil, fmt.Errorf("expert not found")
+	}
+	// Thời lượng và buổi đầu theo quy tắc đặt lịch của expert; các buổi sau được reserveSlot kiểm tra từng buổi
+	if reason := validateSlotWindow(req.FirstBookingDatetime, req.DurationMinutes, expert.BookingRules(), now); reason != "" {
+		return nil, errors.New(reason)
+	}
+
+	// 3. Báo giá một lần cho cả chuỗi (chuỗi lịch không áp dụng mã khuyến mãi)
+	quote, err := bs.helper.CalculateConsultationFee(ctx, expertID, req.ConsultationType, req.DurationMinutes, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate consultation fee: %w", err)
+	}
+	consultationFeeMinor := quote.TotalAmountMinor
+
+	// 4. Kiểm tra từng buổi, giữ lock slot cho các buổi hợp lệ tới khi commit
+	var (
+		available []int
+		starts    = make([]time.Time, req.Occurrences)
+		reserved  []*slotReservation
+		conflicts []dtobookings.SeriesConflict
+	)
+	defer func() {
+		for _, reservation := range reserved {
+			reservation.Release(ctx)
+		}
+	}()
+	for i := 0; i < req.Occurrences; i++ {
+		start := req.FirstBookingDatetime.AddDate(0, 0, 7*req.IntervalWeeks*i)
+		starts[i] = start
+		reservation, err := bs.reserveSlot(ctx, slotRequest{
+			UserID:          req.UserID,
+			ExpertProfileID: expertID,
+			Start:           start,
+			DurationMinutes: req.DurationMinutes,
+		}, now)
+		if reason := slotUnavailableReason(err); reason != "" {
+			conflicts = append(conflicts, dtobookings.SeriesConflict{
+				SeriesIndex:     i + 1,
+				BookingDatetime: start,
+				Reason:          reason,
+			})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		reserved = append(reserved, reservation)
+		available = append(available, i)
+	}
+	if len(available) == 0 {
+		return nil, fmt.Errorf("none of the %d occurrences is available", req.Occurrences)
+	}
+
+	// 5. Tạo series và các booking trong cùng transaction
+	series := &entityBooking.BookingSeries{
+		UserID:            userID,
+		ExpertProfileID:   expertID,
+		IntervalWeeks:     req.IntervalWeeks,
+		OccurrenceCount:   req.Occurrences,
+		FirstOccurrenceAt: req.FirstBookingDatetime,
+		DurationMinutes:   req.DurationMinutes,
+		ConsultationType:  req.ConsultationType,
+		SeriesStatus:      common.SeriesStatusActive,
+		SeriesUpdatedAt:   now,
+	}
+	created := make([]*entityBooking.ConsultationBooking, 0, len(available))
+	err = bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(series).Error; err != nil {
+			return fmt.Errorf("failed to create booking series: %w", err)
+		}
+		for _, i := range available {
+			index := i + 1
+			fee := consultationFeeMinor
+			booking := &entityBooking.ConsultationBooking{
+				UserID:               userID,
+				ExpertProfileID:      expertID,
+				BookingDatetime:      starts[i],
+				DurationMinutes:      req.DurationMinutes,
+				ConsultationType:     req.ConsultationType,
+				BookingStatus:        common.BookingStatusPending,
+				UserNotes:            req.UserNotes,
+				ConsultationFeeMinor: &fee,
+				Currency:             quote.Currency,
+				PaymentStatus:        "pending",
+				SeriesID:             &series.SeriesID,
+				SeriesIndex:          &index,
+			}
+			if err := tx.Create(booking).Error; err != nil {
+				return fmt.Errorf("failed to create booking for occurrence %d: %w", index, err)
+			}
+			created = append(created, booking)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, reservation := range reserved {
+		reservation.Commit()
+	}
+
+	// 6. Cập nhật cache
+	if bs.cache != nil {
+		for _, booking := range created {
+			_ = bs.cache.CacheBooking(ctx, &cache.BookingCacheData{
+				BookingID:        booking.BookingID.String(),
+				UserID:           booking.UserID.String(),
+				ExpertProfileID:  booking.ExpertProfileID.String(),
+				BookingDatetime:  booking.BookingDatetime,
+				DurationMinutes:  booking.DurationMinutes,
+				BookingStatus:    booking.BookingStatus,
+				ConsultationType: booking.ConsultationType,
+			})
+		}
+	}
+
+	// 7. Thông báo realtime cho expert
+	go func() {
+		message := fmt.Sprintf("Bạn có chuỗi %d lịch hẹn mới từ %s, buổi đầu tiên lúc %s",
+			len(created), user.FullName, common.FormatInZone(created[0].BookingDatetime, expert.TimeZone, "15:04 02/01/2006"))
+		_ = realtime.Send(expertID.String(), message)
+	}()
+
+	bs.logger.Info("✅ Booking series created",
+		zap.String("series_id", series.SeriesID.String()),
+		zap.Int("created", len(created)),
+		zap.Int("conflicts", len(conflicts)))
+
+	fee := quote.TotalAmount
+	occurrences := make([]dtobookings.SeriesOccurrence, 0, len(created))
+	for _, booking := range created {
+		occurrences = append(occurrences, toSeriesOccurrence(booking))
+	}
+	return &dtobookings.BookingSeriesResponse{
+		SeriesID:         series.SeriesID.String(),
+		ExpertProfileID:  expertID.String(),
+		IntervalWeeks:    series.IntervalWeeks,
+		SeriesStatus:     series.SeriesStatus,
+		DurationMinutes:  series.DurationMinutes,
+		ConsultationType: series.ConsultationType,
+		FeePerOccurrence: &fee,
+		Currency:         quote.Currency,
+		Occurrences:      occurrences,
+		Conflicts:        conflicts,
+	}, nil
+}
+
+// GetBookingSeries trả về chuỗi lịch cùng mọi buổi (kể cả buổi đã hủy) của người dùng sở hữu
+func (bs *bookingservice) GetBookingSeries(ctx context.Context, req dtobookings.GetBookingSeriesRequest) (*dtobookings.BookingSeriesResponse, error) {
+	series, err := bs.loadOwnedSeries(ctx, req.SeriesID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	bookings, err := bs.seriesBookings(ctx, series.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dtobookings.BookingSeriesResponse{
+		SeriesID:         series.SeriesID.String(),
+		ExpertProfileID:  series.ExpertProfileID.String(),
+		IntervalWeeks:    series.IntervalWeeks,
+		SeriesStatus:     series.SeriesStatus,
+		DurationMinutes:  series.DurationMinutes,
+		ConsultationType: series.ConsultationType,
+		Occurrences:      make([]dtobookings.SeriesOccurrence, 0, len(bookings)),
+	}
+	for i := range bookings {
+		resp.Occurrences = append(resp.Occurrences, toSeriesOccurrence(&bookings[i]))
+	}
+	if len(bookings) > 0 {
+		resp.FeePerOccurrence = bookings[0].ConsultationFee()
+		resp.Currency = bookings[0].Currency
+	}
+	return resp, nil
+}
+
+// CancelSeriesOccurrences hủy một buổi (scope=this) hoặc buổi đó và các buổi sau (scope=following).
+// Buổi đã quá hạn hủy theo chính sách hoàn tiền được bỏ qua và trả về trong Skipped.
+func (bs *bookingservice) CancelSeriesOccurrences(ctx context.Context, req dtobookings.CancelSeriesOccurrencesRequest) (*dtobookings.CancelSeriesOccurrencesResponse, error) {
+	series, err := bs.loadOwnedSeries(ctx, req.SeriesID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := bs.seriesTargets(ctx, series, req.BookingID, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+	userUUID := series.UserID
+
+	policy, err := paymenttransactions.LoadRefundPolicy(ctx, bs.db)
+	if err != nil {
+		return nil, err
+	}
+	minNotice := time.Duration(policy.MinCancelHoursBefore * float64(time.Hour))
+
+	reason := "Người dùng hủy buổi trong chuỗi lịch"
+	if req.Reason != "" {
+		reason = req.Reason
+	}
+
+	resp := &dtobookings.CancelSeriesOccurrencesResponse{SeriesID: series.SeriesID.String()}
+	for i := range targets {
+		target := &targets[i]
+		if time.Until(target.BookingDatetime) < minNotice {
+			resp.Skipped = append(resp.Skipped, dtobookings.SeriesConflict{
+				SeriesIndex:     seriesIndexOf(target),
+				BookingID:       target.BookingID.String(),
+				BookingDatetime: target.BookingDatetime,
+				Reason:          fmt.Sprintf("cannot cancel booking less than %s hours before the appointment", strconv.FormatFloat(policy.MinCancelHoursBefore, 'f', -1, 64)),
+			})
+			continue
+		}
+		updated, err := TransitionBooking(ctx, bs.db, BookingTransition{
+			BookingID: target.BookingID,
+			Event:     BookingEventCancel,
+			Actor:     BookingActorUser,
+			ActorID:   userUUID,
+			Reason:    reason,
+		})
+		if err != nil {
+			resp.Skipped = append(resp.Skipped, dtobookings.SeriesConflict{
+				SeriesIndex:     seriesIndexOf(target),
+				BookingID:       target.BookingID.String(),
+				BookingDatetime: target.BookingDatetime,
+				Reason:          err.Error(),
+			})
+			continue
+		}
+		if bs.cache != nil {
+			_ = bs.cache.DeleteBooking(ctx, updated.BookingID.String())
+		}
+		bs.releaseSlotToWaitlist(ctx, updated.ExpertProfileID, updated.BookingDatetime, updated.DurationMinutes)
+
+		// Lỗi hoàn tiền chỉ log để admin xử lý tay, giống CancelBooking
+		refund, err := paymenttransactions.Payment().RefundBooking(ctx, dtopayments.RefundBookingRequest{
+			BookingID:   updated.BookingID.String(),
+			CancelledBy: common.CancelledByUser,
+			Reason:      reason,
+			CancelledAt: *updated.CancelledAt,
+		})
+		if err != nil {
+			bs.logger.Error("❌ Failed to refund cancelled series occurrence", zap.String("booking_id", updated.BookingID.String()), zap.Error(err))
+		} else {
+			resp.RefundAmount += refund.RefundAmount
+		}
+		resp.Cancelled = append(resp.Cancelled, toSeriesOccurrence(updated))
+	}
+
+	// Chuỗi hết buổi còn hiệu lực thì đánh dấu cancelled
+	var remaining int64
+	if err := bs.db.WithContext(ctx).Model(&entityBooking.ConsultationBooking{}).
+		Where("series_id = ? AND booking_status IN ?", series.SeriesID, []string{common.BookingStatusPending, common.BookingStatusConfirmed}).
+		Count(&remaining).Error; err != nil {
+		return nil, fmt.Errorf("failed to count remaining occurrences: %w", err)
+	}
+	resp.SeriesStatus = series.SeriesStatus
+	if remaining == 0 && series.SeriesStatus != common.SeriesStatusCancelled {
+		if err := bs.db.WithContext(ctx).Model(&entityBooking.BookingSeries{}).
+			Where("series_id = ?", series.SeriesID).
+			Updates(map[string]interface{}{
+				"series_status":     common.SeriesStatusCancelled,
+				"series_updated_at": time.Now(),
+			}).Error; err != nil {
+			return nil, fmt.Errorf("failed to update series status: %w", err)
+		}
+		resp.SeriesStatus = common.SeriesStatusCancelled
+	}
+
+	if len(resp.Cancelled) > 0 {
+		go realtime.Send(series.ExpertProfileID.String(), fmt.Sprintf("%d buổi trong chuỗi lịch %s đã bị hủy!", len(resp.Cancelled), series.SeriesID.String()))
+	}
+	return resp, nil
+}
+
+// RescheduleSeriesOccurrences dời một buổi (scope=this) hoặc buổi đó và các buổi sau (scope=following) cùng một khoảng lệch.
+// Buổi bị trùng lịch ở giờ mới giữ nguyên giờ cũ và được trả về trong Conflicts.
+func (bs *bookingservice) RescheduleSeriesOccurrences(ctx context.Context, req dtobookings.RescheduleSeriesOccurrencesRequest) (*dtobookings.RescheduleSeriesOccurrencesResponse, error) {
+	series, err := bs.loadOwnedSeries(ctx, req.SeriesID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := bs.seriesTargets(ctx, series, req.BookingID, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	rules, err := bs.expertBookingRules(ctx, series.ExpertProfileID)
+	if err != nil {
+		return nil, err
+	}
+	if reason := validateSlotWindow(req.NewBookingDatetime, targets[0].DurationMinutes, rules, now); reason != "" {
+		return nil, errors.New(reason)
+	}
+	policy, err := LoadReschedulePolicy(ctx, bs.db)
+	if err != nil {
+		return nil, err
+	}
+	offset := req.NewBookingDatetime.Sub(targets[0].BookingDatetime)
+	if offset == 0 {
+		return nil, fmt.Errorf("new booking time is the same as the current one")
+	}
+
+	resp := &dtobookings.RescheduleSeriesOccurrencesResponse{SeriesID: series.SeriesID.String()}
+	for i := range targets {
+		target := &targets[i]
+		oldStart := target.BookingDatetime
+		newStart := oldStart.Add(offset)
+		updated, reason, err := bs.rescheduleSeriesOccurrence(ctx, series, target, newStart, req.Reason, policy, now)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			resp.Conflicts = append(resp.Conflicts, dtobookings.SeriesConflict{
+				SeriesIndex:     seriesIndexOf(target),
+				BookingID:       target.BookingID.String(),
+				BookingDatetime: newStart,
+				Reason:          reason,
+			})
+			continue
+		}
+		if bs.cache != nil {
+			_ = bs.cache.DeleteBooking(ctx, updated.BookingID.String())
+		}
+		bs.releaseSlotToWaitlist(ctx, updated.ExpertProfileID, oldStart, updated.DurationMinutes)
+		bs.publishBookingRescheduled(updated, oldStart, req.Reason)
+		resp.Rescheduled = append(resp.Rescheduled, toSeriesOccurrence(updated))
+	}
+
+	if len(resp.Rescheduled) > 0 {
+		go realtime.Send(series.ExpertProfileID.String(), fmt.Sprintf("%d buổi trong chuỗi lịch %s đã được đổi lịch, vui lòng xác nhận lại", len(resp.Rescheduled), series.SeriesID.String()))
+	}
+	return resp, nil
+}
+
+// rescheduleSeriesOccurrence dời một buổi sang newStart theo cùng policy và quy tắc giữ slot với RescheduleBooking;
+// trả về lý do nếu buổi không dời được
+func (bs *bookingservice) rescheduleSeriesOccurrence(ctx context.Context, series *entityBooking.BookingSeries, target *entityBooking.ConsultationBooking, newStart time.Time, reason string, policy dtobookings.ReschedulePolicy, now time.Time) (*entityBooking.ConsultationBooking, string, error) {
+	if violation := reschedulePolicyViolation(policy, target, now); violation != "" {
+		return nil, violation, nil
+	}
+	reservation, err := bs.reserveSlot(ctx, slotRequest{
+		UserID:          series.UserID.String(),
+		ExpertProfileID: series.ExpertProfileID,
+		Start:           newStart,
+		DurationMinutes: target.DurationMinutes,
+		RescheduleOf:    &target.BookingID,
+	}, now)
+	if conflict := slotUnavailableReason(err); conflict != "" {
+		return nil, conflict, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer reservation.Release(ctx)
+
+	// Trạng thái và policy được kiểm tra lại trên dòng đã khoá, như RescheduleBooking
+	var updated *entityBooking.ConsultationBooking
+	err = bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		updated, _, err = applyReschedule(ctx, tx, target.BookingID, newStart, policy, now, series.UserID, reason)
+		return err
+	})
+	if err != nil {
+		if rejected := rescheduleRejectedReason(err); rejected != "" {
+			return nil, rejected, nil
+		}
+		if errors.Is(err, ErrInvalidBookingTransition) {
+			return nil, err.Error(), nil
+		}
+		return nil, "", err
+	}
+	reservation.Commit()
+	return updated, "", nil
+}
+
+func (bs *bookingservice) loadOwnedSeries(ctx context.Context, seriesID, userID string) (*entityBooking.BookingSeries, error) {
+	var series entityBooking.BookingSeries
+	if err := bs.db.WithContext(ctx).First(&series, "series_id = ?", seriesID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("booking series not found")
+		}
+		return nil, fmt.Errorf("failed to get booking series: %w", err)
+	}
+	if series.UserID.String() != userID {
+		return nil, fmt.Errorf("unauthorized: user does not own this booking series")
+	}
+	return &series, nil
+}
+
+func (bs *bookingservice) seriesBookings(ctx context.Context, seriesID uuid.UUID) ([]entityBooking.ConsultationBooking, error) {
+	var bookings []entityBooking.ConsultationBooking
+	if err := bs.db.WithContext(ctx).
+		Where("series_id = ?", seriesID).
+		Order("series_index ASC").
+		Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get series bookings: %w", err)
+	}
+	return bookings, nil
+}
+
+// seriesTargets trả về buổi được chọn (scope=this) hoặc buổi đó cùng các buổi sau còn hiệu lực (scope=following),
+// buổi được chọn luôn đứng đầu
+func (bs *bookingservice) seriesTargets(ctx context.Context, series *entityBooking.BookingSeries, bookingID, scope string) ([]entityBooking.ConsultationBooking, error) {
+	if scope != common.SeriesScopeThis && scope != common.SeriesScopeFollowing {
+		return nil, fmt.Errorf("invalid scope: must be %s or %s", common.SeriesScopeThis, common.SeriesScopeFollowing)
+	}
+	var selected entityBooking.ConsultationBooking
+	if err := bs.db.WithContext(ctx).
+		First(&selected, "booking_id = ? AND series_id = ?", bookingID, series.SeriesID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("booking not found in this series")
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	if scope == common.SeriesScopeThis {
+		return []entityBooking.ConsultationBooking{selected}, nil
+	}
+
+	var following []entityBooking.ConsultationBooking
+	if err := bs.db.WithContext(ctx).
+		Where("series_id = ? AND series_index > ? AND booking_status IN ?",
+			series.SeriesID, seriesIndexOf(&selected), []string{common.BookingStatusPending, common.BookingStatusConfirmed}).
+		Order("series_index ASC").
+		Find(&following).Error; err != nil {
+		return nil, fmt.Errorf("failed to get following occurrences: %w", err)
+	}
+	return append([]entityBooking.ConsultationBooking{selected}, following...), nil
+}
+
+func seriesIndexOf(booking *entityBooking.ConsultationBooking) int {
+	if booking.SeriesIndex == nil {
+		return 0
+	}
+	return *booking.SeriesIndex
+}
+
+func toSeriesOccurrence(booking *entityBooking.ConsultationBooking) dtobookings.SeriesOccurrence {
+	return dtobookings.SeriesOccurrence{
+		SeriesIndex:     seriesIndexOf(booking),
+		BookingID:       booking.BookingID.String(),
+		BookingDatetime: booking.BookingDatetime,
+		BookingStatus:   booking.BookingStatus,
+	}
+}
@@ -27,9 +27,11 @@ func Booking() IBookings {
 
 type IBookings interface {
 	CreateBooking(ctx context.Context, req dtobookings.CreateBookingRequest) (*dtobookings.CreateBookingResponse, error)
+	GetPriceQuote(ctx context.Context, req dtobookings.GetPriceQuoteRequest) (*dtobookings.PriceQuote, error)
 	GetUpcomingBookingsForExpert(ctx context.Context, req dtobookings.GetUpcomingBookingForExpertRequest) ([]*dtobookings.BookingResponse, error)
 	CancelBooking(ctx context.Context, bookingID string, userID string) (*dtobookings.CancelResponse, error)
 	ConfirmBooking(ctx context.Context, req dtobookings.ConfirmBooking) (*dtobookings.ConfirmBookingResponse, error)
+	RejectBooking(ctx context.Context, req dtobookings.RejectBookingRequest) (*dtobookings.RejectBookingResponse, error)
 	GetAvailableSlots(ctx context.Context, req dtobookings.GetAvailableSlotsRequest) (*dtobookings.GetAvailableSlotsResponse, error)
 	UpdateBookingNotes(ctx context.Context, req dtobookings.UpdateBookingNotesRequest) (*dtobookings.UpdateBookingNotesResponse, error)
 	GetBookingStatusHistory(ctx context.Context, req dtobookings.GetBookingStatusHistoryRequest) (*dtobookings.GetBookingStatusHistoryResponse, error)
@@ -40,4 +42,32 @@ type IBookings interface {
 	CompleteBooking(ctx context.Context, req dtobookings.CompleteBookingRequest) (*dtobookings.CompleteBookingResponse, error)
 	GetBookingStats(ctx context.Context, req dtobookings.GetBookingStatsRequest) (*dtobookings.GetBookingStatsResponse, error)
 	SearchBookings(ctx context.Context, req dtobookings.SearchBookingsRequest) (*dtobookings.SearchBookingsResponse, error)
+
+	// Chuỗi lịch định kỳ
+	CreateBookingSeries(ctx context.Context, req dtobookings.CreateBookingSeriesRequest) (*dtobookings.BookingSeriesResponse, error)
+	GetBookingSeries(ctx context.Context, req dtobookings.GetBookingSeriesRequest) (*dtobookings.BookingSeriesResponse, error)
+	CancelSeriesOccurrences(ctx context.Context, req dtobookings.CancelSeriesOccurrencesRequest) (*dtobookings.CancelSeriesOccurrencesResponse, error)
+	RescheduleSeriesOccurrences(ctx context.Context, req dtobookings.RescheduleSeriesOccurrencesRequest) (*dtobookings.RescheduleSeriesOccurrencesResponse, error)
+
+	// Waitlist khi expert kín lịch
+	JoinWaitlist(ctx context.Context, req dtobookings.JoinWaitlistRequest) (*dtobookings.WaitlistEntryResponse, error)
+	GetMyWaitlist(ctx context.Context, req dtobookings.GetMyWaitlistRequest) (*dtobookings.GetMyWaitlistResponse, error)
+	LeaveWaitlist(ctx context.Context, req dtobookings.LeaveWaitlistRequest) (*dtobookings.WaitlistEntryResponse, error)
+	ClaimSlotHold(ctx context.Context, req dtobookings.ClaimSlotHoldRequest) (*dtobookings.CreateBookingResponse, error)
+
+	// Buổi tư vấn nhóm
+	CreateGroupSession(ctx context.Context, req dtobookings.CreateGroupSessionRequest) (*dtobookings.GroupSessionResponse, error)
+	ListGroupSessions(ctx context.Context, req dtobookings.ListGroupSessionsRequest) (*dtobookings.ListGroupSessionsResponse, error)
+	BookGroupSeat(ctx context.Context, req dtobookings.BookGroupSeatRequest) (*dtobookings.BookGroupSeatResponse, error)
+	GetGroupSessionAttendees(ctx context.Context, req dtobookings.GetGroupSessionAttendeesRequest) (*dtobookings.GetGroupSessionAttendeesResponse, error)
+	CancelGroupSession(ctx context.Context, req dtobookings.CancelGroupSessionRequest) (*dtobookings.CancelGroupSessionResponse, error)
+	CompleteGroupSession(ctx context.Context, req dtobookings.CompleteGroupSessionRequest) (*dtobookings.CompleteGroupSessionResponse, error)
+
+	// Lịch làm việc cuối cùng của expert
+	GetExpertSchedule(ctx context.Context, req dtobookings.GetExpertScheduleRequest) (*dtobookings.ExpertScheduleResponse, error)
+
+	// Feed lịch .ics
+	CreateCalendarFeed(ctx context.Context, req dtobookings.CreateCalendarFeedRequest) (*dtobookings.CalendarFeedResponse, error)
+	RevokeCalendarFeed(ctx context.Context, req dtobookings.RevokeCalendarFeedRequest) (*dtobookings.RevokeCalendarFeedResponse, error)
+	GetCalendarFeed(ctx context.Context, token string) (*dtobookings.CalendarFile, error)
 }
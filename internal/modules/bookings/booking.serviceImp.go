@@ -1,10 +1,16 @@
 package bookings
 
 import (
+	"cbs_backend/internal/common"
 	"cbs_backend/internal/kafka"
 	"cbs_backend/internal/modules/bookings/dtobookings"
 	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	exchangerates "cbs_backend/internal/modules/exchange_rates"
 	"cbs_backend/internal/modules/experts/entity"
+	paymenttransactions "cbs_backend/internal/modules/payment_transactions"
+	"cbs_backend/internal/modules/payment_transactions/dtopayments"
+	"cbs_backend/internal/modules/promotions"
+	"cbs_backend/internal/modules/promotions/dtopromotions"
 	"cbs_backend/internal/modules/realtime"
 	entityUser "cbs_backend/internal/modules/users/entity"
 	"cbs_backend/utils/cache"
@@ -15,6 +21,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bsm/redislock"
@@ -64,15 +72,6 @@ func (bs *bookingservice) CreateBooking(ctx context.Context, req dtobookings.Cre
 	if req.BookingDatetime.Before(now) {
 		return nil, fmt.Errorf("cannot book appointment in the past")
 	}
-	if req.DurationMinutes < 15 || req.DurationMinutes > 240 {
-		return nil, fmt.Errorf("invalid duration: must be between 15-240 minutes")
-	}
-	if req.BookingDatetime.Sub(now) < 15*time.Minute {
-		return nil, fmt.Errorf("cannot book less than 15 minutes before the appointment")
-	}
-	if req.BookingDatetime.After(now.Add(90 * 24 * time.Hour)) {
-		return nil, fmt.Errorf("cannot book appointment more than 90 days in advance")
-	}
 	userID, err := uuid.Parse(req.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid user ID format: %w", err)
@@ -91,28 +90,40 @@ func (bs *bookingservice) CreateBooking(ctx context.Context, req dtobookings.Cre
 	if err := bs.db.WithContext(ctx).First(&expert, "expert_profile_id = ?", expertID).Error; err != nil {
 		return nil, fmt.Errorf("expert not found")
 	}
+	if reason := validateSlotWindow(req.BookingDatetime, req.DurationMinutes, expert.BookingRules(), now); reason != "" {
+		return nil, errors.New(reason)
+	}
 
 	// if !expert.User.IsActive || expert.User == nil {
 	// 	return nil, fmt.Errorf("expert is not active")
 	// }
 
-	// 3. Kiểm tra ngày đặt có nằm trong ngày làm việc của expert không
-	goWeekday := int(req.BookingDatetime.Weekday()) // Go: 0=Chủ nhật, 1=Thứ hai, ..., 6=Thứ bảy
-	dbWeekday := goWeekday + 1
-	if dbWeekday > 7 {
-		dbWeekday = 1 // Chủ nhật
-	}
-	var whCount int64
-	if err := bs.db.WithContext(ctx).Model(&entity.ExpertWorkingHour{}).
-		Where("expert_profile_id = ? AND day_of_week = ? AND is_active = true", expertID, dbWeekday).
-		Count(&whCount).Error; err != nil {
-		return nil, fmt.Errorf("failed to check expert working hours: %w", err)
-	}
-	if whCount == 0 {
-		return nil, fmt.Errorf("expert does not work on this day")
+	// Phí tư vấn luôn tính phía server, không tin giá client gửi lên
+	quote, err := bs.helper.CalculateConsultationFee(ctx, expertID, req.ConsultationType, req.DurationMinutes, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate consultation fee: %w", err)
+	}
+
+	// Áp dụng mã khuyến mãi lên báo giá; lượt dùng được ghi nhận cùng transaction tạo booking
+	var couponEvaluation *dtopromotions.CouponEvaluation
+	if req.CouponCode != nil && strings.TrimSpace(*req.CouponCode) != "" {
+		couponEvaluation, err = promotions.Promotion().EvaluateCoupon(ctx, dtopromotions.CheckCouponInput{
+			CouponCode:       *req.CouponCode,
+			UserID:           userID,
+			ExpertProfileID:  expertID,
+			ConsultationType: req.ConsultationType,
+			AmountMinor:      quote.TotalAmountMinor,
+			Currency:         quote.Currency,
+			At:               now,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid coupon: %w", err)
+		}
+		promotions.ApplyToQuote(quote, couponEvaluation)
 	}
+	consultationFeeMinor := quote.TotalAmountMinor
 
-	// 4. Chặn spam đặt lịch liên tục
+	// 3. Chặn spam đặt lịch liên tục
 	var recentCount int64
 	if err := bs.db.WithContext(ctx).Model(&entityBooking.ConsultationBooking{}).
 		Where("user_id = ? AND booking_created_at > ?", userID, now.Add(-1*time.Minute)).
@@ -120,82 +131,59 @@ func (bs *bookingservice) CreateBooking(ctx context.Context, req dtobookings.Cre
 		return nil, fmt.Errorf("too many booking requests, please wait a moment")
 	}
 
-	// 5. Redis distributed lock theo slot (expert + time)
-	lockKey := fmt.Sprintf("booking:lock:%s:%s-%s", req.ExpertProfileID, req.BookingDatetime.Format(time.RFC3339), req.BookingDatetime.Add(time.Duration(req.DurationMinutes)*time.Minute).Format(time.RFC3339))
-	if bs.redisLocker == nil {
-		return nil, fmt.Errorf("redisLocker is not initialized")
-	}
-	lock, err := bs.redisLocker.Obtain(ctx, lockKey, 10*time.Second, &redislock.Options{
-		RetryStrategy: redislock.LimitRetry(redislock.LinearBackoff(100*time.Millisecond), 30),
-	})
-	if err == redislock.ErrNotObtained {
-		return nil, fmt.Errorf("another booking is being processed for this slot, please try again")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to acquire booking lock: %w", err)
-	}
-	defer func() {
-		_ = lock.Release(ctx)
-	}()
-
-	// 6. Kiểm tra double booking và conflict trong DB (sau khi đã lock)
-	startTime := req.BookingDatetime
-	endTime := startTime.Add(time.Duration(req.DurationMinutes) * time.Minute)
-
-	duplicate, err := bs.helper.CheckDuplicateBooking(ctx, req.UserID, req.ExpertProfileID, startTime, endTime)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check duplicate booking: %w", err)
-	}
-	if duplicate {
-		return nil, fmt.Errorf("you have already booked this expert at this time")
-	}
-
-	hasConflict, err := bs.helper.CheckUserConflictDB(ctx, req.UserID, startTime, endTime)
+	// 4. Giữ slot: giờ làm việc, lịch nghỉ, Redis lock, trùng lịch user/expert, slot giữ cho waitlist
+	reservation, err := bs.reserveSlot(ctx, slotRequest{
+		UserID:          req.UserID,
+		ExpertProfileID: expertID,
+		Start:           req.BookingDatetime,
+		DurationMinutes: req.DurationMinutes,
+	}, now)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check user booking conflicts: %w", err)
-	}
-	if hasConflict {
-		return nil, fmt.Errorf("user has a conflicting booking at the requested time")
+		return nil, err
 	}
+	defer reservation.Release(ctx)
+	startTime := reservation.Start
 
-	// 7. Transaction kiểm tra chuyên gia bị trùng lịch (FOR UPDATE)
+	// 5. Tạo booking mới trong transaction
 	tx := bs.db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
 		}
 	}()
-	count, err := bs.helper.CheckExpertAvailabilityDB(ctx, req.ExpertProfileID, startTime, endTime)
-	if err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to check expert availability: %w", err)
-	}
-	if count > 0 {
-		tx.Rollback()
-		return nil, fmt.Errorf("expert is not available for the requested time slot")
-	}
-
-	// 8. Tạo booking mới
 	newBooking := &entityBooking.ConsultationBooking{
-		UserID:           userID,
-		ExpertProfileID:  expertID,
-		BookingDatetime:  startTime,
-		DurationMinutes:  req.DurationMinutes,
-		ConsultationType: req.ConsultationType,
-		BookingStatus:    "pending",
-		UserNotes:        req.UserNotes,
-		ConsultationFee:  req.ConsultationFee,
-		PaymentStatus:    "pending",
+		UserID:               userID,
+		ExpertProfileID:      expertID,
+		BookingDatetime:      startTime,
+		DurationMinutes:      req.DurationMinutes,
+		ConsultationType:     req.ConsultationType,
+		BookingStatus:        "pending",
+		UserNotes:            req.UserNotes,
+		ConsultationFeeMinor: &consultationFeeMinor,
+		Currency:             quote.Currency,
+		PaymentStatus:        "pending",
 	}
 	if err := tx.Create(newBooking).Error; err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("failed to create booking: %w", err)
 	}
+	if couponEvaluation != nil {
+		if err := promotions.Promotion().RedeemCoupon(ctx, tx, dtopromotions.RedeemCouponInput{
+			Evaluation: couponEvaluation,
+			UserID:     userID,
+			BookingID:  newBooking.BookingID,
+			Currency:   quote.Currency,
+		}); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("failed to commit booking: %w", err)
 	}
+	reservation.Commit()
 
-	// 9. Cập nhật cache (nếu có)
+	// 6. Cập nhật cache (nếu có)
 	if bs.cache != nil {
 		_ = bs.cache.CacheBooking(ctx, &cache.BookingCacheData{
 			BookingID:        newBooking.BookingID.String(),
@@ -208,7 +196,7 @@ func (bs *bookingservice) CreateBooking(ctx context.Context, req dtobookings.Cre
 		})
 	}
 
-	// 10. Gửi event, notification, trả response (giữ nguyên như cũ)
+	// 7. Gửi event, notification, trả response (giữ nguyên như cũ)
 	go func() {
 		// Lấy thông tin user và expert
 		var user entityUser.User
@@ -250,10 +238,13 @@ func (bs *bookingservice) CreateBooking(ctx context.Context, req dtobookings.Cre
 			bookingNotes = *newBooking.UserNotes
 		}
 		amount := 0.0
-		if newBooking.ConsultationFee != nil {
-			amount = *newBooking.ConsultationFee
+		if fee := newBooking.ConsultationFee(); fee != nil {
+			amount = *fee
 		}
+		cancellationPolicy := bs.cancellationPolicyText(context.Background())
 
+		// Email xác nhận hiển thị giờ theo múi giờ của user
+		userStart := newBooking.BookingDatetime.In(user.Location())
 		event := kafka.BookingCreatedEvent{
 			EventType:          "booking_confirmation",
 			UserID:             newBooking.UserID.String(),
@@ -261,8 +252,9 @@ func (bs *bookingservice) CreateBooking(ctx context.Context, req dtobookings.Cre
 			ExpertID:           newBooking.ExpertProfileID.String(),
 			DoctorName:         doctorName,
 			DoctorSpecialty:    doctorSpecialty,
-			ConsultationDate:   newBooking.BookingDatetime.Format("2006-01-02"),
-			ConsultationTime:   newBooking.BookingDatetime.Format("15:04"),
+			ConsultationDate:   userStart.Format("2006-01-02"),
+			ConsultationTime:   userStart.Format("15:04"),
+			TimeZone:           user.Location().String(),
 			Duration:           newBooking.DurationMinutes,
 			ConsultationType:   newBooking.ConsultationType,
 			Location:           location,
@@ -270,7 +262,7 @@ func (bs *bookingservice) CreateBooking(ctx context.Context, req dtobookings.Cre
 			Amount:             amount,
 			PaymentStatus:      newBooking.PaymentStatus,
 			BookingNotes:       bookingNotes,
-			CancellationPolicy: "Có thể hủy trước 24 giờ",
+			CancellationPolicy: cancellationPolicy,
 			Email:              email,
 			FullName:           fullName,
 			ConfirmedAt:        time.Time{}, // Chưa xác nhận, để rỗng hoặc nil
@@ -284,7 +276,7 @@ func (bs *bookingservice) CreateBooking(ctx context.Context, req dtobookings.Cre
 	go func() {
 		message := fmt.Sprintf("Bạn có booking mới! Mã: %s, Thời gian: %s",
 			newBooking.BookingID.String(),
-			newBooking.BookingDatetime.Format("02/01/2006 15:04"),
+			common.FormatInZone(newBooking.BookingDatetime, expert.TimeZone, "02/01/2006 15:04"),
 		)
 		_ = realtime.Send(newBooking.ExpertProfileID.String(), message)
 	}()
@@ -299,11 +291,33 @@ func (bs *bookingservice) CreateBooking(ctx context.Context, req dtobookings.Cre
 		BookingStatus:    newBooking.BookingStatus,
 		PaymentStatus:    newBooking.PaymentStatus,
 		UserNotes:        newBooking.UserNotes,
-		ConsultationFee:  newBooking.ConsultationFee,
+		ConsultationFee:  newBooking.ConsultationFee(),
+		Currency:         newBooking.Currency,
 		BookingCreatedAt: newBooking.BookingCreatedAt,
+		Quote:            quote,
 	}
 	return response, nil
 }
+func (bs *bookingservice) GetPriceQuote(ctx context.Context, req dtobookings.GetPriceQuoteRequest) (*dtobookings.PriceQuote, error) {
+	expertID, err := uuid.Parse(req.ExpertProfileID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expert profile ID format: %w", err)
+	}
+	if req.ConsultationType != common.ConsultationTypeOnline && req.ConsultationType != common.ConsultationTypeOffline {
+		return nil, fmt.Errorf("invalid consultation type: %s", req.ConsultationType)
+	}
+	// Thời lượng theo quy tắc đặt lịch riêng của expert, giống bước giữ slot khi đặt lịch
+	rules, err := bs.expertBookingRules(ctx, expertID)
+	if err != nil {
+		return nil, err
+	}
+	if reason := validateDuration(req.DurationMinutes, rules); reason != "" {
+		return nil, errors.New(reason)
+	}
+
+	return bs.helper.CalculateConsultationFee(ctx, expertID, req.ConsultationType, req.DurationMinutes, time.Now())
+}
+
 func (bs *bookingservice) ConfirmBooking(ctx context.Context, req dtobookings.ConfirmBooking) (*dtobookings.ConfirmBookingResponse, error) {
 	var booking entityBooking.ConsultationBooking
 	if err := bs.db.WithContext(ctx).First(&booking, "booking_id = ?", req.BookingID).Error; err != nil {
@@ -314,14 +328,16 @@ func (bs *bookingservice) ConfirmBooking(ctx context.Context, req dtobookings.Co
 		return nil, fmt.Errorf("unauthorized")
 	}
 
-	if booking.BookingStatus != "pending" {
-		return nil, fmt.Errorf("booking not in pending state")
-	}
-
-	booking.BookingStatus = "confirmed"
-	if err := bs.db.WithContext(ctx).Save(&booking).Error; err != nil {
+	updated, err := TransitionBooking(ctx, bs.db, BookingTransition{
+		BookingID: booking.BookingID,
+		Event:     BookingEventConfirm,
+		Actor:     BookingActorExpert,
+		ActorID:   bs.expertUserID(ctx, booking.ExpertProfileID),
+	})
+	if err != nil {
 		return nil, err
 	}
+	booking = *updated
 
 	// Gửi notification với error handling
 	go func() {
@@ -358,32 +374,36 @@ func (bs *bookingservice) ConfirmBooking(ctx context.Context, req dtobookings.Co
 
 		// Kiểm tra ConsultationFee nil
 		var amount float64
-		if booking.ConsultationFee != nil {
-			amount = *booking.ConsultationFee
+		if fee := booking.ConsultationFee(); fee != nil {
+			amount = *fee
 		} else {
 			log.Printf("WARNING: ConsultationFee is nil for booking_id=%s", booking.BookingID.String())
 		}
 
-		// Tạo event với dữ liệu đầy đủ
+		cancellationPolicy := bs.cancellationPolicyText(context.Background())
+
+		// Tạo event với dữ liệu đầy đủ, ngày giờ theo múi giờ của user
+		userStart := booking.BookingDatetime.In(user.Location())
 		event := kafka.CreateBookingConfirmEvent(
-			booking.UserID.String(),                      // userID
-			booking.BookingID.String(),                   // bookingID
-			booking.ExpertProfileID.String(),             // expertID
-			user.UserEmail,                               // email
-			user.FullName,                                // fullName
-			doctorName,                                   // doctorName
-			expert.SpecializationList,                    // doctorSpecialty (nếu có)
-			booking.BookingDatetime.Format("2006-01-02"), // consultationDate
-			booking.BookingDatetime.Format("15:04"),      // consultationTime
-			booking.DurationMinutes,                      // duration
-			booking.ConsultationType,                     // consultationType
-			getLocationString(booking.MeetingAddress),    // location
-			getMeetingLinkString(booking.MeetingLink),    // meetingLink
+			booking.UserID.String(),                   // userID
+			booking.BookingID.String(),                // bookingID
+			booking.ExpertProfileID.String(),          // expertID
+			user.UserEmail,                            // email
+			user.FullName,                             // fullName
+			doctorName,                                // doctorName
+			expert.SpecializationList,                 // doctorSpecialty (nếu có)
+			userStart.Format("2006-01-02"),            // consultationDate
+			userStart.Format("15:04"),                 // consultationTime
+			booking.DurationMinutes,                   // duration
+			booking.ConsultationType,                  // consultationType
+			getLocationString(booking.MeetingAddress), // location
+			getMeetingLinkString(booking.MeetingLink), // meetingLink
 			amount,                                   // amount
 			booking.PaymentStatus,                    // paymentStatus
 			getBookingNotesString(booking.UserNotes), // bookingNotes
-			"Có thể hủy trước 24 giờ",                // cancellationPolicy
+			cancellationPolicy,                       // cancellationPolicy
 		)
+		event.TimeZone = user.Location().String()
 
 		// Publish event
 		if err := kafka.PublishBookingConfirmEvent(event); err != nil {
@@ -442,7 +462,8 @@ func (bs *bookingservice) GetUpcomingBookingsForExpert(ctx context.Context, req
 			ExpertNotes:      booking.ExpertNotes,
 			MeetingLink:      booking.MeetingLink,
 			MeetingAddress:   booking.MeetingAddress,
-			ConsultationFee:  booking.ConsultationFee,
+			ConsultationFee:  booking.ConsultationFee(),
+			Currency:         booking.Currency,
 			PaymentStatus:    booking.PaymentStatus,
 			BookingCreatedAt: booking.BookingCreatedAt,
 		})
@@ -467,36 +488,55 @@ func (bs *bookingservice) CancelBooking(ctx context.Context, bookingID string, u
 		return nil, fmt.Errorf("unauthorized: user does not own this booking")
 	}
 
-	// 3. Kiểm tra trạng thái
-	if booking.BookingStatus == "completed" {
-		return nil, fmt.Errorf("cannot cancel a completed booking")
-	}
-	if booking.BookingStatus == "cancelled" {
-		return nil, fmt.Errorf("booking is already cancelled")
+	// 4. Kiểm tra thời gian hủy theo chính sách hoàn tiền
+	policy, err := paymenttransactions.LoadRefundPolicy(ctx, bs.db)
+	if err != nil {
+		return nil, err
 	}
-
-	// 4. Kiểm tra thời gian hủy
-	if time.Until(booking.BookingDatetime) < time.Hour {
-		return nil, fmt.Errorf("cannot cancel booking less than 1 hour before the appointment")
+	minNotice := time.Duration(policy.MinCancelHoursBefore * float64(time.Hour))
+	if time.Until(booking.BookingDatetime) < minNotice {
+		return nil, fmt.Errorf("cannot cancel booking less than %s hours before the appointment", strconv.FormatFloat(policy.MinCancelHoursBefore, 'f', -1, 64))
 	}
 
-	// 5. Cập nhật trạng thái
-	booking.BookingStatus = "cancelled"
-	now := time.Now()
-	booking.CancelledAt = &now
+	// 5. Cập nhật trạng thái qua state machine (chỉ pending/confirmed mới hủy được)
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid user ID format")
 	}
-	booking.CancelledByUserID = &userUUID
-	if err := bs.db.WithContext(ctx).Save(&booking).Error; err != nil {
+	updated, err := TransitionBooking(ctx, bs.db, BookingTransition{
+		BookingID: booking.BookingID,
+		Event:     BookingEventCancel,
+		Actor:     BookingActorUser,
+		ActorID:   userUUID,
+		Reason:    "Người dùng hủy lịch",
+	})
+	if err != nil {
 		return nil, err
 	}
+	booking = *updated
+	now := *booking.CancelledAt
 
-	// 6. Xóa cache
+	// 6. Xóa cache và giữ slot vừa trống cho người trong waitlist
 	if bs.cache != nil {
 		_ = bs.cache.DeleteBooking(ctx, booking.BookingID.String())
 	}
+	bs.releaseSlotToWaitlist(ctx, booking.ExpertProfileID, booking.BookingDatetime, booking.DurationMinutes)
+
+	// 6.1 Hoàn tiền theo chính sách (booking đã hủy, lỗi hoàn tiền chỉ log để admin xử lý tay)
+	refundAmount := 0.0
+	refundPercentage := 0.0
+	refund, err := paymenttransactions.Payment().RefundBooking(ctx, dtopayments.RefundBookingRequest{
+		BookingID:   booking.BookingID.String(),
+		CancelledBy: common.CancelledByUser,
+		Reason:      "Người dùng hủy lịch",
+		CancelledAt: now,
+	})
+	if err != nil {
+		bs.logger.Error("❌ Failed to refund cancelled booking", zap.String("booking_id", bookingID), zap.Error(err))
+	} else {
+		refundAmount = refund.RefundAmount
+		refundPercentage = refund.RefundPercentage
+	}
 
 	// 7. Gửi notification realtime
 	go realtime.Send(booking.ExpertProfileID.String(), fmt.Sprintf("Lịch hẹn %s đã bị hủy!", booking.BookingID.String()))
@@ -531,8 +571,8 @@ func (bs *bookingservice) CancelBooking(ctx context.Context, bookingID string, u
 			meetingLink = *booking.MeetingLink
 		}
 		amount := 0.0
-		if booking.ConsultationFee != nil {
-			amount = *booking.ConsultationFee
+		if fee := booking.ConsultationFee(); fee != nil {
+			amount = *fee
 		}
 		cancellationBy := "system"
 		if booking.CancelledByUserID != nil {
@@ -546,9 +586,10 @@ func (bs *bookingservice) CancelBooking(ctx context.Context, bookingID string, u
 		if booking.CancellationReason != nil {
 			cancellationNote = *booking.CancellationReason
 		}
-		refundAmount := 0.0 // bạn có thể thêm trường refund trong DB nếu cần
-		refundDays := 7     // giả định default xử lý trong 7 ngày
+		refundDays := policy.RefundProcessDays
 		cancelledAt := now
+		userStart := booking.BookingDatetime.In(user.Location())
+		expertStart := booking.BookingDatetime.In(expert.Location())
 
 		event := kafka.BookingCancelledEvent{
 			EventType:         "booking_cancelled",
@@ -557,8 +598,12 @@ func (bs *bookingservice) CancelBooking(ctx context.Context, bookingID string, u
 			ExpertID:          booking.ExpertProfileID.String(),
 			DoctorName:        doctorName,
 			DoctorSpecialty:   doctorSpecialty,
-			ConsultationDate:  booking.BookingDatetime.Format("02-01-2006"),
-			ConsultationTime:  booking.BookingDatetime.Format("15:04"),
+			ConsultationDate:  userStart.Format("02-01-2006"),
+			ConsultationTime:  userStart.Format("15:04"),
+			TimeZone:          user.Location().String(),
+			ExpertDate:        expertStart.Format("02-01-2006"),
+			ExpertTime:        expertStart.Format("15:04"),
+			ExpertTimeZone:    expert.Location().String(),
 			Duration:          booking.DurationMinutes,
 			ConsultationType:  booking.ConsultationType,
 			Location:          location,
@@ -581,13 +626,197 @@ func (bs *bookingservice) CancelBooking(ctx context.Context, bookingID string, u
 
 	// 9. Trả response
 	return &dtobookings.CancelResponse{
-		BookingID:      bookingID,
-		CancelByUserID: userID,
-		Status:         booking.BookingStatus,
-		CancelledAt:    now,
+		BookingID:        bookingID,
+		CancelByUserID:   userID,
+		Status:           booking.BookingStatus,
+		CancelledAt:      now,
+		RefundPercentage: refundPercentage,
+		RefundAmount:     refundAmount,
 	}, nil
 }
 
+const (
+	defaultRejectAlternatives    = 5
+	maxRejectAlternatives        = 20
+	alternativeSlotLookaheadDays = 14
+)
+
+// RejectBooking cho expert sở hữu booking từ chối booking pending: hoàn tiền/hủy giao dịch đang mở và gợi ý khung giờ trống khác
+func (bs *bookingservice) RejectBooking(ctx context.Context, req dtobookings.RejectBookingRequest) (*dtobookings.RejectBookingResponse, error) {
+	bookingID, err := uuid.Parse(req.BookingID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid booking ID format: %w", err)
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("rejection reason is required")
+	}
+	limit := req.MaxAlternatives
+	if limit <= 0 {
+		limit = defaultRejectAlternatives
+	}
+	if limit > maxRejectAlternatives {
+		limit = maxRejectAlternatives
+	}
+
+	var booking entityBooking.ConsultationBooking
+	if err := bs.db.WithContext(ctx).First(&booking, "booking_id = ?", bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("booking not found")
+		}
+		return nil, err
+	}
+	if bs.expertUserID(ctx, booking.ExpertProfileID) != userID {
+		return nil, fmt.Errorf("unauthorized: only the booked expert can reject this booking")
+	}
+
+	updated, err := TransitionBooking(ctx, bs.db, BookingTransition{
+		BookingID: booking.BookingID,
+		Event:     BookingEventReject,
+		Actor:     BookingActorExpert,
+		ActorID:   userID,
+		Reason:    reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	booking = *updated
+	rejectedAt := booking.BookingUpdatedAt
+
+	if bs.cache != nil {
+		_ = bs.cache.DeleteBooking(ctx, booking.BookingID.String())
+	}
+	bs.releaseSlotToWaitlist(ctx, booking.ExpertProfileID, booking.BookingDatetime, booking.DurationMinutes)
+
+	res := &dtobookings.RejectBookingResponse{
+		BookingID:        booking.BookingID.String(),
+		Status:           booking.BookingStatus,
+		Reason:           reason,
+		RejectedAt:       rejectedAt,
+		AlternativeSlots: []dtobookings.TimeSlot{},
+		Message:          "Booking rejected",
+	}
+
+	// Expert từ chối nên hoàn tiền theo chính sách cho expert; booking chưa thanh toán thì chỉ hủy giao dịch đang mở và trả lại mã giảm giá
+	refundDays := 0
+	refund, err := paymenttransactions.Payment().RefundBooking(ctx, dtopayments.RefundBookingRequest{
+		BookingID:   booking.BookingID.String(),
+		CancelledBy: common.CancelledByExpert,
+		Reason:      "Chuyên gia từ chối lịch: " + reason,
+		CancelledAt: rejectedAt,
+	})
+	if err != nil {
+		bs.logger.Error("❌ Failed to refund rejected booking", zap.String("booking_id", booking.BookingID.String()), zap.Error(err))
+	} else {
+		res.RefundAmount = refund.RefundAmount
+		res.RefundPercentage = refund.RefundPercentage
+		res.CancelledTransactions = refund.CancelledTransactions
+		refundDays = refund.RefundProcessDays
+	}
+
+	alternatives, err := bs.findAlternativeSlots(ctx, &booking, limit)
+	if err != nil {
+		bs.logger.Warn("Failed to find alternative slots", zap.String("booking_id", booking.BookingID.String()), zap.Error(err))
+	} else {
+		res.AlternativeSlots = alternatives
+	}
+
+	go realtime.Send(booking.UserID.String(), fmt.Sprintf("Lịch hẹn %s của bạn đã bị chuyên gia từ chối: %s", booking.BookingID.String(), reason))
+
+	go func() {
+		var user entityUser.User
+		var expert entity.ExpertProfile
+		if err := bs.db.First(&user, "user_id = ?", booking.UserID).Error; err != nil {
+			log.Printf("❌ Failed to get user info: %v", err)
+			return
+		}
+		if err := bs.db.Preload("User").First(&expert, "expert_profile_id = ?", booking.ExpertProfileID).Error; err != nil {
+			log.Printf("❌ Failed to get expert info: %v", err)
+			return
+		}
+		doctorName := ""
+		if expert.User != nil {
+			doctorName = expert.User.FullName
+		}
+
+		// Giờ của booking và các khung giờ gợi ý đều hiển thị theo múi giờ của user
+		userLoc := user.Location()
+		slots := make([]kafka.SuggestedSlot, 0, len(res.AlternativeSlots))
+		for _, slot := range res.AlternativeSlots {
+			slots = append(slots, kafka.SuggestedSlot{
+				Date:      slot.StartTime.In(userLoc).Format("02-01-2006"),
+				StartTime: slot.StartTime.In(userLoc).Format("15:04"),
+				EndTime:   slot.EndTime.In(userLoc).Format("15:04"),
+			})
+		}
+
+		event := kafka.BookingRejectedEvent{
+			BookingID:         booking.BookingID.String(),
+			UserID:            booking.UserID.String(),
+			ExpertID:          booking.ExpertProfileID.String(),
+			DoctorName:        doctorName,
+			ConsultationDate:  booking.BookingDatetime.In(userLoc).Format("02-01-2006"),
+			ConsultationTime:  booking.BookingDatetime.In(userLoc).Format("15:04"),
+			TimeZone:          userLoc.String(),
+			Duration:          booking.DurationMinutes,
+			ConsultationType:  booking.ConsultationType,
+			Email:             user.UserEmail,
+			FullName:          user.FullName,
+			RejectionReason:   reason,
+			RefundAmount:      res.RefundAmount,
+			RefundProcessDays: refundDays,
+			AlternativeSlots:  slots,
+			RejectedAt:        rejectedAt,
+		}
+		if err := kafka.PublishBookingRejectedEvent(event); err != nil {
+			bs.logger.Warn("❌ Failed to publish booking rejected event", zap.Error(err))
+		}
+	}()
+
+	return res, nil
+}
+
+// findAlternativeSlots lấy tối đa limit khung giờ trống sắp tới của expert, cùng thời lượng với booking bị từ chối
+func (bs *bookingservice) findAlternativeSlots(ctx context.Context, booking *entityBooking.ConsultationBooking, limit int) ([]dtobookings.TimeSlot, error) {
+	now := time.Now()
+	available, err := bs.GetAvailableSlots(ctx, dtobookings.GetAvailableSlotsRequest{
+		ExpertProfileID:     booking.ExpertProfileID.String(),
+		FromDate:            now,
+		ToDate:              now.AddDate(0, 0, alternativeSlotLookaheadDays),
+		SlotDurationMinutes: booking.DurationMinutes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slots := make([]dtobookings.TimeSlot, 0, limit)
+	for _, slot := range available.AvailableSlots {
+		if len(slots) >= limit {
+			break
+		}
+		// Expert vừa từ chối khung giờ này nên không gợi ý lại
+		if slot.StartTime.Equal(booking.BookingDatetime) {
+			continue
+		}
+		slots = append(slots, slot)
+	}
+	return slots, nil
+}
+
+// expertTimeZone trả về múi giờ IANA của expert để format thông báo gửi expert; lỗi thì trả rỗng (dùng múi giờ mặc định)
+func (bs *bookingservice) expertTimeZone(ctx context.Context, expertID uuid.UUID) string {
+	var timeZone string
+	_ = bs.db.WithContext(ctx).Model(&entity.ExpertProfile{}).
+		Where("expert_profile_id = ?", expertID).
+		Select("time_zone").
+		Scan(&timeZone).Error
+	return timeZone
+}
+
 // Helper functions để xử lý pointer values
 func getLocationString(location *string) string {
 	if location != nil {
@@ -623,21 +852,40 @@ func (bs *bookingservice) GetAvailableSlots(
 		return nil, fmt.Errorf("from_date cannot be after to_date")
 	}
 
-	if req.FromDate.Before(time.Now().Truncate(24 * time.Hour)) {
+	// Slot sinh ra phải tuân theo quy tắc đặt lịch riêng của expert
+	rules, err := bs.expertBookingRules(ctx, expertID)
+	if err != nil {
+		return nil, err
+	}
+	if req.SlotDurationMinutes < rules.MinDurationMinutes || req.SlotDurationMinutes > rules.MaxDurationMinutes {
+		return nil, fmt.Errorf("slot_duration_minutes must be between %d-%d minutes for this expert", rules.MinDurationMinutes, rules.MaxDurationMinutes)
+	}
+
+	// FromDate/ToDate là ngày theo múi giờ người xem; giờ làm việc vẫn dựng theo múi giờ của expert
+	viewerLoc := rules.Location
+	if req.TimeZone != "" {
+		if err := common.ValidateTimeZone(req.TimeZone); err != nil {
+			return nil, err
+		}
+		viewerLoc = common.LocationOrDefault(req.TimeZone)
+	}
+	windowStart := calendarDayIn(req.FromDate, viewerLoc)
+	windowEnd := calendarDayIn(req.ToDate, viewerLoc).AddDate(0, 0, 1)
+	if !windowEnd.After(time.Now()) {
 		return nil, fmt.Errorf("cannot get slots for past dates")
 	}
 
-	// 2. Get expert working hours
-	var workingHours []dtobookings.WorkingHourRow
-	if err := bs.db.WithContext(ctx).
-		Model(&entity.ExpertWorkingHour{}).
-		Select("day_of_week, start_time, end_time").
-		Where("expert_profile_id = ? AND is_active = true", expertID).
-		Scan(&workingHours).Error; err != nil {
-		return nil, fmt.Errorf("failed to get expert working hours: %w", err)
+	// 2-5. Lưới slot theo từng ngày của expert (giờ làm việc, booking, hold, buổi nhóm, lịch nghỉ), lấy từ cache nếu có
+	gridDays, err := bs.slotGridDays(ctx, expertID, rules, windowStart, windowEnd, req.SlotDurationMinutes)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(workingHours) == 0 {
+	noSchedule := true
+	for _, day := range gridDays {
+		noSchedule = noSchedule && day.NoSchedule
+	}
+	if noSchedule {
 		return &dtobookings.GetAvailableSlotsResponse{
 			ExpertProfileID: req.ExpertProfileID,
 			FromDate:        req.FromDate,
@@ -645,48 +893,48 @@ func (bs *bookingservice) GetAvailableSlots(
 			AvailableSlots:  []dtobookings.TimeSlot{},
 			TotalSlots:      0,
 			Message:         "Expert has no working hours configured",
+			TimeZone:        viewerLoc.String(),
+			ExpertTimeZone:  rules.Location.String(),
 		}, nil
 	}
 
-	// 3. Get existing bookings that might conflict
-	var existingBookings []entityBooking.ConsultationBooking
-	if err := bs.db.WithContext(ctx).
-		Where("expert_profile_id = ? AND booking_datetime >= ? AND booking_datetime <= ? AND booking_status IN (?)",
-			expertID, req.FromDate, req.ToDate.Add(24*time.Hour), []string{"confirmed", "pending"}).
-		Find(&existingBookings).Error; err != nil {
-		return nil, fmt.Errorf("failed to get existing bookings: %w", err)
+	// 6. Lọc theo khoảng xem và thời điểm hiện tại (báo trước tối thiểu, số ngày đặt trước)
+	now := time.Now()
+	availableSlots := make([]dtobookings.TimeSlot, 0)
+	openSessions := make([]dtobookings.GroupSessionResponse, 0)
+	for _, day := range gridDays {
+		for _, slot := range utilshelper.FilterBookableSlots(day.Slots, windowStart, windowEnd, rules, now) {
+			slot.StartTime = slot.StartTime.In(viewerLoc)
+			slot.EndTime = slot.EndTime.In(viewerLoc)
+			availableSlots = append(availableSlots, slot)
+		}
+		for _, session := range day.GroupSessions {
+			if session.SessionStart.Before(windowStart) || !session.SessionStart.Before(windowEnd) {
+				continue
+			}
+			session.SessionStart = session.SessionStart.In(viewerLoc)
+			session.SessionEnd = session.SessionEnd.In(viewerLoc)
+			openSessions = append(openSessions, session)
+		}
 	}
 
-	// 4. Get unavailable times
-	var unavailableTimes []dtobookings.UnavailableTime
-	if err := bs.db.WithContext(ctx).
-		Model(&entity.ExpertUnavailableTime{}).
-		Select("unavailable_start_datetime, unavailable_end_datetime").
-		Where("expert_profile_id = ? AND unavailable_start_datetime <= ? AND unavailable_end_datetime>= ?",
-			expertID, req.ToDate.Add(24*time.Hour), req.FromDate).
-		Scan(&unavailableTimes).Error; err != nil {
-		return nil, fmt.Errorf("failed to get unavailable times: %w", err)
-	}
-
-	// 5. Generate available slots
-	availableSlots := bs.helper.GenerateAvailableSlots(
-		workingHours,
-		existingBookings,
-		unavailableTimes,
-		req.FromDate,
-		req.ToDate,
-		req.SlotDurationMinutes,
-	)
-
 	return &dtobookings.GetAvailableSlotsResponse{
 		ExpertProfileID: req.ExpertProfileID,
 		FromDate:        req.FromDate,
 		ToDate:          req.ToDate,
 		AvailableSlots:  availableSlots,
 		TotalSlots:      len(availableSlots),
+		GroupSessions:   openSessions,
+		TimeZone:        viewerLoc.String(),
+		ExpertTimeZone:  rules.Location.String(),
 	}, nil
 }
 
+// calendarDayIn trả về 00:00 tại loc của ngày dương lịch ghi trong t (theo offset t được gửi lên)
+func calendarDayIn(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
 func (bs *bookingservice) UpdateBookingNotes(ctx context.Context, req dtobookings.UpdateBookingNotesRequest) (*dtobookings.UpdateBookingNotesResponse, error) {
 	// Validate booking ID
 	bookingID, err := uuid.Parse(req.BookingID)
@@ -893,7 +1141,8 @@ func (bs *bookingservice) GetBookingByID(ctx context.Context, req dtobookings.Ge
 		ExpertNotes:      booking.ExpertNotes,
 		MeetingLink:      booking.MeetingLink,
 		MeetingAddress:   booking.MeetingAddress,
-		ConsultationFee:  *booking.ConsultationFee,
+		ConsultationFee:  valueOrZero(booking.ConsultationFee()),
+		Currency:         booking.Currency,
 		BookingCreatedAt: booking.BookingCreatedAt,
 		BookingUpdatedAt: booking.BookingUpdatedAt,
 	}
@@ -952,7 +1201,8 @@ func (bs *bookingservice) GetUserBookingHistory(ctx context.Context, req dtobook
 			ConsultationType: booking.ConsultationType,
 			BookingStatus:    booking.BookingStatus,
 			PaymentStatus:    booking.PaymentStatus,
-			ConsultationFee:  booking.ConsultationFee,
+			ConsultationFee:  booking.ConsultationFee(),
+			Currency:         booking.Currency,
 			BookingCreatedAt: booking.BookingCreatedAt,
 		})
 	}
@@ -968,126 +1218,128 @@ func (bs *bookingservice) GetUserBookingHistory(ctx context.Context, req dtobook
 }
 
 func (bs *bookingservice) RescheduleBooking(ctx context.Context, req dtobookings.RescheduleBookingRequest) (*dtobookings.RescheduleBookingResponse, error) {
+	now := time.Now()
+
+	// 1. Validate input
 	bookingID, err := uuid.Parse(req.BookingID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid booking ID format: %w", err)
 	}
-
 	userID, err := uuid.Parse(req.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid user ID format: %w", err)
 	}
 
-	// Validate new datetime
-	if req.NewBookingDatetime.Before(time.Now()) {
-		return nil, fmt.Errorf("cannot reschedule to past time")
-	}
-
-	// Redis lock for the new slot
-	lockKey := fmt.Sprintf("booking:lock:%s:%s", req.ExpertProfileID, req.NewBookingDatetime.Format(time.RFC3339))
-	lock, err := bs.redisLocker.Obtain(ctx, lockKey, 10*time.Second, &redislock.Options{
-		RetryStrategy: redislock.LimitRetry(redislock.LinearBackoff(100*time.Millisecond), 30),
-	})
-	if err == redislock.ErrNotObtained {
-		return nil, fmt.Errorf("time slot is being processed by another request")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to acquire lock: %w", err)
-	}
-	defer lock.Release(ctx)
-
-	// Start transaction
-	tx := bs.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Get existing booking
+	// 2. Lấy booking, kiểm tra quyền và trạng thái
 	var booking entityBooking.ConsultationBooking
-	if err := tx.WithContext(ctx).First(&booking, "booking_id = ?", bookingID).Error; err != nil {
-		tx.Rollback()
+	if err := bs.db.WithContext(ctx).First(&booking, "booking_id = ?", bookingID).Error; err != nil {
 		return nil, fmt.Errorf("booking not found")
 	}
-
-	// Authorization check
 	if booking.UserID != userID {
-		tx.Rollback()
 		return nil, fmt.Errorf("unauthorized")
 	}
-
-	// Check if booking can be rescheduled
-	if booking.BookingStatus == "completed" || booking.BookingStatus == "cancelled" {
-		tx.Rollback()
-		return nil, fmt.Errorf("cannot reschedule completed or cancelled booking")
+	if booking.BookingStatus != common.BookingStatusPending && booking.BookingStatus != common.BookingStatusConfirmed {
+		return nil, fmt.Errorf("cannot reschedule a %s booking", booking.BookingStatus)
 	}
-
-	// Check expert availability for new slot
-	// expertUUID, err := uuid.Parse(req.ExpertProfileID)
-	if err != nil {
-		return nil, fmt.Errorf("expert ID not is avalable")
+	if booking.GroupSessionID != nil {
+		return nil, fmt.Errorf("cannot reschedule a group session seat, cancel it and book another session instead")
+	}
+	if req.NewBookingDatetime.Equal(booking.BookingDatetime) {
+		return nil, fmt.Errorf("new booking time is the same as the current one")
 	}
-	newEndTime := req.NewBookingDatetime.Add(time.Duration(booking.DurationMinutes) * time.Minute)
 
-	count, err := bs.helper.CheckExpertAvailabilityDB(ctx, req.ExpertProfileID, req.NewBookingDatetime, newEndTime)
+	// 3. Giới hạn số lần đổi lịch và thời gian báo trước; kiểm tra sớm ở đây để khỏi giữ slot vô ích,
+	// kiểm tra quyết định nằm trên dòng đã khoá trong transaction ở bước 5
+	policy, err := LoadReschedulePolicy(ctx, bs.db)
 	if err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to check expert availability: %w", err)
+		return nil, err
 	}
-	if count > 0 {
-		tx.Rollback()
-		return nil, fmt.Errorf("expert is not available for the new time slot")
+	if violation := reschedulePolicyViolation(policy, &booking, now); violation != "" {
+		return nil, errors.New(violation)
 	}
 
-	// Update booking
-	oldDatetime := booking.BookingDatetime
-	booking.BookingDatetime = req.NewBookingDatetime
-	booking.BookingStatus = "pending" // Reset to pending for expert confirmation
-	booking.BookingUpdatedAt = time.Now()
-
-	if err := tx.Save(&booking).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to update booking: %w", err)
+	// 4. Giữ slot mới theo cùng quy tắc với CreateBooking, bỏ qua chính booking đang dời
+	reservation, err := bs.reserveSlot(ctx, slotRequest{
+		UserID:          req.UserID,
+		ExpertProfileID: booking.ExpertProfileID,
+		Start:           req.NewBookingDatetime,
+		DurationMinutes: booking.DurationMinutes,
+		RescheduleOf:    &booking.BookingID,
+	}, now)
+	if err != nil {
+		return nil, err
 	}
+	defer reservation.Release(ctx)
 
-	// Record status history
-	statusHistory := entityBooking.BookingStatusHistory{
-		BookingID:       booking.BookingID,
-		OldStatus:       &booking.BookingStatus,
-		NewStatus:       "rescheduled",
-		ChangedByUserID: userID,
-		ChangeReason:    &req.RescheduleReason,
-		StatusChangedAt: time.Now(),
-	}
-	if err := tx.Create(&statusHistory).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to record status history: %w", err)
+	// 5. Cập nhật giờ mới và đưa về pending chờ expert xác nhận lại (ghi lịch sử trong cùng transaction);
+	// applyReschedule kiểm tra lại trạng thái và policy trên dòng đã khoá
+	var oldDatetime time.Time
+	var updated *entityBooking.ConsultationBooking
+	err = bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		updated, oldDatetime, err = applyReschedule(ctx, tx, booking.BookingID, req.NewBookingDatetime, policy, now, userID, req.RescheduleReason)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	reservation.Commit()
 
-	if err := tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	// 6. Cache, trả slot cũ cho waitlist, event và notification
+	if bs.cache != nil {
+		_ = bs.cache.DeleteBooking(ctx, updated.BookingID.String())
 	}
+	bs.releaseSlotToWaitlist(ctx, updated.ExpertProfileID, oldDatetime, updated.DurationMinutes)
+	bs.publishBookingRescheduled(updated, oldDatetime, req.RescheduleReason)
 
-	// Send notifications
 	go func() {
+		expertZone := bs.expertTimeZone(context.Background(), updated.ExpertProfileID)
 		message := fmt.Sprintf("Booking %s has been rescheduled from %s to %s",
-			booking.BookingID.String(),
-			oldDatetime.Format("02/01/2006 15:04"),
-			req.NewBookingDatetime.Format("02/01/2006 15:04"))
-		_ = realtime.Send(booking.ExpertProfileID.String(), message)
+			updated.BookingID.String(),
+			common.FormatInZone(oldDatetime, expertZone, "02/01/2006 15:04"),
+			common.FormatInZone(updated.BookingDatetime, expertZone, "02/01/2006 15:04"))
+		_ = realtime.Send(updated.ExpertProfileID.String(), message)
 	}()
 
+	remaining := policy.MaxReschedules - updated.RescheduleCount
+	if remaining < 0 {
+		remaining = 0
+	}
 	return &dtobookings.RescheduleBookingResponse{
-		BookingID:          booking.BookingID.String(),
-		OldBookingDatetime: oldDatetime,
-		NewBookingDatetime: req.NewBookingDatetime,
-		BookingStatus:      booking.BookingStatus,
-		RescheduledAt:      time.Now(),
-		Message:            "Booking rescheduled successfully. Waiting for expert confirmation.",
+		BookingID:            updated.BookingID.String(),
+		OldBookingDatetime:   oldDatetime,
+		NewBookingDatetime:   updated.BookingDatetime,
+		BookingStatus:        updated.BookingStatus,
+		RescheduleCount:      updated.RescheduleCount,
+		RemainingReschedules: remaining,
+		RescheduledAt:        now,
+		Message:              "Booking rescheduled successfully. Waiting for expert confirmation.",
 	}, nil
 }
 
+// publishBookingRescheduled gửi event booking_updated để consumer thông báo cho expert về giờ mới
+func (bs *bookingservice) publishBookingRescheduled(booking *entityBooking.ConsultationBooking, oldDatetime time.Time, reason string) {
+	go func() {
+		event := kafka.BookingEvent{
+			BookingID: booking.BookingID.String(),
+			UserID:    booking.UserID.String(),
+			ExpertID:  booking.ExpertProfileID.String(),
+			EventData: map[string]interface{}{
+				"action":               "rescheduled",
+				"old_booking_datetime": oldDatetime,
+				"new_booking_datetime": booking.BookingDatetime,
+				"duration_minutes":     booking.DurationMinutes,
+				"reschedule_count":     booking.RescheduleCount,
+				"booking_status":       booking.BookingStatus,
+				"reason":               reason,
+			},
+		}
+		if err := kafka.PublishBookingUpdatedEvent(event); err != nil {
+			bs.logger.Warn("Failed to publish booking rescheduled event", zap.Error(err))
+		}
+	}()
+}
+
 func (bs *bookingservice) CompleteBooking(ctx context.Context, req dtobookings.CompleteBookingRequest) (*dtobookings.CompleteBookingResponse, error) {
 	bookingID, err := uuid.Parse(req.BookingID)
 	if err != nil {
@@ -1105,20 +1357,18 @@ func (bs *bookingservice) CompleteBooking(ctx context.Context, req dtobookings.C
 		return nil, fmt.Errorf("unauthorized")
 	}
 
-	// Check booking status
-	if booking.BookingStatus != "confirmed" {
-		return nil, fmt.Errorf("can only complete confirmed bookings")
-	}
-
-	// Update booking status
-	booking.BookingStatus = "completed"
-	completedAt := time.Now()
-	booking.BookingCompletedAt = &completedAt
-	booking.BookingUpdatedAt = completedAt
-
-	if err := bs.db.WithContext(ctx).Save(&booking).Error; err != nil {
-		return nil, fmt.Errorf("failed to complete booking: %w", err)
+	// State machine ghi có doanh thu cho expert cùng transaction với việc hoàn thành booking
+	updated, err := TransitionBooking(ctx, bs.db, BookingTransition{
+		BookingID: booking.BookingID,
+		Event:     BookingEventComplete,
+		Actor:     BookingActorExpert,
+		ActorID:   bs.expertUserID(ctx, booking.ExpertProfileID),
+	})
+	if err != nil {
+		return nil, err
 	}
+	booking = *updated
+	completedAt := *booking.BookingCompletedAt
 
 	// Send completion notification
 	go func() {
@@ -1168,15 +1418,29 @@ func (bs *bookingservice) GetBookingStats(ctx context.Context, req dtobookings.G
 		stats.StatusCounts[sc.BookingStatus] = sc.Count
 	}
 
-	// Get total spent
-	var totalSpent float64
+	// Get total spent: cộng theo từng tiền tệ rồi quy đổi về VND
+	var spentByCurrency []struct {
+		Currency   string
+		TotalMinor int64
+	}
 	if err := bs.db.WithContext(ctx).Model(&entityBooking.ConsultationBooking{}).
 		Where("user_id = ? AND booking_datetime >= ? AND booking_datetime <= ? AND payment_status = 'paid'", userUUID, req.FromDate, req.ToDate).
-		Select("COALESCE(SUM(consultation_fee), 0)").
-		Scan(&totalSpent).Error; err != nil {
+		Select("currency, COALESCE(SUM(consultation_fee_minor), 0) AS total_minor").
+		Group("currency").
+		Scan(&spentByCurrency).Error; err != nil {
 		return nil, fmt.Errorf("failed to get total spent: %w", err)
 	}
-	stats.TotalSpent = totalSpent
+	stats.Currency = common.CurrencyVND
+	stats.TotalSpentByCurrency = make(map[string]float64)
+	for _, sc := range spentByCurrency {
+		amount := common.FromMinorUnits(sc.TotalMinor, sc.Currency)
+		stats.TotalSpentByCurrency[sc.Currency] = amount
+		converted, err := exchangerates.ExchangeRate().Convert(ctx, amount, sc.Currency, stats.Currency, req.ToDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert total spent: %w", err)
+		}
+		stats.TotalSpent += converted
+	}
 
 	return &dtobookings.GetBookingStatsResponse{
 		UserID:      req.UserID,
@@ -1247,7 +1511,8 @@ func (bs *bookingservice) SearchBookings(ctx context.Context, req dtobookings.Se
 			ConsultationType: booking.ConsultationType,
 			BookingStatus:    booking.BookingStatus,
 			PaymentStatus:    booking.PaymentStatus,
-			ConsultationFee:  booking.ConsultationFee,
+			ConsultationFee:  booking.ConsultationFee(),
+			Currency:         booking.Currency,
 			BookingCreatedAt: booking.BookingCreatedAt,
 		})
 	}
@@ -1260,3 +1525,31 @@ func (bs *bookingservice) SearchBookings(ctx context.Context, req dtobookings.Se
 		TotalPages:  int((totalCount + int64(req.PageSize) - 1) / int64(req.PageSize)),
 	}, nil
 }
+
+func valueOrZero(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// expertUserID trả về user_id của expert để ghi vào lịch sử trạng thái
+func (bs *bookingservice) expertUserID(ctx context.Context, expertProfileID uuid.UUID) uuid.UUID {
+	var userID uuid.UUID
+	if err := bs.db.WithContext(ctx).Model(&entity.ExpertProfile{}).
+		Select("user_id").
+		Where("expert_profile_id = ?", expertProfileID).
+		Scan(&userID).Error; err != nil {
+		bs.logger.Warn("Failed to resolve expert user", zap.String("expert_profile_id", expertProfileID.String()), zap.Error(err))
+	}
+	return userID
+}
+
+// cancellationPolicyText mô tả chính sách hoàn tiền đang cấu hình để gửi kèm email; lỗi đọc thì dùng mặc định
+func (bs *bookingservice) cancellationPolicyText(ctx context.Context) string {
+	policy, err := paymenttransactions.LoadRefundPolicy(ctx, bs.db)
+	if err != nil {
+		policy = paymenttransactions.DefaultRefundPolicy()
+	}
+	return paymenttransactions.DescribeRefundPolicy(policy)
+}
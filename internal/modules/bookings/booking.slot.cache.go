@@ -0,0 +1,214 @@
+package bookings
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/kafka"
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/internal/modules/experts/entity"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+/*
+Cache lưới slot (slot grid)
+
+GetAvailableSlots là API public, mỗi lần gọi phải đọc giờ làm việc, booking, hold, buổi nhóm và lịch nghỉ của expert.
+Lưới slot của từng ngày (theo múi giờ expert) và từng thời lượng được lưu ở Redis qua BookingCache, chưa lọc theo
+thời điểm hiện tại (báo trước tối thiểu, số ngày đặt trước) nên dùng lại được cả ngày; mỗi request chỉ tính lại các ngày
+chưa có trong cache.
+
+Mọi thay đổi làm lịch trống đổi (booking mới/huỷ/từ chối/đổi lịch, hold của waitlist, buổi nhóm, giờ làm việc, giờ riêng
+theo ngày, lịch nghỉ, ngày nghỉ toàn hệ thống, quy tắc đặt lịch) gửi SlotAvailabilityChangedEvent lên topic booking-events;
+consumer xoá lưới của các ngày bị ảnh hưởng. Mỗi lần xoá tăng thế hệ của các ngày đó; lưới chỉ được ghi lại khi thế hệ
+chưa đổi kể từ lúc đọc cache, nên request đang tính lưới từ dữ liệu trước thay đổi không ghi đè được lần xoá. Hold hết hạn không có event nên lưới của ngày có hold chỉ dùng tới khi hold
+hết hạn (ValidUntil). TTL giới hạn thời gian lưới có thể cũ khi Kafka không chạy; CreateBooking vẫn kiểm tra lại slot
+trên DB nên lưới cũ không làm đặt trùng lịch.
+*/
+
+const (
+	slotGridTTL       = 10 * time.Minute
+	slotGridDayLayout = "2006-01-02"
+)
+
+// slotGridDay là lưới slot của một ngày theo múi giờ expert, dạng lưu trong cache
+type slotGridDay struct {
+	Slots         []dtobookings.TimeSlot             `json:"slots"`
+	GroupSessions []dtobookings.GroupSessionResponse `json:"group_sessions"` // Buổi nhóm còn chỗ bắt đầu trong ngày
+	NoSchedule    bool                               `json:"no_schedule"`    // Expert chưa cấu hình giờ làm việc nào
+	ValidUntil    *time.Time                         `json:"valid_until,omitempty"`
+}
+
+// slotGridDays trả về lưới slot của các ngày (theo múi giờ expert) giao với [windowStart, windowEnd), theo thứ tự ngày.
+// Ngày có trong cache được dùng lại; các ngày còn thiếu được tính bằng một lượt truy vấn rồi ghi lại vào cache.
+func (bs *bookingservice) slotGridDays(ctx context.Context, expertID uuid.UUID, rules entity.BookingRules, windowStart, windowEnd time.Time, slotDuration int) ([]slotGridDay, error) {
+	var dayStarts []time.Time
+	var keys []string
+	for d := rules.DayStart(windowStart); d.Before(windowEnd); d = d.AddDate(0, 0, 1) {
+		dayStarts = append(dayStarts, d)
+		keys = append(keys, d.Format(slotGridDayLayout))
+	}
+
+	var cached map[string][]byte
+	var generations map[string]string
+	if bs.cache != nil {
+		var err error
+		if cached, generations, err = bs.cache.GetSlotGrids(ctx, expertID.String(), keys, slotDuration); err != nil {
+			bs.logger.Warn("Failed to read slot grid cache", zap.String("expert_profile_id", expertID.String()), zap.Error(err))
+		}
+	}
+
+	now := time.Now()
+	days := make([]slotGridDay, len(keys))
+	firstMiss, lastMiss := -1, -1
+	for i, key := range keys {
+		if data, ok := cached[key]; ok {
+			var day slotGridDay
+			if err := json.Unmarshal(data, &day); err == nil && (day.ValidUntil == nil || now.Before(*day.ValidUntil)) {
+				days[i] = day
+				continue
+			}
+		}
+		if firstMiss < 0 {
+			firstMiss = i
+		}
+		lastMiss = i
+	}
+	if firstMiss < 0 {
+		return days, nil
+	}
+
+	computed, err := bs.computeSlotGrid(ctx, expertID, rules, dayStarts[firstMiss], dayStarts[lastMiss].AddDate(0, 0, 1), slotDuration)
+	if err != nil {
+		return nil, err
+	}
+	toCache := make(map[string]interface{}, lastMiss-firstMiss+1)
+	for i := firstMiss; i <= lastMiss; i++ {
+		days[i] = computed[keys[i]]
+		toCache[keys[i]] = days[i]
+	}
+	if bs.cache != nil {
+		if err := bs.cache.SetSlotGrids(ctx, expertID.String(), slotDuration, toCache, generations, slotGridTTL); err != nil {
+			bs.logger.Warn("Failed to write slot grid cache", zap.String("expert_profile_id", expertID.String()), zap.Error(err))
+		}
+	}
+	return days, nil
+}
+
+// computeSlotGrid tính lưới slot của các ngày trong [from, to) (from/to là 00:00 theo múi giờ expert), trả về theo ngày
+func (bs *bookingservice) computeSlotGrid(ctx context.Context, expertID uuid.UUID, rules entity.BookingRules, from, to time.Time, slotDuration int) (map[string]slotGridDay, error) {
+	// Nới đầu khoảng để bắt buổi kéo dài qua 00:00 của ngày đầu
+	queryFrom := from.Add(-time.Duration(rules.MaxDurationMinutes)*time.Minute - rules.Padding())
+	queryTo := to.AddDate(0, 0, 1)
+
+	// 1. Giờ làm việc (lịch tuần + giờ riêng theo ngày)
+	workingSchedule, err := bs.expertWorkingSchedule(ctx, expertID, queryFrom, queryTo, rules.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make(map[string]slotGridDay)
+	dayKey := func(t time.Time) string { return t.In(rules.Location).Format(slotGridDayLayout) }
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		days[d.Format(slotGridDayLayout)] = slotGridDay{
+			Slots:         []dtobookings.TimeSlot{},
+			GroupSessions: []dtobookings.GroupSessionResponse{},
+			NoSchedule:    workingSchedule.IsEmpty(),
+		}
+	}
+	if workingSchedule.IsEmpty() {
+		return days, nil
+	}
+
+	// 2. Booking có thể trùng
+	var existingBookings []entityBooking.ConsultationBooking
+	if err := bs.db.WithContext(ctx).
+		Where("expert_profile_id = ? AND group_session_id IS NULL AND booking_datetime >= ? AND booking_datetime <= ? AND booking_status IN (?)",
+			expertID, queryFrom, queryTo, []string{"confirmed", "pending", "completed"}).
+		Find(&existingBookings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get existing bookings: %w", err)
+	}
+
+	// Slot đang giữ cho người trong waitlist cũng coi như đã có người đặt; lưới của ngày chỉ dùng tới khi hold hết hạn
+	var holds []entityBooking.SlotHold
+	if err := bs.db.WithContext(ctx).
+		Where("expert_profile_id = ? AND hold_status = ? AND expires_at > ? AND slot_start >= ? AND slot_start <= ?",
+			expertID, common.SlotHoldStatusActive, time.Now(), queryFrom, queryTo).
+		Find(&holds).Error; err != nil {
+		return nil, fmt.Errorf("failed to get slot holds: %w", err)
+	}
+	for _, hold := range holds {
+		existingBookings = append(existingBookings, entityBooking.ConsultationBooking{
+			BookingDatetime: hold.SlotStart,
+			DurationMinutes: hold.DurationMinutes,
+			BookingStatus:   common.BookingStatusPending,
+		})
+		key := dayKey(hold.SlotStart)
+		if day, ok := days[key]; ok && (day.ValidUntil == nil || hold.ExpiresAt.Before(*day.ValidUntil)) {
+			expiresAt := hold.ExpiresAt
+			day.ValidUntil = &expiresAt
+			days[key] = day
+		}
+	}
+
+	// 3. Buổi tư vấn nhóm chiếm lịch expert dù chưa có ai đặt chỗ; các buổi còn chỗ được trả về riêng
+	groupSessions, err := bs.scheduledGroupSessions(ctx, expertID, queryFrom, queryTo)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range groupSessions {
+		existingBookings = append(existingBookings, entityBooking.ConsultationBooking{
+			BookingDatetime: session.SessionStart,
+			DurationMinutes: session.DurationMinutes,
+			BookingStatus:   common.BookingStatusConfirmed,
+		})
+		if day, ok := days[dayKey(session.SessionStart)]; ok && session.SeatsLeft > 0 {
+			day.GroupSessions = append(day.GroupSessions, session)
+			days[dayKey(session.SessionStart)] = day
+		}
+	}
+
+	// 4. Lịch nghỉ (trải các lần lặp lại, kể cả ngày nghỉ toàn hệ thống)
+	unavailableTimes, err := bs.unavailableIntervals(ctx, expertID, queryFrom, queryTo, rules.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. Sinh lưới slot rồi chia theo ngày
+	grid := bs.helper.GenerateSlotGrid(workingSchedule, existingBookings, unavailableTimes, from, to, slotDuration, rules)
+	for _, slot := range grid {
+		key := dayKey(slot.StartTime)
+		day := days[key]
+		day.Slots = append(day.Slots, slot)
+		days[key] = day
+	}
+	return days, nil
+}
+
+// publishSlotsChanged báo lịch trống của expert trong [from, to) vừa đổi để consumer xoá lưới slot đã cache.
+// Gửi bất đồng bộ sau khi dữ liệu đã commit; lỗi chỉ log vì lưới cũ tự hết hạn sau slotGridTTL.
+func publishSlotsChanged(expertID uuid.UUID, from, to time.Time, reason string) {
+	go func() {
+		event := kafka.SlotAvailabilityChangedEvent{
+			ExpertID: expertID.String(),
+			From:     &from,
+			To:       &to,
+			Reason:   reason,
+		}
+		if err := kafka.PublishSlotAvailabilityChangedEvent(event); err != nil {
+			log.Printf("⚠️ Failed to publish slot availability changed event for expert %s: %v", expertID, err)
+		}
+	}()
+}
+
+// publishBookingSlotsChanged gọi publishSlotsChanged cho khung giờ của một booking
+func publishBookingSlotsChanged(booking *entityBooking.ConsultationBooking, reason string) {
+	end := booking.BookingDatetime.Add(time.Duration(booking.DurationMinutes) * time.Minute)
+	publishSlotsChanged(booking.ExpertProfileID, booking.BookingDatetime, end, reason)
+}
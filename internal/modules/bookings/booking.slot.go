@@ -0,0 +1,354 @@
+package bookings
+
+import (
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	"cbs_backend/internal/modules/experts/entity"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bsm/redislock"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+/*
+Giữ slot (slot reservation)
+
+Mọi luồng chiếm lịch của expert (đặt lịch, đổi lịch, chuỗi lịch định kỳ, mở buổi nhóm) đều đi qua reserveSlot
+để cùng một bộ quy tắc được áp dụng:
+
+Khung thời gian - theo BookingRules của expert: thời gian báo trước, số ngày đặt trước, thời lượng tối thiểu/tối đa
+Giờ làm việc - slot phải nằm gọn trong một khung giờ làm việc active của expert, tính theo múi giờ của expert
+Lịch nghỉ - slot không chồng lên thời gian expert đã báo bận
+Redis lock - lock theo expert + ngày nên hai request có khung giờ chồng nhau luôn loại trừ nhau
+Trùng lịch - user chưa đặt expert này trong khung giờ, user không có lịch khác, expert còn trống (tính cả buffer), slot không bị giữ cho waitlist
+Giới hạn ngày - expert chưa đủ số buổi tối đa trong ngày
+*/
+
+// slotRequest là khung giờ cần giữ cho một booking mới hoặc một booking đang được dời lịch
+type slotRequest struct {
+	UserID          string // Rỗng khi expert tự chiếm lịch (buổi nhóm), bỏ qua kiểm tra trùng lịch của user
+	ExpertProfileID uuid.UUID
+	Start           time.Time
+	DurationMinutes int
+	RescheduleOf    *uuid.UUID // Booking đang được dời, không tính là trùng với chính nó
+
+	rules entity.BookingRules
+}
+
+func (r slotRequest) End() time.Time {
+	return r.Start.Add(time.Duration(r.DurationMinutes) * time.Minute)
+}
+
+func (r slotRequest) excludeBookingIDs() []uuid.UUID {
+	if r.RescheduleOf == nil {
+		return nil
+	}
+	return []uuid.UUID{*r.RescheduleOf}
+}
+
+// slotReservation giữ Redis lock của slot cho tới khi booking được ghi xong; người gọi phải Release sau khi commit
+type slotReservation struct {
+	Start     time.Time
+	End       time.Time
+	expertID  uuid.UUID
+	lock      *redislock.Lock
+	committed bool
+}
+
+// Commit đánh dấu slot đã được ghi vào DB (transaction đã commit); gọi ngay sau khi commit thành công
+func (r *slotReservation) Commit() {
+	r.committed = true
+}
+
+// Release nhả lock; slot đã Commit thì báo slot đã bị chiếm để consumer xoá lưới slot đã cache của ngày đó.
+// Ghi DB thất bại thì lịch trống không đổi nên không gửi event.
+func (r *slotReservation) Release(ctx context.Context) {
+	_ = r.lock.Release(ctx)
+	if r.committed {
+		publishSlotsChanged(r.expertID, r.Start, r.End, "slot_reserved")
+	}
+}
+
+// slotUnavailableError là lý do nghiệp vụ khiến slot không giữ được, phân biệt với lỗi DB/Redis
+type slotUnavailableError struct {
+	reason string
+}
+
+func (e *slotUnavailableError) Error() string {
+	return e.reason
+}
+
+// slotUnavailableReason trả về lý do nếu err là slotUnavailableError, ngược lại trả về rỗng
+func slotUnavailableReason(err error) string {
+	var unavailable *slotUnavailableError
+	if errors.As(err, &unavailable) {
+		return unavailable.reason
+	}
+	return ""
+}
+
+// reserveSlot kiểm tra slot theo đủ các quy tắc ở trên và giữ lock của slot khi hợp lệ.
+// Slot không hợp lệ trả về *slotUnavailableError; lock đã được nhả trước khi trả lỗi.
+func (bs *bookingservice) reserveSlot(ctx context.Context, req slotRequest, now time.Time) (*slotReservation, error) {
+	rules, err := bs.expertBookingRules(ctx, req.ExpertProfileID)
+	if err != nil {
+		return nil, err
+	}
+	req.rules = rules
+	if reason := validateSlotWindow(req.Start, req.DurationMinutes, rules, now); reason != "" {
+		return nil, &slotUnavailableError{reason: reason}
+	}
+
+	works, err := bs.expertWorksDuring(ctx, req.ExpertProfileID, req.Start, req.End(), rules.Location)
+	if err != nil {
+		return nil, err
+	}
+	if !works {
+		return nil, &slotUnavailableError{reason: "expert does not work at the requested time"}
+	}
+	unavailable, err := bs.expertUnavailableDuring(ctx, req.ExpertProfileID, req.Start, req.End(), rules.Location)
+	if err != nil {
+		return nil, err
+	}
+	if unavailable {
+		return nil, &slotUnavailableError{reason: "expert is unavailable at the requested time"}
+	}
+
+	lock, err := bs.obtainSlotLock(ctx, req.ExpertProfileID.String(), rules.DayStart(req.Start))
+	if err != nil {
+		return nil, err
+	}
+	reason, err := bs.slotConflictReason(ctx, req)
+	if err != nil || reason != "" {
+		_ = lock.Release(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &slotUnavailableError{reason: reason}
+	}
+	return &slotReservation{Start: req.Start, End: req.End(), expertID: req.ExpertProfileID, lock: lock}, nil
+}
+
+// validateDuration kiểm tra thời lượng theo Min/MaxDurationMinutes của expert, trả về lý do nếu không hợp lệ
+func validateDuration(durationMinutes int, rules entity.BookingRules) string {
+	if durationMinutes < rules.MinDurationMinutes || durationMinutes > rules.MaxDurationMinutes {
+		return fmt.Sprintf("invalid duration: must be between %d-%d minutes", rules.MinDurationMinutes, rules.MaxDurationMinutes)
+	}
+	return ""
+}
+
+// validateSlotWindow kiểm tra thời điểm và thời lượng của slot theo quy tắc của expert, trả về lý do nếu không hợp lệ
+func validateSlotWindow(start time.Time, durationMinutes int, rules entity.BookingRules, now time.Time) string {
+	if reason := validateDuration(durationMinutes, rules); reason != "" {
+		return reason
+	}
+	if start.Before(now) {
+		return "cannot book appointment in the past"
+	}
+	if start.Sub(now) < rules.MinNotice {
+		return fmt.Sprintf("cannot book less than %d minutes before the appointment", int(rules.MinNotice.Minutes()))
+	}
+	if start.After(now.Add(rules.MaxAdvance)) {
+		return fmt.Sprintf("cannot book appointment more than %d days in advance", int(rules.MaxAdvance.Hours()/24))
+	}
+	return ""
+}
+
+// slotConflictReason kiểm tra trùng lịch của user và expert (sau khi đã lock); trả về lý do nếu slot đã bị chiếm
+func (bs *bookingservice) slotConflictReason(ctx context.Context, req slotRequest) (string, error) {
+	expertID := req.ExpertProfileID.String()
+	start, end := req.Start, req.End()
+	exclude := req.excludeBookingIDs()
+
+	if req.UserID != "" {
+		duplicate, err := bs.helper.CheckDuplicateBooking(ctx, req.UserID, expertID, start, end, exclude...)
+		if err != nil {
+			return "", fmt.Errorf("failed to check duplicate booking: %w", err)
+		}
+		if duplicate {
+			return "you have already booked this expert at this time", nil
+		}
+		hasConflict, err := bs.helper.CheckUserConflictDB(ctx, req.UserID, start, end, exclude...)
+		if err != nil {
+			return "", fmt.Errorf("failed to check user booking conflicts: %w", err)
+		}
+		if hasConflict {
+			return "user has a conflicting booking at the requested time", nil
+		}
+	}
+
+	// Buffer: khoảng [start, end) nới ra hai phía để giữ đủ buffer sau buổi trước và buffer trước buổi sau
+	padding := req.rules.Padding()
+	count, err := bs.helper.CheckExpertAvailabilityDB(ctx, expertID, start.Add(-padding), end.Add(padding), exclude...)
+	if err != nil {
+		return "", fmt.Errorf("failed to check expert availability: %w", err)
+	}
+	if count > 0 {
+		if padding > 0 {
+			return "expert is not available for the requested time slot (including buffer time)", nil
+		}
+		return "expert is not available for the requested time slot", nil
+	}
+	if req.rules.MaxSessionsPerDay > 0 {
+		dayStart := req.rules.DayStart(start)
+		sessions, err := bs.helper.CountExpertSessionsBetween(ctx, expertID, dayStart, dayStart.AddDate(0, 0, 1), exclude...)
+		if err != nil {
+			return "", fmt.Errorf("failed to count expert sessions: %w", err)
+		}
+		if sessions >= int64(req.rules.MaxSessionsPerDay) {
+			return fmt.Sprintf("expert has reached the maximum of %d sessions on this day", req.rules.MaxSessionsPerDay), nil
+		}
+	}
+	held, err := bs.helper.CheckSlotHeldByOthers(ctx, expertID, req.UserID, start, end)
+	if err != nil {
+		return "", fmt.Errorf("failed to check slot holds: %w", err)
+	}
+	if held {
+		return "time slot is temporarily held for a waitlisted user", nil
+	}
+	return "", nil
+}
+
+// expertBookingRules đọc BookingRules của expert
+func (bs *bookingservice) expertBookingRules(ctx context.Context, expertID uuid.UUID) (entity.BookingRules, error) {
+	return loadExpertBookingRules(ctx, bs.db, expertID)
+}
+
+// loadExpertBookingRules là expertBookingRules cho các luồng không có bookingservice (worker, waitlist)
+func loadExpertBookingRules(ctx context.Context, db *gorm.DB, expertID uuid.UUID) (entity.BookingRules, error) {
+	var expert entity.ExpertProfile
+	if err := db.WithContext(ctx).First(&expert, "expert_profile_id = ?", expertID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.BookingRules{}, fmt.Errorf("expert not found")
+		}
+		return entity.BookingRules{}, fmt.Errorf("failed to get expert booking rules: %w", err)
+	}
+	return expert.BookingRules(), nil
+}
+
+// expertWorksDuring kiểm tra [start, end) nằm gọn trong một khung giờ làm việc của expert.
+// Phép so nằm ở WorkingSchedule.Covers, dựng khung giờ qua WorkingSchedule.On giống GenerateSlotGrid (override theo ngày trước, rồi lịch tuần),
+// tính theo múi giờ loc của expert.
+func (bs *bookingservice) expertWorksDuring(ctx context.Context, expertID uuid.UUID, start, end time.Time, loc *time.Location) (bool, error) {
+	start, end = start.In(loc), end.In(loc)
+	schedule, err := bs.expertWorkingSchedule(ctx, expertID, start, end, loc)
+	if err != nil {
+		return false, err
+	}
+	return schedule.Covers(start, end), nil
+}
+
+// expertWorkingSchedule nạp lịch tuần active và các override theo ngày (múi giờ loc) giao với [from, to)
+func (bs *bookingservice) expertWorkingSchedule(ctx context.Context, expertID uuid.UUID, from, to time.Time, loc *time.Location) (dtobookings.WorkingSchedule, error) {
+	var schedule dtobookings.WorkingSchedule
+	if err := bs.db.WithContext(ctx).
+		Model(&entity.ExpertWorkingHour{}).
+		Select("day_of_week, start_time, end_time").
+		Where("expert_profile_id = ? AND is_active = true", expertID).
+		Scan(&schedule.Weekly).Error; err != nil {
+		return schedule, fmt.Errorf("failed to get expert working hours: %w", err)
+	}
+	fromDay, toDay := from.In(loc), to.In(loc)
+	if err := bs.db.WithContext(ctx).
+		Model(&entity.ExpertWorkingHourOverride{}).
+		Select("override_date, start_time, end_time, is_day_off").
+		Where("expert_profile_id = ? AND override_date BETWEEN ? AND ?",
+			expertID, entity.HolidayDateOf(fromDay.Date()), entity.HolidayDateOf(toDay.Date())).
+		Scan(&schedule.Overrides).Error; err != nil {
+		return schedule, fmt.Errorf("failed to get expert working hour overrides: %w", err)
+	}
+	return schedule, nil
+}
+
+// expertUnavailableDuring kiểm tra [start, end) có chồng lên thời gian expert đã báo bận không, kể cả các lần lặp lại
+func (bs *bookingservice) expertUnavailableDuring(ctx context.Context, expertID uuid.UUID, start, end time.Time, loc *time.Location) (bool, error) {
+	intervals, err := bs.unavailableIntervals(ctx, expertID, start, end, loc)
+	if err != nil {
+		return false, err
+	}
+	return len(intervals) > 0, nil
+}
+
+// unavailableIntervals trả về các khoảng bận cụ thể của expert chồng lên [from, to): bản ghi lặp lại được trải ra
+// theo RecurrencePattern trong múi giờ loc, cộng thêm các ngày nghỉ toàn hệ thống expert chưa opt-in.
+// Bộ sinh slot và bước kiểm tra khi đặt lịch cùng dùng hàm này.
+func (bs *bookingservice) unavailableIntervals(ctx context.Context, expertID uuid.UUID, from, to time.Time, loc *time.Location) ([]dtobookings.UnavailableTime, error) {
+	var rows []entity.ExpertUnavailableTime
+	if err := bs.db.WithContext(ctx).
+		Where("expert_profile_id = ? AND unavailable_start_datetime < ? AND (unavailable_end_datetime > ? OR is_recurring = ?)",
+			expertID, to, from, true).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expert unavailable times: %w", err)
+	}
+
+	var intervals []dtobookings.UnavailableTime
+	for i := range rows {
+		reason := ""
+		if rows[i].UnavailableReason != nil {
+			reason = *rows[i].UnavailableReason
+		}
+		for _, occurrence := range rows[i].Occurrences(from, to, loc) {
+			intervals = append(intervals, dtobookings.UnavailableTime{
+				StartDatetime: occurrence.Start,
+				EndDatetime:   occurrence.End,
+				Reason:        reason,
+			})
+		}
+	}
+
+	holidays, err := bs.holidayIntervals(ctx, expertID, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+	return append(intervals, holidays...), nil
+}
+
+// holidayIntervals chặn trọn các ngày nghỉ toàn hệ thống (nghỉ lễ, ngày công ty nghỉ) theo ngày lịch ở múi giờ của expert
+func (bs *bookingservice) holidayIntervals(ctx context.Context, expertID uuid.UUID, from, to time.Time, loc *time.Location) ([]dtobookings.UnavailableTime, error) {
+	fromDay, toDay := from.In(loc), to.In(loc)
+	optedIn := bs.db.Model(&entity.ExpertHolidayOptIn{}).Select("holiday_id").Where("expert_profile_id = ?", expertID)
+
+	var holidays []entity.PlatformHoliday
+	if err := bs.db.WithContext(ctx).
+		Where("holiday_date BETWEEN ? AND ?",
+			entity.HolidayDateOf(fromDay.Date()), entity.HolidayDateOf(toDay.Date())).
+		Where("holiday_id NOT IN (?)", optedIn).
+		Find(&holidays).Error; err != nil {
+		return nil, fmt.Errorf("failed to get platform holidays: %w", err)
+	}
+
+	var intervals []dtobookings.UnavailableTime
+	for i := range holidays {
+		day := holidays[i].DayIn(loc)
+		if day.Start.Before(to) && day.End.After(from) {
+			intervals = append(intervals, dtobookings.UnavailableTime{
+				StartDatetime: day.Start,
+				EndDatetime:   day.End,
+				Reason:        holidays[i].HolidayName,
+			})
+		}
+	}
+	return intervals, nil
+}
+
+// obtainSlotLock lấy Redis lock theo expert + ngày (theo múi giờ expert) của slot. Slot luôn nằm gọn trong một khung giờ
+// làm việc nên hai slot chồng nhau luôn cùng ngày và dùng chung lock, kể cả khi giờ bắt đầu/kết thúc khác nhau.
+func (bs *bookingservice) obtainSlotLock(ctx context.Context, expertProfileID string, day time.Time) (*redislock.Lock, error) {
+	lockKey := fmt.Sprintf("booking:lock:%s:%s", expertProfileID, day.Format("2006-01-02"))
+	if bs.redisLocker == nil {
+		return nil, fmt.Errorf("redisLocker is not initialized")
+	}
+	lock, err := bs.redisLocker.Obtain(ctx, lockKey, 10*time.Second, &redislock.Options{
+		RetryStrategy: redislock.LimitRetry(redislock.LinearBackoff(100*time.Millisecond), 30),
+	})
+	if err == redislock.ErrNotObtained {
+		return nil, fmt.Errorf("another booking is being processed for this slot, please try again")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire booking lock: %w", err)
+	}
+	return lock, nil
+}
@@ -0,0 +1,250 @@
+package bookings
+
+import (
+	"cbs_backend/internal/common"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/internal/modules/payouts"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Các sự kiện làm thay đổi trạng thái booking
+const (
+	BookingEventConfirm    = "confirm"
+	BookingEventReject     = "reject"
+	BookingEventCancel     = "cancel"
+	BookingEventReschedule = "reschedule"
+	BookingEventComplete   = "complete"
+	BookingEventMarkMissed = "mark_missed"
+	BookingEventMarkNoShow = "mark_no_show"
+)
+
+// Các bên được phép kích hoạt sự kiện
+const (
+	BookingActorUser   = common.UserRoleUser
+	BookingActorExpert = common.UserRoleExpert
+	BookingActorAdmin  = common.UserRoleAdmin
+	BookingActorSystem = "system"
+)
+
+// SystemActorID dùng làm changed_by_user_id cho các thay đổi trạng thái do hệ thống (worker) thực hiện
+var SystemActorID = uuid.Nil
+
+// BookingTransition mô tả một yêu cầu chuyển trạng thái booking
+type BookingTransition struct {
+	BookingID uuid.UUID
+	Event     string
+	Actor     string
+	ActorID   uuid.UUID // user_id của người thao tác, SystemActorID khi hệ thống tự chuyển
+	Reason    string
+	// Guard kiểm tra thêm điều kiện nghiệp vụ trên bản ghi đã khoá (ví dụ booking chưa được thanh toán)
+	Guard func(booking *entityBooking.ConsultationBooking) error
+}
+
+// bookingTransitionRule: trạng thái nguồn hợp lệ, trạng thái đích, ai được kích hoạt và side effect chạy trong cùng transaction
+type bookingTransitionRule struct {
+	from   []string
+	to     string
+	actors []string
+	apply  func(ctx context.Context, tx *gorm.DB, booking *entityBooking.ConsultationBooking, t BookingTransition, updates map[string]interface{}) error
+}
+
+var bookingTransitions = map[string]bookingTransitionRule{
+	BookingEventConfirm: {
+		from:   []string{common.BookingStatusPending},
+		to:     common.BookingStatusConfirmed,
+		actors: []string{BookingActorExpert, BookingActorAdmin, BookingActorSystem},
+	},
+	BookingEventReject: {
+		from:   []string{common.BookingStatusPending},
+		to:     common.BookingStatusRejected,
+		actors: []string{BookingActorExpert, BookingActorAdmin},
+		apply:  applyRejection,
+	},
+	BookingEventCancel: {
+		from:   []string{common.BookingStatusPending, common.BookingStatusConfirmed},
+		to:     common.BookingStatusCancelled,
+		actors: []string{BookingActorUser, BookingActorExpert, BookingActorAdmin, BookingActorSystem},
+		apply:  applyCancellation,
+	},
+	// Đổi lịch đưa booking về pending để expert xác nhận lại khung giờ mới
+	BookingEventReschedule: {
+		from:   []string{common.BookingStatusPending, common.BookingStatusConfirmed},
+		to:     common.BookingStatusPending,
+		actors: []string{BookingActorUser},
+	},
+	BookingEventComplete: {
+		from:   []string{common.BookingStatusConfirmed},
+		to:     common.BookingStatusCompleted,
+		actors: []string{BookingActorExpert, BookingActorAdmin},
+		apply:  applyCompletion,
+	},
+	BookingEventMarkMissed: {
+		from:   []string{common.BookingStatusConfirmed},
+		to:     common.BookingStatusMissed,
+		actors: []string{BookingActorSystem},
+	},
+	BookingEventMarkNoShow: {
+		from:   []string{common.BookingStatusConfirmed},
+		to:     common.BookingStatusNoShow,
+		actors: []string{BookingActorExpert, BookingActorAdmin},
+	},
+}
+
+// ErrInvalidBookingTransition trả về khi trạng thái hiện tại không cho phép sự kiện
+var ErrInvalidBookingTransition = errors.New("invalid booking status transition")
+
+// ApplyBookingTransition là đường duy nhất để đổi booking_status: khoá booking, kiểm tra bước chuyển và người thao tác,
+// chạy side effect rồi ghi BookingStatusHistory, tất cả trong transaction tx của caller.
+// Các side effect gọi ra ngoài (hoàn tiền qua gateway, thông báo) do caller thực hiện sau khi commit.
+func ApplyBookingTransition(ctx context.Context, tx *gorm.DB, t BookingTransition) (*entityBooking.ConsultationBooking, error) {
+	rule, err := bookingTransitionFor(t.Event, t.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	var booking entityBooking.ConsultationBooking
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&booking, "booking_id = ?", t.BookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("booking not found")
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	oldStatus := booking.BookingStatus
+	if err := rule.checkFrom(t.Event, oldStatus); err != nil {
+		return nil, err
+	}
+	if t.Guard != nil {
+		if err := t.Guard(&booking); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"booking_status":     rule.to,
+		"booking_updated_at": now,
+	}
+	if rule.apply != nil {
+		if err := rule.apply(ctx, tx, &booking, t, updates); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.WithContext(ctx).Model(&entityBooking.ConsultationBooking{}).
+		Where("booking_id = ?", booking.BookingID).
+		Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update booking status: %w", err)
+	}
+
+	history := entityBooking.BookingStatusHistory{
+		BookingID:       booking.BookingID,
+		OldStatus:       &oldStatus,
+		NewStatus:       rule.to,
+		ChangedByUserID: t.ActorID,
+		StatusChangedAt: now,
+	}
+	if t.Reason != "" {
+		reason := t.Reason
+		history.ChangeReason = &reason
+	}
+	if err := tx.WithContext(ctx).Create(&history).Error; err != nil {
+		return nil, fmt.Errorf("failed to record status history: %w", err)
+	}
+
+	if err := tx.WithContext(ctx).First(&booking, "booking_id = ?", booking.BookingID).Error; err != nil {
+		return nil, fmt.Errorf("failed to reload booking: %w", err)
+	}
+	return &booking, nil
+}
+
+// bookingTransitionFor trả về quy tắc của sự kiện nếu actor được phép kích hoạt nó
+func bookingTransitionFor(event, actor string) (bookingTransitionRule, error) {
+	rule, ok := bookingTransitions[event]
+	if !ok {
+		return rule, fmt.Errorf("unknown booking event: %s", event)
+	}
+	if !containsString(rule.actors, actor) {
+		return rule, fmt.Errorf("unauthorized: %s cannot %s a booking", actor, event)
+	}
+	return rule, nil
+}
+
+// checkFrom kiểm tra sự kiện được phép xảy ra khi booking đang ở trạng thái status
+func (r bookingTransitionRule) checkFrom(event, status string) error {
+	if !containsString(r.from, status) {
+		return fmt.Errorf("%w: cannot %s a %s booking", ErrInvalidBookingTransition, event, status)
+	}
+	return nil
+}
+
+// slotFreeingEvents là các sự kiện đưa booking ra khỏi trạng thái chiếm lịch của expert
+var slotFreeingEvents = map[string]bool{
+	BookingEventReject:     true,
+	BookingEventCancel:     true,
+	BookingEventMarkMissed: true,
+	BookingEventMarkNoShow: true,
+}
+
+// TransitionBooking chạy ApplyBookingTransition trong transaction riêng, dùng cho các luồng không cần ghi thêm dữ liệu.
+// Sau khi commit, bước chuyển làm trống slot được báo qua Kafka để xoá lưới slot đã cache.
+func TransitionBooking(ctx context.Context, db *gorm.DB, t BookingTransition) (*entityBooking.ConsultationBooking, error) {
+	var booking *entityBooking.ConsultationBooking
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		booking, err = ApplyBookingTransition(ctx, tx, t)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if slotFreeingEvents[t.Event] {
+		publishBookingSlotsChanged(booking, "booking_"+t.Event)
+	}
+	return booking, nil
+}
+
+func applyCancellation(ctx context.Context, tx *gorm.DB, booking *entityBooking.ConsultationBooking, t BookingTransition, updates map[string]interface{}) error {
+	updates["cancelled_at"] = updates["booking_updated_at"]
+	if t.Reason != "" {
+		updates["cancellation_reason"] = t.Reason
+	}
+	if t.ActorID != SystemActorID {
+		updates["cancelled_by_user_id"] = t.ActorID
+	}
+	return nil
+}
+
+// applyRejection bắt buộc có lý do, lưu chung cột cancellation_reason để người dùng xem lại
+func applyRejection(ctx context.Context, tx *gorm.DB, booking *entityBooking.ConsultationBooking, t BookingTransition, updates map[string]interface{}) error {
+	if t.Reason == "" {
+		return fmt.Errorf("rejection reason is required")
+	}
+	updates["cancellation_reason"] = t.Reason
+	return nil
+}
+
+// applyCompletion ghi có doanh thu cho expert cùng transaction với việc hoàn thành booking
+func applyCompletion(ctx context.Context, tx *gorm.DB, booking *entityBooking.ConsultationBooking, t BookingTransition, updates map[string]interface{}) error {
+	updates["booking_completed_at"] = updates["booking_updated_at"]
+	if err := payouts.Payout().CreditCompletedBooking(ctx, tx, booking.BookingID); err != nil {
+		return fmt.Errorf("failed to credit expert payout: %w", err)
+	}
+	return nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package bookings_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/internal/testutil"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TestApplyBookingTransition chạy bước chuyển trên database thật: trạng thái và lịch sử chỉ đổi khi bước chuyển hợp lệ
+func TestApplyBookingTransition(t *testing.T) {
+	db := testutil.OpenPostgres(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		from       string
+		event      string
+		actor      string
+		reason     string
+		wantStatus string
+		wantErr    string // "invalid" là ErrInvalidBookingTransition
+	}{
+		{"expert confirms pending", common.BookingStatusPending, bookings.BookingEventConfirm, bookings.BookingActorExpert, "", common.BookingStatusConfirmed, ""},
+		{"user cancels confirmed", common.BookingStatusConfirmed, bookings.BookingEventCancel, bookings.BookingActorUser, "changed plans", common.BookingStatusCancelled, ""},
+		{"user reschedules confirmed", common.BookingStatusConfirmed, bookings.BookingEventReschedule, bookings.BookingActorUser, "", common.BookingStatusPending, ""},
+		{"user cannot confirm", common.BookingStatusPending, bookings.BookingEventConfirm, bookings.BookingActorUser, "", "", "unauthorized"},
+		{"mark missed from pending", common.BookingStatusPending, bookings.BookingEventMarkMissed, bookings.BookingActorSystem, "", "", "invalid"},
+		{"cancel completed", common.BookingStatusCompleted, bookings.BookingEventCancel, bookings.BookingActorAdmin, "", "", "invalid"},
+		{"reject requires a reason", common.BookingStatusPending, bookings.BookingEventReject, bookings.BookingActorExpert, "", "", "rejection reason is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booking := createBookingWithStatus(t, db, tt.from)
+
+			var updated *entityBooking.ConsultationBooking
+			err := db.Transaction(func(tx *gorm.DB) error {
+				var err error
+				updated, err = bookings.ApplyBookingTransition(ctx, tx, bookings.BookingTransition{
+					BookingID: booking.BookingID,
+					Event:     tt.event,
+					Actor:     tt.actor,
+					ActorID:   booking.UserID,
+					Reason:    tt.reason,
+				})
+				return err
+			})
+
+			var history int64
+			db.Model(&entityBooking.BookingStatusHistory{}).Where("booking_id = ?", booking.BookingID).Count(&history)
+			var stored entityBooking.ConsultationBooking
+			if err := db.First(&stored, "booking_id = ?", booking.BookingID).Error; err != nil {
+				t.Fatalf("reload booking: %v", err)
+			}
+
+			if tt.wantErr != "" {
+				if tt.wantErr == "invalid" && !errors.Is(err, bookings.ErrInvalidBookingTransition) ||
+					tt.wantErr != "invalid" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+					t.Fatalf("ApplyBookingTransition() error = %v, want %q", err, tt.wantErr)
+				}
+				if stored.BookingStatus != tt.from || history != 0 {
+					t.Errorf("rejected transition changed the booking: status %q, %d history rows", stored.BookingStatus, history)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyBookingTransition() error = %v", err)
+			}
+			if updated.BookingStatus != tt.wantStatus || stored.BookingStatus != tt.wantStatus {
+				t.Errorf("status = %q (stored %q), want %q", updated.BookingStatus, stored.BookingStatus, tt.wantStatus)
+			}
+			if history != 1 {
+				t.Errorf("recorded %d history rows, want 1", history)
+			}
+		})
+	}
+}
+
+// createBookingWithStatus tạo booking của một user/expert mới ở trạng thái status
+func createBookingWithStatus(t *testing.T, db *gorm.DB, status string) *entityBooking.ConsultationBooking {
+	t.Helper()
+	var fee int64 = 500000
+	expert := testutil.CreateExpert(t, db, fee)
+	user := testutil.CreateUser(t, db, "user")
+	booking := &entityBooking.ConsultationBooking{
+		BookingID:            uuid.New(),
+		UserID:               user.UserID,
+		ExpertProfileID:      expert.ExpertProfileID,
+		BookingDatetime:      time.Now().Add(72 * time.Hour).Truncate(time.Hour),
+		DurationMinutes:      60,
+		ConsultationType:     "online",
+		BookingStatus:        status,
+		ConsultationFeeMinor: &fee,
+		Currency:             "VND",
+		PaymentStatus:        common.PaymentStatusPending,
+	}
+	if err := db.Omit("User", "ExpertProfile").Create(booking).Error; err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	return booking
+}
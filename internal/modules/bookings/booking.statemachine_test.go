@@ -0,0 +1,104 @@
+package bookings
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"cbs_backend/internal/common"
+)
+
+var (
+	allBookingEvents = []string{
+		BookingEventConfirm, BookingEventReject, BookingEventCancel, BookingEventReschedule,
+		BookingEventComplete, BookingEventMarkMissed, BookingEventMarkNoShow,
+	}
+	allBookingActors   = []string{BookingActorUser, BookingActorExpert, BookingActorAdmin, BookingActorSystem}
+	allBookingStatuses = []string{
+		common.BookingStatusPending, common.BookingStatusConfirmed, common.BookingStatusRejected, common.BookingStatusCancelled,
+		common.BookingStatusCompleted, common.BookingStatusMissed, common.BookingStatusNoShow,
+	}
+)
+
+func TestBookingTransitionCombinations(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   string
+		actor   string
+		from    string
+		wantTo  string
+		wantErr string // "unauthorized", "invalid" hoặc rỗng
+	}{
+		{"expert confirms pending", BookingEventConfirm, BookingActorExpert, common.BookingStatusPending, common.BookingStatusConfirmed, ""},
+		{"system confirms pending", BookingEventConfirm, BookingActorSystem, common.BookingStatusPending, common.BookingStatusConfirmed, ""},
+		{"user cannot confirm", BookingEventConfirm, BookingActorUser, common.BookingStatusPending, "", "unauthorized"},
+		{"expert cannot confirm twice", BookingEventConfirm, BookingActorExpert, common.BookingStatusConfirmed, "", "invalid"},
+		{"admin rejects pending", BookingEventReject, BookingActorAdmin, common.BookingStatusPending, common.BookingStatusRejected, ""},
+		{"system cannot reject", BookingEventReject, BookingActorSystem, common.BookingStatusPending, "", "unauthorized"},
+		{"expert cannot reject confirmed", BookingEventReject, BookingActorExpert, common.BookingStatusConfirmed, "", "invalid"},
+		{"user cancels confirmed", BookingEventCancel, BookingActorUser, common.BookingStatusConfirmed, common.BookingStatusCancelled, ""},
+		{"system cancels unpaid pending", BookingEventCancel, BookingActorSystem, common.BookingStatusPending, common.BookingStatusCancelled, ""},
+		{"cannot cancel completed", BookingEventCancel, BookingActorAdmin, common.BookingStatusCompleted, "", "invalid"},
+		{"cannot cancel twice", BookingEventCancel, BookingActorUser, common.BookingStatusCancelled, "", "invalid"},
+		{"user reschedules confirmed back to pending", BookingEventReschedule, BookingActorUser, common.BookingStatusConfirmed, common.BookingStatusPending, ""},
+		{"expert cannot reschedule", BookingEventReschedule, BookingActorExpert, common.BookingStatusConfirmed, "", "unauthorized"},
+		{"user cannot reschedule rejected", BookingEventReschedule, BookingActorUser, common.BookingStatusRejected, "", "invalid"},
+		{"expert completes confirmed", BookingEventComplete, BookingActorExpert, common.BookingStatusConfirmed, common.BookingStatusCompleted, ""},
+		{"cannot complete pending", BookingEventComplete, BookingActorExpert, common.BookingStatusPending, "", "invalid"},
+		{"user cannot complete", BookingEventComplete, BookingActorUser, common.BookingStatusConfirmed, "", "unauthorized"},
+		{"system marks confirmed missed", BookingEventMarkMissed, BookingActorSystem, common.BookingStatusConfirmed, common.BookingStatusMissed, ""},
+		{"cannot mark pending missed", BookingEventMarkMissed, BookingActorSystem, common.BookingStatusPending, "", "invalid"},
+		{"expert cannot mark missed", BookingEventMarkMissed, BookingActorExpert, common.BookingStatusConfirmed, "", "unauthorized"},
+		{"expert marks no-show", BookingEventMarkNoShow, BookingActorExpert, common.BookingStatusConfirmed, common.BookingStatusNoShow, ""},
+		{"user cannot mark no-show", BookingEventMarkNoShow, BookingActorUser, common.BookingStatusConfirmed, "", "unauthorized"},
+		{"cannot mark completed no-show", BookingEventMarkNoShow, BookingActorAdmin, common.BookingStatusCompleted, "", "invalid"},
+		{"unknown event", "archive", BookingActorAdmin, common.BookingStatusCompleted, "", "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := bookingTransitionFor(tt.event, tt.actor)
+			if err == nil {
+				err = rule.checkFrom(tt.event, tt.from)
+			}
+			switch tt.wantErr {
+			case "":
+				if err != nil {
+					t.Fatalf("transition error = %v, want allowed", err)
+				}
+				if rule.to != tt.wantTo {
+					t.Errorf("transition leads to %q, want %q", rule.to, tt.wantTo)
+				}
+			case "invalid":
+				if !errors.Is(err, ErrInvalidBookingTransition) {
+					t.Fatalf("transition error = %v, want ErrInvalidBookingTransition", err)
+				}
+			default:
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("transition error = %v, want %q", err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+// TestBookingTransitionsOnlyLeaveActiveStates: mọi tổ hợp (sự kiện, actor, trạng thái) được phép đều xuất phát từ
+// pending/confirmed, nên booking đã kết thúc không bao giờ đổi trạng thái
+func TestBookingTransitionsOnlyLeaveActiveStates(t *testing.T) {
+	for _, event := range allBookingEvents {
+		for _, actor := range allBookingActors {
+			rule, err := bookingTransitionFor(event, actor)
+			if err != nil {
+				continue
+			}
+			for _, status := range allBookingStatuses {
+				if rule.checkFrom(event, status) != nil {
+					continue
+				}
+				if status != common.BookingStatusPending && status != common.BookingStatusConfirmed {
+					t.Errorf("%s can %s a %s booking", actor, event, status)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package bookings
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/internal/modules/experts/entity"
+	utilshelper "cbs_backend/utils/helper"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// WaitlistHoldDuration là thời gian người trong waitlist được giữ slot trước khi chuyển cho người kế tiếp
+	WaitlistHoldDuration  = 30 * time.Minute
+	maxWaitlistWindowDays = 30
+)
+
+// JoinWaitlist cho người dùng chờ slot của expert khi GetAvailableSlots không còn khung giờ nào trong khoảng ngày yêu cầu
+func (bs *bookingservice) JoinWaitlist(ctx context.Context, req dtobookings.JoinWaitlistRequest) (*dtobookings.WaitlistEntryResponse, error) {
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+	expertID, err := uuid.Parse(req.ExpertProfileID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expert profile ID format: %w", err)
+	}
+	if req.ToDate.Sub(req.FromDate) > maxWaitlistWindowDays*24*time.Hour {
+		return nil, fmt.Errorf("waitlist window cannot be longer than %d days", maxWaitlistWindowDays)
+	}
+
+	var expert entity.ExpertProfile
+	if err := bs.db.WithContext(ctx).First(&expert, "expert_profile_id = ?", expertID).Error; err != nil {
+		return nil, fmt.Errorf("expert not found")
+	}
+	if reason := validateDuration(req.DurationMinutes, expert.BookingRules()); reason != "" {
+		return nil, errors.New(reason)
+	}
+
+	// 1. Chỉ cho vào waitlist khi expert thực sự kín lịch trong khoảng này
+	slots, err := bs.GetAvailableSlots(ctx, dtobookings.GetAvailableSlotsRequest{
+		ExpertProfileID:     req.ExpertProfileID,
+		FromDate:            req.FromDate,
+		ToDate:              req.ToDate,
+		SlotDurationMinutes: req.DurationMinutes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// GetAvailableSlots đã bỏ các slot ngoài MinNotice/MaxAdvance của expert
+	now := time.Now()
+	if open := len(slots.AvailableSlots); open > 0 {
+		return nil, fmt.Errorf("expert still has %d available slots in this window, please book one directly", open)
+	}
+
+	// 2. Không đăng ký trùng khoảng thời gian với cùng expert
+	windowStart := req.FromDate
+	windowEnd := req.ToDate.Add(24 * time.Hour)
+	var existing int64
+	if err := bs.db.WithContext(ctx).Model(&entityBooking.WaitlistEntry{}).
+		Where("user_id = ? AND expert_profile_id = ? AND waitlist_status IN ?", userID, expertID,
+			[]string{common.WaitlistStatusWaiting, common.WaitlistStatusOffered}).
+		Where("window_start < ? AND window_end > ?", windowEnd, windowStart).
+		Count(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to check existing waitlist entries: %w", err)
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("you are already on this expert's waitlist for an overlapping window")
+	}
+
+	entry := &entityBooking.WaitlistEntry{
+		UserID:            userID,
+		ExpertProfileID:   expertID,
+		WindowStart:       windowStart,
+		WindowEnd:         windowEnd,
+		DurationMinutes:   req.DurationMinutes,
+		ConsultationType:  req.ConsultationType,
+		WaitlistStatus:    common.WaitlistStatusWaiting,
+		WaitlistUpdatedAt: now,
+	}
+	if err := bs.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to join waitlist: %w", err)
+	}
+
+	bs.logger.Info("✅ User joined waitlist",
+		zap.String("waitlist_id", entry.WaitlistID.String()),
+		zap.String("expert_profile_id", expertID.String()))
+
+	return bs.toWaitlistEntryResponse(ctx, entry, nil)
+}
+
+// GetMyWaitlist trả về các đăng ký waitlist còn hiệu lực của người dùng, kèm slot đang được giữ nếu có
+func (bs *bookingservice) GetMyWaitlist(ctx context.Context, req dtobookings.GetMyWaitlistRequest) (*dtobookings.GetMyWaitlistResponse, error) {
+	var entries []entityBooking.WaitlistEntry
+	if err := bs.db.WithContext(ctx).
+		Where("user_id = ? AND waitlist_status IN ?", req.UserID, []string{common.WaitlistStatusWaiting, common.WaitlistStatusOffered}).
+		Order("waitlist_created_at ASC").
+		Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get waitlist entries: %w", err)
+	}
+
+	resp := &dtobookings.GetMyWaitlistResponse{Entries: make([]dtobookings.WaitlistEntryResponse, 0, len(entries))}
+	for i := range entries {
+		var hold *entityBooking.SlotHold
+		if entries[i].WaitlistStatus == common.WaitlistStatusOffered {
+			var active entityBooking.SlotHold
+			err := bs.db.WithContext(ctx).
+				Where("waitlist_id = ? AND hold_status = ?", entries[i].WaitlistID, common.SlotHoldStatusActive).
+				First(&active).Error
+			if err == nil {
+				hold = &active
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("failed to get slot hold: %w", err)
+			}
+		}
+		item, err := bs.toWaitlistEntryResponse(ctx, &entries[i], hold)
+		if err != nil {
+			return nil, err
+		}
+		resp.Entries = append(resp.Entries, *item)
+	}
+	return resp, nil
+}
+
+// LeaveWaitlist hủy đăng ký; nếu đang được giữ slot thì slot được chuyển ngay cho người kế tiếp
+func (bs *bookingservice) LeaveWaitlist(ctx context.Context, req dtobookings.LeaveWaitlistRequest) (*dtobookings.WaitlistEntryResponse, error) {
+	var entry entityBooking.WaitlistEntry
+	var released *entityBooking.SlotHold
+	err := bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&entry, "waitlist_id = ?", req.WaitlistID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("waitlist entry not found")
+			}
+			return fmt.Errorf("failed to get waitlist entry: %w", err)
+		}
+		if entry.UserID.String() != req.UserID {
+			return fmt.Errorf("unauthorized: user does not own this waitlist entry")
+		}
+		if entry.WaitlistStatus != common.WaitlistStatusWaiting && entry.WaitlistStatus != common.WaitlistStatusOffered {
+			return fmt.Errorf("cannot leave a %s waitlist entry", entry.WaitlistStatus)
+		}
+
+		now := time.Now()
+		if entry.WaitlistStatus == common.WaitlistStatusOffered {
+			var hold entityBooking.SlotHold
+			err := tx.Where("waitlist_id = ? AND hold_status = ?", entry.WaitlistID, common.SlotHoldStatusActive).First(&hold).Error
+			if err == nil {
+				if err := tx.Model(&hold).Updates(map[string]interface{}{
+					"hold_status":     common.SlotHoldStatusReleased,
+					"hold_updated_at": now,
+				}).Error; err != nil {
+					return fmt.Errorf("failed to release slot hold: %w", err)
+				}
+				released = &hold
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("failed to get slot hold: %w", err)
+			}
+		}
+		entry.WaitlistStatus = common.WaitlistStatusCancelled
+		entry.WaitlistUpdatedAt = now
+		return tx.Model(&entry).Updates(map[string]interface{}{
+			"waitlist_status":     entry.WaitlistStatus,
+			"waitlist_updated_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if released != nil {
+		bs.releaseSlotToWaitlist(ctx, released.ExpertProfileID, released.SlotStart, released.DurationMinutes)
+	}
+	return bs.toWaitlistEntryResponse(ctx, &entry, nil)
+}
+
+// ClaimSlotHold đặt lịch vào slot đang giữ cho người dùng; booking đi qua luồng CreateBooking bình thường
+func (bs *bookingservice) ClaimSlotHold(ctx context.Context, req dtobookings.ClaimSlotHoldRequest) (*dtobookings.CreateBookingResponse, error) {
+	var hold entityBooking.SlotHold
+	if err := bs.db.WithContext(ctx).Preload("WaitlistEntry").First(&hold, "hold_id = ?", req.HoldID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("slot hold not found")
+		}
+		return nil, fmt.Errorf("failed to get slot hold: %w", err)
+	}
+	if hold.UserID.String() != req.UserID {
+		return nil, fmt.Errorf("unauthorized: this slot is not held for you")
+	}
+	if hold.HoldStatus != common.SlotHoldStatusActive || !hold.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("slot hold has expired")
+	}
+
+	booking, err := bs.CreateBooking(ctx, dtobookings.CreateBookingRequest{
+		UserID:           req.UserID,
+		ExpertProfileID:  hold.ExpertProfileID.String(),
+		BookingDatetime:  hold.SlotStart,
+		DurationMinutes:  hold.DurationMinutes,
+		ConsultationType: hold.WaitlistEntry.ConsultationType,
+		UserNotes:        req.UserNotes,
+		CouponCode:       req.CouponCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bookingID, _ := uuid.Parse(booking.BookingID)
+	now := time.Now()
+	err = bs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entityBooking.SlotHold{}).
+			Where("hold_id = ?", hold.HoldID).
+			Updates(map[string]interface{}{
+				"hold_status":        common.SlotHoldStatusClaimed,
+				"claimed_booking_id": bookingID,
+				"hold_updated_at":    now,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entityBooking.WaitlistEntry{}).
+			Where("waitlist_id = ?", hold.WaitlistID).
+			Updates(map[string]interface{}{
+				"waitlist_status":     common.WaitlistStatusBooked,
+				"waitlist_updated_at": now,
+			}).Error
+	})
+	if err != nil {
+		// Booking đã tạo thành công, chỉ log để không làm người dùng đặt lại
+		bs.logger.Error("❌ Failed to mark slot hold as claimed", zap.String("hold_id", hold.HoldID.String()), zap.Error(err))
+	}
+	return booking, nil
+}
+
+// releaseSlotToWaitlist giữ slot vừa được giải phóng cho người kế tiếp trong waitlist; lỗi chỉ log để không chặn luồng chính
+func (bs *bookingservice) releaseSlotToWaitlist(ctx context.Context, expertID uuid.UUID, start time.Time, durationMinutes int) {
+	// Slot vừa trống (rồi có thể bị giữ lại cho người trong waitlist): lưới slot đã cache của ngày đó không còn đúng
+	defer publishSlotsChanged(expertID, start, start.Add(time.Duration(durationMinutes)*time.Minute), "slot_released")
+	hold, err := OfferFreedSlot(ctx, bs.db, expertID, start, durationMinutes)
+	if err != nil {
+		bs.logger.Warn("⚠️ Failed to offer freed slot to waitlist",
+			zap.String("expert_profile_id", expertID.String()),
+			zap.Time("slot_start", start),
+			zap.Error(err))
+		return
+	}
+	if hold != nil {
+		bs.logger.Info("🎟️ Freed slot held for waitlisted user",
+			zap.String("hold_id", hold.HoldID.String()),
+			zap.String("user_id", hold.UserID.String()))
+	}
+}
+
+func (bs *bookingservice) toWaitlistEntryResponse(ctx context.Context, entry *entityBooking.WaitlistEntry, hold *entityBooking.SlotHold) (*dtobookings.WaitlistEntryResponse, error) {
+	resp := &dtobookings.WaitlistEntryResponse{
+		WaitlistID:       entry.WaitlistID.String(),
+		ExpertProfileID:  entry.ExpertProfileID.String(),
+		WindowStart:      entry.WindowStart,
+		WindowEnd:        entry.WindowEnd,
+		DurationMinutes:  entry.DurationMinutes,
+		ConsultationType: entry.ConsultationType,
+		WaitlistStatus:   entry.WaitlistStatus,
+		CreatedAt:        entry.WaitlistCreatedAt,
+	}
+	if entry.WaitlistStatus == common.WaitlistStatusWaiting {
+		var ahead int64
+		if err := bs.db.WithContext(ctx).Model(&entityBooking.WaitlistEntry{}).
+			Where("expert_profile_id = ? AND waitlist_status = ? AND waitlist_created_at < ?",
+				entry.ExpertProfileID, common.WaitlistStatusWaiting, entry.WaitlistCreatedAt).
+			Count(&ahead).Error; err != nil {
+			return nil, fmt.Errorf("failed to get waitlist position: %w", err)
+		}
+		resp.Position = int(ahead) + 1
+	}
+	if hold != nil {
+		resp.ActiveHold = &dtobookings.SlotHoldResponse{
+			HoldID:          hold.HoldID.String(),
+			SlotStart:       hold.SlotStart,
+			SlotEnd:         hold.SlotStart.Add(time.Duration(hold.DurationMinutes) * time.Minute),
+			DurationMinutes: hold.DurationMinutes,
+			ExpiresAt:       hold.ExpiresAt,
+		}
+	}
+	return resp, nil
+}
+
+// OfferFreedSlot tạo hold cho người chờ lâu nhất có khoảng thời gian chứa slot vừa được giải phóng.
+// Trả về nil nếu không ai phù hợp hoặc slot đã bị đặt/giữ lại. Thông báo realtime + email do worker gửi.
+func OfferFreedSlot(ctx context.Context, db *gorm.DB, expertID uuid.UUID, start time.Time, durationMinutes int) (*entityBooking.SlotHold, error) {
+	now := time.Now()
+	rules, err := loadExpertBookingRules(ctx, db, expertID)
+	if err != nil {
+		return nil, err
+	}
+	if start.Sub(now) < rules.MinNotice {
+		return nil, nil // Slot nằm trong thời gian báo trước tối thiểu của expert, không kịp đặt
+	}
+
+	var hold *entityBooking.SlotHold
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entry entityBooking.WaitlistEntry
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("expert_profile_id = ? AND waitlist_status = ? AND duration_minutes <= ?",
+				expertID, common.WaitlistStatusWaiting, durationMinutes).
+			Where("window_start <= ? AND window_end >= CAST(? AS timestamptz) + (duration_minutes || ' minutes')::interval", start, start).
+			Order("waitlist_created_at ASC").
+			First(&entry).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find waitlist entry: %w", err)
+		}
+
+		// Slot phải vẫn trống: chưa có booking mới và chưa được giữ cho ai khác
+		helper := utilshelper.NewHelperBooking(tx)
+		end := start.Add(time.Duration(entry.DurationMinutes) * time.Minute)
+		count, err := helper.CheckExpertAvailabilityDB(ctx, expertID.String(), start, end)
+		if err != nil {
+			return fmt.Errorf("failed to check expert availability: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+		held, err := helper.CheckSlotHeldByOthers(ctx, expertID.String(), "", start, end)
+		if err != nil {
+			return fmt.Errorf("failed to check slot holds: %w", err)
+		}
+		if held {
+			return nil
+		}
+
+		hold = &entityBooking.SlotHold{
+			WaitlistID:      entry.WaitlistID,
+			UserID:          entry.UserID,
+			ExpertProfileID: expertID,
+			SlotStart:       start,
+			DurationMinutes: entry.DurationMinutes,
+			HoldStatus:      common.SlotHoldStatusActive,
+			ExpiresAt:       now.Add(WaitlistHoldDuration),
+			HoldUpdatedAt:   now,
+		}
+		if err := tx.Create(hold).Error; err != nil {
+			return fmt.Errorf("failed to create slot hold: %w", err)
+		}
+		return tx.Model(&entry).Updates(map[string]interface{}{
+			"waitlist_status":     common.WaitlistStatusOffered,
+			"waitlist_updated_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// ExpireSlotHolds đóng các hold quá hạn chưa được dùng và chuyển slot cho người kế tiếp trong waitlist.
+// Trả về số slot đã được chuyển tiếp.
+func ExpireSlotHolds(ctx context.Context, db *gorm.DB, now time.Time) (int, error) {
+	var holds []entityBooking.SlotHold
+	if err := db.WithContext(ctx).
+		Where("hold_status = ? AND expires_at <= ?", common.SlotHoldStatusActive, now).
+		Find(&holds).Error; err != nil {
+		return 0, fmt.Errorf("failed to find expired slot holds: %w", err)
+	}
+
+	passed := 0
+	for _, hold := range holds {
+		expired := false
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&entityBooking.SlotHold{}).
+				Where("hold_id = ? AND hold_status = ?", hold.HoldID, common.SlotHoldStatusActive).
+				Updates(map[string]interface{}{
+					"hold_status":     common.SlotHoldStatusExpired,
+					"hold_updated_at": now,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return nil // Đã được claim/release ở request khác
+			}
+			expired = true
+			return tx.Model(&entityBooking.WaitlistEntry{}).
+				Where("waitlist_id = ? AND waitlist_status = ?", hold.WaitlistID, common.WaitlistStatusOffered).
+				Updates(map[string]interface{}{
+					"waitlist_status":     common.WaitlistStatusExpired,
+					"waitlist_updated_at": now,
+				}).Error
+		})
+		if err != nil {
+			return passed, fmt.Errorf("failed to expire slot hold %s: %w", hold.HoldID, err)
+		}
+		if !expired {
+			continue
+		}
+
+		next, err := OfferFreedSlot(ctx, db, hold.ExpertProfileID, hold.SlotStart, hold.DurationMinutes)
+		if err != nil {
+			return passed, err
+		}
+		if next != nil {
+			passed++
+			publishSlotsChanged(next.ExpertProfileID, next.SlotStart,
+				next.SlotStart.Add(time.Duration(next.DurationMinutes)*time.Minute), "slot_hold_offered")
+		}
+	}
+	return passed, nil
+}
@@ -0,0 +1,102 @@
+package bookings_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings"
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	entityExpert "cbs_backend/internal/modules/experts/entity"
+	"cbs_backend/internal/testutil"
+	"cbs_backend/utils/cache"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// BenchmarkGetAvailableSlots đo GetAvailableSlots cho khoảng 30 ngày: cold là lưới vừa bị xoá nên mỗi lần đều tính lại
+// từ database, warm là mọi ngày đã có trong cache Redis
+func BenchmarkGetAvailableSlots(b *testing.B) {
+	db := testutil.OpenPostgres(b)
+	bookingCache := cache.NewRedisBookingCache(cache.NewRedisCache(testutil.OpenRedis(b)), zap.NewNop())
+	svc := bookings.NewBookingService(db, bookingCache, zap.NewNop(), nil)
+	ctx := context.Background()
+
+	var fee int64 = 500000
+	expert := testutil.CreateExpert(b, db, fee)
+	user := testutil.CreateUser(b, db, "user")
+	for day := common.DayMonday; day <= common.DayFriday; day++ {
+		for _, hours := range [][2]string{{"08:00:00", "12:00:00"}, {"13:00:00", "17:30:00"}} {
+			wh := &entityExpert.ExpertWorkingHour{
+				WorkingHourID:   uuid.New(),
+				ExpertProfileID: expert.ExpertProfileID,
+				DayOfWeek:       day,
+				StartTime:       hours[0],
+				EndTime:         hours[1],
+				IsActive:        true,
+			}
+			if err := db.Omit("ExpertProfile").Create(wh).Error; err != nil {
+				b.Fatalf("create working hour: %v", err)
+			}
+		}
+	}
+
+	// Vài booking rải trong khoảng xem để lưới phải né lịch đã đặt
+	from := time.Now().AddDate(0, 0, 1)
+	to := from.AddDate(0, 0, 29)
+	for i := 0; i < 30; i += 3 {
+		booking := &entityBooking.ConsultationBooking{
+			BookingID:            uuid.New(),
+			UserID:               user.UserID,
+			ExpertProfileID:      expert.ExpertProfileID,
+			BookingDatetime:      time.Date(from.Year(), from.Month(), from.Day()+i, 3, 0, 0, 0, time.UTC),
+			DurationMinutes:      60,
+			ConsultationType:     "online",
+			BookingStatus:        common.BookingStatusConfirmed,
+			ConsultationFeeMinor: &fee,
+			Currency:             "VND",
+			PaymentStatus:        common.PaymentStatusPending,
+		}
+		if err := db.Omit("User", "ExpertProfile").Create(booking).Error; err != nil {
+			b.Fatalf("create booking: %v", err)
+		}
+	}
+
+	req := dtobookings.GetAvailableSlotsRequest{
+		ExpertProfileID:     expert.ExpertProfileID.String(),
+		FromDate:            from,
+		ToDate:              to,
+		SlotDurationMinutes: 60,
+	}
+	getSlots := func(b *testing.B) {
+		resp, err := svc.GetAvailableSlots(ctx, req)
+		if err != nil {
+			b.Fatalf("GetAvailableSlots: %v", err)
+		}
+		if resp.TotalSlots == 0 {
+			b.Fatal("GetAvailableSlots returned no slots")
+		}
+	}
+
+	b.Run("cold", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			if err := bookingCache.InvalidateSlotGrids(ctx, expert.ExpertProfileID.String(), nil); err != nil {
+				b.Fatalf("InvalidateSlotGrids: %v", err)
+			}
+			b.StartTimer()
+			getSlots(b)
+		}
+	})
+
+	b.Run("warm", func(b *testing.B) {
+		getSlots(b) // Lấp cache cho cả 30 ngày
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			getSlots(b)
+		}
+	})
+}
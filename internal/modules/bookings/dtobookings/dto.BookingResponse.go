@@ -14,6 +14,7 @@ type BookingResponse struct {
 	MeetingLink      *string   `json:"meeting_link,omitempty"`
 	MeetingAddress   *string   `json:"meeting_address,omitempty"`
 	ConsultationFee  *float64  `json:"consultation_fee,omitempty"`
+	Currency         string    `json:"currency"`
 	PaymentStatus    string    `json:"payment_status"`
 	BookingCreatedAt time.Time `json:"booking_created_at"`
 }
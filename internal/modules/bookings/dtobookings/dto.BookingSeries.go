@@ -0,0 +1,82 @@
+package dtobookings
+
+import "time"
+
+// CreateBookingSeriesRequest tạo chuỗi lịch lặp lại mỗi IntervalWeeks tuần, bắt đầu từ FirstBookingDatetime
+type CreateBookingSeriesRequest struct {
+	UserID               string    `json:"-"`
+	ExpertProfileID      string    `json:"expert_profile_id" binding:"required"`
+	FirstBookingDatetime time.Time `json:"first_booking_datetime" binding:"required"`
+	IntervalWeeks        int       `json:"interval_weeks"`
+	Occurrences          int       `json:"occurrences" binding:"required"`
+	DurationMinutes      int       `json:"duration_minutes" binding:"required"`
+	ConsultationType     string    `json:"consultation_type" binding:"required"`
+	UserNotes            *string   `json:"user_notes,omitempty"`
+}
+
+type SeriesOccurrence struct {
+	SeriesIndex     int       `json:"series_index"`
+	BookingID       string    `json:"booking_id"`
+	BookingDatetime time.Time `json:"booking_datetime"`
+	BookingStatus   string    `json:"booking_status"`
+}
+
+// SeriesConflict là buổi không tạo/dời được, kèm lý do để client hiển thị
+type SeriesConflict struct {
+	SeriesIndex     int       `json:"series_index"`
+	BookingID       string    `json:"booking_id,omitempty"`
+	BookingDatetime time.Time `json:"booking_datetime"`
+	Reason          string    `json:"reason"`
+}
+
+type BookingSeriesResponse struct {
+	SeriesID         string             `json:"series_id"`
+	ExpertProfileID  string             `json:"expert_profile_id"`
+	IntervalWeeks    int                `json:"interval_weeks"`
+	SeriesStatus     string             `json:"series_status"`
+	DurationMinutes  int                `json:"duration_minutes"`
+	ConsultationType string             `json:"consultation_type"`
+	FeePerOccurrence *float64           `json:"fee_per_occurrence,omitempty"`
+	Currency         string             `json:"currency"`
+	Occurrences      []SeriesOccurrence `json:"occurrences"`
+	Conflicts        []SeriesConflict   `json:"conflicts,omitempty"`
+}
+
+type GetBookingSeriesRequest struct {
+	SeriesID string `form:"series_id" binding:"required"`
+	UserID   string `form:"-"`
+}
+
+// CancelSeriesOccurrencesRequest hủy buổi BookingID (Scope=this) hoặc buổi đó và mọi buổi sau (Scope=following)
+type CancelSeriesOccurrencesRequest struct {
+	UserID    string `json:"-"`
+	SeriesID  string `json:"series_id" binding:"required"`
+	BookingID string `json:"booking_id" binding:"required"`
+	Scope     string `json:"scope" binding:"required"`
+	Reason    string `json:"reason"`
+}
+
+type CancelSeriesOccurrencesResponse struct {
+	SeriesID     string             `json:"series_id"`
+	SeriesStatus string             `json:"series_status"`
+	Cancelled    []SeriesOccurrence `json:"cancelled"`
+	Skipped      []SeriesConflict   `json:"skipped,omitempty"`
+	RefundAmount float64            `json:"refund_amount"`
+}
+
+// RescheduleSeriesOccurrencesRequest dời buổi BookingID sang NewBookingDatetime;
+// với Scope=following các buổi sau được dời cùng một khoảng lệch.
+type RescheduleSeriesOccurrencesRequest struct {
+	UserID             string    `json:"-"`
+	SeriesID           string    `json:"series_id" binding:"required"`
+	BookingID          string    `json:"booking_id" binding:"required"`
+	Scope              string    `json:"scope" binding:"required"`
+	NewBookingDatetime time.Time `json:"new_booking_datetime" binding:"required"`
+	Reason             string    `json:"reason"`
+}
+
+type RescheduleSeriesOccurrencesResponse struct {
+	SeriesID    string             `json:"series_id"`
+	Rescheduled []SeriesOccurrence `json:"rescheduled"`
+	Conflicts   []SeriesConflict   `json:"conflicts,omitempty"`
+}
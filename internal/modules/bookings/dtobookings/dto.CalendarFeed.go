@@ -0,0 +1,34 @@
+package dtobookings
+
+import "time"
+
+// CreateCalendarFeedRequest tạo (hoặc cấp lại) feed .ics của người dùng; feed_type "expert" lấy lịch của hồ sơ expert
+type CreateCalendarFeedRequest struct {
+	UserID   string `json:"-"`
+	FeedType string `json:"feed_type" binding:"required,oneof=user expert"`
+}
+
+// CalendarFeedResponse trả token gốc đúng một lần, cấp lại feed sẽ làm URL cũ hết hiệu lực
+type CalendarFeedResponse struct {
+	FeedType  string    `json:"feed_type"`
+	Token     string    `json:"token"`
+	FeedURL   string    `json:"feed_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RevokeCalendarFeedRequest struct {
+	UserID   string `json:"-"`
+	FeedType string `json:"feed_type" binding:"required,oneof=user expert"`
+}
+
+type RevokeCalendarFeedResponse struct {
+	FeedType string `json:"feed_type"`
+	Revoked  bool   `json:"revoked"`
+}
+
+// CalendarFile là nội dung .ics trả thẳng cho ứng dụng lịch
+type CalendarFile struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
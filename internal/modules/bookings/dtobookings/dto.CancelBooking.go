@@ -8,8 +8,10 @@ type CancelBookingRequest struct {
 }
 
 type CancelResponse struct {
-	BookingID      string    `json:"booking_id"`
-	CancelByUserID string    `json:"user_id"`
-	Status         string    `json:"status"`
-	CancelledAt    time.Time `json:"cancel_at"`
+	BookingID        string    `json:"booking_id"`
+	CancelByUserID   string    `json:"user_id"`
+	Status           string    `json:"status"`
+	CancelledAt      time.Time `json:"cancel_at"`
+	RefundPercentage float64   `json:"refund_percentage"`
+	RefundAmount     float64   `json:"refund_amount"`
 }
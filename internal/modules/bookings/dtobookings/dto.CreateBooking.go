@@ -13,25 +13,28 @@ type CreateBookingRequest struct {
 	ExpertNotes      *string   `json:"expert_notes,omitempty"`
 	MeetingLink      *string   `json:"meeting_link,omitempty"`
 	MeetingAddress   *string   `json:"meeting_address,omitempty"`
-	ConsultationFee  *float64  `json:"consultation_fee,omitempty"`
 	PaymentStatus    string    `json:"payment_status"`
+	CouponCode       *string   `json:"coupon_code,omitempty"`
+	// ConsultationFee không nhận từ client, được tính phía server (xem PriceQuote)
 }
 
 type CreateBookingResponse struct {
-	BookingID        string    `json:"booking_id"`
-	UserID           string    `json:"user_id"`
-	ExpertProfileID  string    `json:"expert_profile_id"`
-	BookingDatetime  time.Time `json:"booking_datetime"`
-	DurationMinutes  int       `json:"duration_minutes"`
-	ConsultationType string    `json:"consultation_type"`
-	BookingStatus    string    `json:"booking_status"`
-	UserNotes        *string   `json:"user_notes,omitempty"`
-	ExpertNotes      *string   `json:"expert_notes,omitempty"`
-	MeetingLink      *string   `json:"meeting_link,omitempty"`
-	MeetingAddress   *string   `json:"meeting_address,omitempty"`
-	ConsultationFee  *float64  `json:"consultation_fee,omitempty"`
-	PaymentStatus    string    `json:"payment_status"`
-	BookingCreatedAt time.Time `json:"booking_created_at"`
+	BookingID        string      `json:"booking_id"`
+	UserID           string      `json:"user_id"`
+	ExpertProfileID  string      `json:"expert_profile_id"`
+	BookingDatetime  time.Time   `json:"booking_datetime"`
+	DurationMinutes  int         `json:"duration_minutes"`
+	ConsultationType string      `json:"consultation_type"`
+	BookingStatus    string      `json:"booking_status"`
+	UserNotes        *string     `json:"user_notes,omitempty"`
+	ExpertNotes      *string     `json:"expert_notes,omitempty"`
+	MeetingLink      *string     `json:"meeting_link,omitempty"`
+	MeetingAddress   *string     `json:"meeting_address,omitempty"`
+	ConsultationFee  *float64    `json:"consultation_fee,omitempty"`
+	Currency         string      `json:"currency"`
+	PaymentStatus    string      `json:"payment_status"`
+	BookingCreatedAt time.Time   `json:"booking_created_at"`
+	Quote            *PriceQuote `json:"quote,omitempty"`
 }
 
 // CancellationReason *string    `json:"cancellation_reason,omitempty"`
@@ -0,0 +1,44 @@
+package dtobookings
+
+import (
+	"cbs_backend/internal/common"
+	"time"
+)
+
+// GetExpertScheduleRequest xem lịch làm việc cuối cùng của expert đang đăng nhập theo từng ngày (múi giờ của expert)
+type GetExpertScheduleRequest struct {
+	UserID   string `form:"-"`
+	FromDate string `form:"from_date" binding:"required"` // YYYY-MM-DD
+	ToDate   string `form:"to_date" binding:"required"`   // YYYY-MM-DD
+}
+
+type ExpertScheduleResponse struct {
+	ExpertProfileID string              `json:"expert_profile_id"`
+	TimeZone        string              `json:"time_zone"`
+	Days            []ExpertScheduleDay `json:"days"`
+}
+
+// ExpertScheduleDay là lịch của một ngày sau khi áp override theo ngày, thời gian bận, ngày nghỉ toàn hệ thống và lịch hẹn
+type ExpertScheduleDay struct {
+	Date           string                `json:"date"`
+	DayOfWeek      common.Weekday        `json:"day_of_week"`
+	Source         string                `json:"source"` // "weekly" hoặc "override"
+	WorkingPeriods []SchedulePeriod      `json:"working_periods"`
+	Unavailable    []UnavailableTime     `json:"unavailable"`
+	Appointments   []ScheduleAppointment `json:"appointments"`
+}
+
+type SchedulePeriod struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// ScheduleAppointment là booking 1-1 (type "booking") hoặc buổi tư vấn nhóm (type "group_session")
+type ScheduleAppointment struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Status    string    `json:"status"`
+}
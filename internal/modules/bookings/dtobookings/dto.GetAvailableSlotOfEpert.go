@@ -1,6 +1,7 @@
 package dtobookings
 
 import (
+	"cbs_backend/internal/common"
 	"database/sql/driver"
 	"fmt"
 	"time"
@@ -63,6 +64,9 @@ type GetAvailableSlotsRequest struct {
 	FromDate            time.Time `form:"from_date" validate:"required" time_format:"2006-01-02T15:04:05Z07:00"`
 	ToDate              time.Time `form:"to_date" validate:"required" time_format:"2006-01-02T15:04:05Z07:00"`
 	SlotDurationMinutes int       `form:"slot_duration_minutes" validate:"min=15,max=240"`
+	// Múi giờ IANA của người xem: FromDate/ToDate là ngày theo múi giờ này và slot trả về theo múi giờ này.
+	// Bỏ trống thì dùng múi giờ của expert.
+	TimeZone string `form:"time_zone"`
 }
 
 type TimeSlot struct {
@@ -79,16 +83,90 @@ type GetAvailableSlotsResponse struct {
 	AvailableSlots  []TimeSlot `json:"available_slots"`
 	TotalSlots      int        `json:"total_slots"`
 	Message         string     `json:"message,omitempty"`
+	TimeZone        string     `json:"time_zone"`        // Múi giờ của các thời điểm trong response
+	ExpertTimeZone  string     `json:"expert_time_zone"` // Múi giờ expert dùng cho giờ làm việc
+	// Buổi tư vấn nhóm còn chỗ trong khoảng ngày, đặt chỗ qua BookGroupSeat
+	GroupSessions []GroupSessionResponse `json:"group_sessions,omitempty"`
 }
 
 // Database row structs
 type WorkingHourRow struct {
-	DayOfWeek int       `json:"day_of_week"`
-	StartTime TimeOfDay `json:"start_time"`
-	EndTime   TimeOfDay `json:"end_time"`
+	DayOfWeek common.Weekday `json:"day_of_week"`
+	StartTime TimeOfDay      `json:"start_time"`
+	EndTime   TimeOfDay      `json:"end_time"`
+}
+
+// WorkingHoursOn lọc các khung giờ làm việc áp dụng cho ngày chứa day (theo múi giờ day đang mang).
+// Bộ sinh slot và bước kiểm tra khi đặt lịch cùng đi qua hàm này nên luôn thống nhất về ngày trong tuần.
+func WorkingHoursOn(rows []WorkingHourRow, day time.Time) []WorkingHourRow {
+	weekday := common.WeekdayOf(day)
+	var result []WorkingHourRow
+	for _, wh := range rows {
+		if wh.DayOfWeek == weekday {
+			result = append(result, wh)
+		}
+	}
+	return result
+}
+
+// WorkingHourOverrideRow là giờ làm việc riêng của một ngày; IsDayOff = true thì nghỉ cả ngày
+type WorkingHourOverrideRow struct {
+	OverrideDate time.Time `json:"override_date"`
+	StartTime    TimeOfDay `json:"start_time"`
+	EndTime      TimeOfDay `json:"end_time"`
+	IsDayOff     bool      `json:"is_day_off"`
+}
+
+// WorkingSchedule gồm lịch tuần và các ngày có giờ riêng của expert
+type WorkingSchedule struct {
+	Weekly    []WorkingHourRow
+	Overrides []WorkingHourOverrideRow
+}
+
+// IsEmpty cho biết expert chưa cấu hình giờ làm việc nào
+func (s WorkingSchedule) IsEmpty() bool {
+	return len(s.Weekly) == 0 && len(s.Overrides) == 0
+}
+
+// On trả về các khung giờ làm việc của ngày chứa day (theo múi giờ day đang mang) và ngày đó có dùng override không.
+// Ngày có override thì chỉ dùng override, ngày nghỉ trả về rỗng; ngày khác dùng lịch tuần qua WorkingHoursOn.
+func (s WorkingSchedule) On(day time.Time) ([]WorkingHourRow, bool) {
+	y, m, d := day.Date()
+	overridden := false
+	var result []WorkingHourRow
+	for _, o := range s.Overrides {
+		oy, om, od := o.OverrideDate.Date()
+		if oy != y || om != m || od != d {
+			continue
+		}
+		overridden = true
+		if !o.IsDayOff {
+			result = append(result, WorkingHourRow{
+				DayOfWeek: common.WeekdayOf(day),
+				StartTime: o.StartTime,
+				EndTime:   o.EndTime,
+			})
+		}
+	}
+	if overridden {
+		return result, true
+	}
+	return WorkingHoursOn(s.Weekly, day), false
+}
+
+// Covers cho biết [start, end) nằm trọn trong một khung giờ làm việc của ngày chứa start (theo múi giờ start đang mang)
+func (s WorkingSchedule) Covers(start, end time.Time) bool {
+	workingHours, _ := s.On(start)
+	for _, wh := range workingHours {
+		if !start.Before(wh.StartTime.ToTime(start)) && !end.After(wh.EndTime.ToTime(start)) {
+			return true
+		}
+	}
+	return false
 }
 
 type UnavailableTime struct {
 	StartDatetime time.Time `json:"start_datetime"`
 	EndDatetime   time.Time `json:"end_datetime"`
+	Reason        string    `json:"reason,omitempty"`
 }
@@ -21,6 +21,7 @@ type BookingDetailResponse struct {
 	MeetingLink      *string   `json:"meeting_link"`
 	MeetingAddress   *string   `json:"meeting_address"`
 	ConsultationFee  float64   `json:"consultation_fee"`
+	Currency         string    `json:"currency"`
 	BookingCreatedAt time.Time `json:"booking_created_at"`
 	BookingUpdatedAt time.Time `json:"booking_updated_at"`
 }
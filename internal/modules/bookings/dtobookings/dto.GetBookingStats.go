@@ -11,7 +11,10 @@ type GetBookingStatsRequest struct {
 type BookingStats struct {
 	TotalBookings int64            `json:"total_bookings"`
 	StatusCounts  map[string]int64 `json:"status_counts"`
-	TotalSpent    float64          `json:"total_spent"`
+	TotalSpent    float64          `json:"total_spent"` // Đã quy đổi sang Currency theo bảng tỷ giá
+	Currency      string           `json:"currency"`
+	// TotalSpentByCurrency là số tiền gốc theo từng tiền tệ trước khi quy đổi
+	TotalSpentByCurrency map[string]float64 `json:"total_spent_by_currency"`
 }
 
 type GetBookingStatsResponse struct {
@@ -0,0 +1,106 @@
+package dtobookings
+
+import "time"
+
+// CreateGroupSessionRequest expert mở một buổi tư vấn nhóm với số chỗ và giá mỗi chỗ
+type CreateGroupSessionRequest struct {
+	UserID           string    `json:"-"`
+	Title            string    `json:"title" binding:"required"`
+	Description      *string   `json:"description,omitempty"`
+	SessionStart     time.Time `json:"session_start" binding:"required"`
+	DurationMinutes  int       `json:"duration_minutes" binding:"required"`
+	Capacity         int       `json:"capacity" binding:"required"`
+	SeatPrice        float64   `json:"seat_price"`
+	Currency         string    `json:"currency"`
+	ConsultationType string    `json:"consultation_type" binding:"required"`
+	MeetingLink      *string   `json:"meeting_link,omitempty"`
+	MeetingAddress   *string   `json:"meeting_address,omitempty"`
+}
+
+type GroupSessionResponse struct {
+	SessionID        string    `json:"session_id"`
+	ExpertProfileID  string    `json:"expert_profile_id"`
+	Title            string    `json:"title"`
+	Description      *string   `json:"description,omitempty"`
+	SessionStart     time.Time `json:"session_start"`
+	SessionEnd       time.Time `json:"session_end"`
+	DurationMinutes  int       `json:"duration_minutes"`
+	Capacity         int       `json:"capacity"`
+	SeatsBooked      int       `json:"seats_booked"`
+	SeatsLeft        int       `json:"seats_left"`
+	SeatPrice        float64   `json:"seat_price"`
+	Currency         string    `json:"currency"`
+	ConsultationType string    `json:"consultation_type"`
+	SessionStatus    string    `json:"session_status"`
+}
+
+type ListGroupSessionsRequest struct {
+	ExpertProfileID string    `form:"expert_profile_id" binding:"required"`
+	FromDate        time.Time `form:"from_date" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+	ToDate          time.Time `form:"to_date" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type ListGroupSessionsResponse struct {
+	Sessions []GroupSessionResponse `json:"sessions"`
+}
+
+type BookGroupSeatRequest struct {
+	UserID    string  `json:"-"`
+	SessionID string  `json:"session_id" binding:"required"`
+	UserNotes *string `json:"user_notes,omitempty"`
+}
+
+type BookGroupSeatResponse struct {
+	BookingID     string    `json:"booking_id"`
+	SessionID     string    `json:"session_id"`
+	SessionStart  time.Time `json:"session_start"`
+	BookingStatus string    `json:"booking_status"`
+	PaymentStatus string    `json:"payment_status"`
+	SeatPrice     float64   `json:"seat_price"`
+	Currency      string    `json:"currency"`
+	SeatsLeft     int       `json:"seats_left"`
+}
+
+type GetGroupSessionAttendeesRequest struct {
+	UserID    string `form:"-"`
+	SessionID string `form:"session_id" binding:"required"`
+}
+
+type GroupSessionAttendee struct {
+	BookingID     string `json:"booking_id"`
+	UserID        string `json:"user_id"`
+	FullName      string `json:"full_name"`
+	BookingStatus string `json:"booking_status"`
+	PaymentStatus string `json:"payment_status"`
+}
+
+type GetGroupSessionAttendeesResponse struct {
+	SessionID string                 `json:"session_id"`
+	Capacity  int                    `json:"capacity"`
+	Attendees []GroupSessionAttendee `json:"attendees"`
+}
+
+// CancelGroupSessionRequest expert hủy cả buổi: mọi chỗ đã đặt bị hủy và hoàn tiền
+type CancelGroupSessionRequest struct {
+	UserID    string `json:"-"`
+	SessionID string `json:"session_id" binding:"required"`
+	Reason    string `json:"reason" binding:"required"`
+}
+
+type CancelGroupSessionResponse struct {
+	SessionID         string  `json:"session_id"`
+	SessionStatus     string  `json:"session_status"`
+	CancelledBookings int     `json:"cancelled_bookings"`
+	RefundAmount      float64 `json:"refund_amount"`
+}
+
+type CompleteGroupSessionRequest struct {
+	UserID    string `json:"-"`
+	SessionID string `json:"session_id" binding:"required"`
+}
+
+type CompleteGroupSessionResponse struct {
+	SessionID         string `json:"session_id"`
+	SessionStatus     string `json:"session_status"`
+	CompletedBookings int    `json:"completed_bookings"`
+}
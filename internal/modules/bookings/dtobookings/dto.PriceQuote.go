@@ -0,0 +1,35 @@
+package dtobookings
+
+// Nguồn giá của một báo giá
+const (
+	PriceSourcePricingConfig = "pricing_config"
+	PriceSourceExpertProfile = "expert_profile"
+)
+
+type PriceQuoteItem struct {
+	Label  string  `json:"label"`
+	Amount float64 `json:"amount"` // Số âm cho các khoản giảm trừ
+}
+
+// PriceQuote là báo giá chi tiết được tính phía server cho một lịch tư vấn
+type PriceQuote struct {
+	Source             string           `json:"source"`
+	PricingID          string           `json:"pricing_id,omitempty"`
+	ConsultationType   string           `json:"consultation_type"`
+	DurationMinutes    int              `json:"duration_minutes"`
+	BasePrice          float64          `json:"base_price"`
+	DiscountPercentage float64          `json:"discount_percentage"`
+	DiscountAmount     float64          `json:"discount_amount"`
+	CouponCode         string           `json:"coupon_code,omitempty"`
+	CouponDiscount     float64          `json:"coupon_discount,omitempty"`
+	TotalAmount        float64          `json:"total_amount"`
+	TotalAmountMinor   int64            `json:"total_amount_minor"` // TotalAmount ở đơn vị nhỏ nhất của Currency
+	Currency           string           `json:"currency"`
+	Items              []PriceQuoteItem `json:"items"`
+}
+
+type GetPriceQuoteRequest struct {
+	ExpertProfileID  string `form:"expert_profile_id" binding:"required"`
+	ConsultationType string `form:"consultation_type" binding:"required"`
+	DurationMinutes  int    `form:"duration_minutes" binding:"required"`
+}
@@ -0,0 +1,23 @@
+package dtobookings
+
+import "time"
+
+// RejectBookingRequest expert từ chối booking pending; MaxAlternatives giới hạn số khung giờ trống gợi ý lại cho người dùng
+type RejectBookingRequest struct {
+	BookingID       string `json:"booking_id" binding:"required"`
+	Reason          string `json:"reason" binding:"required"`
+	MaxAlternatives int    `json:"max_alternatives"`
+	UserID          string `json:"-"`
+}
+
+type RejectBookingResponse struct {
+	BookingID             string     `json:"booking_id"`
+	Status                string     `json:"status"`
+	Reason                string     `json:"reason"`
+	RejectedAt            time.Time  `json:"rejected_at"`
+	RefundAmount          float64    `json:"refund_amount"`
+	RefundPercentage      float64    `json:"refund_percentage"`
+	CancelledTransactions []string   `json:"cancelled_transactions,omitempty"`
+	AlternativeSlots      []TimeSlot `json:"alternative_slots"`
+	Message               string     `json:"message"`
+}
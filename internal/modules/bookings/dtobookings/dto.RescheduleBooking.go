@@ -11,10 +11,18 @@ type RescheduleBookingRequest struct {
 }
 
 type RescheduleBookingResponse struct {
-	BookingID          string    `json:"booking_id"`
-	OldBookingDatetime time.Time `json:"old_booking_datetime"`
-	NewBookingDatetime time.Time `json:"new_booking_datetime"`
-	BookingStatus      string    `json:"booking_status"`
-	RescheduledAt      time.Time `json:"rescheduled_at"`
-	Message            string    `json:"message"`
+	BookingID            string    `json:"booking_id"`
+	OldBookingDatetime   time.Time `json:"old_booking_datetime"`
+	NewBookingDatetime   time.Time `json:"new_booking_datetime"`
+	BookingStatus        string    `json:"booking_status"`
+	RescheduleCount      int       `json:"reschedule_count"`
+	RemainingReschedules int       `json:"remaining_reschedules"`
+	RescheduledAt        time.Time `json:"rescheduled_at"`
+	Message              string    `json:"message"`
+}
+
+// ReschedulePolicy được lưu trong tbl_system_settings (key booking_reschedule_policy)
+type ReschedulePolicy struct {
+	MaxReschedules       int     `json:"max_reschedules"`         // Số lần tối đa một booking được đổi lịch
+	MinNoticeHoursBefore float64 `json:"min_notice_hours_before"` // Không được đổi lịch sát giờ buổi tư vấn hơn mốc này
 }
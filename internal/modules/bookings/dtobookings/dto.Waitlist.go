@@ -0,0 +1,55 @@
+package dtobookings
+
+import "time"
+
+// JoinWaitlistRequest đăng ký chờ slot của expert trong khoảng FromDate..ToDate (cùng quy ước với GetAvailableSlotsRequest)
+type JoinWaitlistRequest struct {
+	UserID           string    `json:"-"`
+	ExpertProfileID  string    `json:"expert_profile_id" binding:"required"`
+	FromDate         time.Time `json:"from_date" binding:"required"`
+	ToDate           time.Time `json:"to_date" binding:"required"`
+	DurationMinutes  int       `json:"duration_minutes" binding:"required"`
+	ConsultationType string    `json:"consultation_type" binding:"required"`
+}
+
+type WaitlistEntryResponse struct {
+	WaitlistID       string            `json:"waitlist_id"`
+	ExpertProfileID  string            `json:"expert_profile_id"`
+	WindowStart      time.Time         `json:"window_start"`
+	WindowEnd        time.Time         `json:"window_end"`
+	DurationMinutes  int               `json:"duration_minutes"`
+	ConsultationType string            `json:"consultation_type"`
+	WaitlistStatus   string            `json:"waitlist_status"`
+	Position         int               `json:"position,omitempty"` // Thứ tự trong hàng chờ của expert, chỉ có khi đang waiting
+	ActiveHold       *SlotHoldResponse `json:"active_hold,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"`
+}
+
+type SlotHoldResponse struct {
+	HoldID          string    `json:"hold_id"`
+	SlotStart       time.Time `json:"slot_start"`
+	SlotEnd         time.Time `json:"slot_end"`
+	DurationMinutes int       `json:"duration_minutes"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+type GetMyWaitlistRequest struct {
+	UserID string `form:"-"`
+}
+
+type GetMyWaitlistResponse struct {
+	Entries []WaitlistEntryResponse `json:"entries"`
+}
+
+type LeaveWaitlistRequest struct {
+	UserID     string `json:"-"`
+	WaitlistID string `json:"waitlist_id" binding:"required"`
+}
+
+// ClaimSlotHoldRequest đặt lịch vào slot đang được giữ cho người dùng
+type ClaimSlotHoldRequest struct {
+	UserID     string  `json:"-"`
+	HoldID     string  `json:"hold_id" binding:"required"`
+	UserNotes  *string `json:"user_notes,omitempty"`
+	CouponCode *string `json:"coupon_code,omitempty"`
+}
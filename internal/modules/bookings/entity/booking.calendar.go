@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"cbs_backend/internal/common"
+	"cbs_backend/pkg/ical"
+
+	"github.com/google/uuid"
+)
+
+// CalendarFeed represents tbl_calendar_feeds table
+// Feed .ics cá nhân để ứng dụng lịch đăng ký; chỉ lưu hash của token, token gốc chỉ trả về một lần khi tạo.
+type CalendarFeed struct {
+	FeedID         uuid.UUID  `json:"feed_id" db:"feed_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID         uuid.UUID  `json:"user_id" db:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_calendar_feed_owner"`
+	FeedType       string     `json:"feed_type" db:"feed_type" gorm:"type:varchar(10);not null;uniqueIndex:idx_calendar_feed_owner;check:feed_type IN ('user', 'expert')"`
+	TokenHash      string     `json:"-" db:"token_hash" gorm:"type:varchar(64);not null;uniqueIndex"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty" db:"last_accessed_at"`
+	FeedCreatedAt  time.Time  `json:"feed_created_at" db:"feed_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (CalendarFeed) TableName() string {
+	return "tbl_calendar_feeds"
+}
+
+// calendarUIDDomain là phần sau @ của UID, cố định để UID của một booking không bao giờ đổi
+const calendarUIDDomain = "cbs-booking"
+
+// CalendarUID là UID ổn định của booking trong feed và lời mời qua email
+func (b *ConsultationBooking) CalendarUID() string {
+	return fmt.Sprintf("booking-%s@%s", b.BookingID, calendarUIDDomain)
+}
+
+// CalendarEvent dựng VEVENT của booking. Đổi lịch giữ nguyên UID và tăng SEQUENCE theo RescheduleCount,
+// booking bị huỷ/từ chối tăng thêm một bậc với STATUS:CANCELLED để ứng dụng lịch gỡ sự kiện.
+func (b *ConsultationBooking) CalendarEvent(summary, description string) ical.Event {
+	status := ical.StatusConfirmed
+	sequence := b.RescheduleCount
+	switch b.BookingStatus {
+	case common.BookingStatusPending:
+		status = ical.StatusTentative
+	case common.BookingStatusCancelled, common.BookingStatusRejected:
+		status = ical.StatusCancelled
+		sequence++
+	}
+	return ical.Event{
+		UID:         b.CalendarUID(),
+		Sequence:    sequence,
+		Stamp:       calendarStamp(b.BookingUpdatedAt, b.BookingCreatedAt),
+		Start:       b.BookingDatetime,
+		End:         b.BookingDatetime.Add(time.Duration(b.DurationMinutes) * time.Minute),
+		Summary:     summary,
+		Description: description,
+		Location:    calendarLocation(b.MeetingLink, b.MeetingAddress),
+		URL:         valueOrEmpty(b.MeetingLink),
+		Status:      status,
+	}
+}
+
+// CalendarUID là UID ổn định của buổi tư vấn nhóm trong feed của expert
+func (s *GroupSession) CalendarUID() string {
+	return fmt.Sprintf("group-session-%s@%s", s.SessionID, calendarUIDDomain)
+}
+
+// CalendarEvent dựng VEVENT của buổi tư vấn nhóm
+func (s *GroupSession) CalendarEvent(description string) ical.Event {
+	status := ical.StatusConfirmed
+	sequence := 0
+	if s.SessionStatus == common.GroupSessionStatusCancelled {
+		status = ical.StatusCancelled
+		sequence = 1
+	}
+	return ical.Event{
+		UID:         s.CalendarUID(),
+		Sequence:    sequence,
+		Stamp:       calendarStamp(s.SessionUpdatedAt, s.SessionCreatedAt),
+		Start:       s.SessionStart,
+		End:         s.SessionEnd(),
+		Summary:     s.Title,
+		Description: description,
+		Location:    calendarLocation(s.MeetingLink, s.MeetingAddress),
+		URL:         valueOrEmpty(s.MeetingLink),
+		Status:      status,
+	}
+}
+
+// calendarStamp lấy thời điểm cập nhật cuối, bản ghi cũ chưa có updated_at thì dùng created_at
+func calendarStamp(updatedAt, createdAt time.Time) time.Time {
+	if updatedAt.IsZero() {
+		return createdAt
+	}
+	return updatedAt
+}
+
+// calendarLocation ưu tiên link họp online, không có thì dùng địa chỉ gặp trực tiếp
+func calendarLocation(meetingLink, meetingAddress *string) string {
+	if link := valueOrEmpty(meetingLink); link != "" {
+		return link
+	}
+	return valueOrEmpty(meetingAddress)
+}
+
+func valueOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
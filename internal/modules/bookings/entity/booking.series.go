@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookingSeries represents tbl_booking_series table
+// Chuỗi lịch định kỳ (ví dụ thứ Ba 10:00 mỗi tuần trong 8 tuần); mỗi buổi là một ConsultationBooking có SeriesID trỏ về đây.
+type BookingSeries struct {
+	SeriesID          uuid.UUID `json:"series_id" db:"series_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID            uuid.UUID `json:"user_id" db:"user_id" gorm:"type:uuid;not null;index"`
+	ExpertProfileID   uuid.UUID `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;not null;index"`
+	IntervalWeeks     int       `json:"interval_weeks" db:"interval_weeks" gorm:"not null;default:1"`
+	OccurrenceCount   int       `json:"occurrence_count" db:"occurrence_count" gorm:"not null"` // Số buổi được yêu cầu, kể cả buổi bị trùng lịch
+	FirstOccurrenceAt time.Time `json:"first_occurrence_at" db:"first_occurrence_at" gorm:"not null"`
+	DurationMinutes   int       `json:"duration_minutes" db:"duration_minutes" gorm:"not null"`
+	ConsultationType  string    `json:"consultation_type" db:"consultation_type" gorm:"type:varchar(20);not null"`
+	SeriesStatus      string    `json:"series_status" db:"series_status" gorm:"type:varchar(20);not null;default:'active';check:series_status IN ('active', 'cancelled')"`
+	SeriesCreatedAt   time.Time `json:"series_created_at" db:"series_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	SeriesUpdatedAt   time.Time `json:"series_updated_at" db:"series_updated_at"`
+}
+
+func (BookingSeries) TableName() string {
+	return "tbl_booking_series"
+}
@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WaitlistEntry represents tbl_booking_waitlist table
+// Người dùng chờ slot của expert trong khoảng [WindowStart, WindowEnd) khi expert đã kín lịch.
+type WaitlistEntry struct {
+	WaitlistID        uuid.UUID `json:"waitlist_id" db:"waitlist_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID            uuid.UUID `json:"user_id" db:"user_id" gorm:"type:uuid;not null;index"`
+	ExpertProfileID   uuid.UUID `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;not null;index:idx_waitlist_expert_status"`
+	WindowStart       time.Time `json:"window_start" db:"window_start" gorm:"not null"`
+	WindowEnd         time.Time `json:"window_end" db:"window_end" gorm:"not null"`
+	DurationMinutes   int       `json:"duration_minutes" db:"duration_minutes" gorm:"not null"`
+	ConsultationType  string    `json:"consultation_type" db:"consultation_type" gorm:"type:varchar(20);not null"`
+	WaitlistStatus    string    `json:"waitlist_status" db:"waitlist_status" gorm:"type:varchar(20);not null;default:'waiting';index:idx_waitlist_expert_status;check:waitlist_status IN ('waiting', 'offered', 'booked', 'expired', 'cancelled')"`
+	WaitlistCreatedAt time.Time `json:"waitlist_created_at" db:"waitlist_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	WaitlistUpdatedAt time.Time `json:"waitlist_updated_at" db:"waitlist_updated_at"`
+}
+
+func (WaitlistEntry) TableName() string {
+	return "tbl_booking_waitlist"
+}
+
+// SlotHold represents tbl_slot_holds table
+// Slot vừa được giải phóng được giữ cho một người trong waitlist tới ExpiresAt; quá hạn thì chuyển cho người kế tiếp.
+type SlotHold struct {
+	HoldID           uuid.UUID  `json:"hold_id" db:"hold_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	WaitlistID       uuid.UUID  `json:"waitlist_id" db:"waitlist_id" gorm:"type:uuid;not null;index"`
+	UserID           uuid.UUID  `json:"user_id" db:"user_id" gorm:"type:uuid;not null;index"`
+	ExpertProfileID  uuid.UUID  `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;not null;index:idx_slot_hold_expert_status"`
+	SlotStart        time.Time  `json:"slot_start" db:"slot_start" gorm:"not null"`
+	DurationMinutes  int        `json:"duration_minutes" db:"duration_minutes" gorm:"not null"`
+	HoldStatus       string     `json:"hold_status" db:"hold_status" gorm:"type:varchar(20);not null;default:'active';index:idx_slot_hold_expert_status;check:hold_status IN ('active', 'claimed', 'expired', 'released')"`
+	ExpiresAt        time.Time  `json:"expires_at" db:"expires_at" gorm:"not null;index"`
+	NotifiedAt       *time.Time `json:"notified_at,omitempty" db:"notified_at"` // Worker gửi realtime + email xong thì ghi lại
+	ClaimedBookingID *uuid.UUID `json:"claimed_booking_id,omitempty" db:"claimed_booking_id" gorm:"type:uuid"`
+	HoldCreatedAt    time.Time  `json:"hold_created_at" db:"hold_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	HoldUpdatedAt    time.Time  `json:"hold_updated_at" db:"hold_updated_at"`
+
+	// Relationships
+	WaitlistEntry WaitlistEntry `json:"waitlist_entry" gorm:"foreignKey:WaitlistID"`
+}
+
+func (SlotHold) TableName() string {
+	return "tbl_slot_holds"
+}
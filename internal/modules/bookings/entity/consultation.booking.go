@@ -3,6 +3,7 @@ package entity
 import (
 	"time"
 
+	"cbs_backend/internal/common"
 	// entityReview "cbs_backend/internal/modules/consultation_review/entity"
 	entityExpertProfile "cbs_backend/internal/modules/experts/entity"
 	entityUsers "cbs_backend/internal/modules/users/entity"
@@ -12,26 +13,31 @@ import (
 
 // ConsultationBooking represents tbl_consultation_bookings table
 type ConsultationBooking struct {
-	BookingID          uuid.UUID  `json:"booking_id" db:"booking_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	UserID             uuid.UUID  `json:"user_id" db:"user_id" gorm:"type:uuid;not null;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	ExpertProfileID    uuid.UUID  `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;not null;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	BookingDatetime    time.Time  `json:"booking_datetime" db:"booking_datetime" gorm:"not null"`
-	DurationMinutes    int        `json:"duration_minutes" db:"duration_minutes" gorm:"default:60"`
-	ConsultationType   string     `json:"consultation_type" db:"consultation_type" gorm:"type:varchar(20);not null;check:consultation_type IN ('online', 'offline')"`
-	BookingStatus      string     `json:"booking_status" db:"booking_status" gorm:"type:varchar(20);not null;default:'pending';check:booking_status IN ('pending', 'confirmed', 'rejected', 'cancelled', 'completed', 'missed', 'no_show')"`
-	UserNotes          *string    `json:"user_notes,omitempty" db:"user_notes" gorm:"type:text"`
-	ExpertNotes        *string    `json:"expert_notes,omitempty" db:"expert_notes" gorm:"type:text"`
-	MeetingLink        *string    `json:"meeting_link,omitempty" db:"meeting_link" gorm:"type:text"`
-	MeetingAddress     *string    `json:"meeting_address,omitempty" db:"meeting_address" gorm:"type:text"`
-	ConsultationFee    *float64   `json:"consultation_fee,omitempty" db:"consultation_fee" gorm:"type:decimal(10,2)"`
-	PaymentStatus      string     `json:"payment_status" db:"payment_status" gorm:"type:varchar(20);default:'pending';check:payment_status IN ('pending', 'paid', 'refunded', 'failed');constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	CancellationReason *string    `json:"cancellation_reason,omitempty" db:"cancellation_reason" gorm:"type:text"`
-	CancelledByUserID  *uuid.UUID `json:"cancelled_by_user_id,omitempty" db:"cancelled_by_user_id" gorm:"type:uuid;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	CancelledAt        *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
-	ReminderSent       bool       `json:"reminder_sent" db:"reminder_sent" gorm:"default:false"`
-	BookingCompletedAt *time.Time `json:"booking_completed_at" db:"booking_completed_at"`
-	BookingCreatedAt   time.Time  `json:"booking_created_at" db:"booking_created_at" gorm:"default:CURRENT_TIMESTAMP"`
-	BookingUpdatedAt   time.Time  `json:"booking_updated_at" db:"booking_updated_at" `
+	BookingID            uuid.UUID  `json:"booking_id" db:"booking_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID               uuid.UUID  `json:"user_id" db:"user_id" gorm:"type:uuid;not null;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	ExpertProfileID      uuid.UUID  `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;not null;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	BookingDatetime      time.Time  `json:"booking_datetime" db:"booking_datetime" gorm:"not null"`
+	DurationMinutes      int        `json:"duration_minutes" db:"duration_minutes" gorm:"default:60"`
+	ConsultationType     string     `json:"consultation_type" db:"consultation_type" gorm:"type:varchar(20);not null;check:consultation_type IN ('online', 'offline')"`
+	BookingStatus        string     `json:"booking_status" db:"booking_status" gorm:"type:varchar(20);not null;default:'pending';check:booking_status IN ('pending', 'confirmed', 'rejected', 'cancelled', 'completed', 'missed', 'no_show')"`
+	UserNotes            *string    `json:"user_notes,omitempty" db:"user_notes" gorm:"type:text"`
+	ExpertNotes          *string    `json:"expert_notes,omitempty" db:"expert_notes" gorm:"type:text"`
+	MeetingLink          *string    `json:"meeting_link,omitempty" db:"meeting_link" gorm:"type:text"`
+	MeetingAddress       *string    `json:"meeting_address,omitempty" db:"meeting_address" gorm:"type:text"`
+	ConsultationFeeMinor *int64     `json:"consultation_fee_minor,omitempty" db:"consultation_fee_minor" gorm:"type:bigint"` // Đơn vị nhỏ nhất của Currency
+	Currency             string     `json:"currency" db:"currency" gorm:"type:varchar(3);not null;default:'VND'"`
+	PaymentStatus        string     `json:"payment_status" db:"payment_status" gorm:"type:varchar(20);default:'pending';check:payment_status IN ('pending', 'paid', 'partially_refunded', 'refunded', 'failed');constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CancellationReason   *string    `json:"cancellation_reason,omitempty" db:"cancellation_reason" gorm:"type:text"`
+	CancelledByUserID    *uuid.UUID `json:"cancelled_by_user_id,omitempty" db:"cancelled_by_user_id" gorm:"type:uuid;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CancelledAt          *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	ReminderSent         bool       `json:"reminder_sent" db:"reminder_sent" gorm:"default:false"`
+	BookingCompletedAt   *time.Time `json:"booking_completed_at" db:"booking_completed_at"`
+	BookingCreatedAt     time.Time  `json:"booking_created_at" db:"booking_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	BookingUpdatedAt     time.Time  `json:"booking_updated_at" db:"booking_updated_at" `
+	SeriesID             *uuid.UUID `json:"series_id,omitempty" db:"series_id" gorm:"type:uuid;index"`               // Booking thuộc chuỗi lịch định kỳ
+	SeriesIndex          *int       `json:"series_index,omitempty" db:"series_index"`                                // Thứ tự buổi trong chuỗi, bắt đầu từ 1
+	GroupSessionID       *uuid.UUID `json:"group_session_id,omitempty" db:"group_session_id" gorm:"type:uuid;index"` // Chỗ ngồi trong buổi tư vấn nhóm
+	RescheduleCount      int        `json:"reschedule_count" db:"reschedule_count" gorm:"not null;default:0"`        // Số lần đã đổi lịch, giới hạn theo ReschedulePolicy
 
 	// Relationships
 	User            entityUsers.User                  `json:"user" gorm:"foreignKey:UserID"`
@@ -45,3 +51,12 @@ type ConsultationBooking struct {
 func (ConsultationBooking) TableName() string {
 	return "tbl_consultation_bookings"
 }
+
+// ConsultationFee trả về phí tư vấn dạng thập phân theo Currency, nil nếu booking chưa có phí
+func (b *ConsultationBooking) ConsultationFee() *float64 {
+	if b.ConsultationFeeMinor == nil {
+		return nil
+	}
+	fee := common.FromMinorUnits(*b.ConsultationFeeMinor, b.Currency)
+	return &fee
+}
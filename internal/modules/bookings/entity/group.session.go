@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"time"
+
+	"cbs_backend/internal/common"
+
+	"github.com/google/uuid"
+)
+
+// GroupSession represents tbl_group_sessions table
+// Buổi tư vấn nhóm/workshop do expert mở; mỗi chỗ ngồi người dùng đặt là một ConsultationBooking có GroupSessionID trỏ về đây.
+type GroupSession struct {
+	SessionID          uuid.UUID  `json:"session_id" db:"session_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ExpertProfileID    uuid.UUID  `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;not null;index:idx_group_session_expert_start"`
+	Title              string     `json:"title" db:"title" gorm:"type:varchar(200);not null"`
+	Description        *string    `json:"description,omitempty" db:"description" gorm:"type:text"`
+	SessionStart       time.Time  `json:"session_start" db:"session_start" gorm:"not null;index:idx_group_session_expert_start"`
+	DurationMinutes    int        `json:"duration_minutes" db:"duration_minutes" gorm:"not null"`
+	Capacity           int        `json:"capacity" db:"capacity" gorm:"not null;check:capacity > 0"`
+	SeatPriceMinor     int64      `json:"seat_price_minor" db:"seat_price_minor" gorm:"type:bigint;not null;default:0"` // Đơn vị nhỏ nhất của Currency
+	Currency           string     `json:"currency" db:"currency" gorm:"type:varchar(3);not null;default:'VND'"`
+	ConsultationType   string     `json:"consultation_type" db:"consultation_type" gorm:"type:varchar(20);not null;check:consultation_type IN ('online', 'offline')"`
+	MeetingLink        *string    `json:"meeting_link,omitempty" db:"meeting_link" gorm:"type:text"`
+	MeetingAddress     *string    `json:"meeting_address,omitempty" db:"meeting_address" gorm:"type:text"`
+	SessionStatus      string     `json:"session_status" db:"session_status" gorm:"type:varchar(20);not null;default:'scheduled';check:session_status IN ('scheduled', 'cancelled', 'completed')"`
+	ReminderSent       bool       `json:"reminder_sent" db:"reminder_sent" gorm:"default:false"` // Nhắc expert một lần cho cả buổi, người tham dự được nhắc theo từng booking
+	CancellationReason *string    `json:"cancellation_reason,omitempty" db:"cancellation_reason" gorm:"type:text"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	SessionCreatedAt   time.Time  `json:"session_created_at" db:"session_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	SessionUpdatedAt   time.Time  `json:"session_updated_at" db:"session_updated_at"`
+}
+
+func (GroupSession) TableName() string {
+	return "tbl_group_sessions"
+}
+
+// SeatPrice trả về giá mỗi chỗ dạng thập phân theo Currency
+func (s *GroupSession) SeatPrice() float64 {
+	return common.FromMinorUnits(s.SeatPriceMinor, s.Currency)
+}
+
+// SessionEnd là thời điểm kết thúc buổi
+func (s *GroupSession) SessionEnd() time.Time {
+	return s.SessionStart.Add(time.Duration(s.DurationMinutes) * time.Minute)
+}
@@ -1,10 +1,13 @@
 package dashboard
 
 import (
+	"cbs_backend/internal/common"
 	"cbs_backend/internal/modules/dashboard/dtodashboard"
+	exchangerates "cbs_backend/internal/modules/exchange_rates"
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -65,13 +68,18 @@ func (dbs *DashboardService) GetBookingStats(ctx context.Context, req dtodashboa
 		return res, fmt.Errorf("get booking count failed: %w", err)
 	}
 
-	// Tính tổng doanh thu từ các giao dịch thành công
+	// Tính tổng doanh thu từ các giao dịch thành công, quy đổi về VND
 	revenueQuery := query.Session(&gorm.Session{}) // tránh ảnh hưởng bởi Count()
 	revenueQuery = revenueQuery.Where("pt.transaction_status = ?", "completed")
-	if err = revenueQuery.Select("COALESCE(SUM(pt.amount), 0)").Scan(&revenue).Error; err != nil {
+	var revenueRows []currencyTotal
+	if err = revenueQuery.Select("pt.currency AS currency, COALESCE(SUM(pt.amount_minor), 0) AS total_minor").
+		Group("pt.currency").Scan(&revenueRows).Error; err != nil {
 		dbs.logger.Error("❌ Failed to calculate revenue", zap.Error(err))
 		return res, fmt.Errorf("get booking revenue failed: %w", err)
 	}
+	if revenue, _, err = convertTotals(ctx, revenueRows, common.CurrencyVND, time.Now()); err != nil {
+		return res, fmt.Errorf("get booking revenue failed: %w", err)
+	}
 
 	// Định dạng thời gian cho phản hồi
 	period := "All time"
@@ -153,11 +161,8 @@ func (dbs *DashboardService) GetSystemOverview(ctx context.Context) (res dtodash
 		return res, err
 	}
 
-	// Get total revenue from completed transactions
-	if err = dbs.db.WithContext(ctx).Table("tbl_payment_transactions").
-		Select("COALESCE(SUM(amount), 0)").
-		Where("transaction_status = ?", "completed").
-		Scan(&totalRevenue).Error; err != nil {
+	// Get total revenue from completed transactions (quy đổi về VND)
+	if totalRevenue, _, err = dbs.sumRevenue(ctx, common.CurrencyVND, time.Now(), "transaction_status = ?", "completed"); err != nil {
 		dbs.logger.Error("Failed to get total revenue", zap.Error(err))
 		return res, err
 	}
@@ -186,14 +191,17 @@ func (dbs *DashboardService) GetSystemOverview(ctx context.Context) (res dtodash
 func (dbs *DashboardService) GetRevenueReport(ctx context.Context, req dtodashboard.RevenueReportRequest) (res dtodashboard.RevenueReportResponse, err error) {
 	dbs.logger.Info("Getting revenue report", zap.Any("request", req))
 
-	var revenue float64
+	currency := common.NormalizeCurrency(req.Currency)
+	if !common.IsSupportedCurrency(currency) {
+		return res, fmt.Errorf("unsupported currency: %s", currency)
+	}
+
 	var bookingCount int64
 
-	// Get revenue for the period
-	if err = dbs.db.WithContext(ctx).Table("tbl_payment_transactions").
-		Select("COALESCE(SUM(amount), 0)").
-		Where("transaction_status = ? AND transaction_created_at >= ? AND transaction_created_at <= ?", "completed", req.DateFrom, req.DateTo).
-		Scan(&revenue).Error; err != nil {
+	// Get revenue for the period (giao dịch có thể ở nhiều tiền tệ, quy đổi theo tỷ giá tại cuối kỳ)
+	revenue, revenueByCurrency, err := dbs.sumRevenue(ctx, currency, req.DateTo,
+		"transaction_status = ? AND transaction_created_at >= ? AND transaction_created_at <= ?", "completed", req.DateFrom, req.DateTo)
+	if err != nil {
 		dbs.logger.Error("Failed to get revenue", zap.Error(err))
 		return res, err
 	}
@@ -207,15 +215,13 @@ func (dbs *DashboardService) GetRevenueReport(ctx context.Context, req dtodashbo
 	}
 
 	// Calculate growth percentage (compared to previous period)
-	var previousRevenue float64
 	periodDuration := req.DateTo.Sub(req.DateFrom)
 	previousStartDate := req.DateFrom.Add(-periodDuration)
 	previousEndDate := req.DateFrom
 
-	if err = dbs.db.WithContext(ctx).Table("tbl_payment_transactions").
-		Select("COALESCE(SUM(amount), 0)").
-		Where("transaction_status = ? AND transaction_created_at >= ? AND transaction_created_at < ?", "completed", previousStartDate, previousEndDate).
-		Scan(&previousRevenue).Error; err != nil {
+	previousRevenue, _, err := dbs.sumRevenue(ctx, currency, previousEndDate,
+		"transaction_status = ? AND transaction_created_at >= ? AND transaction_created_at < ?", "completed", previousStartDate, previousEndDate)
+	if err != nil {
 		dbs.logger.Error("Failed to get previous revenue", zap.Error(err))
 		return res, err
 	}
@@ -229,15 +235,52 @@ func (dbs *DashboardService) GetRevenueReport(ctx context.Context, req dtodashbo
 	period := req.DateFrom.Format("2006-01-02") + " to " + req.DateTo.Format("2006-01-02")
 
 	res = dtodashboard.RevenueReportResponse{
-		Period:       period,
-		Revenue:      revenue,
-		BookingCount: bookingCount,
-		Growth:       growth,
+		Period:            period,
+		Currency:          currency,
+		Revenue:           revenue,
+		RevenueByCurrency: revenueByCurrency,
+		BookingCount:      bookingCount,
+		Growth:            growth,
 	}
 
 	return res, nil
 }
 
+// sumRevenue cộng doanh thu theo từng tiền tệ giao dịch rồi quy đổi sang currency theo tỷ giá hiệu lực tại at
+func (dbs *DashboardService) sumRevenue(ctx context.Context, currency string, at time.Time, query string, args ...interface{}) (float64, map[string]float64, error) {
+	var rows []currencyTotal
+	if err := dbs.db.WithContext(ctx).Table("tbl_payment_transactions").
+		Select("currency, COALESCE(SUM(amount_minor), 0) AS total_minor").
+		Where(query, args...).
+		Group("currency").
+		Scan(&rows).Error; err != nil {
+		return 0, nil, err
+	}
+	return convertTotals(ctx, rows, currency, at)
+}
+
+// currencyTotal là tổng tiền (đơn vị nhỏ nhất) của một tiền tệ
+type currencyTotal struct {
+	Currency   string
+	TotalMinor int64
+}
+
+// convertTotals quy đổi các tổng theo tiền tệ sang currency và trả kèm tổng gốc của từng tiền tệ
+func convertTotals(ctx context.Context, rows []currencyTotal, currency string, at time.Time) (float64, map[string]float64, error) {
+	var total float64
+	byCurrency := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		amount := common.FromMinorUnits(row.TotalMinor, row.Currency)
+		byCurrency[row.Currency] = amount
+		converted, err := exchangerates.ExchangeRate().Convert(ctx, amount, row.Currency, currency, at)
+		if err != nil {
+			return 0, nil, err
+		}
+		total += converted
+	}
+	return total, byCurrency, nil
+}
+
 func (dbs *DashboardService) GetExpertPerformance(ctx context.Context, expertId string) (res dtodashboard.ExpertPerformanceResponse, err error) {
 	dbs.logger.Info("Getting expert performance", zap.String("expertId", expertId))
 
@@ -312,10 +355,9 @@ func (dbs *DashboardService) GetExpertPerformance(ctx context.Context, expertId
 		return res, err
 	}
 
-	// Revenue
-	if err = dbs.db.WithContext(ctx).Table("tbl_payment_transactions").
-		Where("expert_profile_id = ? AND transaction_status = ?", expertProfileId, "completed").
-		Select("COALESCE(SUM(amount), 0)").Scan(&revenue).Error; err != nil {
+	// Revenue (quy đổi về VND)
+	if revenue, _, err = dbs.sumRevenue(ctx, common.CurrencyVND, time.Now(),
+		"expert_profile_id = ? AND transaction_status = ?", expertProfileId, "completed"); err != nil {
 		dbs.logger.Error("Failed to get revenue", zap.Error(err))
 		return res, err
 	}
@@ -6,11 +6,15 @@ type RevenueReportRequest struct {
 	DateFrom time.Time `json:"date_from"`
 	DateTo   time.Time `json:"date_to"`
 	GroupBy  string    `json:"group_by"` // day, week, month
+	Currency string    `json:"currency"` // Tiền tệ báo cáo, mặc định VND
 }
 
 type RevenueReportResponse struct {
-	Period       string  `json:"period"`
-	Revenue      float64 `json:"revenue"`
-	BookingCount int64   `json:"booking_count"`
-	Growth       float64 `json:"growth_percentage"`
+	Period   string  `json:"period"`
+	Currency string  `json:"currency"`
+	Revenue  float64 `json:"revenue"` // Đã quy đổi sang Currency theo bảng tỷ giá
+	// RevenueByCurrency là doanh thu gốc theo từng tiền tệ giao dịch trước khi quy đổi
+	RevenueByCurrency map[string]float64 `json:"revenue_by_currency"`
+	BookingCount      int64              `json:"booking_count"`
+	Growth            float64            `json:"growth_percentage"`
 }
@@ -0,0 +1,34 @@
+package dtoexchangerates
+
+import "time"
+
+// UpsertExchangeRateRequest tạo mới hoặc sửa tỷ giá của cặp tiền tại cùng thời điểm hiệu lực
+type UpsertExchangeRateRequest struct {
+	BaseCurrency  string     `json:"base_currency" binding:"required"`
+	QuoteCurrency string     `json:"quote_currency" binding:"required"`
+	Rate          float64    `json:"rate" binding:"required"`
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"` // Mặc định là thời điểm hiện tại
+	AdminUserID   string     `json:"-"`
+}
+
+type GetExchangeRatesRequest struct {
+	BaseCurrency  string `form:"base_currency"`
+	QuoteCurrency string `form:"quote_currency"`
+}
+
+type DeleteExchangeRateRequest struct {
+	RateID string `form:"rate_id" binding:"required"`
+}
+
+type ExchangeRateResponse struct {
+	RateID        string    `json:"rate_id"`
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          float64   `json:"rate"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type GetExchangeRatesResponse struct {
+	Rates []ExchangeRateResponse `json:"rates"`
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExchangeRate represents tbl_exchange_rates table
+// Một dòng nghĩa là 1 BaseCurrency = Rate QuoteCurrency, có hiệu lực từ EffectiveFrom cho tới khi có tỷ giá mới hơn.
+type ExchangeRate struct {
+	RateID          uuid.UUID  `json:"rate_id" db:"rate_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	BaseCurrency    string     `json:"base_currency" db:"base_currency" gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate_pair_effective"`
+	QuoteCurrency   string     `json:"quote_currency" db:"quote_currency" gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate_pair_effective"`
+	Rate            float64    `json:"rate" db:"rate" gorm:"type:decimal(18,8);not null;check:rate > 0"`
+	EffectiveFrom   time.Time  `json:"effective_from" db:"effective_from" gorm:"not null;uniqueIndex:idx_exchange_rate_pair_effective"`
+	CreatedByUserID *uuid.UUID `json:"created_by_user_id,omitempty" db:"created_by_user_id" gorm:"type:uuid"`
+	RateCreatedAt   time.Time  `json:"rate_created_at" db:"rate_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	RateUpdatedAt   time.Time  `json:"rate_updated_at" db:"rate_updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (ExchangeRate) TableName() string {
+	return "tbl_exchange_rates"
+}
@@ -0,0 +1,83 @@
+package exchangerates
+
+import (
+	"cbs_backend/internal/modules/exchange_rates/dtoexchangerates"
+	"cbs_backend/pkg/response"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ExchangeRateController struct {
+	Logger *zap.Logger
+}
+
+func NewExchangeRateController(logger *zap.Logger) *ExchangeRateController {
+	return &ExchangeRateController{Logger: logger}
+}
+
+// getUserIDFromContext lấy userID đã được AuthMiddleware gắn vào context
+func getUserIDFromContext(c *gin.Context) (string, error) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		return "", response.NewAPIError(http.StatusUnauthorized, "Unauthorized", "UserID not found in context")
+	}
+	userID, ok := userIDValue.(uuid.UUID)
+	if !ok {
+		return "", response.NewAPIError(http.StatusInternalServerError, "Internal error", "Invalid userID type")
+	}
+	return userID.String(), nil
+}
+
+func (ec *ExchangeRateController) GetRates(c *gin.Context) (res interface{}, err error) {
+	var req dtoexchangerates.GetExchangeRatesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ec.Logger.Error("Invalid get exchange rates request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get exchange rates request", err)
+	}
+
+	resp, err := ExchangeRate().GetRates(context.Background(), req)
+	if err != nil {
+		ec.Logger.Error("Get exchange rates failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get exchange rates failed", err)
+	}
+
+	return resp, nil
+}
+
+func (ec *ExchangeRateController) UpsertRate(c *gin.Context) (res interface{}, err error) {
+	var req dtoexchangerates.UpsertExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ec.Logger.Error("Invalid upsert exchange rate request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid upsert exchange rate request", err)
+	}
+	if req.AdminUserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := ExchangeRate().UpsertRate(context.Background(), req)
+	if err != nil {
+		ec.Logger.Error("Upsert exchange rate failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Upsert exchange rate failed", err)
+	}
+
+	return resp, nil
+}
+
+func (ec *ExchangeRateController) DeleteRate(c *gin.Context) (res interface{}, err error) {
+	var req dtoexchangerates.DeleteExchangeRateRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ec.Logger.Error("Invalid delete exchange rate request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid delete exchange rate request", err)
+	}
+
+	if err := ExchangeRate().DeleteRate(context.Background(), req); err != nil {
+		ec.Logger.Error("Delete exchange rate failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Delete exchange rate failed", err)
+	}
+
+	return map[string]string{"message": "Exchange rate deleted successfully"}, nil
+}
@@ -0,0 +1,34 @@
+package exchangerates
+
+import (
+	"cbs_backend/internal/modules/exchange_rates/dtoexchangerates"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	iExchangeRateService IExchangeRates
+)
+
+func InitExchangeRateService(db *gorm.DB, logger *zap.Logger) {
+	iExchangeRateService = NewExchangeRateService(db, logger)
+}
+
+func ExchangeRate() IExchangeRates {
+	if iExchangeRateService == nil {
+		panic("ExchangeRateService not initialized. Call InitExchangeRateService(db, logger) first.")
+	}
+	return iExchangeRateService
+}
+
+type IExchangeRates interface {
+	GetRates(ctx context.Context, req dtoexchangerates.GetExchangeRatesRequest) (*dtoexchangerates.GetExchangeRatesResponse, error)
+	UpsertRate(ctx context.Context, req dtoexchangerates.UpsertExchangeRateRequest) (*dtoexchangerates.ExchangeRateResponse, error)
+	DeleteRate(ctx context.Context, req dtoexchangerates.DeleteExchangeRateRequest) error
+
+	// Convert đổi số tiền từ tiền tệ from sang to theo tỷ giá có hiệu lực tại thời điểm at
+	Convert(ctx context.Context, amount float64, from, to string, at time.Time) (float64, error)
+}
@@ -0,0 +1,165 @@
+package exchangerates
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/exchange_rates/dtoexchangerates"
+	entityRate "cbs_backend/internal/modules/exchange_rates/entity"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type exchangeRateService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func NewExchangeRateService(db *gorm.DB, logger *zap.Logger) *exchangeRateService {
+	return &exchangeRateService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (es *exchangeRateService) GetRates(ctx context.Context, req dtoexchangerates.GetExchangeRatesRequest) (*dtoexchangerates.GetExchangeRatesResponse, error) {
+	query := es.db.WithContext(ctx).Model(&entityRate.ExchangeRate{})
+	if req.BaseCurrency != "" {
+		query = query.Where("base_currency = ?", common.NormalizeCurrency(req.BaseCurrency))
+	}
+	if req.QuoteCurrency != "" {
+		query = query.Where("quote_currency = ?", common.NormalizeCurrency(req.QuoteCurrency))
+	}
+
+	var rates []entityRate.ExchangeRate
+	if err := query.Order("base_currency, quote_currency, effective_from DESC").Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+
+	resp := &dtoexchangerates.GetExchangeRatesResponse{Rates: make([]dtoexchangerates.ExchangeRateResponse, 0, len(rates))}
+	for i := range rates {
+		resp.Rates = append(resp.Rates, *toExchangeRateResponse(&rates[i]))
+	}
+	return resp, nil
+}
+
+// UpsertRate ghi tỷ giá mới; nếu cặp tiền đã có tỷ giá cùng thời điểm hiệu lực thì cập nhật giá trị
+func (es *exchangeRateService) UpsertRate(ctx context.Context, req dtoexchangerates.UpsertExchangeRateRequest) (*dtoexchangerates.ExchangeRateResponse, error) {
+	adminUserID, err := uuid.Parse(req.AdminUserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid admin user ID format: %w", err)
+	}
+	base := common.NormalizeCurrency(req.BaseCurrency)
+	quote := common.NormalizeCurrency(req.QuoteCurrency)
+	if !common.IsSupportedCurrency(base) || !common.IsSupportedCurrency(quote) {
+		return nil, fmt.Errorf("unsupported currency pair %s/%s", base, quote)
+	}
+	if base == quote {
+		return nil, fmt.Errorf("base and quote currency must be different")
+	}
+	if req.Rate <= 0 {
+		return nil, fmt.Errorf("rate must be greater than 0")
+	}
+	effectiveFrom := time.Now()
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+
+	rate := entityRate.ExchangeRate{
+		BaseCurrency:    base,
+		QuoteCurrency:   quote,
+		Rate:            req.Rate,
+		EffectiveFrom:   effectiveFrom,
+		CreatedByUserID: &adminUserID,
+	}
+	if err := es.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}, {Name: "effective_from"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"rate": req.Rate, "created_by_user_id": adminUserID, "rate_updated_at": time.Now()}),
+	}).Create(&rate).Error; err != nil {
+		return nil, fmt.Errorf("failed to save exchange rate: %w", err)
+	}
+	// Lấy lại bản ghi để trả về đúng rate_id khi rơi vào nhánh cập nhật
+	if err := es.db.WithContext(ctx).
+		First(&rate, "base_currency = ? AND quote_currency = ? AND effective_from = ?", base, quote, effectiveFrom).Error; err != nil {
+		return nil, fmt.Errorf("failed to reload exchange rate: %w", err)
+	}
+
+	es.logger.Info("💱 Exchange rate saved",
+		zap.String("pair", base+"/"+quote),
+		zap.Float64("rate", rate.Rate),
+		zap.Time("effective_from", rate.EffectiveFrom))
+	return toExchangeRateResponse(&rate), nil
+}
+
+func (es *exchangeRateService) DeleteRate(ctx context.Context, req dtoexchangerates.DeleteExchangeRateRequest) error {
+	rateID, err := uuid.Parse(req.RateID)
+	if err != nil {
+		return fmt.Errorf("invalid rate ID format: %w", err)
+	}
+	result := es.db.WithContext(ctx).Delete(&entityRate.ExchangeRate{}, "rate_id = ?", rateID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete exchange rate: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("exchange rate not found")
+	}
+	return nil
+}
+
+// Convert dùng tỷ giá trực tiếp from→to mới nhất tại thời điểm at, không có thì dùng nghịch đảo của to→from.
+// Kết quả được làm tròn theo đơn vị nhỏ nhất của tiền tệ đích.
+func (es *exchangeRateService) Convert(ctx context.Context, amount float64, from, to string, at time.Time) (float64, error) {
+	from = common.NormalizeCurrency(from)
+	to = common.NormalizeCurrency(to)
+	if from == to || amount == 0 {
+		return amount, nil
+	}
+
+	rate, err := es.findRate(ctx, from, to, at)
+	if err != nil {
+		return 0, err
+	}
+	if rate == 0 {
+		inverse, err := es.findRate(ctx, to, from, at)
+		if err != nil {
+			return 0, err
+		}
+		if inverse == 0 {
+			return 0, fmt.Errorf("no exchange rate for %s/%s at %s", from, to, at.Format("02/01/2006"))
+		}
+		rate = 1 / inverse
+	}
+	return common.FromMinorUnits(common.ToMinorUnits(amount*rate, to), to), nil
+}
+
+// findRate trả về 0 khi cặp tiền chưa có tỷ giá hiệu lực tại thời điểm at
+func (es *exchangeRateService) findRate(ctx context.Context, base, quote string, at time.Time) (float64, error) {
+	var rate entityRate.ExchangeRate
+	err := es.db.WithContext(ctx).
+		Where("base_currency = ? AND quote_currency = ? AND effective_from <= ?", base, quote, at).
+		Order("effective_from DESC").
+		First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get exchange rate %s/%s: %w", base, quote, err)
+	}
+	return rate.Rate, nil
+}
+
+func toExchangeRateResponse(rate *entityRate.ExchangeRate) *dtoexchangerates.ExchangeRateResponse {
+	return &dtoexchangerates.ExchangeRateResponse{
+		RateID:        rate.RateID.String(),
+		BaseCurrency:  rate.BaseCurrency,
+		QuoteCurrency: rate.QuoteCurrency,
+		Rate:          rate.Rate,
+		EffectiveFrom: rate.EffectiveFrom,
+		CreatedAt:     rate.RateCreatedAt,
+	}
+}
@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"time"
+
+	"cbs_backend/internal/common"
+)
+
+const (
+	DefaultMinNoticeMinutes   = 15
+	DefaultMaxAdvanceDays     = 90
+	DefaultMinDurationMinutes = 15
+	DefaultMaxDurationMinutes = 240
+)
+
+// BookingRules là quy tắc đặt lịch expert tự cấu hình trên ExpertProfile
+type BookingRules struct {
+	BufferBefore       time.Duration
+	BufferAfter        time.Duration
+	MaxSessionsPerDay  int // 0 = không giới hạn
+	MinNotice          time.Duration
+	MaxAdvance         time.Duration
+	MinDurationMinutes int
+	MaxDurationMinutes int
+	Location           *time.Location // Múi giờ của expert: giờ làm việc và "ngày" (giới hạn số buổi/ngày) tính theo múi giờ này
+}
+
+// DefaultBookingRules là quy tắc mặc định của hệ thống (trước 15 phút, tối đa 90 ngày, 15-240 phút, không buffer)
+func DefaultBookingRules() BookingRules {
+	return BookingRules{
+		MinNotice:          DefaultMinNoticeMinutes * time.Minute,
+		MaxAdvance:         DefaultMaxAdvanceDays * 24 * time.Hour,
+		MinDurationMinutes: DefaultMinDurationMinutes,
+		MaxDurationMinutes: DefaultMaxDurationMinutes,
+		Location:           common.LocationOrDefault(common.DefaultTimeZone),
+	}
+}
+
+// DayStart trả về 00:00 theo múi giờ của expert của ngày chứa t
+func (r BookingRules) DayStart(t time.Time) time.Time {
+	t = t.In(r.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, r.Location)
+}
+
+// Padding là khoảng cách tối thiểu giữa hai buổi liền kề: buffer sau của buổi trước cộng buffer trước của buổi sau
+func (r BookingRules) Padding() time.Duration {
+	return r.BufferBefore + r.BufferAfter
+}
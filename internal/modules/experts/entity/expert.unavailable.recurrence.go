@@ -0,0 +1,228 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"cbs_backend/internal/common"
+)
+
+// Tần suất lặp của thời gian bận, tương ứng FREQ trong RRULE (RFC 5545)
+const (
+	RecurrenceDaily   = "daily"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+)
+
+const (
+	maxRecurrenceInterval = 52
+	maxRecurrenceCount    = 1000
+	// maxOccurrenceDuration giới hạn mỗi lần bận trong một ngày để các lần lặp không chồng lên nhau
+	maxOccurrenceDuration = 24 * time.Hour
+	recurrenceDateLayout  = "2006-01-02"
+)
+
+// RecurrenceRule là định dạng của RecurrencePattern, mô phỏng RRULE:
+//
+//	{"freq": "weekly", "interval": 1, "by_day": [5], "until": "2026-12-31T00:00:00Z", "exdates": ["2026-10-23"]}
+//
+// Lần bận đầu tiên là [UnavailableStartDatetime, UnavailableEndDatetime); các lần sau giữ nguyên giờ bắt đầu
+// và thời lượng theo múi giờ của expert. ByDay dùng common.Weekday (0 = Chủ nhật), chỉ áp dụng cho weekly;
+// Until và Count không dùng cùng lúc. ExDates là các ngày (theo múi giờ expert) bỏ qua, vd expert vẫn làm thứ Sáu tuần này.
+type RecurrenceRule struct {
+	Freq     string           `json:"freq"`
+	Interval int              `json:"interval,omitempty"`
+	ByDay    []common.Weekday `json:"by_day,omitempty"`
+	Until    *time.Time       `json:"until,omitempty"`
+	Count    int              `json:"count,omitempty"`
+	ExDates  []string         `json:"exdates,omitempty"`
+}
+
+// TimeInterval là một khoảng thời gian cụ thể [Start, End)
+type TimeInterval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ParseRecurrenceRule đọc RecurrencePattern đã lưu; pattern rỗng trả về nil
+func ParseRecurrenceRule(pattern common.JSONB) (*RecurrenceRule, error) {
+	if len(pattern) == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence pattern: %w", err)
+	}
+	var rule RecurrenceRule
+	if err := json.Unmarshal(raw, &rule); err != nil {
+		return nil, fmt.Errorf("invalid recurrence pattern: %w", err)
+	}
+	return &rule, nil
+}
+
+// Pattern chuyển rule về JSONB để lưu vào RecurrencePattern
+func (r RecurrenceRule) Pattern() (common.JSONB, error) {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	var pattern common.JSONB
+	if err := json.Unmarshal(raw, &pattern); err != nil {
+		return nil, err
+	}
+	return pattern, nil
+}
+
+// Validate kiểm tra rule với lần bận đầu tiên [start, end)
+func (r RecurrenceRule) Validate(start, end time.Time) error {
+	switch r.Freq {
+	case RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly:
+	default:
+		return fmt.Errorf("invalid recurrence freq %q: must be one of daily, weekly, monthly", r.Freq)
+	}
+	if r.Interval < 0 || r.Interval > maxRecurrenceInterval {
+		return fmt.Errorf("invalid recurrence interval: must be between 1-%d", maxRecurrenceInterval)
+	}
+	if len(r.ByDay) > 0 && r.Freq != RecurrenceWeekly {
+		return fmt.Errorf("by_day is only supported for weekly recurrence")
+	}
+	for _, day := range r.ByDay {
+		if err := common.ValidateWeekday(day); err != nil {
+			return err
+		}
+	}
+	if r.Count < 0 || r.Count > maxRecurrenceCount {
+		return fmt.Errorf("invalid recurrence count: must be between 1-%d", maxRecurrenceCount)
+	}
+	if r.Count > 0 && r.Until != nil {
+		return fmt.Errorf("recurrence count and until cannot be used together")
+	}
+	if r.Until != nil && r.Until.Before(start) {
+		return fmt.Errorf("recurrence until must be after the first occurrence")
+	}
+	for _, date := range r.ExDates {
+		if _, err := time.Parse(recurrenceDateLayout, date); err != nil {
+			return fmt.Errorf("invalid exdate %q: expecting YYYY-MM-DD", date)
+		}
+	}
+	if !end.After(start) {
+		return fmt.Errorf("end time must be after start time")
+	}
+	if end.Sub(start) > maxOccurrenceDuration {
+		return fmt.Errorf("recurring unavailable time cannot exceed 24 hours per occurrence")
+	}
+	return nil
+}
+
+// Skip thêm ngày (theo múi giờ expert) vào ExDates, bỏ qua nếu đã có
+func (r *RecurrenceRule) Skip(date time.Time) {
+	day := date.Format(recurrenceDateLayout)
+	for _, existing := range r.ExDates {
+		if existing == day {
+			return
+		}
+	}
+	r.ExDates = append(r.ExDates, day)
+	sort.Strings(r.ExDates)
+}
+
+// Occurrences trả về các khoảng bận chồng lên [from, to). Bản ghi không lặp trả về chính nó;
+// bản ghi lặp được trải ra theo lịch của loc (múi giờ expert) nên giữ đúng giờ địa phương qua ngày chuyển giờ.
+func (u *ExpertUnavailableTime) Occurrences(from, to time.Time, loc *time.Location) []TimeInterval {
+	base := TimeInterval{Start: u.UnavailableStartDatetime, End: u.UnavailableEndDatetime}
+	rule, err := ParseRecurrenceRule(u.RecurrencePattern)
+	if !u.IsRecurring || err != nil || rule == nil {
+		if base.Start.Before(to) && base.End.After(from) {
+			return []TimeInterval{base}
+		}
+		return nil
+	}
+	return rule.expand(base, from, to, loc)
+}
+
+func (r RecurrenceRule) expand(base TimeInterval, from, to time.Time, loc *time.Location) []TimeInterval {
+	interval := r.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	byDay := r.ByDay
+	first := base.Start.In(loc)
+	if r.Freq == RecurrenceWeekly && len(byDay) == 0 {
+		byDay = []common.Weekday{common.WeekdayOf(first)}
+	}
+	skipped := make(map[string]bool, len(r.ExDates))
+	for _, date := range r.ExDates {
+		skipped[date] = true
+	}
+	duration := base.End.Sub(base.Start)
+
+	// Count tính từ lần đầu tiên nên phải duyệt từ đầu; không có Count thì bắt đầu ngay trước from cho nhanh
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+	if r.Count == 0 {
+		if earliest := from.Add(-duration).In(loc); earliest.After(day) {
+			day = time.Date(earliest.Year(), earliest.Month(), earliest.Day(), 0, 0, 0, 0, loc)
+		}
+	}
+
+	var result []TimeInterval
+	matched := 0
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !r.matches(first, day, interval, byDay) {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), first.Hour(), first.Minute(), first.Second(), 0, loc)
+		if start.Before(base.Start) {
+			continue
+		}
+		if r.Until != nil && start.After(*r.Until) {
+			break
+		}
+		matched++
+		if r.Count > 0 && matched > r.Count {
+			break
+		}
+		if skipped[day.Format(recurrenceDateLayout)] {
+			continue
+		}
+		end := start.Add(duration)
+		if start.Before(to) && end.After(from) {
+			result = append(result, TimeInterval{Start: start, End: end})
+		}
+	}
+	return result
+}
+
+// matches kiểm tra ngày day (00:00 theo múi giờ expert) có một lần lặp của rule hay không
+func (r RecurrenceRule) matches(first, day time.Time, interval int, byDay []common.Weekday) bool {
+	days := civilDaysBetween(first, day)
+	switch r.Freq {
+	case RecurrenceDaily:
+		return days%interval == 0
+	case RecurrenceWeekly:
+		// Tuần tính từ Chủ nhật của tuần chứa lần đầu tiên
+		weeks := (days + int(common.WeekdayOf(first))) / 7
+		if weeks%interval != 0 {
+			return false
+		}
+		weekday := common.WeekdayOf(day)
+		for _, d := range byDay {
+			if d == weekday {
+				return true
+			}
+		}
+		return false
+	case RecurrenceMonthly:
+		months := (day.Year()-first.Year())*12 + int(day.Month()) - int(first.Month())
+		return months%interval == 0 && day.Day() == first.Day()
+	}
+	return false
+}
+
+// civilDaysBetween đếm số ngày lịch từ a đến b, không bị lệch bởi ngày dài 23h/25h
+func civilDaysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
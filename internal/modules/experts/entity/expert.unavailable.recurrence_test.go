@@ -0,0 +1,238 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"cbs_backend/internal/common"
+)
+
+const occurrenceLayout = "2006-01-02 15:04"
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	return loc
+}
+
+func localTime(t *testing.T, loc *time.Location, value string) time.Time {
+	t.Helper()
+	parsed, err := time.ParseInLocation(occurrenceLayout, value, loc)
+	if err != nil {
+		t.Fatalf("ParseInLocation(%q): %v", value, err)
+	}
+	return parsed
+}
+
+func TestRecurrenceRuleValidate(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	before := start.Add(-time.Hour)
+	after := start.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name    string
+		rule    RecurrenceRule
+		end     time.Time
+		wantErr string
+	}{
+		{"daily", RecurrenceRule{Freq: RecurrenceDaily}, end, ""},
+		{"weekly by day with until", RecurrenceRule{Freq: RecurrenceWeekly, Interval: 2, ByDay: []common.Weekday{common.DayMonday, common.DayFriday}, Until: &after}, end, ""},
+		{"monthly with count and exdates", RecurrenceRule{Freq: RecurrenceMonthly, Count: 12, ExDates: []string{"2026-03-05"}}, end, ""},
+		{"unknown freq", RecurrenceRule{Freq: "yearly"}, end, "invalid recurrence freq"},
+		{"interval too large", RecurrenceRule{Freq: RecurrenceDaily, Interval: maxRecurrenceInterval + 1}, end, "invalid recurrence interval"},
+		{"negative interval", RecurrenceRule{Freq: RecurrenceDaily, Interval: -1}, end, "invalid recurrence interval"},
+		{"by day on daily", RecurrenceRule{Freq: RecurrenceDaily, ByDay: []common.Weekday{common.DayMonday}}, end, "by_day is only supported"},
+		{"by day out of range", RecurrenceRule{Freq: RecurrenceWeekly, ByDay: []common.Weekday{7}}, end, "invalid day_of_week"},
+		{"count too large", RecurrenceRule{Freq: RecurrenceDaily, Count: maxRecurrenceCount + 1}, end, "invalid recurrence count"},
+		{"count and until", RecurrenceRule{Freq: RecurrenceDaily, Count: 3, Until: &after}, end, "cannot be used together"},
+		{"until before first occurrence", RecurrenceRule{Freq: RecurrenceDaily, Until: &before}, end, "until must be after"},
+		{"malformed exdate", RecurrenceRule{Freq: RecurrenceDaily, ExDates: []string{"05/01/2026"}}, end, "invalid exdate"},
+		{"end before start", RecurrenceRule{Freq: RecurrenceDaily}, before, "end time must be after start time"},
+		{"occurrence longer than a day", RecurrenceRule{Freq: RecurrenceDaily}, start.Add(25 * time.Hour), "cannot exceed 24 hours"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate(start, tt.end)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleExpand(t *testing.T) {
+	hcm := mustLoadLocation(t, "Asia/Ho_Chi_Minh")
+	newYork := mustLoadLocation(t, "America/New_York")
+	until := localTime(t, hcm, "2026-01-07 10:00")
+
+	tests := []struct {
+		name     string
+		loc      *time.Location
+		rule     RecurrenceRule
+		first    string // Lần bận đầu tiên, kéo dài 1 giờ
+		from, to string
+		want     []string
+	}{
+		{
+			name:  "daily every other day",
+			loc:   hcm,
+			rule:  RecurrenceRule{Freq: RecurrenceDaily, Interval: 2},
+			first: "2026-01-05 10:00", from: "2026-01-05 00:00", to: "2026-01-11 00:00",
+			want: []string{"2026-01-05 10:00", "2026-01-07 10:00", "2026-01-09 10:00"},
+		},
+		{
+			name:  "weekly by day every two weeks",
+			loc:   hcm,
+			rule:  RecurrenceRule{Freq: RecurrenceWeekly, Interval: 2, ByDay: []common.Weekday{common.DayMonday, common.DayWednesday}},
+			first: "2026-01-05 10:00", from: "2026-01-01 00:00", to: "2026-02-01 00:00",
+			want: []string{"2026-01-05 10:00", "2026-01-07 10:00", "2026-01-19 10:00", "2026-01-21 10:00"},
+		},
+		{
+			name:  "weekly without by day repeats the first weekday",
+			loc:   hcm,
+			rule:  RecurrenceRule{Freq: RecurrenceWeekly},
+			first: "2026-01-09 14:00", from: "2026-01-01 00:00", to: "2026-01-24 00:00",
+			want: []string{"2026-01-09 14:00", "2026-01-16 14:00", "2026-01-23 14:00"},
+		},
+		{
+			name:  "until is inclusive",
+			loc:   hcm,
+			rule:  RecurrenceRule{Freq: RecurrenceDaily, Until: &until},
+			first: "2026-01-05 10:00", from: "2026-01-01 00:00", to: "2026-02-01 00:00",
+			want: []string{"2026-01-05 10:00", "2026-01-06 10:00", "2026-01-07 10:00"},
+		},
+		{
+			name:  "count stops after n occurrences",
+			loc:   hcm,
+			rule:  RecurrenceRule{Freq: RecurrenceDaily, Count: 3},
+			first: "2026-01-05 10:00", from: "2026-01-01 00:00", to: "2026-02-01 00:00",
+			want: []string{"2026-01-05 10:00", "2026-01-06 10:00", "2026-01-07 10:00"},
+		},
+		{
+			name:  "exdate still counts toward count",
+			loc:   hcm,
+			rule:  RecurrenceRule{Freq: RecurrenceDaily, Count: 3, ExDates: []string{"2026-01-06"}},
+			first: "2026-01-05 10:00", from: "2026-01-01 00:00", to: "2026-02-01 00:00",
+			want: []string{"2026-01-05 10:00", "2026-01-07 10:00"},
+		},
+		{
+			name:  "count is measured from the first occurrence, not the window",
+			loc:   hcm,
+			rule:  RecurrenceRule{Freq: RecurrenceDaily, Count: 5},
+			first: "2026-01-05 10:00", from: "2026-01-08 00:00", to: "2026-01-20 00:00",
+			want: []string{"2026-01-08 10:00", "2026-01-09 10:00"},
+		},
+		{
+			name:  "monthly skips months without the day",
+			loc:   hcm,
+			rule:  RecurrenceRule{Freq: RecurrenceMonthly},
+			first: "2026-01-31 09:00", from: "2026-01-01 00:00", to: "2026-07-01 00:00",
+			want: []string{"2026-01-31 09:00", "2026-03-31 09:00", "2026-05-31 09:00"},
+		},
+		{
+			name:  "weekly keeps local time across spring forward",
+			loc:   newYork,
+			rule:  RecurrenceRule{Freq: RecurrenceWeekly},
+			first: "2026-03-01 09:00", from: "2026-03-01 00:00", to: "2026-03-16 00:00",
+			want: []string{"2026-03-01 09:00", "2026-03-08 09:00", "2026-03-15 09:00"},
+		},
+		{
+			name:  "daily keeps local time across fall back",
+			loc:   newYork,
+			rule:  RecurrenceRule{Freq: RecurrenceDaily},
+			first: "2026-10-31 09:00", from: "2026-10-31 00:00", to: "2026-11-03 00:00",
+			want: []string{"2026-10-31 09:00", "2026-11-01 09:00", "2026-11-02 09:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := tt.rule.Pattern()
+			if err != nil {
+				t.Fatalf("Pattern(): %v", err)
+			}
+			first := localTime(t, tt.loc, tt.first)
+			if err := tt.rule.Validate(first, first.Add(time.Hour)); err != nil {
+				t.Fatalf("Validate(): %v", err)
+			}
+			unavailable := ExpertUnavailableTime{
+				UnavailableStartDatetime: first,
+				UnavailableEndDatetime:   first.Add(time.Hour),
+				IsRecurring:              true,
+				RecurrencePattern:        pattern,
+			}
+
+			got := unavailable.Occurrences(localTime(t, tt.loc, tt.from), localTime(t, tt.loc, tt.to), tt.loc)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Occurrences() returned %d intervals %v, want %v", len(got), got, tt.want)
+			}
+			for i, occurrence := range got {
+				if start := occurrence.Start.In(tt.loc).Format(occurrenceLayout); start != tt.want[i] {
+					t.Errorf("occurrence %d starts at %s, want %s", i, start, tt.want[i])
+				}
+				if d := occurrence.End.Sub(occurrence.Start); d != time.Hour {
+					t.Errorf("occurrence %d lasts %v, want 1h", i, d)
+				}
+			}
+		})
+	}
+}
+
+func TestExpertUnavailableTimeOccurrencesNonRecurring(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	unavailable := ExpertUnavailableTime{UnavailableStartDatetime: start, UnavailableEndDatetime: start.Add(2 * time.Hour)}
+
+	if got := unavailable.Occurrences(start.Add(time.Hour), start.Add(5*time.Hour), time.UTC); len(got) != 1 || !got[0].Start.Equal(start) {
+		t.Errorf("overlapping window: got %v, want the record itself", got)
+	}
+	if got := unavailable.Occurrences(start.Add(2*time.Hour), start.Add(5*time.Hour), time.UTC); len(got) != 0 {
+		t.Errorf("window starting at the end: got %v, want none", got)
+	}
+}
+
+func TestRecurrenceRuleMatches(t *testing.T) {
+	first := time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC) // Thứ Tư
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	weekdays := []common.Weekday{common.DayMonday, common.DayWednesday}
+
+	tests := []struct {
+		name     string
+		rule     RecurrenceRule
+		interval int
+		day      time.Time
+		want     bool
+	}{
+		{"daily on the first day", RecurrenceRule{Freq: RecurrenceDaily}, 3, first, true},
+		{"daily off interval", RecurrenceRule{Freq: RecurrenceDaily}, 3, day(2026, 1, 9), false},
+		{"daily on interval", RecurrenceRule{Freq: RecurrenceDaily}, 3, day(2026, 1, 10), true},
+		{"weekly same week, earlier listed weekday", RecurrenceRule{Freq: RecurrenceWeekly}, 2, day(2026, 1, 5), true},
+		{"weekly skipped week", RecurrenceRule{Freq: RecurrenceWeekly}, 2, day(2026, 1, 12), false},
+		{"weekly two weeks later", RecurrenceRule{Freq: RecurrenceWeekly}, 2, day(2026, 1, 19), true},
+		{"weekly unlisted weekday", RecurrenceRule{Freq: RecurrenceWeekly}, 2, day(2026, 1, 20), false},
+		{"monthly same day", RecurrenceRule{Freq: RecurrenceMonthly}, 2, day(2026, 3, 7), true},
+		{"monthly off interval", RecurrenceRule{Freq: RecurrenceMonthly}, 2, day(2026, 2, 7), false},
+		{"monthly different day", RecurrenceRule{Freq: RecurrenceMonthly}, 2, day(2026, 3, 8), false},
+		{"unknown freq", RecurrenceRule{Freq: "yearly"}, 1, first, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.matches(first, tt.day, tt.interval, weekdays); got != tt.want {
+				t.Errorf("matches(%s) = %v, want %v", tt.day.Format(recurrenceDateLayout), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpertWorkingHourOverride represents tbl_expert_working_hour_overrides table
+// Giờ làm việc riêng của một ngày, ưu tiên hơn lịch tuần ExpertWorkingHour: ngày có override thì bỏ qua lịch tuần.
+// Một ngày có thể có nhiều dòng (làm ca gãy); dòng IsDayOff = true (không có giờ) nghĩa là nghỉ cả ngày đó.
+type ExpertWorkingHourOverride struct {
+	OverrideID        uuid.UUID `json:"override_id" db:"override_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ExpertProfileID   uuid.UUID `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;not null;index:idx_working_hour_override_expert_date;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	OverrideDate      time.Time `json:"override_date" db:"override_date" gorm:"type:date;not null;index:idx_working_hour_override_expert_date"` // Ngày theo múi giờ của expert
+	StartTime         *string   `json:"start_time,omitempty" db:"start_time" gorm:"type:time"`
+	EndTime           *string   `json:"end_time,omitempty" db:"end_time" gorm:"type:time"`
+	IsDayOff          bool      `json:"is_day_off" db:"is_day_off" gorm:"default:false"`
+	Note              *string   `json:"note,omitempty" db:"note" gorm:"type:text"`
+	OverrideCreatedAt time.Time `json:"override_created_at" db:"override_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (ExpertWorkingHourOverride) TableName() string {
+	return "tbl_expert_working_hour_overrides"
+}
@@ -3,6 +3,7 @@ package entity
 import (
 	"time"
 
+	"cbs_backend/internal/common"
 	entityUser "cbs_backend/internal/modules/users/entity"
 
 	"github.com/google/uuid"
@@ -11,20 +12,29 @@ import (
 
 // ExpertProfile represents tbl_expert_profiles table
 type ExpertProfile struct {
-	ExpertProfileID    uuid.UUID      `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	UserID             uuid.UUID      `json:"user_id" db:"user_id" gorm:"type:uuid;not null;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	SpecializationList pq.StringArray `json:"specialization_list" db:"specialization_list" gorm:"type:text[]"`
-	ExperienceYears    *int           `json:"experience_years,omitempty" db:"experience_years"`
-	ExpertBio          *string        `json:"expert_bio,omitempty" db:"expert_bio" gorm:"type:text"`
-	ConsultationFee    *float64       `json:"consultation_fee,omitempty" db:"consultation_fee" gorm:"type:decimal(10,2)"`
-	AverageRating      float64        `json:"average_rating" db:"average_rating" gorm:"type:decimal(3,2);default:0.00"`
-	TotalReviews       int            `json:"total_reviews" db:"total_reviews" gorm:"default:0"`
-	IsVerified         bool           `json:"is_verified" db:"is_verified" gorm:"default:false"`
-	LicenseNumber      *string        `json:"license_number,omitempty" db:"license_number" gorm:"type:varchar(100)"`
-	AvailableOnline    bool           `json:"available_online" db:"available_online" gorm:"default:true"`
-	AvailableOffline   bool           `json:"available_offline" db:"available_offline" gorm:"default:true"`
-	ExpertCreatedAt    time.Time      `json:"expert_created_at" db:"expert_created_at" gorm:"default:CURRENT_TIMESTAMP"`
-	ExpertUpdatedAt    time.Time      `json:"expert_updated_at" db:"expert_updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+	ExpertProfileID      uuid.UUID      `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID               uuid.UUID      `json:"user_id" db:"user_id" gorm:"type:uuid;not null;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	SpecializationList   pq.StringArray `json:"specialization_list" db:"specialization_list" gorm:"type:text[]"`
+	ExperienceYears      *int           `json:"experience_years,omitempty" db:"experience_years"`
+	ExpertBio            *string        `json:"expert_bio,omitempty" db:"expert_bio" gorm:"type:text"`
+	ConsultationFeeMinor *int64         `json:"consultation_fee_minor,omitempty" db:"consultation_fee_minor" gorm:"type:bigint"` // Đơn vị nhỏ nhất của Currency
+	Currency             string         `json:"currency" db:"currency" gorm:"type:varchar(3);not null;default:'VND'"`
+	AverageRating        float64        `json:"average_rating" db:"average_rating" gorm:"type:decimal(3,2);default:0.00"`
+	TotalReviews         int            `json:"total_reviews" db:"total_reviews" gorm:"default:0"`
+	IsVerified           bool           `json:"is_verified" db:"is_verified" gorm:"default:false"`
+	LicenseNumber        *string        `json:"license_number,omitempty" db:"license_number" gorm:"type:varchar(100)"`
+	AvailableOnline      bool           `json:"available_online" db:"available_online" gorm:"default:true"`
+	AvailableOffline     bool           `json:"available_offline" db:"available_offline" gorm:"default:true"`
+	BufferBeforeMinutes  int            `json:"buffer_before_minutes" db:"buffer_before_minutes" gorm:"not null;default:0"`           // Thời gian trống trước mỗi buổi
+	BufferAfterMinutes   int            `json:"buffer_after_minutes" db:"buffer_after_minutes" gorm:"not null;default:0"`             // Thời gian trống sau mỗi buổi
+	MaxSessionsPerDay    *int           `json:"max_sessions_per_day,omitempty" db:"max_sessions_per_day"`                             // nil = không giới hạn
+	MinNoticeMinutes     int            `json:"min_notice_minutes" db:"min_notice_minutes" gorm:"not null;default:15"`                // Phải đặt trước ít nhất bao nhiêu phút
+	MaxAdvanceDays       int            `json:"max_advance_days" db:"max_advance_days" gorm:"not null;default:90"`                    // Được đặt trước tối đa bao nhiêu ngày
+	MinDurationMinutes   int            `json:"min_duration_minutes" db:"min_duration_minutes" gorm:"not null;default:15"`            // Thời lượng buổi tối thiểu
+	MaxDurationMinutes   int            `json:"max_duration_minutes" db:"max_duration_minutes" gorm:"not null;default:240"`           // Thời lượng buổi tối đa
+	TimeZone             string         `json:"time_zone" db:"time_zone" gorm:"type:varchar(64);not null;default:'Asia/Ho_Chi_Minh'"` // Múi giờ IANA của giờ làm việc
+	ExpertCreatedAt      time.Time      `json:"expert_created_at" db:"expert_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	ExpertUpdatedAt      time.Time      `json:"expert_updated_at" db:"expert_updated_at" gorm:"default:CURRENT_TIMESTAMP"`
 
 	// 👇 Relationships: nên để *pointer và chỉ dùng nếu thực sự cần preload
 	User *entityUser.User `json:"user" gorm:"foreignKey:UserID;references:UserID"`
@@ -42,3 +52,40 @@ type ExpertProfile struct {
 func (ExpertProfile) TableName() string {
 	return "tbl_expert_profiles"
 }
+
+// ConsultationFee trả về phí tư vấn mặc định dạng thập phân theo Currency
+func (e *ExpertProfile) ConsultationFee() *float64 {
+	if e.ConsultationFeeMinor == nil {
+		return nil
+	}
+	fee := common.FromMinorUnits(*e.ConsultationFeeMinor, e.Currency)
+	return &fee
+}
+
+// Location trả về múi giờ của expert; giờ làm việc, lịch nghỉ theo ngày và giới hạn số buổi/ngày đều tính theo múi giờ này
+func (e *ExpertProfile) Location() *time.Location {
+	return common.LocationOrDefault(e.TimeZone)
+}
+
+// BookingRules trả về quy tắc đặt lịch của expert, dùng chung khi sinh slot và khi giữ slot.
+// Giá trị chưa cấu hình (<= 0) của horizon/thời lượng dùng mặc định của hệ thống.
+func (e *ExpertProfile) BookingRules() BookingRules {
+	rules := DefaultBookingRules()
+	rules.Location = e.Location()
+	rules.BufferBefore = time.Duration(e.BufferBeforeMinutes) * time.Minute
+	rules.BufferAfter = time.Duration(e.BufferAfterMinutes) * time.Minute
+	rules.MinNotice = time.Duration(e.MinNoticeMinutes) * time.Minute
+	if e.MaxAdvanceDays > 0 {
+		rules.MaxAdvance = time.Duration(e.MaxAdvanceDays) * 24 * time.Hour
+	}
+	if e.MinDurationMinutes > 0 {
+		rules.MinDurationMinutes = e.MinDurationMinutes
+	}
+	if e.MaxDurationMinutes > 0 {
+		rules.MaxDurationMinutes = e.MaxDurationMinutes
+	}
+	if e.MaxSessionsPerDay != nil {
+		rules.MaxSessionsPerDay = *e.MaxSessionsPerDay
+	}
+	return rules
+}
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Nguồn của ngày nghỉ toàn hệ thống
+const (
+	HolidaySourceImport  = "import"  // Nhập từ file iCalendar (vd lịch nghỉ lễ Việt Nam)
+	HolidaySourceClosure = "closure" // Ngày công ty nghỉ do admin thêm tay
+)
+
+const (
+	// ClosureCalendarName là calendar_name chung của các ngày công ty nghỉ
+	ClosureCalendarName = "company_closure"
+	HolidayDateLayout   = "2006-01-02"
+)
+
+// PlatformHoliday represents tbl_platform_holidays table
+// Mỗi bản ghi là một ngày nghỉ chặn lịch của mọi expert theo ngày lịch ở múi giờ của từng expert;
+// ngày lễ kéo dài nhiều ngày được lưu thành nhiều bản ghi. Expert muốn làm việc ngày đó thì opt-in qua ExpertHolidayOptIn.
+type PlatformHoliday struct {
+	HolidayID        uuid.UUID `json:"holiday_id" db:"holiday_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	HolidayDate      time.Time `json:"holiday_date" db:"holiday_date" gorm:"type:date;not null;uniqueIndex:idx_platform_holiday_calendar_date"`
+	CalendarName     string    `json:"calendar_name" db:"calendar_name" gorm:"type:varchar(100);not null;uniqueIndex:idx_platform_holiday_calendar_date"`
+	HolidayName      string    `json:"holiday_name" db:"holiday_name" gorm:"type:varchar(255);not null"`
+	Source           string    `json:"source" db:"source" gorm:"type:varchar(20);not null;check:source IN ('import', 'closure')"`
+	SourceUID        *string   `json:"source_uid,omitempty" db:"source_uid" gorm:"type:varchar(255)"` // UID của VEVENT khi nhập từ file
+	HolidayCreatedAt time.Time `json:"holiday_created_at" db:"holiday_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (PlatformHoliday) TableName() string {
+	return "tbl_platform_holidays"
+}
+
+// HolidayDateOf chuẩn hoá ngày lịch về 00:00 UTC, dạng lưu trong cột date
+func HolidayDateOf(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Date trả về ngày nghỉ dạng YYYY-MM-DD
+func (h *PlatformHoliday) Date() string {
+	return h.HolidayDate.Format(HolidayDateLayout)
+}
+
+// DayIn trả về cả ngày nghỉ [00:00, 00:00 hôm sau) theo múi giờ loc của expert
+func (h *PlatformHoliday) DayIn(loc *time.Location) TimeInterval {
+	start := time.Date(h.HolidayDate.Year(), h.HolidayDate.Month(), h.HolidayDate.Day(), 0, 0, 0, 0, loc)
+	return TimeInterval{Start: start, End: start.AddDate(0, 0, 1)}
+}
+
+// ExpertHolidayOptIn represents tbl_expert_holiday_opt_ins table
+// Expert vẫn nhận lịch vào ngày nghỉ toàn hệ thống tương ứng
+type ExpertHolidayOptIn struct {
+	ExpertProfileID uuid.UUID `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;primaryKey;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	HolidayID       uuid.UUID `json:"holiday_id" db:"holiday_id" gorm:"type:uuid;primaryKey;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	OptedInAt       time.Time `json:"opted_in_at" db:"opted_in_at" gorm:"default:CURRENT_TIMESTAMP"`
+
+	Holiday PlatformHoliday `json:"holiday" gorm:"foreignKey:HolidayID;references:HolidayID"`
+}
+
+func (ExpertHolidayOptIn) TableName() string {
+	return "tbl_expert_holiday_opt_ins"
+}
@@ -3,6 +3,7 @@ package entity
 import (
 	"time"
 
+	"cbs_backend/internal/common"
 	"github.com/google/uuid"
 )
 
@@ -13,7 +14,8 @@ type PricingConfig struct {
 	ServiceType        string     `json:"service_type" db:"service_type" gorm:"type:varchar(50);not null"`
 	ConsultationType   string     `json:"consultation_type" db:"consultation_type" gorm:"type:varchar(20);not null;check:consultation_type IN ('online', 'offline')"`
 	DurationMinutes    int        `json:"duration_minutes" db:"duration_minutes" gorm:"not null"`
-	BasePrice          float64    `json:"base_price" db:"base_price" gorm:"type:decimal(10,2);not null"`
+	BasePriceMinor     int64      `json:"base_price_minor" db:"base_price_minor" gorm:"type:bigint;not null;default:0"` // Đơn vị nhỏ nhất của Currency
+	Currency           string     `json:"currency" db:"currency" gorm:"type:varchar(3);not null;default:'VND'"`
 	DiscountPercentage float64    `json:"discount_percentage" db:"discount_percentage" gorm:"type:decimal(5,2);default:0"`
 	IsActive           bool       `json:"is_active" db:"is_active" gorm:"default:true"`
 	ValidFrom          time.Time  `json:"valid_from" db:"valid_from" gorm:"default:CURRENT_TIMESTAMP"`
@@ -27,3 +29,8 @@ type PricingConfig struct {
 func (PricingConfig) TableName() string {
 	return "tbl_pricing_configs"
 }
+
+// BasePrice trả về giá gốc dạng thập phân theo Currency
+func (p *PricingConfig) BasePrice() float64 {
+	return common.FromMinorUnits(p.BasePriceMinor, p.Currency)
+}
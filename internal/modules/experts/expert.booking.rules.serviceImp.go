@@ -0,0 +1,157 @@
+package experts
+
+import (
+	entityexpert "cbs_backend/internal/modules/experts/entity"
+	dtoexperts "cbs_backend/internal/modules/experts/expertsdto"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxBufferMinutes    = 120
+	maxNoticeMinutes    = 14 * 24 * 60
+	maxAdvanceDaysLimit = 365
+	minSessionMinutes   = 15
+	maxSessionMinutes   = 240
+)
+
+func (es *expertService) GetBookingRules(ctx context.Context, expertID string) (*dtoexperts.BookingRulesResponse, error) {
+	expert, err := es.loadExpertProfile(ctx, expertID)
+	if err != nil {
+		return nil, err
+	}
+	return toBookingRulesResponse(expert), nil
+}
+
+// UpdateBookingRules cập nhật buffer, giới hạn số buổi/ngày, thời gian báo trước và khoảng đặt trước của expert.
+// Quy tắc được áp dụng ngay cho GetAvailableSlots và các booking mới; booking đã đặt không bị ảnh hưởng.
+func (es *expertService) UpdateBookingRules(ctx context.Context, req dtoexperts.UpdateBookingRulesRequest) (*dtoexperts.BookingRulesResponse, error) {
+	expert, err := es.loadOwnedExpertProfile(ctx, req.ExpertProfileID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.BufferBeforeMinutes != nil {
+		expert.BufferBeforeMinutes = *req.BufferBeforeMinutes
+	}
+	if req.BufferAfterMinutes != nil {
+		expert.BufferAfterMinutes = *req.BufferAfterMinutes
+	}
+	if req.MaxSessionsPerDay != nil {
+		if *req.MaxSessionsPerDay == 0 {
+			expert.MaxSessionsPerDay = nil
+		} else {
+			expert.MaxSessionsPerDay = req.MaxSessionsPerDay
+		}
+	}
+	if req.MinNoticeMinutes != nil {
+		expert.MinNoticeMinutes = *req.MinNoticeMinutes
+	}
+	if req.MaxAdvanceDays != nil {
+		expert.MaxAdvanceDays = *req.MaxAdvanceDays
+	}
+	if req.MinDurationMinutes != nil {
+		expert.MinDurationMinutes = *req.MinDurationMinutes
+	}
+	if req.MaxDurationMinutes != nil {
+		expert.MaxDurationMinutes = *req.MaxDurationMinutes
+	}
+	if err := validateBookingRules(expert); err != nil {
+		return nil, err
+	}
+
+	expert.ExpertUpdatedAt = time.Now()
+	if err := es.db.WithContext(ctx).Model(&entityexpert.ExpertProfile{}).
+		Where("expert_profile_id = ?", expert.ExpertProfileID).
+		Updates(map[string]interface{}{
+			"buffer_before_minutes": expert.BufferBeforeMinutes,
+			"buffer_after_minutes":  expert.BufferAfterMinutes,
+			"max_sessions_per_day":  expert.MaxSessionsPerDay,
+			"min_notice_minutes":    expert.MinNoticeMinutes,
+			"max_advance_days":      expert.MaxAdvanceDays,
+			"min_duration_minutes":  expert.MinDurationMinutes,
+			"max_duration_minutes":  expert.MaxDurationMinutes,
+			"expert_updated_at":     expert.ExpertUpdatedAt,
+		}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update booking rules: %w", err)
+	}
+
+	_ = es.cache.DeleteExpertDetail(ctx, expert.ExpertProfileID.String())
+	publishSlotsChanged(expert.ExpertProfileID, nil, nil, "booking_rules_updated")
+	return toBookingRulesResponse(expert), nil
+}
+
+func validateBookingRules(expert *entityexpert.ExpertProfile) error {
+	if expert.BufferBeforeMinutes < 0 || expert.BufferBeforeMinutes > maxBufferMinutes ||
+		expert.BufferAfterMinutes < 0 || expert.BufferAfterMinutes > maxBufferMinutes {
+		return fmt.Errorf("buffers must be between 0-%d minutes", maxBufferMinutes)
+	}
+	if expert.MaxSessionsPerDay != nil && *expert.MaxSessionsPerDay < 0 {
+		return fmt.Errorf("max_sessions_per_day cannot be negative")
+	}
+	if expert.MinNoticeMinutes < 0 || expert.MinNoticeMinutes > maxNoticeMinutes {
+		return fmt.Errorf("min_notice_minutes must be between 0-%d", maxNoticeMinutes)
+	}
+	if expert.MaxAdvanceDays < 1 || expert.MaxAdvanceDays > maxAdvanceDaysLimit {
+		return fmt.Errorf("max_advance_days must be between 1-%d", maxAdvanceDaysLimit)
+	}
+	if time.Duration(expert.MinNoticeMinutes)*time.Minute >= time.Duration(expert.MaxAdvanceDays)*24*time.Hour {
+		return fmt.Errorf("min_notice_minutes must be shorter than max_advance_days")
+	}
+	if expert.MinDurationMinutes < minSessionMinutes || expert.MaxDurationMinutes > maxSessionMinutes {
+		return fmt.Errorf("session duration must be between %d-%d minutes", minSessionMinutes, maxSessionMinutes)
+	}
+	if expert.MinDurationMinutes > expert.MaxDurationMinutes {
+		return fmt.Errorf("min_duration_minutes cannot be greater than max_duration_minutes")
+	}
+	return nil
+}
+
+// ErrNotProfileOwner được trả về khi user đăng nhập sửa hồ sơ expert không phải của mình
+var ErrNotProfileOwner = errors.New("expert profile does not belong to the current user")
+
+// loadOwnedExpertProfile nạp hồ sơ expert và chỉ cho phép chính user sở hữu hồ sơ thao tác ghi
+func (es *expertService) loadOwnedExpertProfile(ctx context.Context, expertID, userID string) (*entityexpert.ExpertProfile, error) {
+	expert, err := es.loadExpertProfile(ctx, expertID)
+	if err != nil {
+		return nil, err
+	}
+	if expert.UserID.String() != userID {
+		return nil, ErrNotProfileOwner
+	}
+	return expert, nil
+}
+
+func (es *expertService) loadExpertProfile(ctx context.Context, expertID string) (*entityexpert.ExpertProfile, error) {
+	expertUUID, err := uuid.Parse(expertID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expert profile ID format: %w", err)
+	}
+	var expert entityexpert.ExpertProfile
+	if err := es.db.WithContext(ctx).First(&expert, "expert_profile_id = ?", expertUUID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("expert profile not found")
+		}
+		return nil, fmt.Errorf("failed to load expert profile: %w", err)
+	}
+	return &expert, nil
+}
+
+func toBookingRulesResponse(expert *entityexpert.ExpertProfile) *dtoexperts.BookingRulesResponse {
+	return &dtoexperts.BookingRulesResponse{
+		ExpertProfileID:     expert.ExpertProfileID.String(),
+		BufferBeforeMinutes: expert.BufferBeforeMinutes,
+		BufferAfterMinutes:  expert.BufferAfterMinutes,
+		MaxSessionsPerDay:   expert.MaxSessionsPerDay,
+		MinNoticeMinutes:    expert.MinNoticeMinutes,
+		MaxAdvanceDays:      expert.MaxAdvanceDays,
+		MinDurationMinutes:  expert.MinDurationMinutes,
+		MaxDurationMinutes:  expert.MaxDurationMinutes,
+		ExpertUpdatedAt:     expert.ExpertUpdatedAt,
+	}
+}
@@ -3,9 +3,12 @@ package experts
 import (
 	dtoexperts "cbs_backend/internal/modules/experts/expertsdto"
 	"cbs_backend/pkg/response"
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type ExpertController struct{}
@@ -14,6 +17,27 @@ func NewExpertController() *ExpertController {
 	return &ExpertController{}
 }
 
+// getUserIDFromContext lấy user đăng nhập do AuthMiddleware gắn vào context
+func getUserIDFromContext(c *gin.Context) (string, error) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		return "", response.NewAPIError(http.StatusUnauthorized, "Unauthorized", "UserID not found in context")
+	}
+	userID, ok := userIDValue.(uuid.UUID)
+	if !ok {
+		return "", response.NewAPIError(http.StatusInternalServerError, "Internal error", "Invalid userID type")
+	}
+	return userID.String(), nil
+}
+
+// ownerAPIError trả 403 khi user sửa hồ sơ expert không phải của mình, các lỗi khác là 400
+func ownerAPIError(message string, err error) error {
+	if errors.Is(err, ErrNotProfileOwner) {
+		return response.NewAPIError(http.StatusForbidden, "Forbidden", err.Error())
+	}
+	return response.NewAPIError(http.StatusBadRequest, message, err.Error())
+}
+
 func (ec *ExpertController) GetAllExpert(ctx *gin.Context) (res interface{}, err error) {
 	req, err := Expert().GetAllsExpert(ctx)
 	if err != nil {
@@ -95,6 +119,26 @@ func (ec *ExpertController) UpdateWorkHour(ctx *gin.Context) (res interface{}, e
 	return resUpdateWorkHour, nil
 }
 
+// ReplaceWeeklySchedule thay cả lịch tuần; lỗi kiểm tra trả về danh sách lỗi theo từng khung giờ
+func (ec *ExpertController) ReplaceWeeklySchedule(ctx *gin.Context) (res interface{}, err error) {
+	var req dtoexperts.ReplaceWeeklyScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+	if req.UserID, err = getUserIDFromContext(ctx); err != nil {
+		return nil, err
+	}
+	resSchedule, err := Expert().ReplaceWeeklySchedule(ctx, req)
+	if err != nil {
+		var fieldErrs response.FieldErrors
+		if errors.As(err, &fieldErrs) {
+			return nil, response.NewAPIError(http.StatusUnprocessableEntity, "invalid weekly schedule", fieldErrs)
+		}
+		return nil, ownerAPIError("replace weekly schedule failed", err)
+	}
+	return resSchedule, nil
+}
+
 func (ec *ExpertController) GetAllWorkHourByExpertID(ctx *gin.Context) (res interface{}, err error) {
 	expertID := ctx.Param("expertId")
 	if expertID == "" {
@@ -108,6 +152,81 @@ func (ec *ExpertController) GetAllWorkHourByExpertID(ctx *gin.Context) (res inte
 	return resWorkHours, nil
 }
 
+func (ec *ExpertController) SetWorkingHourOverride(ctx *gin.Context) (res interface{}, err error) {
+	var req dtoexperts.SetWorkingHourOverrideRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+	if req.UserID, err = getUserIDFromContext(ctx); err != nil {
+		return nil, err
+	}
+	resOverride, err := Expert().SetWorkingHourOverride(ctx, req)
+	if err != nil {
+		return nil, ownerAPIError("set working hour override failed", err)
+	}
+	return resOverride, nil
+}
+
+func (ec *ExpertController) GetWorkingHourOverrides(ctx *gin.Context) (res interface{}, err error) {
+	expertID := ctx.Param("expertId")
+	if expertID == "" {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Expert ID is required", "Expert ID parameter is missing")
+	}
+	var req dtoexperts.GetWorkingHourOverridesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+
+	resOverrides, err := Expert().GetWorkingHourOverrides(ctx, expertID, req)
+	if err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Failed to get working hour overrides", err.Error())
+	}
+	return resOverrides, nil
+}
+
+func (ec *ExpertController) DeleteWorkingHourOverride(ctx *gin.Context) (res interface{}, err error) {
+	var req dtoexperts.DeleteWorkingHourOverrideRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+	if req.UserID, err = getUserIDFromContext(ctx); err != nil {
+		return nil, err
+	}
+	if err := Expert().DeleteWorkingHourOverride(ctx, req); err != nil {
+		return nil, ownerAPIError("delete working hour override failed", err)
+	}
+	return map[string]string{"message": "Working hour override deleted successfully"}, nil
+}
+
+// Booking Rules Controllers
+func (ec *ExpertController) GetBookingRules(ctx *gin.Context) (res interface{}, err error) {
+	expertID := ctx.Param("expertId")
+	if expertID == "" {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Expert ID is required", "Expert ID parameter is missing")
+	}
+
+	resRules, err := Expert().GetBookingRules(ctx, expertID)
+	if err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Failed to get booking rules", err.Error())
+	}
+	return resRules, nil
+}
+
+func (ec *ExpertController) UpdateBookingRules(ctx *gin.Context) (res interface{}, err error) {
+	var req dtoexperts.UpdateBookingRulesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+	if req.UserID, err = getUserIDFromContext(ctx); err != nil {
+		return nil, err
+	}
+	resRules, err := Expert().UpdateBookingRules(ctx, req)
+	if err != nil {
+		return nil, ownerAPIError("update booking rules failed", err)
+	}
+	return resRules, nil
+}
+
 func (ec *ExpertController) DeleteWorkHour(ctx *gin.Context) (res interface{}, err error) {
 	workingHourID := ctx.Param("workingHourId")
 	if workingHourID == "" {
@@ -146,6 +265,21 @@ func (ec *ExpertController) UpdateUnavailableTime(ctx *gin.Context) (res interfa
 	return resUpdateUnavailableTime, nil
 }
 
+func (ec *ExpertController) SkipUnavailableOccurrence(ctx *gin.Context) (res interface{}, err error) {
+	var req dtoexperts.SkipUnavailableOccurrenceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+	if req.UserID, err = getUserIDFromContext(ctx); err != nil {
+		return nil, err
+	}
+	resSkip, err := Expert().SkipUnavailableOccurrence(ctx, req)
+	if err != nil {
+		return nil, ownerAPIError("skip unavailable occurrence is failed", err)
+	}
+	return resSkip, nil
+}
+
 func (ec *ExpertController) GetAllUnavailableTimeByExpertID(ctx *gin.Context) (res interface{}, err error) {
 	expertID := ctx.Param("expertId")
 	if expertID == "" {
@@ -274,3 +408,103 @@ func (ec *ExpertController) DeletePrice(ctx *gin.Context) (res interface{}, err
 	}
 	return map[string]string{"message": "Pricing config deleted successfully"}, nil
 }
+
+// -------------------- Holiday Controllers --------------------
+
+// maxHolidayFileSize giới hạn file .ics nhập lịch nghỉ lễ
+const maxHolidayFileSize = 1 << 20
+
+func (ec *ExpertController) ImportHolidayCalendar(ctx *gin.Context) (res interface{}, err error) {
+	var req dtoexperts.ImportHolidayCalendarRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "iCalendar file is required", err.Error())
+	}
+	if fileHeader.Size > maxHolidayFileSize {
+		return nil, response.NewAPIError(http.StatusBadRequest, "iCalendar file is too large", "File must not exceed 1MB")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Cannot read iCalendar file", err.Error())
+	}
+	defer file.Close()
+	if req.Content, err = io.ReadAll(io.LimitReader(file, maxHolidayFileSize)); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Cannot read iCalendar file", err.Error())
+	}
+
+	resImport, err := Expert().ImportHolidayCalendar(ctx, req)
+	if err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "import holiday calendar failed", err.Error())
+	}
+	return resImport, nil
+}
+
+func (ec *ExpertController) CreateClosureDays(ctx *gin.Context) (res interface{}, err error) {
+	var req dtoexperts.CreateClosureDaysRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+	resClosure, err := Expert().CreateClosureDays(ctx, req)
+	if err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "create closure days failed", err.Error())
+	}
+	return resClosure, nil
+}
+
+func (ec *ExpertController) DeleteHoliday(ctx *gin.Context) (res interface{}, err error) {
+	holidayID := ctx.Param("holidayId")
+	if holidayID == "" {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Holiday ID is required", "Holiday ID parameter is missing")
+	}
+
+	err = Expert().DeleteHoliday(ctx, holidayID)
+	if err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "delete holiday failed", err.Error())
+	}
+	return map[string]string{"message": "Holiday deleted successfully"}, nil
+}
+
+func (ec *ExpertController) ListHolidays(ctx *gin.Context) (res interface{}, err error) {
+	var req dtoexperts.ListHolidaysRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+	resHolidays, err := Expert().ListHolidays(ctx, req)
+	if err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "get holidays failed", err.Error())
+	}
+	return resHolidays, nil
+}
+
+func (ec *ExpertController) OptInHoliday(ctx *gin.Context) (res interface{}, err error) {
+	var req dtoexperts.HolidayOptInRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+	if req.UserID, err = getUserIDFromContext(ctx); err != nil {
+		return nil, err
+	}
+	resOptIn, err := Expert().OptInHoliday(ctx, req)
+	if err != nil {
+		return nil, ownerAPIError("opt in holiday failed", err)
+	}
+	return resOptIn, nil
+}
+
+func (ec *ExpertController) OptOutHoliday(ctx *gin.Context) (res interface{}, err error) {
+	var req dtoexperts.HolidayOptInRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid request payload", err.Error())
+	}
+	if req.UserID, err = getUserIDFromContext(ctx); err != nil {
+		return nil, err
+	}
+	resOptOut, err := Expert().OptOutHoliday(ctx, req)
+	if err != nil {
+		return nil, ownerAPIError("opt out holiday failed", err)
+	}
+	return resOptOut, nil
+}
@@ -0,0 +1,318 @@
+package experts
+
+import (
+	"bytes"
+	entityexpert "cbs_backend/internal/modules/experts/entity"
+	dtoexperts "cbs_backend/internal/modules/experts/expertsdto"
+	"cbs_backend/pkg/ical"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxHolidaySpanDays giới hạn số ngày của một sự kiện nghỉ lễ/đợt nghỉ công ty, chặn file nhập nhầm
+	maxHolidaySpanDays = 31
+	maxHolidayListDays = 366
+)
+
+// ImportHolidayCalendar nhập các ngày nghỉ lễ từ file iCalendar. Mỗi ngày của sự kiện thành một PlatformHoliday;
+// nhập lại cùng calendar_name chỉ cập nhật tên nên các expert đã opt-in vẫn giữ lựa chọn của mình.
+func (es *expertService) ImportHolidayCalendar(ctx context.Context, req dtoexperts.ImportHolidayCalendarRequest) (*dtoexperts.ImportHolidayCalendarResponse, error) {
+	calendarName := strings.TrimSpace(req.CalendarName)
+	if calendarName == "" || calendarName == entityexpert.ClosureCalendarName {
+		return nil, fmt.Errorf("invalid calendar name %q", req.CalendarName)
+	}
+	events, err := ical.Parse(bytes.NewReader(req.Content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid iCalendar file: %w", err)
+	}
+
+	res := &dtoexperts.ImportHolidayCalendarResponse{CalendarName: calendarName}
+	var holidays []entityexpert.PlatformHoliday
+	byDate := make(map[time.Time]int)
+	for _, event := range events {
+		if event.Recurring {
+			res.Skipped = append(res.Skipped, skippedHoliday(event, "recurring events are not supported, export the calendar with expanded occurrences"))
+			continue
+		}
+		dates := holidayDates(event)
+		if len(dates) == 0 || len(dates) > maxHolidaySpanDays {
+			res.Skipped = append(res.Skipped, skippedHoliday(event, fmt.Sprintf("event must span 1-%d days", maxHolidaySpanDays)))
+			continue
+		}
+		uid := event.UID
+		for _, date := range dates {
+			// Hai sự kiện cùng ngày (vd lễ trùng cuối tuần bù) gộp tên vào một bản ghi
+			if i, ok := byDate[date]; ok {
+				holidays[i].HolidayName = holidayName(holidays[i].HolidayName + " / " + event.Summary)
+				continue
+			}
+			byDate[date] = len(holidays)
+			holidays = append(holidays, entityexpert.PlatformHoliday{
+				HolidayDate:  date,
+				CalendarName: calendarName,
+				HolidayName:  holidayName(event.Summary),
+				Source:       entityexpert.HolidaySourceImport,
+				SourceUID:    &uid,
+			})
+		}
+	}
+
+	err = es.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := upsertHolidays(tx, holidays); err != nil {
+			return err
+		}
+		if req.ReplaceExisting {
+			keep := make([]time.Time, 0, len(holidays))
+			for _, h := range holidays {
+				keep = append(keep, h.HolidayDate)
+			}
+			query := tx.Where("calendar_name = ?", calendarName)
+			if len(keep) > 0 {
+				query = query.Where("holiday_date NOT IN ?", keep)
+			}
+			result := query.Delete(&entityexpert.PlatformHoliday{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to remove old holidays: %w", result.Error)
+			}
+			res.Removed = result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res.Imported = len(holidays)
+	res.Holidays = toHolidayResponses(holidays, nil)
+	// Nhập lại có thể xoá ngày cũ ở bất kỳ đâu trong lịch nên xoá lưới slot của mọi expert
+	publishSlotsChanged(uuid.Nil, nil, nil, "holiday_calendar_imported")
+	return res, nil
+}
+
+// CreateClosureDays thêm các ngày công ty nghỉ từ start_date đến end_date (bao gồm)
+func (es *expertService) CreateClosureDays(ctx context.Context, req dtoexperts.CreateClosureDaysRequest) (*dtoexperts.ListHolidaysResponse, error) {
+	start, err := time.Parse(entityexpert.HolidayDateLayout, req.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_date format (expecting YYYY-MM-DD): %w", err)
+	}
+	end := start
+	if req.EndDate != "" {
+		if end, err = time.Parse(entityexpert.HolidayDateLayout, req.EndDate); err != nil {
+			return nil, fmt.Errorf("invalid end_date format (expecting YYYY-MM-DD): %w", err)
+		}
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end_date must not be before start_date")
+	}
+	if days := int(end.Sub(start).Hours()/24) + 1; days > maxHolidaySpanDays {
+		return nil, fmt.Errorf("closure cannot exceed %d days", maxHolidaySpanDays)
+	}
+
+	var holidays []entityexpert.PlatformHoliday
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		holidays = append(holidays, entityexpert.PlatformHoliday{
+			HolidayDate:  d,
+			CalendarName: entityexpert.ClosureCalendarName,
+			HolidayName:  strings.TrimSpace(req.Name),
+			Source:       entityexpert.HolidaySourceClosure,
+		})
+	}
+	if err := upsertHolidays(es.db.WithContext(ctx), holidays); err != nil {
+		return nil, err
+	}
+	publishDaysChanged(uuid.Nil, start, end, "closure_days_created")
+	return &dtoexperts.ListHolidaysResponse{Holidays: toHolidayResponses(holidays, nil)}, nil
+}
+
+func (es *expertService) DeleteHoliday(ctx context.Context, holidayID string) error {
+	holidayUUID, err := uuid.Parse(holidayID)
+	if err != nil {
+		return fmt.Errorf("invalid holiday ID format: %w", err)
+	}
+	var holiday entityexpert.PlatformHoliday
+	result := es.db.WithContext(ctx).Clauses(clause.Returning{}).Delete(&holiday, "holiday_id = ?", holidayUUID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete holiday: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("holiday not found")
+	}
+	publishDaysChanged(uuid.Nil, holiday.HolidayDate, holiday.HolidayDate, "holiday_deleted")
+	return nil
+}
+
+// ListHolidays trả về các ngày nghỉ toàn hệ thống trong khoảng; có expert_profile_id thì kèm trạng thái opt-in của expert
+func (es *expertService) ListHolidays(ctx context.Context, req dtoexperts.ListHolidaysRequest) (*dtoexperts.ListHolidaysResponse, error) {
+	from, err := time.Parse(entityexpert.HolidayDateLayout, req.FromDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from_date format (expecting YYYY-MM-DD): %w", err)
+	}
+	to, err := time.Parse(entityexpert.HolidayDateLayout, req.ToDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to_date format (expecting YYYY-MM-DD): %w", err)
+	}
+	if to.Before(from) || to.Sub(from) > maxHolidayListDays*24*time.Hour {
+		return nil, fmt.Errorf("date range must be between 0-%d days", maxHolidayListDays)
+	}
+
+	var holidays []entityexpert.PlatformHoliday
+	if err := es.db.WithContext(ctx).
+		Where("holiday_date BETWEEN ? AND ?", from, to).
+		Order("holiday_date ASC, calendar_name ASC").
+		Find(&holidays).Error; err != nil {
+		return nil, fmt.Errorf("failed to get holidays: %w", err)
+	}
+
+	var optedIn map[uuid.UUID]bool
+	if req.ExpertProfileID != "" {
+		expertUUID, err := uuid.Parse(req.ExpertProfileID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expert profile ID format: %w", err)
+		}
+		var holidayIDs []uuid.UUID
+		if err := es.db.WithContext(ctx).Model(&entityexpert.ExpertHolidayOptIn{}).
+			Where("expert_profile_id = ?", expertUUID).
+			Pluck("holiday_id", &holidayIDs).Error; err != nil {
+			return nil, fmt.Errorf("failed to get holiday opt-ins: %w", err)
+		}
+		optedIn = make(map[uuid.UUID]bool, len(holidayIDs))
+		for _, id := range holidayIDs {
+			optedIn[id] = true
+		}
+	}
+	return &dtoexperts.ListHolidaysResponse{Holidays: toHolidayResponses(holidays, optedIn)}, nil
+}
+
+// OptInHoliday cho phép expert vẫn nhận lịch vào ngày nghỉ toàn hệ thống
+func (es *expertService) OptInHoliday(ctx context.Context, req dtoexperts.HolidayOptInRequest) (*dtoexperts.HolidayOptInResponse, error) {
+	expert, holiday, err := es.loadHolidayOptInTarget(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	optIn := entityexpert.ExpertHolidayOptIn{
+		ExpertProfileID: expert.ExpertProfileID,
+		HolidayID:       holiday.HolidayID,
+	}
+	if err := es.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&optIn).Error; err != nil {
+		return nil, fmt.Errorf("failed to opt in holiday: %w", err)
+	}
+	publishDaysChanged(expert.ExpertProfileID, holiday.HolidayDate, holiday.HolidayDate, "holiday_opted_in")
+	return &dtoexperts.HolidayOptInResponse{
+		ExpertProfileID: req.ExpertProfileID,
+		HolidayID:       req.HolidayID,
+		OptedIn:         true,
+	}, nil
+}
+
+// OptOutHoliday huỷ opt-in, ngày nghỉ lại chặn lịch của expert
+func (es *expertService) OptOutHoliday(ctx context.Context, req dtoexperts.HolidayOptInRequest) (*dtoexperts.HolidayOptInResponse, error) {
+	expert, holiday, err := es.loadHolidayOptInTarget(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := es.db.WithContext(ctx).
+		Delete(&entityexpert.ExpertHolidayOptIn{}, "expert_profile_id = ? AND holiday_id = ?", expert.ExpertProfileID, holiday.HolidayID).Error; err != nil {
+		return nil, fmt.Errorf("failed to opt out holiday: %w", err)
+	}
+	publishDaysChanged(expert.ExpertProfileID, holiday.HolidayDate, holiday.HolidayDate, "holiday_opted_out")
+	return &dtoexperts.HolidayOptInResponse{
+		ExpertProfileID: req.ExpertProfileID,
+		HolidayID:       req.HolidayID,
+		OptedIn:         false,
+	}, nil
+}
+
+func (es *expertService) loadHolidayOptInTarget(ctx context.Context, req dtoexperts.HolidayOptInRequest) (*entityexpert.ExpertProfile, *entityexpert.PlatformHoliday, error) {
+	expert, err := es.loadOwnedExpertProfile(ctx, req.ExpertProfileID, req.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	holidayID, err := uuid.Parse(req.HolidayID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid holiday ID format: %w", err)
+	}
+	var holiday entityexpert.PlatformHoliday
+	if err := es.db.WithContext(ctx).First(&holiday, "holiday_id = ?", holidayID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("holiday not found")
+		}
+		return nil, nil, fmt.Errorf("failed to get holiday: %w", err)
+	}
+	return expert, &holiday, nil
+}
+
+// upsertHolidays ghi các ngày nghỉ, trùng (calendar_name, holiday_date) thì chỉ cập nhật tên và UID nguồn
+func upsertHolidays(db *gorm.DB, holidays []entityexpert.PlatformHoliday) error {
+	if len(holidays) == 0 {
+		return nil
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "holiday_date"}, {Name: "calendar_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"holiday_name", "source_uid"}),
+	}).Create(&holidays).Error; err != nil {
+		return fmt.Errorf("failed to save holidays: %w", err)
+	}
+	return nil
+}
+
+// holidayDates trả về các ngày lịch của sự kiện; sự kiện có giờ lấy theo ngày ở múi giờ ghi trong file
+func holidayDates(event ical.ParsedEvent) []time.Time {
+	start := entityexpert.HolidayDateOf(event.Start.Date())
+	end := entityexpert.HolidayDateOf(event.End.Date())
+	if !event.AllDay {
+		// DTEND không bao gồm: sự kiện kết thúc đúng 00:00 không chiếm ngày kết thúc
+		if endClock := event.End; endClock.Hour() != 0 || endClock.Minute() != 0 || endClock.Second() != 0 {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+	if !end.After(start) {
+		end = start.AddDate(0, 0, 1)
+	}
+	var dates []time.Time
+	for d := start; d.Before(end) && len(dates) <= maxHolidaySpanDays; d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d)
+	}
+	return dates
+}
+
+func holidayName(summary string) string {
+	if summary = strings.TrimSpace(summary); summary == "" {
+		return "Holiday"
+	}
+	if len([]rune(summary)) > 255 {
+		return string([]rune(summary)[:255])
+	}
+	return summary
+}
+
+func skippedHoliday(event ical.ParsedEvent, reason string) dtoexperts.SkippedHoliday {
+	return dtoexperts.SkippedHoliday{UID: event.UID, Summary: event.Summary, Reason: reason}
+}
+
+func toHolidayResponses(holidays []entityexpert.PlatformHoliday, optedIn map[uuid.UUID]bool) []dtoexperts.HolidayResponse {
+	result := make([]dtoexperts.HolidayResponse, 0, len(holidays))
+	for i := range holidays {
+		h := &holidays[i]
+		item := dtoexperts.HolidayResponse{
+			HolidayID:    h.HolidayID.String(),
+			HolidayDate:  h.Date(),
+			CalendarName: h.CalendarName,
+			HolidayName:  h.HolidayName,
+			Source:       h.Source,
+		}
+		if optedIn != nil {
+			opted := optedIn[h.HolidayID]
+			item.OptedIn = &opted
+		}
+		result = append(result, item)
+	}
+	return result
+}
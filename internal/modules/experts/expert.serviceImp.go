@@ -12,11 +12,13 @@ import (
 
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type expertService struct {
@@ -57,17 +59,23 @@ func (es *expertService) CreateExpertProfile(ctx context.Context, req dtoexperts
 		return nil, fmt.Errorf("failed to check expert profile: %w", err)
 	}
 
+	currency, err := resolveCurrency(req.Currency, common.CurrencyVND)
+	if err != nil {
+		return nil, err
+	}
+
 	// 4. Tạo ExpertProfile
 	newProfile := entityexpert.ExpertProfile{
-		UserID:             userUUID,
-		SpecializationList: req.SpecializationList,
-		ExperienceYears:    req.ExperienceYears,
-		ExpertBio:          req.ExpertBio,
-		ConsultationFee:    req.ConsultationFee,
-		IsVerified:         false,
-		LicenseNumber:      req.LicenseNumber,
-		AvailableOnline:    req.AvailableOnline,
-		AvailableOffline:   req.AvailableOffline,
+		UserID:               userUUID,
+		SpecializationList:   req.SpecializationList,
+		ExperienceYears:      req.ExperienceYears,
+		ExpertBio:            req.ExpertBio,
+		ConsultationFeeMinor: feeToMinorUnits(req.ConsultationFee, currency),
+		Currency:             currency,
+		IsVerified:           false,
+		LicenseNumber:        req.LicenseNumber,
+		AvailableOnline:      req.AvailableOnline,
+		AvailableOffline:     req.AvailableOffline,
 	}
 
 	if err := es.db.WithContext(ctx).Create(&newProfile).Error; err != nil {
@@ -99,7 +107,8 @@ func (es *expertService) CreateExpertProfile(ctx context.Context, req dtoexperts
 		SpecializationList: newProfile.SpecializationList,
 		ExperienceYears:    newProfile.ExperienceYears,
 		ExpertBio:          newProfile.ExpertBio,
-		ConsultationFee:    newProfile.ConsultationFee,
+		ConsultationFee:    newProfile.ConsultationFee(),
+		Currency:           newProfile.Currency,
 		AverageRating:      newProfile.AverageRating,
 		TotalReviews:       newProfile.TotalReviews,
 		IsVerified:         newProfile.IsVerified,
@@ -187,7 +196,8 @@ func (es *expertService) GetExpertProfileDetails(ctx context.Context, expertID s
 			ServiceType:        p.ServiceType,
 			ConsultationType:   p.ConsultationType,
 			DurationMinutes:    p.DurationMinutes,
-			BasePrice:          p.BasePrice,
+			BasePrice:          p.BasePrice(),
+			Currency:           p.Currency,
 			DiscountPercentage: p.DiscountPercentage,
 			IsActive:           p.IsActive,
 			ValidFrom:          p.ValidFrom,
@@ -217,7 +227,9 @@ func (es *expertService) GetExpertProfileDetails(ctx context.Context, expertID s
 		SpecializationList: expert.SpecializationList,
 		ExperienceYears:    *expert.ExperienceYears,
 		ExpertBio:          *expert.ExpertBio,
-		ConsultationFee:    *expert.ConsultationFee,
+		ConsultationFee:    valueOrZero(expert.ConsultationFee()),
+		Currency:           expert.Currency,
+		TimeZone:           expert.TimeZone,
 		AverageRating:      expert.AverageRating,
 		TotalReviews:       expert.TotalReviews,
 		IsVerified:         expert.IsVerified,
@@ -252,11 +264,22 @@ func (es *expertService) UpdateExpertProfile(ctx context.Context, req dtoexperts
 		return nil, fmt.Errorf("failed to load expert profile: %w", err)
 	}
 
+	currency, err := resolveCurrency(req.Currency, expert.Currency)
+	if err != nil {
+		return nil, err
+	}
+	timeZone, err := resolveTimeZone(req.TimeZone, expert.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
 	// 2. Cập nhật thông tin
 	expert.SpecializationList = req.SpecializationList
 	expert.ExperienceYears = req.ExperienceYears
 	expert.ExpertBio = req.ExpertBio
-	expert.ConsultationFee = req.ConsultationFee
+	expert.ConsultationFeeMinor = feeToMinorUnits(req.ConsultationFee, currency)
+	expert.Currency = currency
+	expert.TimeZone = timeZone
 	expert.LicenseNumber = req.LicenseNumber
 	expert.AvailableOnline = req.AvailableOnline
 	expert.AvailableOffline = req.AvailableOffline
@@ -265,6 +288,8 @@ func (es *expertService) UpdateExpertProfile(ctx context.Context, req dtoexperts
 	if err := es.db.WithContext(ctx).Save(&expert).Error; err != nil {
 		return nil, fmt.Errorf("failed to update expert profile: %w", err)
 	}
+	// Múi giờ đổi thì ngày của mọi slot đổi theo
+	publishSlotsChanged(expert.ExpertProfileID, nil, nil, "expert_profile_updated")
 
 	// 4. Map DTO để trả về
 	userDTO := dtoexperts.UserDTO{
@@ -283,7 +308,9 @@ func (es *expertService) UpdateExpertProfile(ctx context.Context, req dtoexperts
 		SpecializationList: expert.SpecializationList,
 		ExperienceYears:    *expert.ExperienceYears,
 		ExpertBio:          *expert.ExpertBio,
-		ConsultationFee:    *expert.ConsultationFee,
+		ConsultationFee:    valueOrZero(expert.ConsultationFee()),
+		Currency:           expert.Currency,
+		TimeZone:           expert.TimeZone,
 		AverageRating:      expert.AverageRating,
 		TotalReviews:       expert.TotalReviews,
 		IsVerified:         expert.IsVerified,
@@ -303,7 +330,9 @@ func (es *expertService) UpdateExpertProfile(ctx context.Context, req dtoexperts
 		SpecializationList: expert.SpecializationList,
 		ExperienceYears:    expert.ExperienceYears,
 		ExpertBio:          expert.ExpertBio,
-		ConsultationFee:    expert.ConsultationFee,
+		ConsultationFee:    expert.ConsultationFee(),
+		Currency:           expert.Currency,
+		TimeZone:           expert.TimeZone,
 		AverageRating:      expert.AverageRating,
 		TotalReviews:       expert.TotalReviews,
 		IsVerified:         expert.IsVerified,
@@ -341,7 +370,8 @@ func (es *expertService) GetAllsExpert(ctx context.Context) (*[]dtoexperts.GetAl
 			ExpertProfileID:    expert.ExpertProfileID.String(),
 			SpecializationList: expert.SpecializationList,
 			ExperienceYears:    expert.ExperienceYears,
-			ConsultationFee:    expert.ConsultationFee,
+			ConsultationFee:    expert.ConsultationFee(),
+			Currency:           expert.Currency,
 			AverageRating:      expert.AverageRating,
 			TotalReviews:       expert.TotalReviews,
 			User:               userDTO,
@@ -362,6 +392,7 @@ func (es *expertService) DeleteExpertProfile(ctx context.Context, expertID strin
 	if err := es.db.WithContext(ctx).Delete(&entityexpert.ExpertProfile{}, "expert_profile_id = ?", expertUUID).Error; err != nil {
 		return fmt.Errorf("failed to delete expert profile: %w", err)
 	}
+	publishSlotsChanged(expertUUID, nil, nil, "expert_profile_deleted")
 
 	// Clear cache
 	_ = es.cache.DeleteExpertDetail(ctx, expertID)
@@ -371,9 +402,12 @@ func (es *expertService) DeleteExpertProfile(ctx context.Context, expertID strin
 
 // Working hour
 func (es *expertService) CreateWorkHour(ctx context.Context, req dtoexperts.CreateWorkingHourRequest) (*dtoexperts.CreateWorkingHourResponse, error) {
-	expertUUID, err := uuid.Parse(req.ExpertProfileID)
+	expert, err := es.loadExpertProfile(ctx, req.ExpertProfileID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid expert profile ID format: %w", err)
+		return nil, err
+	}
+	if err := common.ValidateWeekday(req.DayOfWeek); err != nil {
+		return nil, err
 	}
 
 	// Parse chuỗi thành time.Time
@@ -386,9 +420,12 @@ func (es *expertService) CreateWorkHour(ctx context.Context, req dtoexperts.Crea
 	if err != nil {
 		return nil, fmt.Errorf("invalid end_time format: %w", err)
 	}
+	if err := es.ensureWorkingHourFits(ctx, expert, req.DayOfWeek, startTimeParsed, endTimeParsed, nil); err != nil {
+		return nil, err
+	}
 
 	workingHour := entityexpert.ExpertWorkingHour{
-		ExpertProfileID: expertUUID,
+		ExpertProfileID: expert.ExpertProfileID,
 		DayOfWeek:       req.DayOfWeek,
 		StartTime:       startTimeParsed.Format("15:04:05"),
 		EndTime:         endTimeParsed.Format("15:04:05"),
@@ -398,6 +435,7 @@ func (es *expertService) CreateWorkHour(ctx context.Context, req dtoexperts.Crea
 	if err := es.db.WithContext(ctx).Create(&workingHour).Error; err != nil {
 		return nil, fmt.Errorf("insert work hour failed: %w", err)
 	}
+	publishSlotsChanged(expert.ExpertProfileID, nil, nil, "working_hour_created")
 
 	return &dtoexperts.CreateWorkingHourResponse{
 		WorkingHourID: workingHour.WorkingHourID.String(),
@@ -413,6 +451,9 @@ func (es *expertService) UpdateWorkHour(ctx context.Context, req dtoexperts.Upda
 	if err := es.db.WithContext(ctx).First(&wh, "working_hour_id = ?", req.WorkingHourID).Error; err != nil {
 		return nil, fmt.Errorf("work hour not found: %w", err)
 	}
+	if err := common.ValidateWeekday(req.DayOfWeek); err != nil {
+		return nil, err
+	}
 
 	// Parse chuỗi thời gian từ request
 	startTimeParsed, err := time.Parse("15:04", req.StartTime)
@@ -424,6 +465,15 @@ func (es *expertService) UpdateWorkHour(ctx context.Context, req dtoexperts.Upda
 	if err != nil {
 		return nil, fmt.Errorf("invalid end_time format: %w", err)
 	}
+	expert, err := es.loadExpertProfile(ctx, wh.ExpertProfileID.String())
+	if err != nil {
+		return nil, err
+	}
+	if wh.IsActive {
+		if err := es.ensureWorkingHourFits(ctx, expert, req.DayOfWeek, startTimeParsed, endTimeParsed, &wh.WorkingHourID); err != nil {
+			return nil, err
+		}
+	}
 
 	// Gán vào entity (sẽ lưu dưới dạng "08:00:00")
 	wh.DayOfWeek = req.DayOfWeek
@@ -433,6 +483,7 @@ func (es *expertService) UpdateWorkHour(ctx context.Context, req dtoexperts.Upda
 	if err := es.db.WithContext(ctx).Save(&wh).Error; err != nil {
 		return nil, fmt.Errorf("update work hour failed: %w", err)
 	}
+	publishSlotsChanged(wh.ExpertProfileID, nil, nil, "working_hour_updated")
 
 	// Parse lại từ chuỗi để trả response
 	startTimeReturn, _ := time.Parse("15:04:05", wh.StartTime)
@@ -474,9 +525,13 @@ func (es *expertService) DeleteWorkHour(ctx context.Context, workingHourID strin
 		return fmt.Errorf("invalid working hour ID format: %w", err)
 	}
 
-	if err := es.db.WithContext(ctx).Delete(&entityexpert.ExpertWorkingHour{}, "working_hour_id = ?", workingHourUUID).Error; err != nil {
+	var wh entityexpert.ExpertWorkingHour
+	if err := es.db.WithContext(ctx).Clauses(clause.Returning{}).Delete(&wh, "working_hour_id = ?", workingHourUUID).Error; err != nil {
 		return fmt.Errorf("failed to delete working hour: %w", err)
 	}
+	if wh.ExpertProfileID != uuid.Nil {
+		publishSlotsChanged(wh.ExpertProfileID, nil, nil, "working_hour_deleted")
+	}
 
 	return nil
 }
@@ -489,9 +544,9 @@ func (es *expertService) CreateUnavailableTime(ctx context.Context, req dtoexper
 		return nil, fmt.Errorf("invalid expert profile ID format: %w", err)
 	}
 
-	var recurrencePattern common.JSONB
-	if req.RecurrencePattern != nil {
-		recurrencePattern, _ = req.RecurrencePattern.(common.JSONB)
+	recurrencePattern, err := normalizeRecurrencePattern(req.IsRecurring, req.RecurrencePattern, req.StartDatetime, req.EndDatetime)
+	if err != nil {
+		return nil, err
 	}
 	unavailable := entityexpert.ExpertUnavailableTime{
 		ExpertProfileID:          expertUUID,
@@ -505,6 +560,7 @@ func (es *expertService) CreateUnavailableTime(ctx context.Context, req dtoexper
 	if err := es.db.WithContext(ctx).Create(&unavailable).Error; err != nil {
 		return nil, fmt.Errorf("create unavailable time failed: %w", err)
 	}
+	publishUnavailableChanged(&unavailable, "unavailable_time_created")
 
 	return &dtoexperts.CreateUnavailableTimeResponse{
 		UnavailableTimeID: unavailable.UnavailableTimeID.String(),
@@ -512,6 +568,7 @@ func (es *expertService) CreateUnavailableTime(ctx context.Context, req dtoexper
 		EndTime:           unavailable.UnavailableEndDatetime,
 		Reason:            unavailable.UnavailableReason,
 		IsRecurring:       unavailable.IsRecurring,
+		RecurrencePattern: unavailable.RecurrencePattern,
 	}, nil
 }
 
@@ -541,20 +598,16 @@ func (es *expertService) UpdateUnavailableTime(ctx context.Context, req dtoexper
 	ua.IsRecurring = req.IsRecurring
 
 	// Xử lý recurrence pattern nếu có
-	if req.RecurrencePattern != nil {
-		if rp, ok := req.RecurrencePattern.(common.JSONB); ok {
-			ua.RecurrencePattern = rp
-		} else {
-			return nil, fmt.Errorf("invalid recurrence pattern type, must be JSONB")
-		}
-	} else {
-		ua.RecurrencePattern = nil
+	if ua.RecurrencePattern, err = normalizeRecurrencePattern(req.IsRecurring, req.RecurrencePattern, startTime, endTime); err != nil {
+		return nil, err
 	}
 
 	// Cập nhật DB
 	if err := es.db.WithContext(ctx).Save(&ua).Error; err != nil {
 		return nil, fmt.Errorf("update unavailable time failed: %w", err)
 	}
+	// Khoảng cũ và mới đều đổi nên xoá mọi ngày của expert
+	publishSlotsChanged(ua.ExpertProfileID, nil, nil, "unavailable_time_updated")
 
 	// Trả về response
 	return &dtoexperts.UpdateUnavailableTimeResponse{
@@ -567,6 +620,79 @@ func (es *expertService) UpdateUnavailableTime(ctx context.Context, req dtoexper
 	}, nil
 }
 
+// SkipUnavailableOccurrence thêm một ngày vào exdates của thời gian bận lặp lại để expert nhận lịch riêng lần đó
+func (es *expertService) SkipUnavailableOccurrence(ctx context.Context, req dtoexperts.SkipUnavailableOccurrenceRequest) (*dtoexperts.UpdateUnavailableTimeResponse, error) {
+	expert, err := es.loadOwnedExpertProfile(ctx, req.ExpertProfileID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	var ua entityexpert.ExpertUnavailableTime
+	if err := es.db.WithContext(ctx).First(&ua, "unavailable_time_id = ?", req.UnavailableTimeID).Error; err != nil {
+		return nil, fmt.Errorf("unavailable time not found: %w", err)
+	}
+	if ua.ExpertProfileID != expert.ExpertProfileID {
+		return nil, fmt.Errorf("unavailable time does not belong to this expert")
+	}
+	if !ua.IsRecurring {
+		return nil, fmt.Errorf("unavailable time is not recurring")
+	}
+	rule, err := entityexpert.ParseRecurrenceRule(ua.RecurrencePattern)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("unavailable time has no recurrence pattern")
+	}
+
+	loc := expert.Location()
+	date, err := time.ParseInLocation("2006-01-02", req.OccurrenceDate, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid occurrence_date format (expecting YYYY-MM-DD): %w", err)
+	}
+	if len(ua.Occurrences(date, date.AddDate(0, 0, 1), loc)) == 0 {
+		return nil, fmt.Errorf("no occurrence on %s", req.OccurrenceDate)
+	}
+
+	rule.Skip(date)
+	if ua.RecurrencePattern, err = rule.Pattern(); err != nil {
+		return nil, fmt.Errorf("invalid recurrence pattern: %w", err)
+	}
+	if err := es.db.WithContext(ctx).Model(&ua).Update("recurrence_pattern", ua.RecurrencePattern).Error; err != nil {
+		return nil, fmt.Errorf("skip unavailable occurrence failed: %w", err)
+	}
+	publishDaysChanged(ua.ExpertProfileID, date, date, "unavailable_occurrence_skipped")
+
+	return &dtoexperts.UpdateUnavailableTimeResponse{
+		UnavailableTimeID: ua.UnavailableTimeID.String(),
+		StartTime:         ua.UnavailableStartDatetime,
+		EndTime:           ua.UnavailableEndDatetime,
+		Reason:            ua.UnavailableReason,
+		IsRecurring:       ua.IsRecurring,
+		RecurrencePattern: ua.RecurrencePattern,
+	}, nil
+}
+
+// normalizeRecurrencePattern kiểm tra pattern theo định dạng entity.RecurrenceRule và trả về dạng chuẩn để lưu
+func normalizeRecurrencePattern(isRecurring bool, pattern common.JSONB, start, end time.Time) (common.JSONB, error) {
+	if !isRecurring {
+		if len(pattern) > 0 {
+			return nil, fmt.Errorf("recurrence_pattern requires is_recurring to be true")
+		}
+		return nil, nil
+	}
+	rule, err := entityexpert.ParseRecurrenceRule(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("recurrence_pattern is required when is_recurring is true")
+	}
+	if err := rule.Validate(start, end); err != nil {
+		return nil, err
+	}
+	return rule.Pattern()
+}
+
 func (es *expertService) GetAllUnavailableTimeByExpertID(ctx context.Context, expertID string) ([]*dtoexperts.GetAllsExpertUnavailableTimeResponse, error) {
 	if expertID == "" {
 		return nil, fmt.Errorf("expert Id must be not empty")
@@ -598,9 +724,13 @@ func (es *expertService) DeleteUnavailableTime(ctx context.Context, unavailableT
 		return fmt.Errorf("invalid unavailable time ID format: %w", err)
 	}
 
-	if err := es.db.WithContext(ctx).Delete(&entityexpert.ExpertUnavailableTime{}, "unavailable_time_id = ?", unavailableTimeUUID).Error; err != nil {
+	var ua entityexpert.ExpertUnavailableTime
+	if err := es.db.WithContext(ctx).Clauses(clause.Returning{}).Delete(&ua, "unavailable_time_id = ?", unavailableTimeUUID).Error; err != nil {
 		return fmt.Errorf("failed to delete unavailable time: %w", err)
 	}
+	if ua.ExpertProfileID != uuid.Nil {
+		publishUnavailableChanged(&ua, "unavailable_time_deleted")
+	}
 
 	return nil
 }
@@ -705,12 +835,17 @@ func (es *expertService) CreatePrice(ctx context.Context, req dtoexperts.CreateP
 	if err != nil {
 		return nil, fmt.Errorf("expert id is empty")
 	}
+	currency, err := resolveCurrency(req.Currency, common.CurrencyVND)
+	if err != nil {
+		return nil, err
+	}
 	pricingConfig := &entityexpert.PricingConfig{
 		ExpertProfileID:    &ExpertID,
 		ServiceType:        req.ServiceType,
 		ConsultationType:   req.ConsultationType,
 		DurationMinutes:    req.DurationMinutes,
-		BasePrice:          req.BasePrice,
+		BasePriceMinor:     common.ToMinorUnits(req.BasePrice, currency),
+		Currency:           currency,
 		DiscountPercentage: req.DiscountPercentage,
 		ValidFrom:          req.ValidFrom,
 		ValidUntil:         req.ValidUntil,
@@ -724,7 +859,8 @@ func (es *expertService) CreatePrice(ctx context.Context, req dtoexperts.CreateP
 		ServiceType:        pricingConfig.ServiceType,
 		ConsultationType:   pricingConfig.ConsultationType,
 		DurationMinutes:    pricingConfig.DurationMinutes,
-		BasePrice:          pricingConfig.BasePrice,
+		BasePrice:          pricingConfig.BasePrice(),
+		Currency:           pricingConfig.Currency,
 		DiscountPercentage: pricingConfig.DiscountPercentage,
 		IsActive:           pricingConfig.IsActive,
 		ValidFrom:          pricingConfig.ValidFrom,
@@ -740,11 +876,17 @@ func (es *expertService) UpdatePrice(ctx context.Context, req dtoexperts.UpdateP
 		return nil, fmt.Errorf("pricing config not found: %w", err)
 	}
 
+	currency, err := resolveCurrency(req.Currency, pricingConfig.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	// Cập nhật các trường
 	pricingConfig.ServiceType = req.ServiceType
 	pricingConfig.ConsultationType = req.ConsultationType
 	pricingConfig.DurationMinutes = req.DurationMinutes
-	pricingConfig.BasePrice = req.BasePrice
+	pricingConfig.BasePriceMinor = common.ToMinorUnits(req.BasePrice, currency)
+	pricingConfig.Currency = currency
 	pricingConfig.DiscountPercentage = req.DiscountPercentage
 	pricingConfig.ValidFrom = req.ValidFrom
 	pricingConfig.ValidUntil = req.ValidUntil
@@ -782,7 +924,8 @@ func (es *expertService) GetAllPriceByExpertID(ctx context.Context, expertID str
 			ServiceType:        p.ServiceType,
 			ConsultationType:   p.ConsultationType,
 			DurationMinutes:    p.DurationMinutes,
-			BasePrice:          p.BasePrice,
+			BasePrice:          p.BasePrice(),
+			Currency:           p.Currency,
 			DiscountPercentage: p.DiscountPercentage,
 			IsActive:           p.IsActive,
 			ValidFrom:          p.ValidFrom,
@@ -806,3 +949,46 @@ func (es *expertService) DeletePrice(ctx context.Context, pricingID string) erro
 
 	return nil
 }
+
+// resolveCurrency chuẩn hoá mã tiền tệ từ request, rỗng thì giữ giá trị fallback
+func resolveCurrency(requested, fallback string) (string, error) {
+	if requested == "" {
+		requested = fallback
+	}
+	currency := common.NormalizeCurrency(requested)
+	if !common.IsSupportedCurrency(currency) {
+		return "", fmt.Errorf("unsupported currency %q", requested)
+	}
+	return currency, nil
+}
+
+// resolveTimeZone lấy múi giờ từ request, rỗng thì giữ múi giờ hiện tại; chỉ nhận tên IANA hợp lệ
+func resolveTimeZone(requested, fallback string) (string, error) {
+	requested = strings.TrimSpace(requested)
+	if requested == "" {
+		requested = fallback
+	}
+	if requested == "" {
+		return common.DefaultTimeZone, nil
+	}
+	if err := common.ValidateTimeZone(requested); err != nil {
+		return "", err
+	}
+	return requested, nil
+}
+
+// feeToMinorUnits đổi phí tư vấn (có thể nil) sang đơn vị tiền nhỏ nhất
+func feeToMinorUnits(fee *float64, currency string) *int64 {
+	if fee == nil {
+		return nil
+	}
+	minor := common.ToMinorUnits(*fee, currency)
+	return &minor
+}
+
+func valueOrZero(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
@@ -0,0 +1,39 @@
+package experts
+
+import (
+	"cbs_backend/internal/kafka"
+	entityexpert "cbs_backend/internal/modules/experts/entity"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// publishSlotsChanged báo lịch trống của expert vừa đổi để consumer xoá lưới slot đã cache (xem bookings/booking.slot.cache.go).
+// expertID là uuid.Nil khi thay đổi áp dụng cho mọi expert; from/to nil là mọi ngày. Lỗi chỉ log vì lưới cũ tự hết hạn.
+func publishSlotsChanged(expertID uuid.UUID, from, to *time.Time, reason string) {
+	event := kafka.SlotAvailabilityChangedEvent{From: from, To: to, Reason: reason}
+	if expertID != uuid.Nil {
+		event.ExpertID = expertID.String()
+	}
+	go func() {
+		if err := kafka.PublishSlotAvailabilityChangedEvent(event); err != nil {
+			log.Printf("⚠️ Failed to publish slot availability changed event for expert %q: %v", event.ExpertID, err)
+		}
+	}()
+}
+
+// publishDaysChanged báo các ngày lịch [firstDay, lastDay] (00:00 của ngày, múi giờ nào cũng được vì consumer nới thêm một ngày) vừa đổi
+func publishDaysChanged(expertID uuid.UUID, firstDay, lastDay time.Time, reason string) {
+	to := lastDay.AddDate(0, 0, 1)
+	publishSlotsChanged(expertID, &firstDay, &to, reason)
+}
+
+// publishUnavailableChanged báo khoảng thời gian báo bận vừa thêm/xoá; lịch lặp lại thì xoá mọi ngày của expert
+func publishUnavailableChanged(ua *entityexpert.ExpertUnavailableTime, reason string) {
+	if ua.IsRecurring {
+		publishSlotsChanged(ua.ExpertProfileID, nil, nil, reason)
+		return
+	}
+	publishSlotsChanged(ua.ExpertProfileID, &ua.UnavailableStartDatetime, &ua.UnavailableEndDatetime, reason)
+}
@@ -0,0 +1,206 @@
+package experts
+
+import (
+	entityexpert "cbs_backend/internal/modules/experts/entity"
+	dtoexperts "cbs_backend/internal/modules/experts/expertsdto"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxOverridePeriods   = 6
+	maxOverrideListDays  = 366
+	overrideDateLayout   = "2006-01-02"
+	overrideTimeLayout   = "15:04"
+	overrideStoredLayout = "15:04:05"
+)
+
+// SetWorkingHourOverride thay toàn bộ giờ làm việc riêng của một ngày; ngày có override thì lịch tuần bị bỏ qua.
+// Booking đã đặt trong ngày không bị huỷ, override chỉ áp dụng cho slot và booking mới.
+func (es *expertService) SetWorkingHourOverride(ctx context.Context, req dtoexperts.SetWorkingHourOverrideRequest) (*dtoexperts.WorkingHourOverrideResponse, error) {
+	expert, err := es.loadOwnedExpertProfile(ctx, req.ExpertProfileID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	date, err := time.Parse(overrideDateLayout, req.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format (expecting YYYY-MM-DD): %w", err)
+	}
+	periods, err := validateOverridePeriods(req.IsDayOff, req.Periods)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []entityexpert.ExpertWorkingHourOverride
+	if req.IsDayOff {
+		rows = append(rows, entityexpert.ExpertWorkingHourOverride{
+			ExpertProfileID: expert.ExpertProfileID,
+			OverrideDate:    date,
+			IsDayOff:        true,
+			Note:            req.Note,
+		})
+	}
+	for _, p := range periods {
+		start, end := p[0].Format(overrideStoredLayout), p[1].Format(overrideStoredLayout)
+		rows = append(rows, entityexpert.ExpertWorkingHourOverride{
+			ExpertProfileID: expert.ExpertProfileID,
+			OverrideDate:    date,
+			StartTime:       &start,
+			EndTime:         &end,
+			Note:            req.Note,
+		})
+	}
+
+	err = es.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expert_profile_id = ? AND override_date = ?", expert.ExpertProfileID, date).
+			Delete(&entityexpert.ExpertWorkingHourOverride{}).Error; err != nil {
+			return fmt.Errorf("failed to replace working hour override: %w", err)
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to save working hour override: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	publishDaysChanged(expert.ExpertProfileID, date, date, "working_hour_override_set")
+
+	return toOverrideResponses(expert.ExpertProfileID, rows)[0], nil
+}
+
+// DeleteWorkingHourOverride xoá giờ riêng của ngày, ngày đó quay về lịch tuần
+func (es *expertService) DeleteWorkingHourOverride(ctx context.Context, req dtoexperts.DeleteWorkingHourOverrideRequest) error {
+	expert, err := es.loadOwnedExpertProfile(ctx, req.ExpertProfileID, req.UserID)
+	if err != nil {
+		return err
+	}
+	date, err := time.Parse(overrideDateLayout, req.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date format (expecting YYYY-MM-DD): %w", err)
+	}
+	result := es.db.WithContext(ctx).
+		Where("expert_profile_id = ? AND override_date = ?", expert.ExpertProfileID, date).
+		Delete(&entityexpert.ExpertWorkingHourOverride{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete working hour override: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("working hour override not found")
+	}
+	publishDaysChanged(expert.ExpertProfileID, date, date, "working_hour_override_deleted")
+	return nil
+}
+
+// GetWorkingHourOverrides liệt kê giờ riêng theo ngày của expert trong khoảng ngày
+func (es *expertService) GetWorkingHourOverrides(ctx context.Context, expertID string, req dtoexperts.GetWorkingHourOverridesRequest) ([]*dtoexperts.WorkingHourOverrideResponse, error) {
+	expertUUID, err := uuid.Parse(expertID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expert profile ID format: %w", err)
+	}
+	from, err := time.Parse(overrideDateLayout, req.FromDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from_date format (expecting YYYY-MM-DD): %w", err)
+	}
+	to, err := time.Parse(overrideDateLayout, req.ToDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to_date format (expecting YYYY-MM-DD): %w", err)
+	}
+	if to.Before(from) || to.Sub(from) > maxOverrideListDays*24*time.Hour {
+		return nil, fmt.Errorf("date range must be between 0-%d days", maxOverrideListDays)
+	}
+
+	var rows []entityexpert.ExpertWorkingHourOverride
+	if err := es.db.WithContext(ctx).
+		Where("expert_profile_id = ? AND override_date BETWEEN ? AND ?", expertUUID, from, to).
+		Order("override_date ASC, start_time ASC").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("query working hour overrides: %w", err)
+	}
+	return toOverrideResponses(expertUUID, rows), nil
+}
+
+// validateOverridePeriods parse các khung giờ, sắp theo giờ bắt đầu và kiểm tra không chồng nhau
+func validateOverridePeriods(isDayOff bool, periods []dtoexperts.WorkingPeriodDTO) ([][2]time.Time, error) {
+	if isDayOff {
+		if len(periods) > 0 {
+			return nil, fmt.Errorf("periods must be empty when is_day_off is true")
+		}
+		return nil, nil
+	}
+	if len(periods) == 0 {
+		return nil, fmt.Errorf("periods are required unless is_day_off is true")
+	}
+	if len(periods) > maxOverridePeriods {
+		return nil, fmt.Errorf("at most %d periods per day", maxOverridePeriods)
+	}
+
+	parsed := make([][2]time.Time, 0, len(periods))
+	for _, p := range periods {
+		start, err := time.Parse(overrideTimeLayout, p.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_time format: %w", err)
+		}
+		end, err := time.Parse(overrideTimeLayout, p.EndTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end_time format: %w", err)
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("end_time %s must be after start_time %s", p.EndTime, p.StartTime)
+		}
+		parsed = append(parsed, [2]time.Time{start, end})
+	}
+	sort.Slice(parsed, func(i, j int) bool { return parsed[i][0].Before(parsed[j][0]) })
+	for i := 1; i < len(parsed); i++ {
+		if parsed[i][0].Before(parsed[i-1][1]) {
+			return nil, fmt.Errorf("periods %s-%s and %s-%s overlap",
+				parsed[i-1][0].Format(overrideTimeLayout), parsed[i-1][1].Format(overrideTimeLayout),
+				parsed[i][0].Format(overrideTimeLayout), parsed[i][1].Format(overrideTimeLayout))
+		}
+	}
+	return parsed, nil
+}
+
+// toOverrideResponses gộp các dòng override theo ngày, giữ thứ tự ngày của rows
+func toOverrideResponses(expertID uuid.UUID, rows []entityexpert.ExpertWorkingHourOverride) []*dtoexperts.WorkingHourOverrideResponse {
+	var result []*dtoexperts.WorkingHourOverrideResponse
+	byDate := make(map[string]*dtoexperts.WorkingHourOverrideResponse)
+	for _, row := range rows {
+		date := row.OverrideDate.Format(overrideDateLayout)
+		item, ok := byDate[date]
+		if !ok {
+			item = &dtoexperts.WorkingHourOverrideResponse{
+				ExpertProfileID: expertID.String(),
+				Date:            date,
+				Periods:         []dtoexperts.WorkingPeriodDTO{},
+				Note:            row.Note,
+			}
+			byDate[date] = item
+			result = append(result, item)
+		}
+		if row.IsDayOff {
+			item.IsDayOff = true
+			continue
+		}
+		if row.StartTime != nil && row.EndTime != nil {
+			item.Periods = append(item.Periods, dtoexperts.WorkingPeriodDTO{
+				StartTime: trimSeconds(*row.StartTime),
+				EndTime:   trimSeconds(*row.EndTime),
+			})
+		}
+	}
+	return result
+}
+
+// trimSeconds đổi "08:00:00" (cột time) về "08:00" như định dạng request
+func trimSeconds(clock string) string {
+	if t, err := time.Parse(overrideStoredLayout, clock); err == nil {
+		return t.Format(overrideTimeLayout)
+	}
+	return clock
+}
@@ -0,0 +1,193 @@
+package experts
+
+import (
+	"cbs_backend/internal/common"
+	entityexpert "cbs_backend/internal/modules/experts/entity"
+	dtoexperts "cbs_backend/internal/modules/experts/expertsdto"
+	"cbs_backend/pkg/response"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	workHourLayout       = "15:04"
+	workHourStoredLayout = "15:04:05"
+	// maxWeeklyEntries giới hạn số khung giờ trong một lịch tuần (tối đa 6 khung mỗi ngày)
+	maxWeeklyEntries = 7 * 6
+)
+
+// Mã lỗi của từng khung giờ khi kiểm tra lịch tuần
+const (
+	workHourErrInvalidDay     = "invalid_day_of_week"
+	workHourErrInvalidFormat  = "invalid_format"
+	workHourErrEndBeforeStart = "end_before_start"
+	workHourErrTooShort       = "too_short"
+	workHourErrOverlap        = "overlap"
+	workHourErrTooMany        = "too_many_entries"
+)
+
+// workingRange là một khung giờ đã parse của lịch tuần
+type workingRange struct {
+	index int
+	day   common.Weekday
+	start time.Time
+	end   time.Time
+}
+
+func (r workingRange) String() string {
+	return r.start.Format(workHourLayout) + "-" + r.end.Format(workHourLayout)
+}
+
+// validateWeeklySchedule kiểm tra cả tuần một lượt và trả về mọi lỗi: ngày hợp lệ, đúng định dạng "15:04",
+// kết thúc sau bắt đầu, dài ít nhất minMinutes (đủ một slot ngắn nhất) và không chồng nhau trong cùng ngày.
+func validateWeeklySchedule(entries []dtoexperts.WeeklyScheduleEntry, minMinutes int) ([]workingRange, response.FieldErrors) {
+	var errs response.FieldErrors
+	if len(entries) > maxWeeklyEntries {
+		errs.Add("entries", workHourErrTooMany, fmt.Sprintf("at most %d working hour entries per week", maxWeeklyEntries))
+		return nil, errs
+	}
+
+	ranges := make([]workingRange, 0, len(entries))
+	for i, entry := range entries {
+		field := fmt.Sprintf("entries[%d]", i)
+		valid := true
+		if err := common.ValidateWeekday(entry.DayOfWeek); err != nil {
+			errs.Add(field+".day_of_week", workHourErrInvalidDay, err.Error())
+			valid = false
+		}
+		start, err := time.Parse(workHourLayout, entry.StartTime)
+		if err != nil {
+			errs.Add(field+".start_time", workHourErrInvalidFormat, fmt.Sprintf("invalid start_time %q, expecting HH:MM", entry.StartTime))
+			valid = false
+		}
+		end, err := time.Parse(workHourLayout, entry.EndTime)
+		if err != nil {
+			errs.Add(field+".end_time", workHourErrInvalidFormat, fmt.Sprintf("invalid end_time %q, expecting HH:MM", entry.EndTime))
+			valid = false
+		}
+		if !valid {
+			continue
+		}
+		if code, message := checkWorkingRange(start, end, minMinutes); code != "" {
+			errs.Add(field+".end_time", code, message)
+			continue
+		}
+		ranges = append(ranges, workingRange{index: i, day: entry.DayOfWeek, start: start, end: end})
+	}
+
+	// Sắp theo ngày rồi giờ bắt đầu; khung sau chồng lên khung kết thúc muộn nhất trước nó là lỗi
+	sorted := append([]workingRange(nil), ranges...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].day != sorted[j].day {
+			return sorted[i].day < sorted[j].day
+		}
+		return sorted[i].start.Before(sorted[j].start)
+	})
+	for i := 1; i < len(sorted); i++ {
+		latest := sorted[i-1]
+		for j := i - 2; j >= 0 && sorted[j].day == sorted[i].day; j-- {
+			if sorted[j].end.After(latest.end) {
+				latest = sorted[j]
+			}
+		}
+		if latest.day == sorted[i].day && sorted[i].start.Before(latest.end) {
+			errs.Add(fmt.Sprintf("entries[%d]", sorted[i].index), workHourErrOverlap,
+				fmt.Sprintf("%s on %s overlaps entries[%d] (%s)", sorted[i], sorted[i].day, latest.index, latest))
+		}
+	}
+	return ranges, errs
+}
+
+// checkWorkingRange trả về mã lỗi và mô tả nếu khung giờ không hợp lệ
+func checkWorkingRange(start, end time.Time, minMinutes int) (string, string) {
+	if !end.After(start) {
+		return workHourErrEndBeforeStart, fmt.Sprintf("end_time %s must be after start_time %s", end.Format(workHourLayout), start.Format(workHourLayout))
+	}
+	if end.Sub(start) < time.Duration(minMinutes)*time.Minute {
+		return workHourErrTooShort, fmt.Sprintf("working hour must be at least %d minutes", minMinutes)
+	}
+	return "", ""
+}
+
+// ensureWorkingHourFits kiểm tra một khung giờ tạo/sửa lẻ: hợp lệ và không chồng lên khung active khác cùng ngày
+func (es *expertService) ensureWorkingHourFits(ctx context.Context, expert *entityexpert.ExpertProfile, day common.Weekday, start, end time.Time, excludeID *uuid.UUID) error {
+	if code, message := checkWorkingRange(start, end, expert.BookingRules().MinDurationMinutes); code != "" {
+		return fmt.Errorf("%s", message)
+	}
+	query := es.db.WithContext(ctx).
+		Model(&entityexpert.ExpertWorkingHour{}).
+		Where("expert_profile_id = ? AND day_of_week = ? AND is_active = true AND start_time < ? AND end_time > ?",
+			expert.ExpertProfileID, day, end.Format(workHourStoredLayout), start.Format(workHourStoredLayout))
+	if excludeID != nil {
+		query = query.Where("working_hour_id <> ?", *excludeID)
+	}
+	var overlapping entityexpert.ExpertWorkingHour
+	if err := query.First(&overlapping).Error; err == nil {
+		return fmt.Errorf("working hour %s-%s overlaps existing %s-%s on %s",
+			start.Format(workHourLayout), end.Format(workHourLayout), trimSeconds(overlapping.StartTime), trimSeconds(overlapping.EndTime), day)
+	} else if err != gorm.ErrRecordNotFound {
+		return fmt.Errorf("failed to check overlapping working hours: %w", err)
+	}
+	return nil
+}
+
+// ReplaceWeeklySchedule thay toàn bộ lịch tuần của expert trong một transaction; có lỗi thì trả response.FieldErrors
+// liệt kê mọi khung giờ sai và không thay đổi gì.
+func (es *expertService) ReplaceWeeklySchedule(ctx context.Context, req dtoexperts.ReplaceWeeklyScheduleRequest) (*dtoexperts.ReplaceWeeklyScheduleResponse, error) {
+	expert, err := es.loadOwnedExpertProfile(ctx, req.ExpertProfileID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	ranges, errs := validateWeeklySchedule(req.Entries, expert.BookingRules().MinDurationMinutes)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	rows := make([]entityexpert.ExpertWorkingHour, 0, len(ranges))
+	for _, r := range ranges {
+		rows = append(rows, entityexpert.ExpertWorkingHour{
+			ExpertProfileID: expert.ExpertProfileID,
+			DayOfWeek:       r.day,
+			StartTime:       r.start.Format(workHourStoredLayout),
+			EndTime:         r.end.Format(workHourStoredLayout),
+			IsActive:        true,
+		})
+	}
+	err = es.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expert_profile_id = ?", expert.ExpertProfileID).
+			Delete(&entityexpert.ExpertWorkingHour{}).Error; err != nil {
+			return fmt.Errorf("failed to clear weekly schedule: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to save weekly schedule: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	publishSlotsChanged(expert.ExpertProfileID, nil, nil, "weekly_schedule_replaced")
+
+	res := &dtoexperts.ReplaceWeeklyScheduleResponse{
+		ExpertProfileID: expert.ExpertProfileID.String(),
+		WorkingHours:    make([]*dtoexperts.CreateWorkingHourResponse, 0, len(rows)),
+	}
+	for _, row := range rows {
+		res.WorkingHours = append(res.WorkingHours, &dtoexperts.CreateWorkingHourResponse{
+			WorkingHourID: row.WorkingHourID.String(),
+			DayOfWeek:     row.DayOfWeek,
+			StartTime:     row.StartTime,
+			EndTime:       row.EndTime,
+			IsActive:      row.IsActive,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,232 @@
+package experts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"cbs_backend/internal/common"
+	dtoexperts "cbs_backend/internal/modules/experts/expertsdto"
+	"cbs_backend/pkg/response"
+)
+
+func weeklyEntry(day common.Weekday, start, end string) dtoexperts.WeeklyScheduleEntry {
+	return dtoexperts.WeeklyScheduleEntry{DayOfWeek: day, StartTime: start, EndTime: end}
+}
+
+// fieldCode là phần field/code của một FieldError, đủ để so sánh mà không phụ thuộc câu chữ
+type fieldCode struct {
+	field string
+	code  string
+}
+
+func TestValidateWeeklySchedule(t *testing.T) {
+	tooMany := make([]dtoexperts.WeeklyScheduleEntry, maxWeeklyEntries+1)
+	for i := range tooMany {
+		tooMany[i] = weeklyEntry(common.DayMonday, "08:00", "09:00")
+	}
+
+	tests := []struct {
+		name       string
+		entries    []dtoexperts.WeeklyScheduleEntry
+		wantRanges int
+		wantErrs   []fieldCode
+	}{
+		{
+			name:       "empty schedule",
+			entries:    nil,
+			wantRanges: 0,
+		},
+		{
+			name: "separate days",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.DayMonday, "08:00", "17:00"),
+				weeklyEntry(common.DayTuesday, "08:00", "17:00"),
+			},
+			wantRanges: 2,
+		},
+		{
+			name: "touching boundaries do not overlap",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.DayMonday, "08:00", "12:00"),
+				weeklyEntry(common.DayMonday, "12:00", "17:00"),
+				weeklyEntry(common.DayMonday, "17:00", "17:15"),
+			},
+			wantRanges: 3,
+		},
+		{
+			name: "one minute overlap",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.DayMonday, "08:00", "12:01"),
+				weeklyEntry(common.DayMonday, "12:00", "17:00"),
+			},
+			wantRanges: 2,
+			wantErrs:   []fieldCode{{"entries[1]", workHourErrOverlap}},
+		},
+		{
+			name: "unsorted input reports the later entry in the day",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.DayMonday, "13:00", "15:00"),
+				weeklyEntry(common.DayMonday, "08:00", "14:00"),
+			},
+			wantRanges: 2,
+			wantErrs:   []fieldCode{{"entries[0]", workHourErrOverlap}},
+		},
+		{
+			name: "entries nested inside an earlier longer range",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.DayMonday, "08:00", "17:00"),
+				weeklyEntry(common.DayMonday, "09:00", "10:00"),
+				weeklyEntry(common.DayMonday, "10:00", "11:00"),
+			},
+			wantRanges: 3,
+			wantErrs: []fieldCode{
+				{"entries[1]", workHourErrOverlap},
+				{"entries[2]", workHourErrOverlap},
+			},
+		},
+		{
+			name: "range after the nested one still ends inside the longer range",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.DayFriday, "08:00", "12:00"),
+				weeklyEntry(common.DayFriday, "09:00", "09:30"),
+				weeklyEntry(common.DayFriday, "11:30", "13:00"),
+				weeklyEntry(common.DayFriday, "13:00", "14:00"),
+			},
+			wantRanges: 4,
+			wantErrs: []fieldCode{
+				{"entries[1]", workHourErrOverlap},
+				{"entries[2]", workHourErrOverlap},
+			},
+		},
+		{
+			name: "same hours on different days",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.DaySunday, "08:00", "17:00"),
+				weeklyEntry(common.DaySaturday, "09:00", "10:00"),
+			},
+			wantRanges: 2,
+		},
+		{
+			name: "00:00 end time is before the start",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.DayMonday, "22:00", "00:00"),
+			},
+			wantRanges: 0,
+			wantErrs:   []fieldCode{{"entries[0].end_time", workHourErrEndBeforeStart}},
+		},
+		{
+			name: "00:00 to 00:00 is empty",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.DayMonday, "00:00", "00:00"),
+			},
+			wantRanges: 0,
+			wantErrs:   []fieldCode{{"entries[0].end_time", workHourErrEndBeforeStart}},
+		},
+		{
+			name: "00:00 start time is allowed",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.DayMonday, "00:00", "06:00"),
+			},
+			wantRanges: 1,
+		},
+		{
+			name: "shorter than the minimum duration",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.DayMonday, "08:00", "08:14"),
+				weeklyEntry(common.DayMonday, "09:00", "09:15"),
+			},
+			wantRanges: 1,
+			wantErrs:   []fieldCode{{"entries[0].end_time", workHourErrTooShort}},
+		},
+		{
+			name: "every error is reported in one pass",
+			entries: []dtoexperts.WeeklyScheduleEntry{
+				weeklyEntry(common.Weekday(7), "8am", "17:00"),
+				weeklyEntry(common.DayMonday, "08:00", "25:00"),
+				weeklyEntry(common.DayMonday, "10:00", "09:00"),
+				weeklyEntry(common.DayTuesday, "08:00", "12:00"),
+				weeklyEntry(common.DayTuesday, "11:00", "13:00"),
+				weeklyEntry(common.DayWednesday, "08:00", "12:00"),
+			},
+			wantRanges: 3,
+			wantErrs: []fieldCode{
+				{"entries[0].day_of_week", workHourErrInvalidDay},
+				{"entries[0].start_time", workHourErrInvalidFormat},
+				{"entries[1].end_time", workHourErrInvalidFormat},
+				{"entries[2].end_time", workHourErrEndBeforeStart},
+				{"entries[4]", workHourErrOverlap},
+			},
+		},
+		{
+			name:       "too many entries",
+			entries:    tooMany,
+			wantRanges: 0,
+			wantErrs:   []fieldCode{{"entries", workHourErrTooMany}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges, errs := validateWeeklySchedule(tt.entries, 15)
+			if len(ranges) != tt.wantRanges {
+				t.Errorf("validateWeeklySchedule() returned %d ranges, want %d", len(ranges), tt.wantRanges)
+			}
+			got := make([]fieldCode, 0, len(errs))
+			for _, fe := range errs {
+				got = append(got, fieldCode{fe.Field, fe.Code})
+				if fe.Message == "" {
+					t.Errorf("error for %s has no message", fe.Field)
+				}
+			}
+			if want := append([]fieldCode{}, tt.wantErrs...); !reflect.DeepEqual(got, want) {
+				t.Errorf("validateWeeklySchedule() errors = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestValidateWeeklyScheduleFieldErrors(t *testing.T) {
+	_, errs := validateWeeklySchedule([]dtoexperts.WeeklyScheduleEntry{
+		weeklyEntry(common.DayMonday, "08:00", "17:00"),
+		weeklyEntry(common.DayMonday, "09:00", "10:00"),
+		weeklyEntry(common.DayTuesday, "08:00", "8:30pm"),
+	}, 15)
+
+	want := response.FieldErrors{
+		{Field: "entries[2].end_time", Code: workHourErrInvalidFormat, Message: `invalid end_time "8:30pm", expecting HH:MM`},
+		{Field: "entries[1]", Code: workHourErrOverlap, Message: "09:00-10:00 on Monday overlaps entries[0] (08:00-17:00)"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Fatalf("validateWeeklySchedule() errors = %#v, want %#v", errs, want)
+	}
+
+	// ReplaceWeeklySchedule trả nguyên FieldErrors dưới dạng error để response.Wrap trả danh sách cho client
+	var err error = errs
+	var fieldErrs response.FieldErrors
+	if !errors.As(err, &fieldErrs) || len(fieldErrs) != len(want) {
+		t.Fatalf("errors.As(FieldErrors) = %v, want %d field errors", fieldErrs, len(want))
+	}
+	wantMessage := fmt.Sprintf("%s: %s; %s: %s", want[0].Field, want[0].Message, want[1].Field, want[1].Message)
+	if err.Error() != wantMessage {
+		t.Errorf("Error() = %q, want %q", err.Error(), wantMessage)
+	}
+
+	raw, err := json.Marshal(errs)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var decoded []map[string]string
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	wantJSON := []map[string]string{
+		{"field": want[0].Field, "code": want[0].Code, "message": want[0].Message},
+		{"field": want[1].Field, "code": want[1].Code, "message": want[1].Message},
+	}
+	if !reflect.DeepEqual(decoded, wantJSON) {
+		t.Errorf("JSON = %s, want %v", raw, wantJSON)
+	}
+}
@@ -34,11 +34,28 @@ type IExperts interface {
 	CreateWorkHour(ctx context.Context, req dtoexperts.CreateWorkingHourRequest) (*dtoexperts.CreateWorkingHourResponse, error)
 	UpdateWorkHour(ctx context.Context, req dtoexperts.UpdateWorkingHourRequest) (*dtoexperts.UpdateWorkingHourResponse, error)
 	GetAllWorkHourByExpertID(ctx context.Context, expertID string) ([]*dtoexperts.GetAllWorkingHourResponse, error)
+	ReplaceWeeklySchedule(ctx context.Context, req dtoexperts.ReplaceWeeklyScheduleRequest) (*dtoexperts.ReplaceWeeklyScheduleResponse, error)
+	SetWorkingHourOverride(ctx context.Context, req dtoexperts.SetWorkingHourOverrideRequest) (*dtoexperts.WorkingHourOverrideResponse, error)
+	GetWorkingHourOverrides(ctx context.Context, expertID string, req dtoexperts.GetWorkingHourOverridesRequest) ([]*dtoexperts.WorkingHourOverrideResponse, error)
+	DeleteWorkingHourOverride(ctx context.Context, req dtoexperts.DeleteWorkingHourOverrideRequest) error
+
+	//Booking rules
+	GetBookingRules(ctx context.Context, expertID string) (*dtoexperts.BookingRulesResponse, error)
+	UpdateBookingRules(ctx context.Context, req dtoexperts.UpdateBookingRulesRequest) (*dtoexperts.BookingRulesResponse, error)
 
 	//Unavailable Time
 	CreateUnavailableTime(ctx context.Context, req dtoexperts.CreateUnavailableTimeRequest) (*dtoexperts.CreateUnavailableTimeResponse, error)
 	UpdateUnavailableTime(ctx context.Context, req dtoexperts.UpdateUnavailableTimeRequest) (*dtoexperts.UpdateUnavailableTimeResponse, error)
 	GetAllUnavailableTimeByExpertID(ctx context.Context, expertID string) ([]*dtoexperts.GetAllsExpertUnavailableTimeResponse, error)
+	SkipUnavailableOccurrence(ctx context.Context, req dtoexperts.SkipUnavailableOccurrenceRequest) (*dtoexperts.UpdateUnavailableTimeResponse, error)
+
+	// Ngày nghỉ toàn hệ thống
+	ImportHolidayCalendar(ctx context.Context, req dtoexperts.ImportHolidayCalendarRequest) (*dtoexperts.ImportHolidayCalendarResponse, error)
+	CreateClosureDays(ctx context.Context, req dtoexperts.CreateClosureDaysRequest) (*dtoexperts.ListHolidaysResponse, error)
+	DeleteHoliday(ctx context.Context, holidayID string) error
+	ListHolidays(ctx context.Context, req dtoexperts.ListHolidaysRequest) (*dtoexperts.ListHolidaysResponse, error)
+	OptInHoliday(ctx context.Context, req dtoexperts.HolidayOptInRequest) (*dtoexperts.HolidayOptInResponse, error)
+	OptOutHoliday(ctx context.Context, req dtoexperts.HolidayOptInRequest) (*dtoexperts.HolidayOptInResponse, error)
 
 	//Expert Specializations
 	CreateExpertSpecialization(ctx context.Context, req dtoexperts.CreateSpecializationRequest) (*dtoexperts.CreateSpecializationResponse, error)
@@ -0,0 +1,28 @@
+package dtoexperts
+
+import "time"
+
+// UpdateBookingRulesRequest expert tự đặt quy tắc nhận lịch; các trường bỏ trống giữ nguyên giá trị cũ
+type UpdateBookingRulesRequest struct {
+	ExpertProfileID     string `json:"expert_profile_id" binding:"required"`
+	BufferBeforeMinutes *int   `json:"buffer_before_minutes,omitempty"`
+	BufferAfterMinutes  *int   `json:"buffer_after_minutes,omitempty"`
+	MaxSessionsPerDay   *int   `json:"max_sessions_per_day,omitempty"` // 0 = không giới hạn
+	MinNoticeMinutes    *int   `json:"min_notice_minutes,omitempty"`
+	MaxAdvanceDays      *int   `json:"max_advance_days,omitempty"`
+	MinDurationMinutes  *int   `json:"min_duration_minutes,omitempty"`
+	MaxDurationMinutes  *int   `json:"max_duration_minutes,omitempty"`
+	UserID              string `json:"-"` // Lấy từ context, không nhận từ body
+}
+
+type BookingRulesResponse struct {
+	ExpertProfileID     string    `json:"expert_profile_id"`
+	BufferBeforeMinutes int       `json:"buffer_before_minutes"`
+	BufferAfterMinutes  int       `json:"buffer_after_minutes"`
+	MaxSessionsPerDay   *int      `json:"max_sessions_per_day,omitempty"`
+	MinNoticeMinutes    int       `json:"min_notice_minutes"`
+	MaxAdvanceDays      int       `json:"max_advance_days"`
+	MinDurationMinutes  int       `json:"min_duration_minutes"`
+	MaxDurationMinutes  int       `json:"max_duration_minutes"`
+	ExpertUpdatedAt     time.Time `json:"expert_updated_at"`
+}
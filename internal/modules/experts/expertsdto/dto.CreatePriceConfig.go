@@ -8,6 +8,7 @@ type CreatePricingConfigRequest struct {
 	ConsultationType   string     `json:"consultation_type" binding:"required"`
 	DurationMinutes    int        `json:"duration_minutes" binding:"required"`
 	BasePrice          float64    `json:"base_price" binding:"required"`
+	Currency           string     `json:"currency,omitempty"`
 	DiscountPercentage float64    `json:"discount_percentage"`
 	IsActive           bool       `json:"is_active" binding:"required"`
 	ValidFrom          time.Time  `json:"valid_from" binding:"required"`
@@ -20,6 +21,7 @@ type CreatePricingConfigResponse struct {
 	ConsultationType   string     `json:"consultation_type"`
 	DurationMinutes    int        `json:"duration_minutes"`
 	BasePrice          float64    `json:"base_price"`
+	Currency           string     `json:"currency"`
 	DiscountPercentage float64    `json:"discount_percentage"`
 	IsActive           bool       `json:"is_active"`
 	ValidFrom          time.Time  `json:"valid_from"`
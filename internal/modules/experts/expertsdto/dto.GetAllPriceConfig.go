@@ -9,6 +9,7 @@ type PricingConfigResponse struct {
 	ConsultationType   string     `json:"consultation_type"`
 	DurationMinutes    int        `json:"duration_minutes"`
 	BasePrice          float64    `json:"base_price"`
+	Currency           string     `json:"currency"`
 	DiscountPercentage float64    `json:"discount_percentage"`
 	IsActive           bool       `json:"is_active"`
 	ValidFrom          time.Time  `json:"valid_from"`
@@ -0,0 +1,63 @@
+package dtoexperts
+
+// ImportHolidayCalendarRequest nhập lịch nghỉ lễ từ file .ics (multipart field "file").
+// replace_existing = true thì xoá các ngày của calendar_name không còn trong file.
+type ImportHolidayCalendarRequest struct {
+	CalendarName    string `form:"calendar_name" binding:"required,max=100"`
+	ReplaceExisting bool   `form:"replace_existing"`
+	Content         []byte `form:"-" json:"-"`
+}
+
+type ImportHolidayCalendarResponse struct {
+	CalendarName string            `json:"calendar_name"`
+	Imported     int               `json:"imported"`
+	Removed      int64             `json:"removed"`
+	Skipped      []SkippedHoliday  `json:"skipped,omitempty"`
+	Holidays     []HolidayResponse `json:"holidays"`
+}
+
+// SkippedHoliday là sự kiện trong file không nhập được, kèm lý do
+type SkippedHoliday struct {
+	UID     string `json:"uid"`
+	Summary string `json:"summary"`
+	Reason  string `json:"reason"`
+}
+
+// CreateClosureDaysRequest thêm ngày công ty nghỉ; end_date (bao gồm) bỏ trống thì chỉ nghỉ start_date
+type CreateClosureDaysRequest struct {
+	StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date"`                      // YYYY-MM-DD
+	Name      string `json:"name" binding:"required,max=255"`
+}
+
+type ListHolidaysRequest struct {
+	FromDate        string `form:"from_date" binding:"required"` // YYYY-MM-DD
+	ToDate          string `form:"to_date" binding:"required"`   // YYYY-MM-DD
+	ExpertProfileID string `form:"expert_profile_id"`            // Có thì trả thêm opted_in của expert
+}
+
+type ListHolidaysResponse struct {
+	Holidays []HolidayResponse `json:"holidays"`
+}
+
+type HolidayResponse struct {
+	HolidayID    string `json:"holiday_id"`
+	HolidayDate  string `json:"holiday_date"`
+	CalendarName string `json:"calendar_name"`
+	HolidayName  string `json:"holiday_name"`
+	Source       string `json:"source"`
+	OptedIn      *bool  `json:"opted_in,omitempty"`
+}
+
+// HolidayOptInRequest để expert vẫn nhận lịch (hoặc huỷ nhận lịch) vào một ngày nghỉ toàn hệ thống
+type HolidayOptInRequest struct {
+	ExpertProfileID string `json:"expert_profile_id" binding:"required"`
+	HolidayID       string `json:"holiday_id" binding:"required"`
+	UserID          string `json:"-"` // Lấy từ context, không nhận từ body
+}
+
+type HolidayOptInResponse struct {
+	ExpertProfileID string `json:"expert_profile_id"`
+	HolidayID       string `json:"holiday_id"`
+	OptedIn         bool   `json:"opted_in"`
+}
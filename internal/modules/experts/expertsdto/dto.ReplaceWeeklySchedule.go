@@ -0,0 +1,21 @@
+package dtoexperts
+
+import "cbs_backend/internal/common"
+
+// ReplaceWeeklyScheduleRequest thay toàn bộ lịch tuần của expert trong một lần; entries rỗng nghĩa là không nhận lịch theo tuần
+type ReplaceWeeklyScheduleRequest struct {
+	ExpertProfileID string                `json:"expert_profile_id" binding:"required"`
+	Entries         []WeeklyScheduleEntry `json:"entries"`
+	UserID          string                `json:"-"` // Lấy từ context, không nhận từ body
+}
+
+type WeeklyScheduleEntry struct {
+	DayOfWeek common.Weekday `json:"day_of_week"` // 0 = Chủ nhật, 1 = Thứ 2,...
+	StartTime string         `json:"start_time"`  // "08:00"
+	EndTime   string         `json:"end_time"`    // "17:00"
+}
+
+type ReplaceWeeklyScheduleResponse struct {
+	ExpertProfileID string                       `json:"expert_profile_id"`
+	WorkingHours    []*CreateWorkingHourResponse `json:"working_hours"`
+}
@@ -9,7 +9,7 @@ type UpdateExpertSpecializationRequest struct {
 }
 
 type UpdateExpertSpecializationRespone struct {
-	SpecializationID          string `json:"expert_profile_id" binding:"required"`
+	SpecializationID          string `json:"specialization_id"`
 	ExpertProfileID           string `json:"expert_profile_id"`
 	SpecializationName        string `json:"specialization_name"`
 	SpecializationDescription string `json:"specialization_description"`
@@ -9,6 +9,7 @@ type UpdatePricingConfigRequest struct {
 	ConsultationType   string     `json:"consultation_type" binding:"required"`
 	DurationMinutes    int        `json:"duration_minutes" binding:"required"`
 	BasePrice          float64    `json:"base_price" binding:"required"`
+	Currency           string     `json:"currency,omitempty"`
 	DiscountPercentage float64    `json:"discount_percentage"`
 	IsActive           bool       `json:"is_active" binding:"required"`
 	ValidFrom          time.Time  `json:"valid_from" binding:"required"`
@@ -21,6 +22,7 @@ type UpdatePricingConfigResponse struct {
 	ConsultationType   string     `json:"consultation_type"`
 	DurationMinutes    int        `json:"duration_minutes"`
 	BasePrice          float64    `json:"base_price"`
+	Currency           string     `json:"currency"`
 	DiscountPercentage float64    `json:"discount_percentage"`
 	IsActive           bool       `json:"is_active"`
 	ValidFrom          time.Time  `json:"valid_from"`
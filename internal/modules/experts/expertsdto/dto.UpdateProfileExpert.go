@@ -10,6 +10,8 @@ type UpdateProfileExpertRequest struct {
 	ExperienceYears    *int     `json:"experience_years,omitempty"`
 	ExpertBio          *string  `json:"expert_bio,omitempty"`
 	ConsultationFee    *float64 `json:"consultation_fee,omitempty"`
+	Currency           string   `json:"currency,omitempty"`
+	TimeZone           string   `json:"time_zone,omitempty"` // Múi giờ IANA của giờ làm việc, rỗng thì giữ nguyên
 	LicenseNumber      *string  `json:"license_number,omitempty"`
 	AvailableOnline    bool     `json:"available_online"`
 	AvailableOffline   bool     `json:"available_offline"`
@@ -21,6 +23,8 @@ type UpdateProfileExpertResponse struct {
 	ExperienceYears    *int      `json:"experience_years,omitempty"`
 	ExpertBio          *string   `json:"expert_bio,omitempty"`
 	ConsultationFee    *float64  `json:"consultation_fee,omitempty"`
+	Currency           string    `json:"currency,omitempty"`
+	TimeZone           string    `json:"time_zone"`
 	AverageRating      float64   `json:"average_rating"`
 	TotalReviews       int       `json:"total_reviews"`
 	IsVerified         bool      `json:"is_verified"`
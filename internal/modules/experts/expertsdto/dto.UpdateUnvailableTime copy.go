@@ -2,23 +2,33 @@ package dtoexperts
 
 import (
 	"time"
+
+	"cbs_backend/internal/common"
 )
 
 type UpdateUnavailableTimeRequest struct {
-	UnavailableTimeID string  `json:"unavailable_time_id" binding:"required"`
-	ExpertProfileID   string  `json:"expert_profile_id" binding:"required"`
-	StartDatetime     string  `json:"start_datetime" binding:"required"` // đổi sang string
-	EndDatetime       string  `json:"end_datetime" binding:"required"`
-	Reason            *string `json:"reason"`
-	IsRecurring       bool    `json:"is_recurring"`
-	RecurrencePattern any     `json:"recurrence_pattern"` // nếu có dùng
+	UnavailableTimeID string       `json:"unavailable_time_id" binding:"required"`
+	ExpertProfileID   string       `json:"expert_profile_id" binding:"required"`
+	StartDatetime     string       `json:"start_datetime" binding:"required"` // đổi sang string
+	EndDatetime       string       `json:"end_datetime" binding:"required"`
+	Reason            *string      `json:"reason"`
+	IsRecurring       bool         `json:"is_recurring"`
+	RecurrencePattern common.JSONB `json:"recurrence_pattern"` // Dạng RRULE, xem entity.RecurrenceRule
 }
 
 type UpdateUnavailableTimeResponse struct {
-	UnavailableTimeID string    `json:"unavailable_time_id"`
-	StartTime         time.Time `json:"start_time"`
-	EndTime           time.Time `json:"end_time"`
-	Reason            *string   `json:"reason"`
-	IsRecurring       bool      `json:"is_recurring"`
-	RecurrencePattern any       `json:"recurrence_pattern"`
+	UnavailableTimeID string       `json:"unavailable_time_id"`
+	StartTime         time.Time    `json:"start_time"`
+	EndTime           time.Time    `json:"end_time"`
+	Reason            *string      `json:"reason"`
+	IsRecurring       bool         `json:"is_recurring"`
+	RecurrencePattern common.JSONB `json:"recurrence_pattern"`
+}
+
+// SkipUnavailableOccurrenceRequest bỏ qua một lần của thời gian bận lặp lại, vd expert vẫn nhận lịch chiều thứ Sáu tuần này
+type SkipUnavailableOccurrenceRequest struct {
+	UnavailableTimeID string `json:"unavailable_time_id" binding:"required"`
+	ExpertProfileID   string `json:"expert_profile_id" binding:"required"`
+	OccurrenceDate    string `json:"occurrence_date" binding:"required"` // YYYY-MM-DD theo múi giờ của expert
+	UserID            string `json:"-"`                                  // Lấy từ context, không nhận từ body
 }
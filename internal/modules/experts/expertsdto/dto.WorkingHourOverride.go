@@ -0,0 +1,38 @@
+package dtoexperts
+
+// WorkingPeriodDTO là một khung giờ làm việc trong ngày, định dạng "15:04"
+type WorkingPeriodDTO struct {
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+}
+
+// SetWorkingHourOverrideRequest đặt giờ làm việc riêng cho một ngày, thay toàn bộ override cũ của ngày đó.
+// is_day_off = true thì nghỉ cả ngày (không truyền periods).
+type SetWorkingHourOverrideRequest struct {
+	ExpertProfileID string             `json:"expert_profile_id" binding:"required"`
+	Date            string             `json:"date" binding:"required"` // YYYY-MM-DD theo múi giờ của expert
+	IsDayOff        bool               `json:"is_day_off"`
+	Periods         []WorkingPeriodDTO `json:"periods" binding:"dive"`
+	Note            *string            `json:"note"`
+	UserID          string             `json:"-"` // Lấy từ context, không nhận từ body
+}
+
+// DeleteWorkingHourOverrideRequest xoá override, ngày đó quay về lịch tuần
+type DeleteWorkingHourOverrideRequest struct {
+	ExpertProfileID string `json:"expert_profile_id" binding:"required"`
+	Date            string `json:"date" binding:"required"`
+	UserID          string `json:"-"` // Lấy từ context, không nhận từ body
+}
+
+type GetWorkingHourOverridesRequest struct {
+	FromDate string `form:"from_date" binding:"required"` // YYYY-MM-DD
+	ToDate   string `form:"to_date" binding:"required"`   // YYYY-MM-DD
+}
+
+type WorkingHourOverrideResponse struct {
+	ExpertProfileID string             `json:"expert_profile_id"`
+	Date            string             `json:"date"`
+	IsDayOff        bool               `json:"is_day_off"`
+	Periods         []WorkingPeriodDTO `json:"periods"`
+	Note            *string            `json:"note,omitempty"`
+}
@@ -11,6 +11,7 @@ type CreateProfileExpertRequest struct {
 	ExperienceYears    *int     `json:"experience_years,omitempty"`
 	ExpertBio          *string  `json:"expert_bio,omitempty"`
 	ConsultationFee    *float64 `json:"consultation_fee,omitempty"`
+	Currency           string   `json:"currency,omitempty"`
 	LicenseNumber      *string  `json:"license_number,omitempty"`
 	AvailableOnline    bool     `json:"available_online"`
 	AvailableOffline   bool     `json:"available_offline"`
@@ -23,6 +24,7 @@ type CreateProfileExpertResponse struct {
 	ExperienceYears    *int      `json:"experience_years,omitempty"`
 	ExpertBio          *string   `json:"expert_bio,omitempty"`
 	ConsultationFee    *float64  `json:"consultation_fee,omitempty"`
+	Currency           string    `json:"currency,omitempty"`
 	AverageRating      float64   `json:"average_rating"`
 	TotalReviews       int       `json:"total_reviews"`
 	IsVerified         bool      `json:"is_verified"`
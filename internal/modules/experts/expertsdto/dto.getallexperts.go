@@ -9,6 +9,7 @@ type GetAllExpertsRespone struct {
 	SpecializationList []string `json:"specialization_list"`
 	ExperienceYears    *int     `json:"experience_years,omitempty"`
 	ConsultationFee    *float64 `json:"consultation_fee,omitempty"`
+	Currency           string   `json:"currency,omitempty"`
 	AverageRating      float64  `json:"average_rating"`
 	TotalReviews       int      `json:"total_reviews"`
 	User               UserDTO  `json:"user"`
@@ -21,6 +21,8 @@ type ExpertFullDetailResponse struct {
 	ExperienceYears    int                                 `json:"experience_years"`
 	ExpertBio          string                              `json:"expert_bio"`
 	ConsultationFee    float64                             `json:"consultation_fee"`
+	Currency           string                              `json:"currency"`
+	TimeZone           string                              `json:"time_zone"`
 	AverageRating      float64                             `json:"average_rating"`
 	TotalReviews       int                                 `json:"total_reviews"`
 	IsVerified         bool                                `json:"is_verified"`
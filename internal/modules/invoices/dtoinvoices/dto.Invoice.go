@@ -0,0 +1,51 @@
+package dtoinvoices
+
+import "time"
+
+type GetBookingInvoicesRequest struct {
+	BookingID string `form:"booking_id" binding:"required"`
+	UserID    string `form:"-"`
+}
+
+// DownloadInvoiceRequest tải hoá đơn của booking; không truyền invoice_id thì lấy hoá đơn mới nhất
+type DownloadInvoiceRequest struct {
+	BookingID string `form:"booking_id" binding:"required"`
+	InvoiceID string `form:"invoice_id"`
+	UserID    string `form:"-"`
+}
+
+type InvoiceResponse struct {
+	InvoiceID        string    `json:"invoice_id"`
+	InvoiceNumber    string    `json:"invoice_number"`
+	BookingID        string    `json:"booking_id"`
+	TransactionID    string    `json:"transaction_id"`
+	CustomerName     string    `json:"customer_name"`
+	ExpertName       string    `json:"expert_name"`
+	ConsultationType string    `json:"consultation_type"`
+	BookingDatetime  time.Time `json:"booking_datetime"`
+	DurationMinutes  int       `json:"duration_minutes"`
+	Currency         string    `json:"currency"`
+	Subtotal         float64   `json:"subtotal"`
+	Discount         float64   `json:"discount"`
+	CouponCode       *string   `json:"coupon_code,omitempty"`
+	TaxPercentage    float64   `json:"tax_percentage"`
+	Tax              float64   `json:"tax"`
+	Total            float64   `json:"total"`
+	IssuedAt         time.Time `json:"issued_at"`
+	FileReady        bool      `json:"file_ready"`
+}
+
+type GetBookingInvoicesResponse struct {
+	Invoices []InvoiceResponse `json:"invoices"`
+}
+
+type InvoiceFile struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+type RenderPendingInvoicesResponse struct {
+	Rendered int `json:"rendered"`
+	Failed   int `json:"failed"`
+}
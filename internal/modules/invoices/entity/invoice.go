@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invoice represents tbl_invoices table
+// Mỗi giao dịch thanh toán completed sinh đúng một hoá đơn (unique transaction_id).
+// Thông tin khách hàng/expert được chụp lại tại thời điểm phát hành để hoá đơn không đổi khi hồ sơ thay đổi.
+// Số tiền lưu theo đơn vị nhỏ nhất của Currency; giá đã bao gồm thuế nên TotalMinor = SubtotalMinor - DiscountMinor.
+type Invoice struct {
+	InvoiceID          uuid.UUID  `json:"invoice_id" db:"invoice_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	InvoiceNumber      string     `json:"invoice_number" db:"invoice_number" gorm:"type:varchar(30);not null;uniqueIndex"`
+	BookingID          uuid.UUID  `json:"booking_id" db:"booking_id" gorm:"type:uuid;not null;index"`
+	TransactionID      uuid.UUID  `json:"transaction_id" db:"transaction_id" gorm:"type:uuid;not null;uniqueIndex"`
+	UserID             uuid.UUID  `json:"user_id" db:"user_id" gorm:"type:uuid;not null;index"`
+	ExpertProfileID    uuid.UUID  `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;not null"`
+	CustomerName       string     `json:"customer_name" db:"customer_name" gorm:"type:varchar(255)"`
+	CustomerEmail      string     `json:"customer_email" db:"customer_email" gorm:"type:varchar(255)"`
+	ExpertName         string     `json:"expert_name" db:"expert_name" gorm:"type:varchar(255)"`
+	ConsultationType   string     `json:"consultation_type" db:"consultation_type" gorm:"type:varchar(20)"`
+	BookingDatetime    time.Time  `json:"booking_datetime" db:"booking_datetime"`
+	DurationMinutes    int        `json:"duration_minutes" db:"duration_minutes"`
+	Currency           string     `json:"currency" db:"currency" gorm:"type:varchar(3);not null;default:'VND'"`
+	SubtotalMinor      int64      `json:"subtotal_minor" db:"subtotal_minor" gorm:"not null"` // Phí trước giảm giá
+	DiscountMinor      int64      `json:"discount_minor" db:"discount_minor" gorm:"not null;default:0"`
+	CouponCode         *string    `json:"coupon_code,omitempty" db:"coupon_code" gorm:"type:varchar(50)"`
+	TaxPercentage      float64    `json:"tax_percentage" db:"tax_percentage" gorm:"type:decimal(5,2);not null;default:0"`
+	TaxMinor           int64      `json:"tax_minor" db:"tax_minor" gorm:"not null;default:0"` // Thuế đã bao gồm trong TotalMinor
+	TotalMinor         int64      `json:"total_minor" db:"total_minor" gorm:"not null"`
+	FilePath           *string    `json:"-" db:"file_path" gorm:"type:text"` // Null khi PDF chưa được render
+	FileGeneratedAt    *time.Time `json:"file_generated_at,omitempty" db:"file_generated_at"`
+	ConfirmationSentAt *time.Time `json:"confirmation_sent_at,omitempty" db:"confirmation_sent_at"`
+	IssuedAt           time.Time  `json:"issued_at" db:"issued_at" gorm:"not null"`
+}
+
+func (Invoice) TableName() string {
+	return "tbl_invoices"
+}
+
+// InvoiceSequence represents tbl_invoice_sequences table
+// Bộ đếm số hoá đơn theo năm, được khoá FOR UPDATE khi cấp số để dãy số liên tục, không trùng.
+type InvoiceSequence struct {
+	Year       int   `json:"year" db:"year" gorm:"primary_key;autoIncrement:false"`
+	LastNumber int64 `json:"last_number" db:"last_number" gorm:"not null;default:0"`
+}
+
+func (InvoiceSequence) TableName() string {
+	return "tbl_invoice_sequences"
+}
@@ -0,0 +1,79 @@
+package invoices
+
+import (
+	"cbs_backend/internal/modules/invoices/dtoinvoices"
+	"cbs_backend/pkg/response"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type InvoiceController struct {
+	Logger *zap.Logger
+}
+
+func NewInvoiceController(logger *zap.Logger) *InvoiceController {
+	return &InvoiceController{Logger: logger}
+}
+
+// getUserIDFromContext lấy userID đã được AuthMiddleware gắn vào context
+func getUserIDFromContext(c *gin.Context) (string, error) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		return "", response.NewAPIError(http.StatusUnauthorized, "Unauthorized", "UserID not found in context")
+	}
+	userID, ok := userIDValue.(uuid.UUID)
+	if !ok {
+		return "", response.NewAPIError(http.StatusInternalServerError, "Internal error", "Invalid userID type")
+	}
+	return userID.String(), nil
+}
+
+func (ic *InvoiceController) GetBookingInvoices(c *gin.Context) (res interface{}, err error) {
+	var req dtoinvoices.GetBookingInvoicesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ic.Logger.Error("Invalid get booking invoices request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get booking invoices request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Invoice().GetBookingInvoices(context.Background(), req)
+	if err != nil {
+		ic.Logger.Error("Get booking invoices failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get booking invoices failed", err)
+	}
+
+	return resp, nil
+}
+
+func (ic *InvoiceController) DownloadInvoice(c *gin.Context) {
+	var req dtoinvoices.DownloadInvoiceRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		ic.Logger.Error("Invalid download invoice request", zap.Error(err))
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid download invoice request", err.Error())
+		return
+	}
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		apiErr := err.(*response.APIError)
+		response.ErrorResponse(c, apiErr.StatusCode, apiErr.Message, apiErr.Error())
+		return
+	}
+	req.UserID = userID
+
+	file, err := Invoice().DownloadInvoice(context.Background(), req)
+	if err != nil {
+		ic.Logger.Error("Download invoice failed", zap.Error(err))
+		response.ErrorResponse(c, http.StatusBadRequest, "Download invoice failed", err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+	c.Data(http.StatusOK, file.ContentType, file.Content)
+}
@@ -0,0 +1,113 @@
+package invoices
+
+import (
+	"bytes"
+	"cbs_backend/internal/common"
+	entityInvoice "cbs_backend/internal/modules/invoices/entity"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/text/unicode/norm"
+)
+
+// renderInvoicePDF dựng file PDF hoá đơn bằng font chuẩn của fpdf (không cần file font ngoài)
+func renderInvoicePDF(invoice *entityInvoice.Invoice, issuerName string) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(invoice.InvoiceNumber, false)
+	pdf.SetCreator(pdfText(issuerName), false)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, pdfText(issuerName), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 8, "INVOICE "+invoice.InvoiceNumber, "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, "Issued at: "+invoice.IssuedAt.Format("2006-01-02 15:04"), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	writeSection(pdf, "Billed to", [][2]string{
+		{"Customer", invoice.CustomerName},
+		{"Email", invoice.CustomerEmail},
+	})
+	writeSection(pdf, "Consultation", [][2]string{
+		{"Booking ID", invoice.BookingID.String()},
+		{"Expert", invoice.ExpertName},
+		{"Type", invoice.ConsultationType},
+		{"Date & time", invoice.BookingDatetime.Format("2006-01-02 15:04")},
+		{"Duration", fmt.Sprintf("%d minutes", invoice.DurationMinutes)},
+		{"Transaction ID", invoice.TransactionID.String()},
+	})
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 8, "Fee breakdown", "B", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	writeAmountRow(pdf, "Consultation fee", invoice.SubtotalMinor, invoice.Currency, false)
+	if invoice.DiscountMinor > 0 {
+		label := "Discount"
+		if invoice.CouponCode != nil {
+			label += " (" + *invoice.CouponCode + ")"
+		}
+		writeAmountRow(pdf, label, -invoice.DiscountMinor, invoice.Currency, false)
+	}
+	if invoice.TaxMinor > 0 {
+		label := "Included tax (" + strconv.FormatFloat(invoice.TaxPercentage, 'f', -1, 64) + "%)"
+		writeAmountRow(pdf, label, invoice.TaxMinor, invoice.Currency, false)
+	}
+	writeAmountRow(pdf, "Total paid", invoice.TotalMinor, invoice.Currency, true)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render invoice PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeSection(pdf *fpdf.Fpdf, title string, rows [][2]string) {
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 8, title, "B", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	for _, row := range rows {
+		pdf.CellFormat(45, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, pdfText(row[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+func writeAmountRow(pdf *fpdf.Fpdf, label string, amountMinor int64, currency string, bold bool) {
+	if bold {
+		pdf.SetFont("Helvetica", "B", 11)
+	}
+	pdf.CellFormat(130, 7, pdfText(label), "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 7, formatMinor(amountMinor, currency), "", 1, "R", false, 0, "")
+	if bold {
+		pdf.SetFont("Helvetica", "", 10)
+	}
+}
+
+// formatMinor hiển thị số tiền theo số chữ số thập phân của loại tiền, ví dụ "150000 VND", "12.50 USD"
+func formatMinor(amountMinor int64, currency string) string {
+	return common.FormatMinorUnits(amountMinor, currency) + " " + currency
+}
+
+// pdfText bỏ dấu tiếng Việt vì font chuẩn của PDF không có glyph cho các ký tự này
+func pdfText(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			b.WriteRune('d')
+		case r == 'Đ':
+			b.WriteRune('D')
+		case r > unicode.MaxASCII:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
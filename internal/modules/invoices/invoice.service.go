@@ -0,0 +1,37 @@
+package invoices
+
+import (
+	"cbs_backend/internal/modules/invoices/dtoinvoices"
+	entityInvoice "cbs_backend/internal/modules/invoices/entity"
+	entityPayment "cbs_backend/internal/modules/payment_transactions/entity"
+	"cbs_backend/pkg/configs"
+	"context"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	iInvoiceService IInvoices
+)
+
+func InitInvoiceService(db *gorm.DB, logger *zap.Logger, cfg *configs.PaymentConfig) {
+	iInvoiceService = NewInvoiceService(db, logger, cfg)
+}
+
+func Invoice() IInvoices {
+	if iInvoiceService == nil {
+		panic("InvoiceService not initialized. Call InitInvoiceService(db, logger, cfg) first.")
+	}
+	return iInvoiceService
+}
+
+type IInvoices interface {
+	// IssueForTransaction cấp số và ghi hoá đơn cho giao dịch vừa completed, gọi bên trong transaction của payment
+	IssueForTransaction(ctx context.Context, tx *gorm.DB, txn *entityPayment.PaymentTransaction) (*entityInvoice.Invoice, error)
+	// RenderPendingInvoices render PDF cho các hoá đơn chưa có file và gửi lại email xác nhận kèm hoá đơn
+	RenderPendingInvoices(ctx context.Context, limit int) (*dtoinvoices.RenderPendingInvoicesResponse, error)
+
+	GetBookingInvoices(ctx context.Context, req dtoinvoices.GetBookingInvoicesRequest) (*dtoinvoices.GetBookingInvoicesResponse, error)
+	DownloadInvoice(ctx context.Context, req dtoinvoices.DownloadInvoiceRequest) (*dtoinvoices.InvoiceFile, error)
+}
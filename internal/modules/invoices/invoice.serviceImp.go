@@ -0,0 +1,377 @@
+package invoices
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/kafka"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/internal/modules/invoices/dtoinvoices"
+	entityInvoice "cbs_backend/internal/modules/invoices/entity"
+	entityPayment "cbs_backend/internal/modules/payment_transactions/entity"
+	entityPromotion "cbs_backend/internal/modules/promotions/entity"
+	"cbs_backend/pkg/configs"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultRenderBatchSize = 50
+	invoiceContentType     = "application/pdf"
+)
+
+type invoiceService struct {
+	db            *gorm.DB
+	logger        *zap.Logger
+	storage       *invoiceStorage
+	taxPercentage float64
+	issuerName    string
+}
+
+func NewInvoiceService(db *gorm.DB, logger *zap.Logger, cfg *configs.PaymentConfig) *invoiceService {
+	taxPercentage := cfg.InvoiceTaxPercentage
+	if taxPercentage < 0 || taxPercentage > 100 {
+		logger.Warn("⚠️ Invalid invoice tax percentage, fallback to 0", zap.Float64("tax_percentage", taxPercentage))
+		taxPercentage = 0
+	}
+	return &invoiceService{
+		db:            db,
+		logger:        logger,
+		storage:       newInvoiceStorage(cfg.InvoiceStorageDir),
+		taxPercentage: taxPercentage,
+		issuerName:    cfg.InvoiceIssuerName,
+	}
+}
+
+// IssueForTransaction tạo hoá đơn cho giao dịch thanh toán; gọi lại nhiều lần cho cùng giao dịch chỉ trả về hoá đơn đã có
+func (is *invoiceService) IssueForTransaction(ctx context.Context, tx *gorm.DB, txn *entityPayment.PaymentTransaction) (*entityInvoice.Invoice, error) {
+	if txn.TransactionType != common.TransactionTypePayment {
+		return nil, fmt.Errorf("invoices are only issued for payment transactions")
+	}
+
+	var existing entityInvoice.Invoice
+	err := tx.WithContext(ctx).First(&existing, "transaction_id = ?", txn.TransactionID).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check existing invoice: %w", err)
+	}
+
+	var booking entityBooking.ConsultationBooking
+	if err := tx.WithContext(ctx).
+		Preload("User").
+		Preload("ExpertProfile.User").
+		First(&booking, "booking_id = ?", txn.BookingID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get booking for invoice: %w", err)
+	}
+
+	currency := common.NormalizeCurrency(txn.Currency)
+	totalMinor := txn.AmountMinor
+
+	// Tiền giảm lấy từ lượt dùng mã của booking (nếu có)
+	var discountMinor int64
+	var couponCode *string
+	var redemption entityPromotion.CouponRedemption
+	err = tx.WithContext(ctx).Preload("Coupon").
+		Where("booking_id = ? AND redemption_status <> ?", txn.BookingID, common.RedemptionStatusReleased).
+		First(&redemption).Error
+	if err == nil {
+		discountMinor = redemption.DiscountAmountMinor
+		if redemption.Coupon != nil {
+			code := redemption.Coupon.CouponCode
+			couponCode = &code
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get coupon redemption: %w", err)
+	}
+
+	number, err := is.nextInvoiceNumber(ctx, tx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := entityInvoice.Invoice{
+		InvoiceNumber:    number,
+		BookingID:        booking.BookingID,
+		TransactionID:    txn.TransactionID,
+		UserID:           booking.UserID,
+		ExpertProfileID:  booking.ExpertProfileID,
+		CustomerName:     booking.User.FullName,
+		CustomerEmail:    booking.User.UserEmail,
+		ConsultationType: booking.ConsultationType,
+		BookingDatetime:  booking.BookingDatetime,
+		DurationMinutes:  booking.DurationMinutes,
+		Currency:         currency,
+		SubtotalMinor:    totalMinor + discountMinor,
+		DiscountMinor:    discountMinor,
+		CouponCode:       couponCode,
+		TaxPercentage:    is.taxPercentage,
+		TaxMinor:         includedTax(totalMinor, is.taxPercentage),
+		TotalMinor:       totalMinor,
+		IssuedAt:         time.Now(),
+	}
+	if booking.ExpertProfile.User != nil {
+		invoice.ExpertName = booking.ExpertProfile.User.FullName
+	}
+	if err := tx.WithContext(ctx).Create(&invoice).Error; err != nil {
+		return nil, fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	is.logger.Info("🧾 Invoice issued",
+		zap.String("invoice_number", invoice.InvoiceNumber),
+		zap.String("booking_id", invoice.BookingID.String()),
+		zap.String("transaction_id", invoice.TransactionID.String()))
+	return &invoice, nil
+}
+
+// nextInvoiceNumber cấp số hoá đơn liên tục theo năm, dạng INV-2025-000001
+func (is *invoiceService) nextInvoiceNumber(ctx context.Context, tx *gorm.DB, at time.Time) (string, error) {
+	year := at.Year()
+	if err := tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entityInvoice.InvoiceSequence{Year: year}).Error; err != nil {
+		return "", fmt.Errorf("failed to init invoice sequence: %w", err)
+	}
+
+	var seq entityInvoice.InvoiceSequence
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&seq, "year = ?", year).Error; err != nil {
+		return "", fmt.Errorf("failed to lock invoice sequence: %w", err)
+	}
+	seq.LastNumber++
+	if err := tx.WithContext(ctx).Model(&entityInvoice.InvoiceSequence{}).
+		Where("year = ?", year).
+		Update("last_number", seq.LastNumber).Error; err != nil {
+		return "", fmt.Errorf("failed to update invoice sequence: %w", err)
+	}
+	return fmt.Sprintf("INV-%d-%06d", year, seq.LastNumber), nil
+}
+
+func (is *invoiceService) RenderPendingInvoices(ctx context.Context, limit int) (*dtoinvoices.RenderPendingInvoicesResponse, error) {
+	if limit <= 0 {
+		limit = defaultRenderBatchSize
+	}
+
+	var pending []entityInvoice.Invoice
+	if err := is.db.WithContext(ctx).
+		Where("file_path IS NULL").
+		Order("issued_at ASC").
+		Limit(limit).
+		Find(&pending).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pending invoices: %w", err)
+	}
+
+	res := &dtoinvoices.RenderPendingInvoicesResponse{}
+	for i := range pending {
+		invoice := &pending[i]
+		if _, err := is.renderAndStore(ctx, invoice); err != nil {
+			is.logger.Error("Failed to render invoice", zap.String("invoice_number", invoice.InvoiceNumber), zap.Error(err))
+			res.Failed++
+			continue
+		}
+		res.Rendered++
+
+		if invoice.ConfirmationSentAt == nil {
+			is.sendConfirmationWithInvoice(ctx, invoice)
+		}
+	}
+	return res, nil
+}
+
+// renderAndStore render PDF, ghi ra storage và lưu đường dẫn vào hoá đơn
+func (is *invoiceService) renderAndStore(ctx context.Context, invoice *entityInvoice.Invoice) ([]byte, error) {
+	content, err := renderInvoicePDF(invoice, is.issuerName)
+	if err != nil {
+		return nil, err
+	}
+	path, err := is.storage.Save(invoice.InvoiceNumber, content)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := is.db.WithContext(ctx).Model(&entityInvoice.Invoice{}).
+		Where("invoice_id = ?", invoice.InvoiceID).
+		Updates(map[string]interface{}{
+			"file_path":         path,
+			"file_generated_at": now,
+		}).Error; err != nil {
+		return nil, fmt.Errorf("failed to save invoice file path: %w", err)
+	}
+	invoice.FilePath = &path
+	invoice.FileGeneratedAt = &now
+	return content, nil
+}
+
+// sendConfirmationWithInvoice phát lại sự kiện xác nhận booking sau khi thanh toán; email xác nhận sẽ đính kèm PDF hoá đơn
+func (is *invoiceService) sendConfirmationWithInvoice(ctx context.Context, invoice *entityInvoice.Invoice) {
+	var booking entityBooking.ConsultationBooking
+	if err := is.db.WithContext(ctx).
+		Preload("ExpertProfile").
+		First(&booking, "booking_id = ?", invoice.BookingID).Error; err != nil {
+		is.logger.Warn("Failed to load booking for invoice confirmation", zap.String("booking_id", invoice.BookingID.String()), zap.Error(err))
+		return
+	}
+
+	event := kafka.BookingCreatedEvent{
+		UserID:           invoice.UserID.String(),
+		BookingID:        invoice.BookingID.String(),
+		ExpertID:         invoice.ExpertProfileID.String(),
+		DoctorName:       invoice.ExpertName,
+		DoctorSpecialty:  booking.ExpertProfile.SpecializationList,
+		ConsultationDate: booking.BookingDatetime.Format("2006-01-02"),
+		ConsultationTime: booking.BookingDatetime.Format("15:04"),
+		Duration:         booking.DurationMinutes,
+		ConsultationType: booking.ConsultationType,
+		Amount:           common.FromMinorUnits(invoice.TotalMinor, invoice.Currency),
+		PaymentStatus:    booking.PaymentStatus,
+		Email:            invoice.CustomerEmail,
+		FullName:         invoice.CustomerName,
+	}
+	if booking.MeetingAddress != nil {
+		event.Location = *booking.MeetingAddress
+	}
+	if booking.MeetingLink != nil {
+		event.MeetingLink = *booking.MeetingLink
+	}
+	if booking.UserNotes != nil {
+		event.BookingNotes = *booking.UserNotes
+	}
+	if err := kafka.PublishBookingCreatedEvent(event); err != nil {
+		is.logger.Warn("Failed to publish booking confirmation with invoice", zap.String("invoice_number", invoice.InvoiceNumber), zap.Error(err))
+		return
+	}
+
+	if err := is.db.WithContext(ctx).Model(&entityInvoice.Invoice{}).
+		Where("invoice_id = ?", invoice.InvoiceID).
+		Update("confirmation_sent_at", time.Now()).Error; err != nil {
+		is.logger.Warn("Failed to mark invoice confirmation sent", zap.String("invoice_number", invoice.InvoiceNumber), zap.Error(err))
+	}
+}
+
+func (is *invoiceService) GetBookingInvoices(ctx context.Context, req dtoinvoices.GetBookingInvoicesRequest) (*dtoinvoices.GetBookingInvoicesResponse, error) {
+	bookingID, err := is.authorizeBooking(ctx, req.BookingID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var invoices []entityInvoice.Invoice
+	if err := is.db.WithContext(ctx).
+		Where("booking_id = ?", bookingID).
+		Order("issued_at DESC").
+		Find(&invoices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get invoices: %w", err)
+	}
+
+	res := &dtoinvoices.GetBookingInvoicesResponse{Invoices: make([]dtoinvoices.InvoiceResponse, 0, len(invoices))}
+	for i := range invoices {
+		res.Invoices = append(res.Invoices, toInvoiceResponse(&invoices[i]))
+	}
+	return res, nil
+}
+
+// DownloadInvoice trả về file PDF đã lưu; nếu file chưa được render hoặc bị mất thì render lại từ dữ liệu hoá đơn
+func (is *invoiceService) DownloadInvoice(ctx context.Context, req dtoinvoices.DownloadInvoiceRequest) (*dtoinvoices.InvoiceFile, error) {
+	bookingID, err := is.authorizeBooking(ctx, req.BookingID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := is.db.WithContext(ctx).Where("booking_id = ?", bookingID)
+	if req.InvoiceID != "" {
+		invoiceID, err := uuid.Parse(req.InvoiceID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid invoice ID format: %w", err)
+		}
+		query = query.Where("invoice_id = ?", invoiceID)
+	}
+	var invoice entityInvoice.Invoice
+	if err := query.Order("issued_at DESC").First(&invoice).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("invoice not found")
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	var content []byte
+	if invoice.FilePath != nil {
+		content, err = is.storage.Load(*invoice.FilePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if content == nil {
+		if content, err = is.renderAndStore(ctx, &invoice); err != nil {
+			return nil, err
+		}
+	}
+
+	return &dtoinvoices.InvoiceFile{
+		FileName:    invoice.InvoiceNumber + ".pdf",
+		ContentType: invoiceContentType,
+		Content:     content,
+	}, nil
+}
+
+// authorizeBooking chỉ cho người đặt lịch xem hoá đơn của booking
+func (is *invoiceService) authorizeBooking(ctx context.Context, bookingIDStr, userIDStr string) (uuid.UUID, error) {
+	bookingID, err := uuid.Parse(bookingIDStr)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid booking ID format: %w", err)
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	var booking entityBooking.ConsultationBooking
+	if err := is.db.WithContext(ctx).Select("booking_id", "user_id").
+		First(&booking, "booking_id = ?", bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, fmt.Errorf("booking not found")
+		}
+		return uuid.Nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	if booking.UserID != userID {
+		return uuid.Nil, fmt.Errorf("unauthorized")
+	}
+	return bookingID, nil
+}
+
+// includedTax tách phần thuế đã nằm trong tổng tiền: total * rate / (100 + rate)
+func includedTax(totalMinor int64, taxPercentage float64) int64 {
+	if taxPercentage <= 0 {
+		return 0
+	}
+	return int64(math.Round(float64(totalMinor) * taxPercentage / (100 + taxPercentage)))
+}
+
+func toInvoiceResponse(invoice *entityInvoice.Invoice) dtoinvoices.InvoiceResponse {
+	return dtoinvoices.InvoiceResponse{
+		InvoiceID:        invoice.InvoiceID.String(),
+		InvoiceNumber:    invoice.InvoiceNumber,
+		BookingID:        invoice.BookingID.String(),
+		TransactionID:    invoice.TransactionID.String(),
+		CustomerName:     invoice.CustomerName,
+		ExpertName:       invoice.ExpertName,
+		ConsultationType: invoice.ConsultationType,
+		BookingDatetime:  invoice.BookingDatetime,
+		DurationMinutes:  invoice.DurationMinutes,
+		Currency:         invoice.Currency,
+		Subtotal:         common.FromMinorUnits(invoice.SubtotalMinor, invoice.Currency),
+		Discount:         common.FromMinorUnits(invoice.DiscountMinor, invoice.Currency),
+		CouponCode:       invoice.CouponCode,
+		TaxPercentage:    invoice.TaxPercentage,
+		Tax:              common.FromMinorUnits(invoice.TaxMinor, invoice.Currency),
+		Total:            common.FromMinorUnits(invoice.TotalMinor, invoice.Currency),
+		IssuedAt:         invoice.IssuedAt,
+		FileReady:        invoice.FilePath != nil,
+	}
+}
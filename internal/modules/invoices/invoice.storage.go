@@ -0,0 +1,43 @@
+package invoices
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// invoiceStorage lưu file PDF hoá đơn trên ổ đĩa local, mỗi hoá đơn một file theo số hoá đơn
+type invoiceStorage struct {
+	dir string
+}
+
+func newInvoiceStorage(dir string) *invoiceStorage {
+	if dir == "" {
+		dir = "storage/invoices"
+	}
+	return &invoiceStorage{dir: dir}
+}
+
+func (s *invoiceStorage) Save(invoiceNumber string, content []byte) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create invoice storage dir: %w", err)
+	}
+	path := filepath.Join(s.dir, invoiceNumber+".pdf")
+	// Ghi ra file tạm rồi rename để không bao giờ đọc phải file ghi dở
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write invoice file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("failed to store invoice file: %w", err)
+	}
+	return path, nil
+}
+
+func (s *invoiceStorage) Load(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read invoice file: %w", err)
+	}
+	return content, nil
+}
@@ -0,0 +1,9 @@
+package dtopayments
+
+type CreatePaymentRequest struct {
+	BookingID     string `json:"booking_id" binding:"required"`
+	UserID        string `json:"-"` // Lấy từ context, không nhận từ body
+	PaymentMethod string `json:"payment_method" binding:"required"`
+	Currency      string `json:"currency,omitempty"`
+	Gateway       string `json:"gateway,omitempty"` // Bỏ trống để dùng gateway mặc định
+}
@@ -0,0 +1,17 @@
+package dtopayments
+
+type GetPaymentByIDRequest struct {
+	TransactionID string `form:"transaction_id" binding:"required"`
+	UserID        string `form:"-"`
+}
+
+type GetPaymentsByBookingRequest struct {
+	BookingID string `form:"booking_id" binding:"required"`
+	UserID    string `form:"-"`
+}
+
+type GetPaymentsByBookingResponse struct {
+	BookingID    string            `json:"booking_id"`
+	Transactions []PaymentResponse `json:"transactions"`
+	Total        int               `json:"total"`
+}
@@ -0,0 +1,22 @@
+package dtopayments
+
+import "time"
+
+type PaymentResponse struct {
+	TransactionID         string     `json:"transaction_id"`
+	BookingID             string     `json:"booking_id"`
+	UserID                string     `json:"user_id"`
+	ExpertProfileID       string     `json:"expert_profile_id"`
+	TransactionType       string     `json:"transaction_type"`
+	ParentTransactionID   string     `json:"parent_transaction_id,omitempty"`
+	Amount                float64    `json:"amount"`
+	Currency              string     `json:"currency"`
+	PaymentMethod         string     `json:"payment_method,omitempty"`
+	TransactionStatus     string     `json:"transaction_status"`
+	BookingPaymentStatus  string     `json:"booking_payment_status"`
+	ExternalTransactionID string     `json:"external_transaction_id,omitempty"`
+	PaymentGateway        string     `json:"payment_gateway,omitempty"`
+	PaymentURL            string     `json:"payment_url,omitempty"`
+	ProcessedAt           *time.Time `json:"processed_at,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package dtopayments
+
+type PaymentWebhookRequest struct {
+	Gateway   string
+	Signature string
+	Body      []byte
+}
+
+type PaymentWebhookResponse struct {
+	EventID           string `json:"event_id"`
+	TransactionID     string `json:"transaction_id,omitempty"`
+	TransactionStatus string `json:"transaction_status,omitempty"`
+	Applied           bool   `json:"applied"`   // Trạng thái giao dịch đã được cập nhật bởi event này
+	Duplicate         bool   `json:"duplicate"` // Event đã được xử lý trước đó
+}
@@ -0,0 +1,20 @@
+package dtopayments
+
+import "time"
+
+type RefundBookingRequest struct {
+	BookingID   string
+	CancelledBy string // common.CancelledByUser / CancelledByExpert / CancelledBySystem
+	Reason      string
+	CancelledAt time.Time
+}
+
+type RefundBookingResponse struct {
+	BookingID             string                  `json:"booking_id"`
+	RefundPercentage      float64                 `json:"refund_percentage"`
+	RefundAmount          float64                 `json:"refund_amount"`
+	RefundProcessDays     int                     `json:"refund_process_days"`
+	PolicyDescription     string                  `json:"policy_description"`
+	Refunds               []RefundPaymentResponse `json:"refunds,omitempty"`
+	CancelledTransactions []string                `json:"cancelled_transactions,omitempty"`
+}
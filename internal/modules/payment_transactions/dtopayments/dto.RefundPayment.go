@@ -0,0 +1,13 @@
+package dtopayments
+
+type RefundPaymentRequest struct {
+	TransactionID string  `json:"transaction_id" binding:"required"`
+	Amount        float64 `json:"amount,omitempty"` // Bỏ trống để hoàn toàn bộ số tiền còn lại
+	Reason        string  `json:"reason,omitempty"`
+}
+
+type RefundPaymentResponse struct {
+	OriginalTransaction PaymentResponse `json:"original_transaction"`
+	RefundTransaction   PaymentResponse `json:"refund_transaction"`
+	RefundedTotal       float64         `json:"refunded_total"`
+}
@@ -0,0 +1,21 @@
+package dtopayments
+
+// RefundTier: hủy trước buổi tư vấn ít nhất MinHoursBefore giờ thì được hoàn RefundPercentage %
+type RefundTier struct {
+	MinHoursBefore   float64 `json:"min_hours_before"`
+	RefundPercentage float64 `json:"refund_percentage"`
+}
+
+// RefundPolicy được lưu trong tbl_system_settings (key cancellation_refund_policy)
+type RefundPolicy struct {
+	Tiers                  []RefundTier `json:"tiers" binding:"required"`
+	ExpertRefundPercentage float64      `json:"expert_refund_percentage"` // Expert từ chối/hủy lịch
+	SystemRefundPercentage float64      `json:"system_refund_percentage"` // Hệ thống tự hủy (trùng lịch...)
+	MinCancelHoursBefore   float64      `json:"min_cancel_hours_before"`  // User không được hủy sát giờ hơn mốc này
+	RefundProcessDays      int          `json:"refund_process_days"`
+}
+
+type RefundPolicyResponse struct {
+	Policy      RefundPolicy `json:"policy"`
+	Description string       `json:"description"`
+}
@@ -0,0 +1,12 @@
+package dtopayments
+
+type UpdatePaymentStatusRequest struct {
+	TransactionID string `json:"transaction_id" binding:"required"`
+	Status        string `json:"status" binding:"required"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+type CancelPaymentRequest struct {
+	TransactionID string `json:"transaction_id" binding:"required"`
+	UserID        string `json:"-"`
+}
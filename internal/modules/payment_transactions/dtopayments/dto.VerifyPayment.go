@@ -0,0 +1,6 @@
+package dtopayments
+
+type VerifyPaymentRequest struct {
+	TransactionID string `json:"transaction_id" binding:"required"`
+	UserID        string `json:"-"`
+}
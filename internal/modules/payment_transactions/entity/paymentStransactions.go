@@ -17,7 +17,9 @@ type PaymentTransaction struct {
 	BookingID             uuid.UUID    `json:"booking_id" db:"booking_id" gorm:"type:uuid;not null;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	UserID                uuid.UUID    `json:"user_id" db:"user_id" gorm:"type:uuid;not null;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	ExpertProfileID       uuid.UUID    `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;not null;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	Amount                float64      `json:"amount" db:"amount" gorm:"type:decimal(10,2);not null"`
+	TransactionType       string       `json:"transaction_type" db:"transaction_type" gorm:"type:varchar(20);not null;default:'payment';check:transaction_type IN ('payment', 'refund')"`
+	ParentTransactionID   *uuid.UUID   `json:"parent_transaction_id,omitempty" db:"parent_transaction_id" gorm:"type:uuid"` // Giao dịch gốc của một khoản hoàn tiền
+	AmountMinor           int64        `json:"amount_minor" db:"amount_minor" gorm:"type:bigint;not null;default:0"`        // Đơn vị nhỏ nhất của Currency
 	Currency              string       `json:"currency" db:"currency" gorm:"type:varchar(3);default:'VND'"`
 	PaymentMethod         *string      `json:"payment_method,omitempty" db:"payment_method" gorm:"type:varchar(50)"`
 	TransactionStatus     string       `json:"transaction_status" db:"transaction_status" gorm:"type:varchar(20);default:'pending';check:transaction_status IN ('pending', 'processing', 'completed', 'failed', 'refunded', 'cancelled')"`
@@ -36,3 +38,8 @@ type PaymentTransaction struct {
 func (PaymentTransaction) TableName() string {
 	return "tbl_payment_transactions"
 }
+
+// Amount trả về số tiền giao dịch dạng thập phân theo Currency (để hiển thị/gửi gateway)
+func (t *PaymentTransaction) Amount() float64 {
+	return common.FromMinorUnits(t.AmountMinor, t.Currency)
+}
@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentWebhookEvent represents tbl_payment_webhook_events table
+// Mỗi event của gateway chỉ được ghi một lần (unique gateway + event_id) để chống xử lý trùng.
+type PaymentWebhookEvent struct {
+	WebhookEventID uuid.UUID  `json:"webhook_event_id" db:"webhook_event_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	PaymentGateway string     `json:"payment_gateway" db:"payment_gateway" gorm:"type:varchar(50);not null;uniqueIndex:idx_webhook_gateway_event"`
+	EventID        string     `json:"event_id" db:"event_id" gorm:"type:varchar(255);not null;uniqueIndex:idx_webhook_gateway_event"`
+	TransactionID  *uuid.UUID `json:"transaction_id,omitempty" db:"transaction_id" gorm:"type:uuid"`
+	EventStatus    string     `json:"event_status" db:"event_status" gorm:"type:varchar(20);not null"`
+	Applied        bool       `json:"applied" db:"applied" gorm:"default:false"` // Event có làm thay đổi trạng thái giao dịch hay không
+	ReceivedAt     time.Time  `json:"received_at" db:"received_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (PaymentWebhookEvent) TableName() string {
+	return "tbl_payment_webhook_events"
+}
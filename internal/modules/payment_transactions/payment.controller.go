@@ -1 +1,218 @@
 package paymenttransactions
+
+import (
+	"cbs_backend/internal/modules/payment_transactions/dtopayments"
+	servicePayment "cbs_backend/internal/service/payment"
+	"cbs_backend/pkg/response"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type PaymentController struct {
+	Logger *zap.Logger
+}
+
+func NewPaymentController(logger *zap.Logger) *PaymentController {
+	return &PaymentController{Logger: logger}
+}
+
+// getUserIDFromContext lấy userID đã được AuthMiddleware gắn vào context
+func getUserIDFromContext(c *gin.Context) (string, error) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		return "", response.NewAPIError(http.StatusUnauthorized, "Unauthorized", "UserID not found in context")
+	}
+	userID, ok := userIDValue.(uuid.UUID)
+	if !ok {
+		return "", response.NewAPIError(http.StatusInternalServerError, "Internal error", "Invalid userID type")
+	}
+	return userID.String(), nil
+}
+
+func (pc *PaymentController) CreatePayment(c *gin.Context) (res interface{}, err error) {
+	var req dtopayments.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.Logger.Error("Invalid create payment request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid create payment request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Payment().CreatePayment(context.Background(), req)
+	if errors.Is(err, ErrPaymentsDisabled) {
+		pc.Logger.Warn("Create payment rejected", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusServiceUnavailable, "Payments are disabled", err)
+	}
+	if err != nil {
+		pc.Logger.Error("Create payment failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Create payment failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PaymentController) GetPaymentByID(c *gin.Context) (res interface{}, err error) {
+	var req dtopayments.GetPaymentByIDRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		pc.Logger.Error("Invalid get payment request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get payment request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Payment().GetPaymentByID(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Get payment failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get payment failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PaymentController) GetPaymentsByBooking(c *gin.Context) (res interface{}, err error) {
+	var req dtopayments.GetPaymentsByBookingRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		pc.Logger.Error("Invalid get booking payments request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get booking payments request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Payment().GetPaymentsByBooking(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Get booking payments failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get booking payments failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PaymentController) CancelPayment(c *gin.Context) (res interface{}, err error) {
+	var req dtopayments.CancelPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.Logger.Error("Invalid cancel payment request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid cancel payment request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Payment().CancelPayment(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Cancel payment failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Cancel payment failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PaymentController) UpdatePaymentStatus(c *gin.Context) (res interface{}, err error) {
+	var req dtopayments.UpdatePaymentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.Logger.Error("Invalid update payment status request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid update payment status request", err)
+	}
+
+	resp, err := Payment().UpdatePaymentStatus(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Update payment status failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Update payment status failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PaymentController) VerifyPayment(c *gin.Context) (res interface{}, err error) {
+	var req dtopayments.VerifyPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.Logger.Error("Invalid verify payment request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid verify payment request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Payment().VerifyPayment(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Verify payment failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Verify payment failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PaymentController) RefundPayment(c *gin.Context) (res interface{}, err error) {
+	var req dtopayments.RefundPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.Logger.Error("Invalid refund payment request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid refund payment request", err)
+	}
+
+	resp, err := Payment().RefundPayment(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Refund payment failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Refund payment failed", err)
+	}
+
+	return resp, nil
+}
+
+// HandleWebhook nhận callback từ gateway; body phải được đọc nguyên vẹn để kiểm tra chữ ký
+func (pc *PaymentController) HandleWebhook(c *gin.Context) (res interface{}, err error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		pc.Logger.Error("Invalid payment webhook body", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid payment webhook body", err)
+	}
+
+	req := dtopayments.PaymentWebhookRequest{
+		Gateway:   c.Param("gateway"),
+		Signature: c.GetHeader(servicePayment.WebhookSignatureHeader),
+		Body:      body,
+	}
+
+	resp, err := Payment().HandleWebhook(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Handle payment webhook failed", zap.Error(err))
+		if errors.Is(err, ErrInvalidWebhookSignature) {
+			return nil, response.NewAPIError(http.StatusUnauthorized, "Invalid webhook signature", err)
+		}
+		return nil, response.NewAPIError(http.StatusBadRequest, "Handle payment webhook failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PaymentController) GetRefundPolicy(c *gin.Context) (res interface{}, err error) {
+	resp, err := Payment().GetRefundPolicy(context.Background())
+	if err != nil {
+		pc.Logger.Error("Get refund policy failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusInternalServerError, "Get refund policy failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PaymentController) UpdateRefundPolicy(c *gin.Context) (res interface{}, err error) {
+	var req dtopayments.RefundPolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.Logger.Error("Invalid update refund policy request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid update refund policy request", err)
+	}
+
+	resp, err := Payment().UpdateRefundPolicy(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Update refund policy failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Update refund policy failed", err)
+	}
+
+	return resp, nil
+}
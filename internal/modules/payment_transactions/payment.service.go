@@ -1 +1,40 @@
 package paymenttransactions
+
+import (
+	"cbs_backend/internal/modules/payment_transactions/dtopayments"
+	"cbs_backend/internal/service/interfaces"
+	"cbs_backend/pkg/configs"
+	"context"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	iPaymentService IPayments
+)
+
+func InitPaymentService(db *gorm.DB, logger *zap.Logger, cfg *configs.PaymentConfig, gateways ...interfaces.PaymentGateway) {
+	iPaymentService = NewPaymentService(db, logger, cfg, gateways...)
+}
+
+func Payment() IPayments {
+	if iPaymentService == nil {
+		panic("PaymentService not initialized. Call InitPaymentService(db, logger, cfg, gateways...) first.")
+	}
+	return iPaymentService
+}
+
+type IPayments interface {
+	CreatePayment(ctx context.Context, req dtopayments.CreatePaymentRequest) (*dtopayments.PaymentResponse, error)
+	GetPaymentByID(ctx context.Context, req dtopayments.GetPaymentByIDRequest) (*dtopayments.PaymentResponse, error)
+	GetPaymentsByBooking(ctx context.Context, req dtopayments.GetPaymentsByBookingRequest) (*dtopayments.GetPaymentsByBookingResponse, error)
+	CancelPayment(ctx context.Context, req dtopayments.CancelPaymentRequest) (*dtopayments.PaymentResponse, error)
+	VerifyPayment(ctx context.Context, req dtopayments.VerifyPaymentRequest) (*dtopayments.PaymentResponse, error)
+	UpdatePaymentStatus(ctx context.Context, req dtopayments.UpdatePaymentStatusRequest) (*dtopayments.PaymentResponse, error)
+	RefundPayment(ctx context.Context, req dtopayments.RefundPaymentRequest) (*dtopayments.RefundPaymentResponse, error)
+	RefundBooking(ctx context.Context, req dtopayments.RefundBookingRequest) (*dtopayments.RefundBookingResponse, error)
+	GetRefundPolicy(ctx context.Context) (*dtopayments.RefundPolicyResponse, error)
+	UpdateRefundPolicy(ctx context.Context, req dtopayments.RefundPolicy) (*dtopayments.RefundPolicyResponse, error)
+	HandleWebhook(ctx context.Context, req dtopayments.PaymentWebhookRequest) (*dtopayments.PaymentWebhookResponse, error)
+}
@@ -1 +1,1007 @@
 package paymenttransactions
+
+import (
+	"cbs_backend/internal/common"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/internal/modules/invoices"
+	"cbs_backend/internal/modules/payment_transactions/dtopayments"
+	entityPayment "cbs_backend/internal/modules/payment_transactions/entity"
+	"cbs_backend/internal/modules/payouts"
+	"cbs_backend/internal/modules/promotions"
+	entitySystem "cbs_backend/internal/modules/system_setting/entity"
+	"cbs_backend/internal/service/interfaces"
+	servicePayment "cbs_backend/internal/service/payment"
+	"cbs_backend/pkg/configs"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type paymentService struct {
+	db       *gorm.DB
+	logger   *zap.Logger
+	cfg      *configs.PaymentConfig
+	gateways map[string]interfaces.PaymentGateway
+}
+
+func NewPaymentService(db *gorm.DB, logger *zap.Logger, cfg *configs.PaymentConfig, gateways ...interfaces.PaymentGateway) *paymentService {
+	if cfg == nil {
+		cfg = &configs.PaymentConfig{}
+	}
+	registry := make(map[string]interfaces.PaymentGateway, len(gateways))
+	for _, gw := range gateways {
+		registry[gw.Name()] = gw
+	}
+	return &paymentService{
+		db:       db,
+		logger:   logger,
+		cfg:      cfg,
+		gateways: registry,
+	}
+}
+
+// ErrPaymentsDisabled được trả về khi server chạy mà không có payment gateway nào được cấu hình
+var ErrPaymentsDisabled = errors.New("payments are currently disabled: no payment gateway is configured")
+
+// getGateway trả về gateway theo tên, rỗng thì dùng gateway mặc định trong config
+func (ps *paymentService) getGateway(name string) (interfaces.PaymentGateway, error) {
+	if len(ps.gateways) == 0 {
+		return nil, ErrPaymentsDisabled
+	}
+	if name == "" {
+		name = ps.cfg.DefaultGateway
+	}
+	gw, ok := ps.gateways[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported payment gateway: %s", name)
+	}
+	return gw, nil
+}
+
+// Các bước chuyển trạng thái hợp lệ của một giao dịch qua webhook, đối soát gateway và cập nhật thủ công.
+// failed, refunded, cancelled là trạng thái cuối, không chuyển tiếp được nữa.
+// completed -> refunded không nằm ở đây: hoàn tiền chỉ đi qua RefundPayment để luôn tạo giao dịch hoàn
+// và trừ payout của expert, webhook/đối soát báo refunded chỉ được ghi nhận chứ không áp dụng.
+var transactionTransitions = map[string][]string{
+	common.TransactionStatusPending: {
+		common.TransactionStatusProcessing,
+		common.TransactionStatusCompleted,
+		common.TransactionStatusFailed,
+		common.TransactionStatusCancelled,
+	},
+	common.TransactionStatusProcessing: {
+		common.TransactionStatusCompleted,
+		common.TransactionStatusFailed,
+		common.TransactionStatusCancelled,
+	},
+}
+
+func canTransitionTransaction(from, to string) bool {
+	for _, next := range transactionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidTransactionStatus(status string) bool {
+	switch status {
+	case common.TransactionStatusPending,
+		common.TransactionStatusProcessing,
+		common.TransactionStatusCompleted,
+		common.TransactionStatusFailed,
+		common.TransactionStatusRefunded,
+		common.TransactionStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (ps *paymentService) CreatePayment(ctx context.Context, req dtopayments.CreatePaymentRequest) (*dtopayments.PaymentResponse, error) {
+	// 1. Validate input
+	bookingID, err := uuid.Parse(req.BookingID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid booking ID format: %w", err)
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+	requestedCurrency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if requestedCurrency != "" && !common.IsSupportedCurrency(requestedCurrency) {
+		return nil, fmt.Errorf("unsupported currency: %s", requestedCurrency)
+	}
+	method := strings.TrimSpace(req.PaymentMethod)
+	gateway, err := ps.getGateway(req.Gateway)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		txn     entityPayment.PaymentTransaction
+		booking entityBooking.ConsultationBooking
+	)
+
+	// 2. Transaction: khoá booking để tránh tạo 2 giao dịch song song
+	err = ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&booking, "booking_id = ?", bookingID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("booking not found")
+			}
+			return fmt.Errorf("failed to get booking: %w", err)
+		}
+
+		if booking.UserID != userID {
+			return fmt.Errorf("unauthorized: only the booking owner can pay for this booking")
+		}
+		if booking.BookingStatus == common.BookingStatusCancelled || booking.BookingStatus == common.BookingStatusRejected {
+			return fmt.Errorf("cannot pay for a %s booking", booking.BookingStatus)
+		}
+		if booking.PaymentStatus == common.PaymentStatusPaid {
+			return fmt.Errorf("booking has already been paid")
+		}
+		if booking.ConsultationFeeMinor == nil || *booking.ConsultationFeeMinor <= 0 {
+			return fmt.Errorf("booking has no consultation fee to pay")
+		}
+		// Giao dịch luôn dùng tiền tệ của booking, không cho client tự đổi
+		if requestedCurrency != "" && requestedCurrency != booking.Currency {
+			return fmt.Errorf("currency mismatch: booking is priced in %s", booking.Currency)
+		}
+
+		// Mỗi booking chỉ có tối đa 1 giao dịch đang mở
+		var openCount int64
+		if err := tx.Model(&entityPayment.PaymentTransaction{}).
+			Where("booking_id = ? AND transaction_type = ? AND transaction_status IN ?", bookingID, common.TransactionTypePayment,
+				[]string{common.TransactionStatusPending, common.TransactionStatusProcessing}).
+			Count(&openCount).Error; err != nil {
+			return fmt.Errorf("failed to check open transactions: %w", err)
+		}
+		if openCount > 0 {
+			return fmt.Errorf("booking already has an open payment transaction")
+		}
+
+		txn = entityPayment.PaymentTransaction{
+			TransactionType:   common.TransactionTypePayment,
+			BookingID:         booking.BookingID,
+			UserID:            booking.UserID,
+			ExpertProfileID:   booking.ExpertProfileID,
+			AmountMinor:       *booking.ConsultationFeeMinor,
+			Currency:          booking.Currency,
+			PaymentMethod:     &method,
+			TransactionStatus: common.TransactionStatusPending,
+		}
+		if err := tx.Create(&txn).Error; err != nil {
+			return fmt.Errorf("failed to create payment transaction: %w", err)
+		}
+		// Gắn giao dịch vào lượt dùng mã khuyến mãi (nếu có) để đo hiệu quả campaign
+		return promotions.Promotion().AttachTransaction(ctx, tx, booking.BookingID, txn.TransactionID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ps.logger.Info("💳 Payment transaction created",
+		zap.String("transaction_id", txn.TransactionID.String()),
+		zap.String("booking_id", txn.BookingID.String()),
+		zap.String("gateway", gateway.Name()),
+		zap.Float64("amount", txn.Amount()))
+
+	// 3. Khởi tạo phiên thanh toán phía gateway (ngoài DB transaction để không giữ lock khi gọi ra ngoài)
+	result, err := gateway.InitiatePayment(ctx, interfaces.GatewayInitiateRequest{
+		TransactionID: txn.TransactionID.String(),
+		BookingID:     txn.BookingID.String(),
+		UserID:        txn.UserID.String(),
+		Amount:        txn.Amount(),
+		Currency:      txn.Currency,
+		Description:   fmt.Sprintf("Thanh toán lịch tư vấn %s", txn.BookingID.String()),
+		ReturnURL:     ps.cfg.ReturnURL,
+	})
+	if err != nil {
+		ps.logger.Error("❌ Failed to initiate payment at gateway",
+			zap.String("transaction_id", txn.TransactionID.String()),
+			zap.String("gateway", gateway.Name()),
+			zap.Error(err))
+		if _, failErr := ps.applyStatus(ctx, txn.TransactionID, common.TransactionStatusFailed, "gateway initiate failed", nil); failErr != nil {
+			ps.logger.Error("Failed to mark transaction as failed", zap.Error(failErr))
+		}
+		return nil, fmt.Errorf("failed to initiate payment: %w", err)
+	}
+
+	// 4. Ghi lại thông tin gateway và chuyển trạng thái theo kết quả
+	var bookingPaymentStatus string
+	err = ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&txn, "transaction_id = ?", txn.TransactionID).Error; err != nil {
+			return fmt.Errorf("failed to reload payment transaction: %w", err)
+		}
+
+		gatewayName := gateway.Name()
+		txn.PaymentGateway = &gatewayName
+		txn.ExternalTransactionID = &result.ExternalTransactionID
+		txn.GatewayResponse = mergeGatewayResponse(txn.GatewayResponse, "initiate", result.RawResponse)
+		if err := tx.Model(&entityPayment.PaymentTransaction{}).
+			Where("transaction_id = ?", txn.TransactionID).
+			Updates(map[string]interface{}{
+				"payment_gateway":         gatewayName,
+				"external_transaction_id": result.ExternalTransactionID,
+				"gateway_response":        txn.GatewayResponse,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to save gateway response: %w", err)
+		}
+
+		bookingPaymentStatus = booking.PaymentStatus
+		if result.Status != txn.TransactionStatus && canTransitionTransaction(txn.TransactionStatus, result.Status) {
+			status, err := transitionTransaction(tx, &txn, result.Status)
+			if err != nil {
+				return err
+			}
+			bookingPaymentStatus = status
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := toPaymentResponse(&txn, bookingPaymentStatus)
+	res.PaymentURL = result.PaymentURL
+	return res, nil
+}
+
+func (ps *paymentService) GetPaymentByID(ctx context.Context, req dtopayments.GetPaymentByIDRequest) (*dtopayments.PaymentResponse, error) {
+	transactionID, err := uuid.Parse(req.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction ID format: %w", err)
+	}
+
+	var txn entityPayment.PaymentTransaction
+	if err := ps.db.WithContext(ctx).Preload("Booking").
+		First(&txn, "transaction_id = ?", transactionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("payment transaction not found")
+		}
+		return nil, fmt.Errorf("failed to get payment transaction: %w", err)
+	}
+	if txn.UserID.String() != req.UserID {
+		return nil, fmt.Errorf("unauthorized: you cannot view this payment transaction")
+	}
+
+	bookingPaymentStatus := ""
+	if txn.Booking != nil {
+		bookingPaymentStatus = txn.Booking.PaymentStatus
+	}
+	return toPaymentResponse(&txn, bookingPaymentStatus), nil
+}
+
+func (ps *paymentService) GetPaymentsByBooking(ctx context.Context, req dtopayments.GetPaymentsByBookingRequest) (*dtopayments.GetPaymentsByBookingResponse, error) {
+	bookingID, err := uuid.Parse(req.BookingID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid booking ID format: %w", err)
+	}
+
+	var booking entityBooking.ConsultationBooking
+	if err := ps.db.WithContext(ctx).First(&booking, "booking_id = ?", bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("booking not found")
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	if booking.UserID.String() != req.UserID {
+		return nil, fmt.Errorf("unauthorized: you cannot view payments of this booking")
+	}
+
+	var txns []entityPayment.PaymentTransaction
+	if err := ps.db.WithContext(ctx).
+		Where("booking_id = ?", bookingID).
+		Order("transaction_created_at DESC").
+		Find(&txns).Error; err != nil {
+		return nil, fmt.Errorf("failed to get payment transactions: %w", err)
+	}
+
+	res := &dtopayments.GetPaymentsByBookingResponse{
+		BookingID:    booking.BookingID.String(),
+		Transactions: make([]dtopayments.PaymentResponse, 0, len(txns)),
+		Total:        len(txns),
+	}
+	for i := range txns {
+		res.Transactions = append(res.Transactions, *toPaymentResponse(&txns[i], booking.PaymentStatus))
+	}
+	return res, nil
+}
+
+func (ps *paymentService) CancelPayment(ctx context.Context, req dtopayments.CancelPaymentRequest) (*dtopayments.PaymentResponse, error) {
+	transactionID, err := uuid.Parse(req.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction ID format: %w", err)
+	}
+
+	return ps.applyStatus(ctx, transactionID, common.TransactionStatusCancelled, "cancelled by user", func(txn *entityPayment.PaymentTransaction) error {
+		if txn.UserID.String() != req.UserID {
+			return fmt.Errorf("unauthorized: only the payer can cancel this transaction")
+		}
+		return nil
+	})
+}
+
+func (ps *paymentService) UpdatePaymentStatus(ctx context.Context, req dtopayments.UpdatePaymentStatusRequest) (*dtopayments.PaymentResponse, error) {
+	transactionID, err := uuid.Parse(req.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction ID format: %w", err)
+	}
+	if !isValidTransactionStatus(req.Status) {
+		return nil, fmt.Errorf("invalid transaction status: %s", req.Status)
+	}
+
+	return ps.applyStatus(ctx, transactionID, req.Status, req.Reason, nil)
+}
+
+func (ps *paymentService) VerifyPayment(ctx context.Context, req dtopayments.VerifyPaymentRequest) (*dtopayments.PaymentResponse, error) {
+	transactionID, err := uuid.Parse(req.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction ID format: %w", err)
+	}
+
+	var txn entityPayment.PaymentTransaction
+	if err := ps.db.WithContext(ctx).First(&txn, "transaction_id = ?", transactionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("payment transaction not found")
+		}
+		return nil, fmt.Errorf("failed to get payment transaction: %w", err)
+	}
+	if txn.UserID.String() != req.UserID {
+		return nil, fmt.Errorf("unauthorized: you cannot verify this payment transaction")
+	}
+	if txn.PaymentGateway == nil || txn.ExternalTransactionID == nil {
+		return nil, fmt.Errorf("payment transaction has not been sent to any gateway")
+	}
+
+	gateway, err := ps.getGateway(*txn.PaymentGateway)
+	if err != nil {
+		return nil, err
+	}
+	result, err := gateway.VerifyPayment(ctx, *txn.ExternalTransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify payment at gateway: %w", err)
+	}
+
+	var bookingPaymentStatus string
+	err = ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&txn, "transaction_id = ?", transactionID).Error; err != nil {
+			return fmt.Errorf("failed to reload payment transaction: %w", err)
+		}
+
+		txn.GatewayResponse = mergeGatewayResponse(txn.GatewayResponse, "verify", result.RawResponse)
+		if err := tx.Model(&entityPayment.PaymentTransaction{}).
+			Where("transaction_id = ?", txn.TransactionID).
+			Update("gateway_response", txn.GatewayResponse).Error; err != nil {
+			return fmt.Errorf("failed to save gateway response: %w", err)
+		}
+
+		if result.Status != txn.TransactionStatus && canTransitionTransaction(txn.TransactionStatus, result.Status) {
+			status, err := transitionTransaction(tx, &txn, result.Status)
+			if err != nil {
+				return err
+			}
+			bookingPaymentStatus = status
+			return nil
+		}
+
+		var booking entityBooking.ConsultationBooking
+		if err := tx.Select("payment_status").First(&booking, "booking_id = ?", txn.BookingID).Error; err != nil {
+			return fmt.Errorf("failed to get booking: %w", err)
+		}
+		bookingPaymentStatus = booking.PaymentStatus
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ps.logger.Info("🔎 Payment verified with gateway",
+		zap.String("transaction_id", txn.TransactionID.String()),
+		zap.String("gateway_status", result.Status),
+		zap.String("transaction_status", txn.TransactionStatus))
+
+	return toPaymentResponse(&txn, bookingPaymentStatus), nil
+}
+
+func (ps *paymentService) RefundPayment(ctx context.Context, req dtopayments.RefundPaymentRequest) (*dtopayments.RefundPaymentResponse, error) {
+	transactionID, err := uuid.Parse(req.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction ID format: %w", err)
+	}
+	if req.Amount < 0 {
+		return nil, fmt.Errorf("refund amount cannot be negative")
+	}
+
+	var original entityPayment.PaymentTransaction
+	if err := ps.db.WithContext(ctx).First(&original, "transaction_id = ?", transactionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("payment transaction not found")
+		}
+		return nil, fmt.Errorf("failed to get payment transaction: %w", err)
+	}
+	if original.TransactionType != common.TransactionTypePayment {
+		return nil, fmt.Errorf("only payment transactions can be refunded")
+	}
+	if original.TransactionStatus != common.TransactionStatusCompleted {
+		return nil, fmt.Errorf("cannot refund a %s transaction", original.TransactionStatus)
+	}
+	if original.PaymentGateway == nil || original.ExternalTransactionID == nil {
+		return nil, fmt.Errorf("payment transaction has not been sent to any gateway")
+	}
+
+	gateway, err := ps.getGateway(*original.PaymentGateway)
+	if err != nil {
+		return nil, err
+	}
+
+	// Số tiền còn được hoàn chỉ tính sau khi đã khoá giao dịch gốc: RefundBooking và endpoint /refund của admin
+	// có thể chạy đồng thời, tính ngoài khoá thì cả hai cùng thấy đủ tiền và hoàn quá số đã thu.
+	// Gateway được gọi trong lúc giữ khoá để hai lần hoàn không cùng gửi đi.
+	var (
+		amountMinor          int64
+		refundTxn            entityPayment.PaymentTransaction
+		bookingPaymentStatus string
+		refundedTotalMinor   int64
+	)
+	err = ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&original, "transaction_id = ?", transactionID).Error; err != nil {
+			return fmt.Errorf("failed to reload payment transaction: %w", err)
+		}
+		if original.TransactionStatus != common.TransactionStatusCompleted {
+			return fmt.Errorf("cannot refund a %s transaction", original.TransactionStatus)
+		}
+
+		refunded, err := ps.refundedAmount(tx, original.TransactionID)
+		if err != nil {
+			return err
+		}
+		amountMinor, err = refundableAmount(original.AmountMinor, refunded, common.ToMinorUnits(req.Amount, original.Currency), original.Currency)
+		if err != nil {
+			return err
+		}
+
+		result, err := gateway.RefundPayment(ctx, interfaces.GatewayRefundRequest{
+			ExternalTransactionID: *original.ExternalTransactionID,
+			Amount:                common.FromMinorUnits(amountMinor, original.Currency),
+			Currency:              original.Currency,
+			Reason:                req.Reason,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to refund payment at gateway: %w", err)
+		}
+
+		now := time.Now()
+		gatewayName := gateway.Name()
+		refundTxn = entityPayment.PaymentTransaction{
+			TransactionType:       common.TransactionTypeRefund,
+			ParentTransactionID:   &original.TransactionID,
+			BookingID:             original.BookingID,
+			UserID:                original.UserID,
+			ExpertProfileID:       original.ExpertProfileID,
+			AmountMinor:           amountMinor,
+			Currency:              original.Currency,
+			PaymentMethod:         original.PaymentMethod,
+			TransactionStatus:     common.TransactionStatusRefunded,
+			ExternalTransactionID: &result.RefundID,
+			PaymentGateway:        &gatewayName,
+			GatewayResponse:       mergeGatewayResponse(nil, "refund", result.RawResponse),
+			ProcessedAt:           &now,
+		}
+		if err := tx.Create(&refundTxn).Error; err != nil {
+			return fmt.Errorf("failed to create refund transaction: %w", err)
+		}
+		if err := payouts.Payout().DebitRefund(ctx, tx, &refundTxn); err != nil {
+			return err
+		}
+		refundedTotalMinor = refunded + amountMinor
+
+		// Hoàn đủ thì giao dịch gốc và booking chuyển sang refunded, hoàn một phần thì booking là partially_refunded
+		bookingPaymentStatus = common.PaymentStatusPartiallyRefunded
+		if refundedTotalMinor >= original.AmountMinor {
+			if _, err := transitionTransaction(tx, &original, common.TransactionStatusRefunded); err != nil {
+				return err
+			}
+			bookingPaymentStatus = common.PaymentStatusRefunded
+		}
+		if err := tx.Model(&entityBooking.ConsultationBooking{}).
+			Where("booking_id = ?", original.BookingID).
+			Updates(map[string]interface{}{
+				"payment_status":     bookingPaymentStatus,
+				"booking_updated_at": now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to sync booking payment status: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ps.logger.Info("💸 Payment refunded",
+		zap.String("transaction_id", original.TransactionID.String()),
+		zap.String("refund_transaction_id", refundTxn.TransactionID.String()),
+		zap.Float64("amount", common.FromMinorUnits(amountMinor, original.Currency)),
+		zap.String("reason", req.Reason))
+
+	return &dtopayments.RefundPaymentResponse{
+		OriginalTransaction: *toPaymentResponse(&original, bookingPaymentStatus),
+		RefundTransaction:   *toPaymentResponse(&refundTxn, bookingPaymentStatus),
+		RefundedTotal:       common.FromMinorUnits(refundedTotalMinor, original.Currency),
+	}, nil
+}
+
+// ErrInvalidWebhookSignature được trả về khi chữ ký HMAC của webhook không khớp
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+func (ps *paymentService) HandleWebhook(ctx context.Context, req dtopayments.PaymentWebhookRequest) (*dtopayments.PaymentWebhookResponse, error) {
+	// 1. Xác thực chữ ký trước khi đọc nội dung
+	if !servicePayment.VerifyWebhookSignature(ps.cfg.WebhookSecret, req.Body, req.Signature) {
+		ps.logger.Warn("⚠️ Rejected payment webhook with invalid signature", zap.String("gateway", req.Gateway))
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	gateway, err := ps.getGateway(req.Gateway)
+	if err != nil {
+		return nil, err
+	}
+	event, err := gateway.ParseWebhook(ctx, req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook: %w", err)
+	}
+	if !isValidTransactionStatus(event.Status) {
+		return nil, fmt.Errorf("invalid transaction status in webhook: %s", event.Status)
+	}
+
+	res := &dtopayments.PaymentWebhookResponse{EventID: event.EventID}
+
+	// 2. Ghi nhận event + cập nhật giao dịch trong cùng một DB transaction.
+	// Unique (gateway, event_id) đảm bảo mỗi event chỉ được áp dụng đúng một lần.
+	err = ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		webhookEvent := entityPayment.PaymentWebhookEvent{
+			PaymentGateway: gateway.Name(),
+			EventID:        event.EventID,
+			EventStatus:    event.Status,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&webhookEvent)
+		if result.Error != nil {
+			return fmt.Errorf("failed to record webhook event: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			res.Duplicate = true
+			return nil
+		}
+
+		var txn entityPayment.PaymentTransaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("external_transaction_id = ? AND payment_gateway = ?", event.ExternalTransactionID, gateway.Name()).
+			First(&txn).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("payment transaction not found for external ID %s", event.ExternalTransactionID)
+			}
+			return fmt.Errorf("failed to get payment transaction: %w", err)
+		}
+
+		// Lưu nguyên payload vào gateway_response để phục vụ đối soát
+		txn.GatewayResponse = appendWebhookPayload(txn.GatewayResponse, event)
+		if err := tx.Model(&entityPayment.PaymentTransaction{}).
+			Where("transaction_id = ?", txn.TransactionID).
+			Update("gateway_response", txn.GatewayResponse).Error; err != nil {
+			return fmt.Errorf("failed to save webhook payload: %w", err)
+		}
+
+		// Chỉ áp dụng khi là bước chuyển hợp lệ; event cũ/đến trễ được ghi nhận nhưng bỏ qua
+		applied := false
+		if event.Status != txn.TransactionStatus && canTransitionTransaction(txn.TransactionStatus, event.Status) {
+			if _, err := transitionTransaction(tx, &txn, event.Status); err != nil {
+				return err
+			}
+			applied = true
+		} else if event.Status == common.TransactionStatusRefunded && txn.TransactionStatus == common.TransactionStatusCompleted {
+			ps.logger.Warn("⚠️ Ignored refunded status from payment webhook, refunds are issued through RefundPayment",
+				zap.String("transaction_id", txn.TransactionID.String()),
+				zap.String("event_id", event.EventID))
+		}
+
+		if err := tx.Model(&entityPayment.PaymentWebhookEvent{}).
+			Where("webhook_event_id = ?", webhookEvent.WebhookEventID).
+			Updates(map[string]interface{}{
+				"transaction_id": txn.TransactionID,
+				"applied":        applied,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update webhook event: %w", err)
+		}
+
+		res.TransactionID = txn.TransactionID.String()
+		res.TransactionStatus = txn.TransactionStatus
+		res.Applied = applied
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ps.logger.Info("📩 Payment webhook processed",
+		zap.String("gateway", gateway.Name()),
+		zap.String("event_id", event.EventID),
+		zap.String("external_transaction_id", event.ExternalTransactionID),
+		zap.String("event_status", event.Status),
+		zap.Bool("applied", res.Applied),
+		zap.Bool("duplicate", res.Duplicate))
+
+	return res, nil
+}
+
+// appendWebhookPayload thêm payload webhook vào danh sách "webhooks" trong gateway_response
+func appendWebhookPayload(current common.JSONB, event *interfaces.GatewayWebhookEvent) common.JSONB {
+	var webhooks []interface{}
+	if existing, ok := current["webhooks"].([]interface{}); ok {
+		webhooks = existing
+	}
+	webhooks = append(webhooks, map[string]interface{}{
+		"event_id":    event.EventID,
+		"status":      event.Status,
+		"received_at": time.Now().Format(time.RFC3339),
+		"payload":     event.RawPayload,
+	})
+	return mergeGatewayResponse(current, "webhooks", webhooks)
+}
+
+// refundableAmount trả về số tiền (đơn vị nhỏ nhất) sẽ hoàn cho giao dịch đã thu paidMinor và đã hoàn refundedMinor.
+// requestedMinor = 0 nghĩa là hoàn toàn bộ phần còn lại; vượt quá phần còn lại thì báo lỗi.
+func refundableAmount(paidMinor, refundedMinor, requestedMinor int64, currency string) (int64, error) {
+	remaining := paidMinor - refundedMinor
+	amountMinor := requestedMinor
+	if amountMinor == 0 {
+		amountMinor = remaining
+	}
+	if amountMinor <= 0 || amountMinor > remaining {
+		return 0, fmt.Errorf("invalid refund amount %s, refundable amount is %s",
+			common.FormatMinorUnits(amountMinor, currency), common.FormatMinorUnits(remaining, currency))
+	}
+	return amountMinor, nil
+}
+
+// cancellationRefundAmount là số tiền hoàn khi hủy booking: percentage % của giao dịch, không vượt phần chưa hoàn
+func cancellationRefundAmount(paidMinor, refundedMinor int64, percentage float64) int64 {
+	return min(common.PercentOfMinor(paidMinor, percentage), paidMinor-refundedMinor)
+}
+
+// refundedAmount tính tổng số tiền đã hoàn (đơn vị nhỏ nhất) cho một giao dịch gốc
+func (ps *paymentService) refundedAmount(db *gorm.DB, transactionID uuid.UUID) (int64, error) {
+	var total int64
+	if err := db.Model(&entityPayment.PaymentTransaction{}).
+		Where("parent_transaction_id = ? AND transaction_type = ?", transactionID, common.TransactionTypeRefund).
+		Select("COALESCE(SUM(amount_minor), 0)").
+		Scan(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to sum refunded amount: %w", err)
+	}
+	return total, nil
+}
+
+// applyStatus khoá giao dịch, kiểm tra bước chuyển trạng thái rồi cập nhật
+// giao dịch và payment_status của booking trong cùng một DB transaction.
+func (ps *paymentService) applyStatus(
+	ctx context.Context,
+	transactionID uuid.UUID,
+	newStatus string,
+	reason string,
+	authorize func(txn *entityPayment.PaymentTransaction) error,
+) (*dtopayments.PaymentResponse, error) {
+	var (
+		txn                  entityPayment.PaymentTransaction
+		oldStatus            string
+		bookingPaymentStatus string
+	)
+
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&txn, "transaction_id = ?", transactionID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("payment transaction not found")
+			}
+			return fmt.Errorf("failed to get payment transaction: %w", err)
+		}
+		if authorize != nil {
+			if err := authorize(&txn); err != nil {
+				return err
+			}
+		}
+
+		oldStatus = txn.TransactionStatus
+		if newStatus == common.TransactionStatusRefunded {
+			return fmt.Errorf("refunds must be issued through the refund endpoint")
+		}
+		if !canTransitionTransaction(oldStatus, newStatus) {
+			return fmt.Errorf("cannot change transaction status from %s to %s", oldStatus, newStatus)
+		}
+
+		var err error
+		bookingPaymentStatus, err = transitionTransaction(tx, &txn, newStatus)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ps.logger.Info("💳 Payment transaction status changed",
+		zap.String("transaction_id", txn.TransactionID.String()),
+		zap.String("booking_id", txn.BookingID.String()),
+		zap.String("old_status", oldStatus),
+		zap.String("new_status", newStatus),
+		zap.String("reason", reason))
+
+	return toPaymentResponse(&txn, bookingPaymentStatus), nil
+}
+
+// transitionTransaction ghi trạng thái mới cho giao dịch và đồng bộ
+// payment_status của booking. Hàm phải được gọi bên trong một DB transaction
+// và giả định bước chuyển đã được kiểm tra hợp lệ.
+func transitionTransaction(tx *gorm.DB, txn *entityPayment.PaymentTransaction, newStatus string) (string, error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"transaction_status": newStatus,
+	}
+	if newStatus != common.TransactionStatusPending && newStatus != common.TransactionStatusProcessing {
+		updates["processed_at"] = now
+		txn.ProcessedAt = &now
+	}
+	if err := tx.Model(&entityPayment.PaymentTransaction{}).
+		Where("transaction_id = ?", txn.TransactionID).
+		Updates(updates).Error; err != nil {
+		return "", fmt.Errorf("failed to update payment transaction: %w", err)
+	}
+	txn.TransactionStatus = newStatus
+
+	if newStatus == common.TransactionStatusCompleted && txn.TransactionType == common.TransactionTypePayment {
+		if err := promotions.Promotion().ConfirmRedemption(tx.Statement.Context, tx, txn.BookingID, txn.TransactionID); err != nil {
+			return "", err
+		}
+		// PDF được worker render sau, ở đây chỉ cấp số hoá đơn trong cùng transaction
+		if _, err := invoices.Invoice().IssueForTransaction(tx.Statement.Context, tx, txn); err != nil {
+			return "", err
+		}
+	}
+
+	var booking entityBooking.ConsultationBooking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&booking, "booking_id = ?", txn.BookingID).Error; err != nil {
+		return "", fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	paymentStatus := bookingPaymentStatusFor(booking.PaymentStatus, newStatus)
+	if paymentStatus != booking.PaymentStatus {
+		if err := tx.Model(&entityBooking.ConsultationBooking{}).
+			Where("booking_id = ?", booking.BookingID).
+			Updates(map[string]interface{}{
+				"payment_status":     paymentStatus,
+				"booking_updated_at": now,
+			}).Error; err != nil {
+			return "", fmt.Errorf("failed to sync booking payment status: %w", err)
+		}
+	}
+	return paymentStatus, nil
+}
+
+// bookingPaymentStatusFor trả về payment_status mới của booking
+// tương ứng với trạng thái giao dịch vừa chuyển tới.
+func bookingPaymentStatusFor(current, transactionStatus string) string {
+	switch transactionStatus {
+	case common.TransactionStatusCompleted:
+		return common.PaymentStatusPaid
+	case common.TransactionStatusRefunded:
+		return common.PaymentStatusRefunded
+	case common.TransactionStatusFailed:
+		// Không ghi đè nếu booking đã được thanh toán bằng giao dịch khác
+		if current == common.PaymentStatusPaid {
+			return current
+		}
+		return common.PaymentStatusFailed
+	default:
+		return current
+	}
+}
+
+// mergeGatewayResponse lưu raw response của gateway theo từng bước (initiate, verify, refund...)
+// để giữ lại toàn bộ lịch sử phục vụ đối soát.
+func mergeGatewayResponse(current common.JSONB, step string, raw interface{}) common.JSONB {
+	merged := common.JSONB{}
+	for k, v := range current {
+		merged[k] = v
+	}
+	merged[step] = raw
+	return merged
+}
+
+func toPaymentResponse(txn *entityPayment.PaymentTransaction, bookingPaymentStatus string) *dtopayments.PaymentResponse {
+	res := &dtopayments.PaymentResponse{
+		TransactionID:        txn.TransactionID.String(),
+		BookingID:            txn.BookingID.String(),
+		UserID:               txn.UserID.String(),
+		ExpertProfileID:      txn.ExpertProfileID.String(),
+		TransactionType:      txn.TransactionType,
+		Amount:               txn.Amount(),
+		Currency:             txn.Currency,
+		TransactionStatus:    txn.TransactionStatus,
+		BookingPaymentStatus: bookingPaymentStatus,
+		ProcessedAt:          txn.ProcessedAt,
+		CreatedAt:            txn.TransactionCreatedAt,
+	}
+	if txn.ParentTransactionID != nil {
+		res.ParentTransactionID = txn.ParentTransactionID.String()
+	}
+	if txn.PaymentMethod != nil {
+		res.PaymentMethod = *txn.PaymentMethod
+	}
+	if txn.ExternalTransactionID != nil {
+		res.ExternalTransactionID = *txn.ExternalTransactionID
+	}
+	if txn.PaymentGateway != nil {
+		res.PaymentGateway = *txn.PaymentGateway
+	}
+	return res
+}
+
+// RefundBooking áp dụng chính sách hoàn tiền cho một booking vừa bị hủy:
+// hủy các giao dịch đang mở và hoàn tiền các giao dịch đã thanh toán theo % của chính sách.
+func (ps *paymentService) RefundBooking(ctx context.Context, req dtopayments.RefundBookingRequest) (*dtopayments.RefundBookingResponse, error) {
+	bookingID, err := uuid.Parse(req.BookingID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid booking ID format: %w", err)
+	}
+
+	var booking entityBooking.ConsultationBooking
+	if err := ps.db.WithContext(ctx).First(&booking, "booking_id = ?", bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("booking not found")
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	policy, err := LoadRefundPolicy(ctx, ps.db)
+	if err != nil {
+		return nil, err
+	}
+	cancelledAt := req.CancelledAt
+	if cancelledAt.IsZero() {
+		cancelledAt = time.Now()
+	}
+	hoursBefore := booking.BookingDatetime.Sub(cancelledAt).Hours()
+	percentage := RefundPercentageFor(policy, req.CancelledBy, hoursBefore)
+
+	res := &dtopayments.RefundBookingResponse{
+		BookingID:         booking.BookingID.String(),
+		RefundPercentage:  percentage,
+		RefundProcessDays: policy.RefundProcessDays,
+		PolicyDescription: DescribeRefundPolicy(policy),
+	}
+
+	var txns []entityPayment.PaymentTransaction
+	if err := ps.db.WithContext(ctx).
+		Where("booking_id = ? AND transaction_type = ?", bookingID, common.TransactionTypePayment).
+		Order("transaction_created_at ASC").
+		Find(&txns).Error; err != nil {
+		return nil, fmt.Errorf("failed to get payment transactions: %w", err)
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("booking cancelled by %s", req.CancelledBy)
+	}
+
+	paid := false
+	var refundMinor int64
+	for _, txn := range txns {
+		switch txn.TransactionStatus {
+		case common.TransactionStatusRefunded:
+			paid = true
+
+		case common.TransactionStatusPending, common.TransactionStatusProcessing:
+			// Booking đã hủy thì không cho thanh toán tiếp
+			if _, err := ps.applyStatus(ctx, txn.TransactionID, common.TransactionStatusCancelled, reason, nil); err != nil {
+				return nil, err
+			}
+			res.CancelledTransactions = append(res.CancelledTransactions, txn.TransactionID.String())
+
+		case common.TransactionStatusCompleted:
+			paid = true
+			if percentage <= 0 {
+				continue
+			}
+			refunded, err := ps.refundedAmount(ps.db.WithContext(ctx), txn.TransactionID)
+			if err != nil {
+				return nil, err
+			}
+			amountMinor := cancellationRefundAmount(txn.AmountMinor, refunded, percentage)
+			if amountMinor <= 0 {
+				continue
+			}
+			refund, err := ps.RefundPayment(ctx, dtopayments.RefundPaymentRequest{
+				TransactionID: txn.TransactionID.String(),
+				Amount:        common.FromMinorUnits(amountMinor, txn.Currency),
+				Reason:        reason,
+			})
+			if err != nil {
+				return nil, err
+			}
+			res.Refunds = append(res.Refunds, *refund)
+			refundMinor += amountMinor
+		}
+	}
+	res.RefundAmount = common.FromMinorUnits(refundMinor, booking.Currency)
+
+	// Booking chưa từng được thanh toán thì trả lại lượt dùng mã khuyến mãi
+	if !paid {
+		if err := promotions.Promotion().ReleaseRedemption(ctx, ps.db, bookingID); err != nil {
+			return nil, err
+		}
+	}
+
+	ps.logger.Info("💸 Cancellation refund policy applied",
+		zap.String("booking_id", res.BookingID),
+		zap.String("cancelled_by", req.CancelledBy),
+		zap.Float64("hours_before", hoursBefore),
+		zap.Float64("refund_percentage", percentage),
+		zap.Float64("refund_amount", res.RefundAmount))
+
+	return res, nil
+}
+
+func (ps *paymentService) GetRefundPolicy(ctx context.Context) (*dtopayments.RefundPolicyResponse, error) {
+	policy, err := LoadRefundPolicy(ctx, ps.db)
+	if err != nil {
+		return nil, err
+	}
+	return &dtopayments.RefundPolicyResponse{
+		Policy:      policy,
+		Description: DescribeRefundPolicy(policy),
+	}, nil
+}
+
+func (ps *paymentService) UpdateRefundPolicy(ctx context.Context, req dtopayments.RefundPolicy) (*dtopayments.RefundPolicyResponse, error) {
+	if err := ValidateRefundPolicy(&req); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode refund policy: %w", err)
+	}
+	var value common.JSONB
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("failed to encode refund policy: %w", err)
+	}
+
+	setting := entitySystem.SystemSetting{
+		SettingKey:         SettingKeyRefundPolicy,
+		SettingValue:       value,
+		SettingDescription: "Chính sách hoàn tiền khi hủy lịch tư vấn",
+		SettingUpdatedAt:   time.Now(),
+	}
+	if err := ps.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "setting_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"setting_value", "setting_description", "setting_updated_at"}),
+	}).Create(&setting).Error; err != nil {
+		return nil, fmt.Errorf("failed to save refund policy: %w", err)
+	}
+
+	ps.logger.Info("⚙️ Refund policy updated", zap.Any("policy", req))
+
+	return &dtopayments.RefundPolicyResponse{
+		Policy:      req,
+		Description: DescribeRefundPolicy(req),
+	}, nil
+}
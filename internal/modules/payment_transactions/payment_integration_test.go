@@ -0,0 +1,207 @@
+package paymenttransactions_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/internal/modules/invoices"
+	paymenttransactions "cbs_backend/internal/modules/payment_transactions"
+	"cbs_backend/internal/modules/payment_transactions/dtopayments"
+	"cbs_backend/internal/modules/payouts"
+	"cbs_backend/internal/modules/promotions"
+	servicePayment "cbs_backend/internal/service/payment"
+	"cbs_backend/internal/testutil"
+	"cbs_backend/pkg/configs"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const testWebhookSecret = "test-webhook-secret"
+
+// setupPayments dựng payment service với fake gateway trên database test
+func setupPayments(t *testing.T) (*gorm.DB, *servicePayment.FakeGateway, paymenttransactions.IPayments) {
+	t.Helper()
+	db := testutil.OpenPostgres(t)
+	logger := zap.NewNop()
+	cfg := &configs.PaymentConfig{
+		DefaultGateway:       servicePayment.FakeGatewayName,
+		WebhookSecret:        testWebhookSecret,
+		PaymentWindow:        30 * time.Minute,
+		CommissionPercentage: 20,
+		InvoiceTaxPercentage: 10,
+		InvoiceStorageDir:    t.TempDir(),
+		InvoiceIssuerName:    "Test",
+	}
+	promotions.InitPromotionService(db, logger)
+	payouts.InitPayoutService(db, logger, cfg.CommissionPercentage)
+	invoices.InitInvoiceService(db, logger, cfg)
+	gateway := servicePayment.NewFakeGateway("http://localhost", false)
+	return db, gateway, paymenttransactions.NewPaymentService(db, logger, cfg, gateway)
+}
+
+// createPendingBooking tạo booking pending chưa thanh toán với phí feeMinor (VND)
+func createPendingBooking(t *testing.T, db *gorm.DB, feeMinor int64) *entityBooking.ConsultationBooking {
+	t.Helper()
+	expert := testutil.CreateExpert(t, db, feeMinor)
+	user := testutil.CreateUser(t, db, "user")
+	booking := &entityBooking.ConsultationBooking{
+		BookingID:            uuid.New(),
+		UserID:               user.UserID,
+		ExpertProfileID:      expert.ExpertProfileID,
+		BookingDatetime:      time.Now().Add(72 * time.Hour).Truncate(time.Hour),
+		DurationMinutes:      60,
+		ConsultationType:     "online",
+		BookingStatus:        common.BookingStatusPending,
+		ConsultationFeeMinor: &feeMinor,
+		Currency:             "VND",
+		PaymentStatus:        common.PaymentStatusPending,
+	}
+	if err := db.Create(booking).Error; err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	return booking
+}
+
+// payBooking tạo giao dịch qua fake gateway rồi giả lập user thanh toán thành công qua webhook
+func payBooking(t *testing.T, gateway *servicePayment.FakeGateway, svc paymenttransactions.IPayments, booking *entityBooking.ConsultationBooking) *dtopayments.PaymentResponse {
+	t.Helper()
+	ctx := context.Background()
+	payment, err := svc.CreatePayment(ctx, dtopayments.CreatePaymentRequest{
+		BookingID:     booking.BookingID.String(),
+		UserID:        booking.UserID.String(),
+		PaymentMethod: "card",
+	})
+	if err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	if payment.ExternalTransactionID == "" {
+		t.Fatalf("CreatePayment did not return an external transaction ID")
+	}
+
+	body, err := gateway.SimulateResult(payment.ExternalTransactionID, common.TransactionStatusCompleted)
+	if err != nil {
+		t.Fatalf("SimulateResult: %v", err)
+	}
+	res, err := svc.HandleWebhook(ctx, dtopayments.PaymentWebhookRequest{
+		Gateway:   servicePayment.FakeGatewayName,
+		Body:      body,
+		Signature: servicePayment.SignWebhook(testWebhookSecret, body),
+	})
+	if err != nil {
+		t.Fatalf("HandleWebhook: %v", err)
+	}
+	if !res.Applied || res.TransactionStatus != common.TransactionStatusCompleted {
+		t.Fatalf("webhook applied=%v status=%s, want applied completed", res.Applied, res.TransactionStatus)
+	}
+	return payment
+}
+
+func reloadBooking(t *testing.T, db *gorm.DB, bookingID uuid.UUID) *entityBooking.ConsultationBooking {
+	t.Helper()
+	var booking entityBooking.ConsultationBooking
+	if err := db.First(&booking, "booking_id = ?", bookingID).Error; err != nil {
+		t.Fatalf("reload booking: %v", err)
+	}
+	return &booking
+}
+
+func TestRefundPaymentConcurrentRefundsDoNotOverRefund(t *testing.T) {
+	db, gateway, svc := setupPayments(t)
+	booking := createPendingBooking(t, db, 500000)
+	payment := payBooking(t, gateway, svc, booking)
+
+	// Hai lần hoàn 60% chạy song song: chỉ một lần được chấp nhận
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.RefundPayment(context.Background(), dtopayments.RefundPaymentRequest{
+				TransactionID: payment.TransactionID,
+				Amount:        300000,
+				Reason:        "concurrent refund",
+			})
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Fatalf("expected exactly one refund to succeed, got %d", succeeded)
+	}
+
+	if got := reloadBooking(t, db, booking.BookingID).PaymentStatus; got != common.PaymentStatusPartiallyRefunded {
+		t.Errorf("booking payment status after partial refund = %s, want %s", got, common.PaymentStatusPartiallyRefunded)
+	}
+
+	// Hoàn nốt phần còn lại thì booking mới là refunded
+	res, err := svc.RefundPayment(context.Background(), dtopayments.RefundPaymentRequest{TransactionID: payment.TransactionID})
+	if err != nil {
+		t.Fatalf("refund remaining: %v", err)
+	}
+	if res.RefundedTotal != 500000 {
+		t.Errorf("refunded total = %v, want 500000", res.RefundedTotal)
+	}
+	if got := reloadBooking(t, db, booking.BookingID).PaymentStatus; got != common.PaymentStatusRefunded {
+		t.Errorf("booking payment status after full refund = %s, want %s", got, common.PaymentStatusRefunded)
+	}
+}
+
+func TestPayThenConfirmWithFakeGateway(t *testing.T) {
+	db, gateway, svc := setupPayments(t)
+	booking := createPendingBooking(t, db, 350000)
+	ctx := context.Background()
+
+	payment := payBooking(t, gateway, svc, booking)
+	if payment.PaymentGateway != servicePayment.FakeGatewayName {
+		t.Errorf("payment gateway = %q, want %q", payment.PaymentGateway, servicePayment.FakeGatewayName)
+	}
+	if payment.Amount != 350000 {
+		t.Errorf("payment amount = %v, want 350000", payment.Amount)
+	}
+
+	paid := reloadBooking(t, db, booking.BookingID)
+	if paid.PaymentStatus != common.PaymentStatusPaid || paid.BookingStatus != common.BookingStatusPending {
+		t.Fatalf("after payment booking is %s/%s, want pending/paid", paid.BookingStatus, paid.PaymentStatus)
+	}
+
+	// Giao dịch đã lưu phản ánh kết quả gateway gửi về
+	stored, err := svc.GetPaymentByID(ctx, dtopayments.GetPaymentByIDRequest{
+		TransactionID: payment.TransactionID,
+		UserID:        booking.UserID.String(),
+	})
+	if err != nil {
+		t.Fatalf("GetPaymentByID: %v", err)
+	}
+	if stored.TransactionStatus != common.TransactionStatusCompleted || stored.BookingPaymentStatus != common.PaymentStatusPaid {
+		t.Fatalf("stored payment is %s (booking %s), want completed (paid)", stored.TransactionStatus, stored.BookingPaymentStatus)
+	}
+
+	confirmed, err := bookings.TransitionBooking(ctx, db, bookings.BookingTransition{
+		BookingID: booking.BookingID,
+		Event:     bookings.BookingEventConfirm,
+		Actor:     bookings.BookingActorExpert,
+		ActorID:   uuid.New(),
+		Reason:    "confirmed after payment",
+	})
+	if err != nil {
+		t.Fatalf("confirm booking: %v", err)
+	}
+	if confirmed.BookingStatus != common.BookingStatusConfirmed || confirmed.PaymentStatus != common.PaymentStatusPaid {
+		t.Errorf("confirmed booking is %s/%s, want confirmed/paid", confirmed.BookingStatus, confirmed.PaymentStatus)
+	}
+}
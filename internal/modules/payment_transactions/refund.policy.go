@@ -0,0 +1,134 @@
+package paymenttransactions
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/payment_transactions/dtopayments"
+	entitySystem "cbs_backend/internal/modules/system_setting/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SettingKeyRefundPolicy là key của chính sách hoàn tiền trong tbl_system_settings
+const SettingKeyRefundPolicy = "cancellation_refund_policy"
+
+// DefaultRefundPolicy dùng khi chưa cấu hình trong system settings:
+// hủy trước 24h hoàn 100%, trước 6h hoàn 50%, sau đó không hoàn.
+func DefaultRefundPolicy() dtopayments.RefundPolicy {
+	return dtopayments.RefundPolicy{
+		Tiers: []dtopayments.RefundTier{
+			{MinHoursBefore: 24, RefundPercentage: 100},
+			{MinHoursBefore: 6, RefundPercentage: 50},
+		},
+		ExpertRefundPercentage: 100,
+		SystemRefundPercentage: 100,
+		MinCancelHoursBefore:   1,
+		RefundProcessDays:      7,
+	}
+}
+
+// LoadRefundPolicy đọc chính sách hoàn tiền từ system settings, không có thì trả về mặc định
+func LoadRefundPolicy(ctx context.Context, db *gorm.DB) (dtopayments.RefundPolicy, error) {
+	var setting entitySystem.SystemSetting
+	err := db.WithContext(ctx).Where("setting_key = ?", SettingKeyRefundPolicy).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultRefundPolicy(), nil
+	}
+	if err != nil {
+		return dtopayments.RefundPolicy{}, fmt.Errorf("failed to load refund policy: %w", err)
+	}
+
+	raw, err := json.Marshal(setting.SettingValue)
+	if err != nil {
+		return dtopayments.RefundPolicy{}, fmt.Errorf("failed to read refund policy: %w", err)
+	}
+	var policy dtopayments.RefundPolicy
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return dtopayments.RefundPolicy{}, fmt.Errorf("invalid refund policy setting: %w", err)
+	}
+	if err := ValidateRefundPolicy(&policy); err != nil {
+		return dtopayments.RefundPolicy{}, fmt.Errorf("invalid refund policy setting: %w", err)
+	}
+	return policy, nil
+}
+
+// ValidateRefundPolicy kiểm tra và sắp xếp các bậc theo số giờ giảm dần
+func ValidateRefundPolicy(policy *dtopayments.RefundPolicy) error {
+	for _, tier := range policy.Tiers {
+		if tier.MinHoursBefore < 0 {
+			return fmt.Errorf("min_hours_before cannot be negative")
+		}
+		if tier.RefundPercentage < 0 || tier.RefundPercentage > 100 {
+			return fmt.Errorf("refund_percentage must be between 0 and 100")
+		}
+	}
+	if policy.ExpertRefundPercentage < 0 || policy.ExpertRefundPercentage > 100 ||
+		policy.SystemRefundPercentage < 0 || policy.SystemRefundPercentage > 100 {
+		return fmt.Errorf("expert/system refund percentage must be between 0 and 100")
+	}
+	if policy.MinCancelHoursBefore < 0 {
+		return fmt.Errorf("min_cancel_hours_before cannot be negative")
+	}
+	if policy.RefundProcessDays <= 0 {
+		policy.RefundProcessDays = DefaultRefundPolicy().RefundProcessDays
+	}
+
+	sort.SliceStable(policy.Tiers, func(i, j int) bool {
+		return policy.Tiers[i].MinHoursBefore > policy.Tiers[j].MinHoursBefore
+	})
+	return nil
+}
+
+// RefundPercentageFor trả về % được hoàn theo người hủy và số giờ còn lại trước buổi tư vấn
+func RefundPercentageFor(policy dtopayments.RefundPolicy, cancelledBy string, hoursBefore float64) float64 {
+	switch cancelledBy {
+	case common.CancelledByExpert:
+		return policy.ExpertRefundPercentage
+	case common.CancelledBySystem:
+		return policy.SystemRefundPercentage
+	}
+
+	// Tiers đã được sắp xếp giảm dần, bậc đầu tiên thỏa mãn là bậc cao nhất
+	for _, tier := range policy.Tiers {
+		if hoursBefore >= tier.MinHoursBefore {
+			return tier.RefundPercentage
+		}
+	}
+	return 0
+}
+
+// DescribeRefundPolicy tạo mô tả chính sách hủy cho email/event
+func DescribeRefundPolicy(policy dtopayments.RefundPolicy) string {
+	if len(policy.Tiers) == 0 {
+		return "Không hoàn tiền khi hủy lịch"
+	}
+
+	parts := make([]string, 0, len(policy.Tiers)+1)
+	for _, tier := range policy.Tiers {
+		parts = append(parts, fmt.Sprintf("hoàn %s%% nếu hủy trước %s giờ",
+			formatNumber(tier.RefundPercentage), formatNumber(tier.MinHoursBefore)))
+	}
+	last := policy.Tiers[len(policy.Tiers)-1]
+	if last.MinHoursBefore > 0 && last.RefundPercentage > 0 {
+		parts = append(parts, "sau đó không hoàn tiền")
+	}
+
+	desc := strings.Join(parts, ", ")
+	desc = strings.ToUpper(desc[:1]) + desc[1:]
+	if policy.MinCancelHoursBefore > 0 {
+		desc += fmt.Sprintf(". Không thể hủy trong vòng %s giờ trước buổi tư vấn", formatNumber(policy.MinCancelHoursBefore))
+	}
+	return desc
+}
+
+func formatNumber(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.1f", v)
+}
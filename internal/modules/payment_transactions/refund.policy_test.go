@@ -0,0 +1,112 @@
+package paymenttransactions
+
+import (
+	"strings"
+	"testing"
+
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/payment_transactions/dtopayments"
+)
+
+func TestRefundPercentageFor(t *testing.T) {
+	policy := DefaultRefundPolicy() // 100% từ 24 giờ, 50% từ 6 giờ, expert/hệ thống hủy hoàn 100%
+	custom := dtopayments.RefundPolicy{
+		Tiers:                  []dtopayments.RefundTier{{MinHoursBefore: 6, RefundPercentage: 30}, {MinHoursBefore: 48, RefundPercentage: 90}},
+		ExpertRefundPercentage: 80,
+		SystemRefundPercentage: 60,
+	}
+	if err := ValidateRefundPolicy(&custom); err != nil {
+		t.Fatalf("ValidateRefundPolicy: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		policy      dtopayments.RefundPolicy
+		cancelledBy string
+		hoursBefore float64
+		want        float64
+	}{
+		{"user well ahead", policy, common.CancelledByUser, 72, 100},
+		{"user exactly at the full refund boundary", policy, common.CancelledByUser, 24, 100},
+		{"user just under the full refund boundary", policy, common.CancelledByUser, 23.99, 50},
+		{"user exactly at the half refund boundary", policy, common.CancelledByUser, 6, 50},
+		{"user just under the half refund boundary", policy, common.CancelledByUser, 5.99, 0},
+		{"user after the session started", policy, common.CancelledByUser, -1, 0},
+		{"expert ignores tiers", policy, common.CancelledByExpert, 0.5, 100},
+		{"system ignores tiers", policy, common.CancelledBySystem, 0, 100},
+		{"custom tiers are sorted before matching", custom, common.CancelledByUser, 50, 90},
+		{"custom middle tier", custom, common.CancelledByUser, 47, 30},
+		{"custom expert percentage", custom, common.CancelledByExpert, 100, 80},
+		{"custom system percentage", custom, common.CancelledBySystem, 100, 60},
+		{"no tiers refunds nothing", dtopayments.RefundPolicy{}, common.CancelledByUser, 1000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RefundPercentageFor(tt.policy, tt.cancelledBy, tt.hoursBefore); got != tt.want {
+				t.Errorf("RefundPercentageFor(%s, %v) = %v, want %v", tt.cancelledBy, tt.hoursBefore, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefundableAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		paid      int64
+		refunded  int64
+		requested int64
+		currency  string
+		want      int64
+		wantErr   bool
+	}{
+		{"full refund by default", 500000, 0, 0, "VND", 500000, false},
+		{"remaining after a partial refund", 500000, 200000, 0, "VND", 300000, false},
+		{"partial refund", 500000, 0, 150000, "VND", 150000, false},
+		{"exactly the remaining amount", 500000, 200000, 300000, "VND", 300000, false},
+		{"one minor unit over the remaining amount", 500000, 200000, 300001, "VND", 0, true},
+		{"cents are not lost", 10001, 3333, 6668, "USD", 6668, false},
+		{"nothing left to refund", 500000, 500000, 0, "VND", 0, true},
+		{"negative amount", 500000, 0, -1, "VND", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := refundableAmount(tt.paid, tt.refunded, tt.requested, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("refundableAmount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "refundable amount is") {
+				t.Errorf("refundableAmount() error = %v, want the refundable amount in the message", err)
+			}
+			if got != tt.want {
+				t.Errorf("refundableAmount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCancellationRefundAmount(t *testing.T) {
+	tests := []struct {
+		name       string
+		paid       int64
+		refunded   int64
+		percentage float64
+		want       int64
+	}{
+		{"full refund", 500000, 0, 100, 500000},
+		{"half refund", 500000, 0, 50, 250000},
+		{"rounds to the nearest minor unit", 1001, 0, 50, 501},
+		{"capped by an earlier partial refund", 500000, 400000, 50, 100000},
+		{"already fully refunded", 500000, 500000, 100, 0},
+		{"no refund tier", 500000, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cancellationRefundAmount(tt.paid, tt.refunded, tt.percentage); got != tt.want {
+				t.Errorf("cancellationRefundAmount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package dtopayouts
+
+import "time"
+
+type GenerateStatementsRequest struct {
+	PeriodEnd time.Time `json:"period_end"` // Rỗng thì quyết toán đến 00:00 hôm nay
+}
+
+type GenerateStatementsResponse struct {
+	PeriodEnd          time.Time                 `json:"period_end"`
+	Statements         []PayoutStatementResponse `json:"statements"`
+	CarriedOverExperts []string                  `json:"carried_over_experts,omitempty"` // Expert có số dư <= 0, chuyển sang kỳ sau
+}
@@ -0,0 +1,23 @@
+package dtopayouts
+
+type GetMyPayoutStatementsRequest struct {
+	UserID       string `form:"-"`
+	PayoutStatus string `form:"payout_status"`
+	Page         int    `form:"page"`
+	PageSize     int    `form:"page_size"`
+}
+
+type GetPayoutStatementsRequest struct {
+	ExpertProfileID string `form:"expert_profile_id"`
+	PayoutStatus    string `form:"payout_status"`
+	Page            int    `form:"page"`
+	PageSize        int    `form:"page_size"`
+}
+
+type GetPayoutStatementsResponse struct {
+	Statements  []PayoutStatementResponse `json:"statements"`
+	TotalCount  int                       `json:"total_count"`
+	CurrentPage int                       `json:"current_page"`
+	PageSize    int                       `json:"page_size"`
+	TotalPages  int                       `json:"total_pages"`
+}
@@ -0,0 +1,8 @@
+package dtopayouts
+
+type MarkPayoutPaidRequest struct {
+	StatementID     string `json:"statement_id" binding:"required"`
+	PayoutReference string `json:"payout_reference" binding:"required"`
+	Note            string `json:"note"`
+	AdminUserID     string `json:"-"`
+}
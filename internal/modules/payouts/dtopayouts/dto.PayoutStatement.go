@@ -0,0 +1,48 @@
+package dtopayouts
+
+import "time"
+
+type PayoutLedgerEntryResponse struct {
+	LedgerEntryID        string    `json:"ledger_entry_id"`
+	BookingID            string    `json:"booking_id"`
+	TransactionID        string    `json:"transaction_id"`
+	EntryType            string    `json:"entry_type"`
+	GrossAmount          float64   `json:"gross_amount"`
+	CommissionPercentage float64   `json:"commission_percentage"`
+	CommissionAmount     float64   `json:"commission_amount"`
+	NetAmount            float64   `json:"net_amount"`
+	Currency             string    `json:"currency"`
+	Description          string    `json:"description"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+type PayoutStatementResponse struct {
+	StatementID      string                      `json:"statement_id"`
+	ExpertProfileID  string                      `json:"expert_profile_id"`
+	Currency         string                      `json:"currency"`
+	PeriodStart      time.Time                   `json:"period_start"`
+	PeriodEnd        time.Time                   `json:"period_end"`
+	GrossAmount      float64                     `json:"gross_amount"`
+	CommissionAmount float64                     `json:"commission_amount"`
+	NetAmount        float64                     `json:"net_amount"`
+	EntryCount       int                         `json:"entry_count"`
+	PayoutStatus     string                      `json:"payout_status"`
+	PayoutReference  *string                     `json:"payout_reference,omitempty"`
+	PayoutNote       *string                     `json:"payout_note,omitempty"`
+	PaidAt           *time.Time                  `json:"paid_at,omitempty"`
+	CreatedAt        time.Time                   `json:"created_at"`
+	Items            []PayoutLedgerEntryResponse `json:"items,omitempty"`
+}
+
+type GetPayoutStatementRequest struct {
+	StatementID string `form:"statement_id" binding:"required"`
+	UserID      string `form:"-"`
+	IsAdmin     bool   `form:"-"` // Admin xem được bảng kê của mọi expert
+}
+
+// PayoutStatementFile là file bảng kê trả về cho expert tải xuống
+type PayoutStatementFile struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
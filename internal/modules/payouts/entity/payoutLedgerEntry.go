@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"time"
+
+	entityPayment "cbs_backend/internal/modules/payment_transactions/entity"
+
+	"github.com/google/uuid"
+)
+
+// PayoutLedgerEntry represents tbl_expert_payout_ledger table
+// Mỗi giao dịch chỉ được ghi một lần cho mỗi loại bút toán (unique transaction_id + entry_type).
+// Các số tiền mang dấu: credit dương, debit âm, nên số dư của expert là tổng NetAmount.
+type PayoutLedgerEntry struct {
+	LedgerEntryID         uuid.UUID  `json:"ledger_entry_id" db:"ledger_entry_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ExpertProfileID       uuid.UUID  `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;not null;index"`
+	BookingID             uuid.UUID  `json:"booking_id" db:"booking_id" gorm:"type:uuid;not null"`
+	TransactionID         uuid.UUID  `json:"transaction_id" db:"transaction_id" gorm:"type:uuid;not null;uniqueIndex:idx_payout_ledger_transaction_entry"`
+	EntryType             string     `json:"entry_type" db:"entry_type" gorm:"type:varchar(10);not null;uniqueIndex:idx_payout_ledger_transaction_entry;check:entry_type IN ('credit', 'debit')"`
+	GrossAmountMinor      int64      `json:"gross_amount_minor" db:"gross_amount_minor" gorm:"type:bigint;not null;default:0"` // Đơn vị nhỏ nhất của Currency
+	CommissionPercentage  float64    `json:"commission_percentage" db:"commission_percentage" gorm:"type:decimal(5,2);not null"`
+	CommissionAmountMinor int64      `json:"commission_amount_minor" db:"commission_amount_minor" gorm:"type:bigint;not null;default:0"`
+	NetAmountMinor        int64      `json:"net_amount_minor" db:"net_amount_minor" gorm:"type:bigint;not null;default:0"`
+	Currency              string     `json:"currency" db:"currency" gorm:"type:varchar(3);default:'VND'"`
+	Description           string     `json:"description" db:"description" gorm:"type:text"`
+	StatementID           *uuid.UUID `json:"statement_id,omitempty" db:"statement_id" gorm:"type:uuid;index"` // Null khi chưa được quyết toán
+	EntryCreatedAt        time.Time  `json:"entry_created_at" db:"entry_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+
+	Transaction *entityPayment.PaymentTransaction `json:"transaction,omitempty" gorm:"foreignKey:TransactionID;references:TransactionID"`
+}
+
+func (PayoutLedgerEntry) TableName() string {
+	return "tbl_expert_payout_ledger"
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"time"
+
+	entityExpertProfile "cbs_backend/internal/modules/experts/entity"
+
+	"github.com/google/uuid"
+)
+
+// PayoutStatement represents tbl_expert_payout_statements table
+// Bảng kê quyết toán định kỳ của expert, gom các bút toán chưa quyết toán trong kỳ.
+type PayoutStatement struct {
+	StatementID           uuid.UUID  `json:"statement_id" db:"statement_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ExpertProfileID       uuid.UUID  `json:"expert_profile_id" db:"expert_profile_id" gorm:"type:uuid;not null;uniqueIndex:idx_payout_statement_period;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Currency              string     `json:"currency" db:"currency" gorm:"type:varchar(3);not null;uniqueIndex:idx_payout_statement_period"`
+	PeriodStart           time.Time  `json:"period_start" db:"period_start" gorm:"not null"`
+	PeriodEnd             time.Time  `json:"period_end" db:"period_end" gorm:"not null;uniqueIndex:idx_payout_statement_period"`
+	GrossAmountMinor      int64      `json:"gross_amount_minor" db:"gross_amount_minor" gorm:"type:bigint;not null;default:0"` // Đơn vị nhỏ nhất của Currency
+	CommissionAmountMinor int64      `json:"commission_amount_minor" db:"commission_amount_minor" gorm:"type:bigint;not null;default:0"`
+	NetAmountMinor        int64      `json:"net_amount_minor" db:"net_amount_minor" gorm:"type:bigint;not null;default:0"`
+	EntryCount            int        `json:"entry_count" db:"entry_count" gorm:"not null"`
+	PayoutStatus          string     `json:"payout_status" db:"payout_status" gorm:"type:varchar(20);default:'pending';check:payout_status IN ('pending', 'paid')"`
+	PayoutReference       *string    `json:"payout_reference,omitempty" db:"payout_reference" gorm:"type:varchar(255)"` // Mã chuyển khoản của bộ phận tài chính
+	PayoutNote            *string    `json:"payout_note,omitempty" db:"payout_note" gorm:"type:text"`
+	PaidAt                *time.Time `json:"paid_at,omitempty" db:"paid_at"`
+	PaidByUserID          *uuid.UUID `json:"paid_by_user_id,omitempty" db:"paid_by_user_id" gorm:"type:uuid"`
+	StatementCreatedAt    time.Time  `json:"statement_created_at" db:"statement_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+
+	Entries       []PayoutLedgerEntry                `json:"entries,omitempty" gorm:"foreignKey:StatementID;references:StatementID"`
+	ExpertProfile *entityExpertProfile.ExpertProfile `json:"expert_profile,omitempty" gorm:"foreignKey:ExpertProfileID;references:ExpertProfileID"`
+}
+
+func (PayoutStatement) TableName() string {
+	return "tbl_expert_payout_statements"
+}
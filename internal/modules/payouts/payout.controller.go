@@ -0,0 +1,168 @@
+package payouts
+
+import (
+	"cbs_backend/internal/modules/payouts/dtopayouts"
+	"cbs_backend/pkg/response"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type PayoutController struct {
+	Logger *zap.Logger
+}
+
+func NewPayoutController(logger *zap.Logger) *PayoutController {
+	return &PayoutController{Logger: logger}
+}
+
+// getUserIDFromContext lấy userID đã được AuthMiddleware gắn vào context
+func getUserIDFromContext(c *gin.Context) (string, error) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		return "", response.NewAPIError(http.StatusUnauthorized, "Unauthorized", "UserID not found in context")
+	}
+	userID, ok := userIDValue.(uuid.UUID)
+	if !ok {
+		return "", response.NewAPIError(http.StatusInternalServerError, "Internal error", "Invalid userID type")
+	}
+	return userID.String(), nil
+}
+
+func (pc *PayoutController) GetMyStatements(c *gin.Context) (res interface{}, err error) {
+	var req dtopayouts.GetMyPayoutStatementsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		pc.Logger.Error("Invalid get payout statements request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get payout statements request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Payout().GetMyStatements(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Get payout statements failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get payout statements failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PayoutController) GetMyStatement(c *gin.Context) (res interface{}, err error) {
+	return pc.getStatement(c, false)
+}
+
+func (pc *PayoutController) GetStatement(c *gin.Context) (res interface{}, err error) {
+	return pc.getStatement(c, true)
+}
+
+func (pc *PayoutController) getStatement(c *gin.Context, isAdmin bool) (res interface{}, err error) {
+	var req dtopayouts.GetPayoutStatementRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		pc.Logger.Error("Invalid get payout statement request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get payout statement request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+	req.IsAdmin = isAdmin
+
+	resp, err := Payout().GetStatement(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Get payout statement failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get payout statement failed", err)
+	}
+
+	return resp, nil
+}
+
+// DownloadMyStatement trả file CSV trực tiếp nên không đi qua response.Wrap
+func (pc *PayoutController) DownloadMyStatement(c *gin.Context) {
+	pc.downloadStatement(c, false)
+}
+
+func (pc *PayoutController) DownloadStatement(c *gin.Context) {
+	pc.downloadStatement(c, true)
+}
+
+func (pc *PayoutController) downloadStatement(c *gin.Context, isAdmin bool) {
+	var req dtopayouts.GetPayoutStatementRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		pc.Logger.Error("Invalid download payout statement request", zap.Error(err))
+		response.ErrorResponse(c, http.StatusBadRequest, "Invalid download payout statement request", err.Error())
+		return
+	}
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		apiErr := err.(*response.APIError)
+		response.ErrorResponse(c, apiErr.StatusCode, apiErr.Message, apiErr.Error())
+		return
+	}
+	req.UserID = userID
+	req.IsAdmin = isAdmin
+
+	file, err := Payout().DownloadStatement(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Download payout statement failed", zap.Error(err))
+		response.ErrorResponse(c, http.StatusBadRequest, "Download payout statement failed", err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+	c.Data(http.StatusOK, file.ContentType, file.Content)
+}
+
+func (pc *PayoutController) GetStatements(c *gin.Context) (res interface{}, err error) {
+	var req dtopayouts.GetPayoutStatementsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		pc.Logger.Error("Invalid get payout statements request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get payout statements request", err)
+	}
+
+	resp, err := Payout().GetStatements(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Get payout statements failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get payout statements failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PayoutController) MarkStatementPaid(c *gin.Context) (res interface{}, err error) {
+	var req dtopayouts.MarkPayoutPaidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.Logger.Error("Invalid mark payout paid request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid mark payout paid request", err)
+	}
+	if req.AdminUserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Payout().MarkStatementPaid(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Mark payout paid failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Mark payout paid failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PayoutController) GenerateStatements(c *gin.Context) (res interface{}, err error) {
+	var req dtopayouts.GenerateStatementsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.Logger.Error("Invalid generate payout statements request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid generate payout statements request", err)
+	}
+
+	resp, err := Payout().GenerateStatements(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Generate payout statements failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusInternalServerError, "Generate payout statements failed", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,39 @@
+package payouts
+
+import (
+	entityPayment "cbs_backend/internal/modules/payment_transactions/entity"
+	"cbs_backend/internal/modules/payouts/dtopayouts"
+	"context"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	iPayoutService IPayouts
+)
+
+func InitPayoutService(db *gorm.DB, logger *zap.Logger, commissionPercentage float64) {
+	iPayoutService = NewPayoutService(db, logger, commissionPercentage)
+}
+
+func Payout() IPayouts {
+	if iPayoutService == nil {
+		panic("PayoutService not initialized. Call InitPayoutService(db, logger, commissionPercentage) first.")
+	}
+	return iPayoutService
+}
+
+type IPayouts interface {
+	// Ghi sổ: được gọi bên trong transaction của module khác để bút toán và thay đổi trạng thái cùng commit
+	CreditCompletedBooking(ctx context.Context, tx *gorm.DB, bookingID uuid.UUID) error
+	DebitRefund(ctx context.Context, tx *gorm.DB, refund *entityPayment.PaymentTransaction) error
+
+	GenerateStatements(ctx context.Context, req dtopayouts.GenerateStatementsRequest) (*dtopayouts.GenerateStatementsResponse, error)
+	GetMyStatements(ctx context.Context, req dtopayouts.GetMyPayoutStatementsRequest) (*dtopayouts.GetPayoutStatementsResponse, error)
+	GetStatement(ctx context.Context, req dtopayouts.GetPayoutStatementRequest) (*dtopayouts.PayoutStatementResponse, error)
+	DownloadStatement(ctx context.Context, req dtopayouts.GetPayoutStatementRequest) (*dtopayouts.PayoutStatementFile, error)
+	GetStatements(ctx context.Context, req dtopayouts.GetPayoutStatementsRequest) (*dtopayouts.GetPayoutStatementsResponse, error)
+	MarkStatementPaid(ctx context.Context, req dtopayouts.MarkPayoutPaidRequest) (*dtopayouts.PayoutStatementResponse, error)
+}
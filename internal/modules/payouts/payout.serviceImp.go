@@ -0,0 +1,500 @@
+package payouts
+
+import (
+	"bytes"
+	"cbs_backend/internal/common"
+	entityExpert "cbs_backend/internal/modules/experts/entity"
+	entityPayment "cbs_backend/internal/modules/payment_transactions/entity"
+	"cbs_backend/internal/modules/payouts/dtopayouts"
+	entityPayout "cbs_backend/internal/modules/payouts/entity"
+	"cbs_backend/internal/modules/realtime"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultStatementPageSize = 20
+	maxStatementPageSize     = 100
+)
+
+type payoutService struct {
+	db                   *gorm.DB
+	logger               *zap.Logger
+	commissionPercentage float64
+}
+
+func NewPayoutService(db *gorm.DB, logger *zap.Logger, commissionPercentage float64) *payoutService {
+	if commissionPercentage < 0 || commissionPercentage > 100 {
+		logger.Warn("⚠️ Invalid platform commission percentage, fallback to 0", zap.Float64("commission_percentage", commissionPercentage))
+		commissionPercentage = 0
+	}
+	return &payoutService{
+		db:                   db,
+		logger:               logger,
+		commissionPercentage: commissionPercentage,
+	}
+}
+
+// CreditCompletedBooking ghi có cho expert phần doanh thu còn lại (sau các khoản đã hoàn) của mỗi giao dịch thanh toán của booking
+func (ps *payoutService) CreditCompletedBooking(ctx context.Context, tx *gorm.DB, bookingID uuid.UUID) error {
+	var payments []entityPayment.PaymentTransaction
+	if err := tx.WithContext(ctx).
+		Where("booking_id = ? AND transaction_type = ? AND transaction_status IN ?", bookingID, common.TransactionTypePayment,
+			[]string{common.TransactionStatusCompleted, common.TransactionStatusRefunded}).
+		Find(&payments).Error; err != nil {
+		return fmt.Errorf("failed to get booking payments: %w", err)
+	}
+
+	for _, payment := range payments {
+		var refundedMinor int64
+		if err := tx.WithContext(ctx).Model(&entityPayment.PaymentTransaction{}).
+			Where("parent_transaction_id = ? AND transaction_type = ?", payment.TransactionID, common.TransactionTypeRefund).
+			Select("COALESCE(SUM(amount_minor), 0)").Scan(&refundedMinor).Error; err != nil {
+			return fmt.Errorf("failed to calculate refunded amount: %w", err)
+		}
+
+		gross := payment.AmountMinor - refundedMinor
+		if gross <= 0 {
+			continue
+		}
+		commission := common.PercentOfMinor(gross, ps.commissionPercentage)
+		entry := entityPayout.PayoutLedgerEntry{
+			ExpertProfileID:       payment.ExpertProfileID,
+			BookingID:             payment.BookingID,
+			TransactionID:         payment.TransactionID,
+			EntryType:             common.LedgerEntryCredit,
+			GrossAmountMinor:      gross,
+			CommissionPercentage:  ps.commissionPercentage,
+			CommissionAmountMinor: commission,
+			NetAmountMinor:        gross - commission,
+			Currency:              payment.Currency,
+			Description:           fmt.Sprintf("Doanh thu buổi tư vấn %s", payment.BookingID),
+		}
+		// Bút toán đã tồn tại (complete gọi lại) thì bỏ qua
+		if err := tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error; err != nil {
+			return fmt.Errorf("failed to create payout credit: %w", err)
+		}
+	}
+	return nil
+}
+
+// DebitRefund ghi nợ expert khi hoàn tiền cho giao dịch đã được ghi có, dùng lại % hoa hồng của bút toán gốc
+func (ps *payoutService) DebitRefund(ctx context.Context, tx *gorm.DB, refund *entityPayment.PaymentTransaction) error {
+	if refund == nil || refund.ParentTransactionID == nil {
+		return nil
+	}
+
+	var credit entityPayout.PayoutLedgerEntry
+	err := tx.WithContext(ctx).
+		Where("transaction_id = ? AND entry_type = ?", *refund.ParentTransactionID, common.LedgerEntryCredit).
+		First(&credit).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Booking chưa hoàn thành nên expert chưa được ghi có, phần hoàn đã được trừ khi ghi có sau này
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get payout credit: %w", err)
+	}
+
+	gross := refund.AmountMinor
+	commission := common.PercentOfMinor(gross, credit.CommissionPercentage)
+	entry := entityPayout.PayoutLedgerEntry{
+		ExpertProfileID:       credit.ExpertProfileID,
+		BookingID:             credit.BookingID,
+		TransactionID:         refund.TransactionID,
+		EntryType:             common.LedgerEntryDebit,
+		GrossAmountMinor:      -gross,
+		CommissionPercentage:  credit.CommissionPercentage,
+		CommissionAmountMinor: -commission,
+		NetAmountMinor:        -(gross - commission),
+		Currency:              credit.Currency,
+		Description:           fmt.Sprintf("Hoàn tiền buổi tư vấn %s", credit.BookingID),
+	}
+	if err := tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to create payout debit: %w", err)
+	}
+	return nil
+}
+
+type expertCurrency struct {
+	ExpertProfileID uuid.UUID
+	Currency        string
+}
+
+func (ps *payoutService) GenerateStatements(ctx context.Context, req dtopayouts.GenerateStatementsRequest) (*dtopayouts.GenerateStatementsResponse, error) {
+	periodEnd := req.PeriodEnd
+	if periodEnd.IsZero() {
+		y, m, d := time.Now().Date()
+		periodEnd = time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+
+	var groups []expertCurrency
+	if err := ps.db.WithContext(ctx).Model(&entityPayout.PayoutLedgerEntry{}).
+		Distinct("expert_profile_id", "currency").
+		Where("statement_id IS NULL AND entry_created_at < ?", periodEnd).
+		Scan(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get unsettled payout entries: %w", err)
+	}
+
+	resp := &dtopayouts.GenerateStatementsResponse{
+		PeriodEnd:  periodEnd,
+		Statements: make([]dtopayouts.PayoutStatementResponse, 0, len(groups)),
+	}
+	for _, group := range groups {
+		statement, err := ps.settleExpert(ctx, group, periodEnd)
+		if err != nil {
+			// Lỗi của một expert không chặn quyết toán của các expert khác
+			ps.logger.Error("❌ Failed to settle expert payout",
+				zap.String("expert_profile_id", group.ExpertProfileID.String()),
+				zap.String("currency", group.Currency),
+				zap.Error(err))
+			continue
+		}
+		if statement == nil {
+			resp.CarriedOverExperts = append(resp.CarriedOverExperts, group.ExpertProfileID.String())
+			continue
+		}
+		resp.Statements = append(resp.Statements, *toStatementResponse(statement, false))
+	}
+
+	ps.logger.Info("🧾 Payout statements generated",
+		zap.Time("period_end", periodEnd),
+		zap.Int("statements", len(resp.Statements)),
+		zap.Int("carried_over", len(resp.CarriedOverExperts)))
+	return resp, nil
+}
+
+// settleExpert gom các bút toán chưa quyết toán của expert trước periodEnd vào một bảng kê.
+// Trả về nil nếu số dư <= 0, khi đó các bút toán được giữ lại cho kỳ sau.
+func (ps *payoutService) settleExpert(ctx context.Context, group expertCurrency, periodEnd time.Time) (*entityPayout.PayoutStatement, error) {
+	var statement *entityPayout.PayoutStatement
+	err := ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entries []entityPayout.PayoutLedgerEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("expert_profile_id = ? AND currency = ? AND statement_id IS NULL AND entry_created_at < ?",
+				group.ExpertProfileID, group.Currency, periodEnd).
+			Order("entry_created_at ASC").
+			Find(&entries).Error; err != nil {
+			return fmt.Errorf("failed to lock payout entries: %w", err)
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		var gross, commission, net int64
+		entryIDs := make([]uuid.UUID, 0, len(entries))
+		for _, entry := range entries {
+			gross += entry.GrossAmountMinor
+			commission += entry.CommissionAmountMinor
+			net += entry.NetAmountMinor
+			entryIDs = append(entryIDs, entry.LedgerEntryID)
+		}
+		if net <= 0 {
+			return nil
+		}
+
+		// Kỳ bắt đầu từ cuối kỳ trước, expert mới thì từ bút toán đầu tiên
+		periodStart := entries[0].EntryCreatedAt
+		var previous entityPayout.PayoutStatement
+		err := tx.Where("expert_profile_id = ? AND currency = ?", group.ExpertProfileID, group.Currency).
+			Order("period_end DESC").First(&previous).Error
+		if err == nil {
+			periodStart = previous.PeriodEnd
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get previous payout statement: %w", err)
+		}
+
+		statement = &entityPayout.PayoutStatement{
+			ExpertProfileID:       group.ExpertProfileID,
+			Currency:              group.Currency,
+			PeriodStart:           periodStart,
+			PeriodEnd:             periodEnd,
+			GrossAmountMinor:      gross,
+			CommissionAmountMinor: commission,
+			NetAmountMinor:        net,
+			EntryCount:            len(entries),
+			PayoutStatus:          common.PayoutStatusPending,
+		}
+		if err := tx.Create(statement).Error; err != nil {
+			return fmt.Errorf("failed to create payout statement: %w", err)
+		}
+		if err := tx.Model(&entityPayout.PayoutLedgerEntry{}).
+			Where("ledger_entry_id IN ?", entryIDs).
+			Update("statement_id", statement.StatementID).Error; err != nil {
+			return fmt.Errorf("failed to assign payout entries to statement: %w", err)
+		}
+		for i := range entries {
+			entries[i].StatementID = &statement.StatementID
+		}
+		statement.Entries = entries
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+func (ps *payoutService) GetMyStatements(ctx context.Context, req dtopayouts.GetMyPayoutStatementsRequest) (*dtopayouts.GetPayoutStatementsResponse, error) {
+	expertProfileID, err := ps.expertProfileIDForUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return ps.GetStatements(ctx, dtopayouts.GetPayoutStatementsRequest{
+		ExpertProfileID: expertProfileID.String(),
+		PayoutStatus:    req.PayoutStatus,
+		Page:            req.Page,
+		PageSize:        req.PageSize,
+	})
+}
+
+func (ps *payoutService) GetStatements(ctx context.Context, req dtopayouts.GetPayoutStatementsRequest) (*dtopayouts.GetPayoutStatementsResponse, error) {
+	query := ps.db.WithContext(ctx).Model(&entityPayout.PayoutStatement{})
+	if req.ExpertProfileID != "" {
+		expertProfileID, err := uuid.Parse(req.ExpertProfileID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expert profile ID format: %w", err)
+		}
+		query = query.Where("expert_profile_id = ?", expertProfileID)
+	}
+	if req.PayoutStatus != "" {
+		if req.PayoutStatus != common.PayoutStatusPending && req.PayoutStatus != common.PayoutStatusPaid {
+			return nil, fmt.Errorf("invalid payout status: %s", req.PayoutStatus)
+		}
+		query = query.Where("payout_status = ?", req.PayoutStatus)
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 {
+		req.PageSize = defaultStatementPageSize
+	}
+	if req.PageSize > maxStatementPageSize {
+		req.PageSize = maxStatementPageSize
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to count payout statements: %w", err)
+	}
+
+	var statements []entityPayout.PayoutStatement
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Limit(req.PageSize).Offset(offset).Order("period_end DESC").Find(&statements).Error; err != nil {
+		return nil, fmt.Errorf("failed to get payout statements: %w", err)
+	}
+
+	resp := &dtopayouts.GetPayoutStatementsResponse{
+		Statements:  make([]dtopayouts.PayoutStatementResponse, 0, len(statements)),
+		TotalCount:  int(totalCount),
+		CurrentPage: req.Page,
+		PageSize:    req.PageSize,
+		TotalPages:  int((totalCount + int64(req.PageSize) - 1) / int64(req.PageSize)),
+	}
+	for i := range statements {
+		resp.Statements = append(resp.Statements, *toStatementResponse(&statements[i], false))
+	}
+	return resp, nil
+}
+
+func (ps *payoutService) GetStatement(ctx context.Context, req dtopayouts.GetPayoutStatementRequest) (*dtopayouts.PayoutStatementResponse, error) {
+	statement, err := ps.loadStatement(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return toStatementResponse(statement, true), nil
+}
+
+// DownloadStatement xuất bảng kê dạng CSV, mỗi dòng chi tiết gắn với một PaymentTransaction
+func (ps *payoutService) DownloadStatement(ctx context.Context, req dtopayouts.GetPayoutStatementRequest) (*dtopayouts.PayoutStatementFile, error) {
+	statement, err := ps.loadStatement(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	rows := [][]string{
+		{"Statement ID", statement.StatementID.String()},
+		{"Expert Profile ID", statement.ExpertProfileID.String()},
+		{"Period", statement.PeriodStart.Format("02/01/2006 15:04"), statement.PeriodEnd.Format("02/01/2006 15:04")},
+		{"Currency", statement.Currency},
+		{"Payout Status", statement.PayoutStatus},
+		{},
+		{"Date", "Booking ID", "Transaction ID", "Entry Type", "Gross Amount", "Commission %", "Commission Amount", "Net Amount", "Description"},
+	}
+	for _, entry := range statement.Entries {
+		rows = append(rows, []string{
+			entry.EntryCreatedAt.Format("02/01/2006 15:04"),
+			entry.BookingID.String(),
+			entry.TransactionID.String(),
+			entry.EntryType,
+			common.FormatMinorUnits(entry.GrossAmountMinor, entry.Currency),
+			fmt.Sprintf("%.2f", entry.CommissionPercentage),
+			common.FormatMinorUnits(entry.CommissionAmountMinor, entry.Currency),
+			common.FormatMinorUnits(entry.NetAmountMinor, entry.Currency),
+			entry.Description,
+		})
+	}
+	rows = append(rows, []string{"Total", "", "", "",
+		common.FormatMinorUnits(statement.GrossAmountMinor, statement.Currency), "",
+		common.FormatMinorUnits(statement.CommissionAmountMinor, statement.Currency),
+		common.FormatMinorUnits(statement.NetAmountMinor, statement.Currency), ""})
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("failed to write payout statement: %w", err)
+	}
+
+	return &dtopayouts.PayoutStatementFile{
+		FileName:    fmt.Sprintf("payout_statement_%s_%s.csv", statement.PeriodEnd.Format("20060102"), statement.StatementID.String()[:8]),
+		ContentType: "text/csv",
+		Content:     buf.Bytes(),
+	}, nil
+}
+
+func (ps *payoutService) MarkStatementPaid(ctx context.Context, req dtopayouts.MarkPayoutPaidRequest) (*dtopayouts.PayoutStatementResponse, error) {
+	statementID, err := uuid.Parse(req.StatementID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid statement ID format: %w", err)
+	}
+	adminUserID, err := uuid.Parse(req.AdminUserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid admin user ID format: %w", err)
+	}
+
+	var statement entityPayout.PayoutStatement
+	err = ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&statement, "statement_id = ?", statementID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("payout statement not found")
+			}
+			return fmt.Errorf("failed to get payout statement: %w", err)
+		}
+		if statement.PayoutStatus != common.PayoutStatusPending {
+			return fmt.Errorf("payout statement is already %s", statement.PayoutStatus)
+		}
+
+		now := time.Now()
+		statement.PayoutStatus = common.PayoutStatusPaid
+		statement.PayoutReference = &req.PayoutReference
+		if req.Note != "" {
+			statement.PayoutNote = &req.Note
+		}
+		statement.PaidAt = &now
+		statement.PaidByUserID = &adminUserID
+		if err := tx.Save(&statement).Error; err != nil {
+			return fmt.Errorf("failed to mark payout statement as paid: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ps.logger.Info("💰 Payout statement marked as paid",
+		zap.String("statement_id", statement.StatementID.String()),
+		zap.String("payout_reference", req.PayoutReference),
+		zap.String("admin_user_id", adminUserID.String()))
+
+	go func() {
+		message := fmt.Sprintf("Khoản thanh toán %s %s cho kỳ đến %s đã được chuyển",
+			common.FormatMinorUnits(statement.NetAmountMinor, statement.Currency), statement.Currency, statement.PeriodEnd.Format("02/01/2006"))
+		_ = realtime.Send(statement.ExpertProfileID.String(), message)
+	}()
+
+	return toStatementResponse(&statement, false), nil
+}
+
+// loadStatement lấy bảng kê kèm các dòng chi tiết; expert chỉ được xem bảng kê của chính mình
+func (ps *payoutService) loadStatement(ctx context.Context, req dtopayouts.GetPayoutStatementRequest) (*entityPayout.PayoutStatement, error) {
+	statementID, err := uuid.Parse(req.StatementID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid statement ID format: %w", err)
+	}
+
+	var statement entityPayout.PayoutStatement
+	if err := ps.db.WithContext(ctx).
+		Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("entry_created_at ASC") }).
+		First(&statement, "statement_id = ?", statementID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("payout statement not found")
+		}
+		return nil, fmt.Errorf("failed to get payout statement: %w", err)
+	}
+
+	if !req.IsAdmin {
+		expertProfileID, err := ps.expertProfileIDForUser(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if statement.ExpertProfileID != expertProfileID {
+			return nil, fmt.Errorf("unauthorized")
+		}
+	}
+	return &statement, nil
+}
+
+func (ps *payoutService) expertProfileIDForUser(ctx context.Context, userID string) (uuid.UUID, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	var profile entityExpert.ExpertProfile
+	if err := ps.db.WithContext(ctx).Select("expert_profile_id").
+		First(&profile, "user_id = ?", userUUID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, fmt.Errorf("expert profile not found")
+		}
+		return uuid.Nil, fmt.Errorf("failed to get expert profile: %w", err)
+	}
+	return profile.ExpertProfileID, nil
+}
+
+func toStatementResponse(statement *entityPayout.PayoutStatement, withItems bool) *dtopayouts.PayoutStatementResponse {
+	resp := &dtopayouts.PayoutStatementResponse{
+		StatementID:      statement.StatementID.String(),
+		ExpertProfileID:  statement.ExpertProfileID.String(),
+		Currency:         statement.Currency,
+		PeriodStart:      statement.PeriodStart,
+		PeriodEnd:        statement.PeriodEnd,
+		GrossAmount:      common.FromMinorUnits(statement.GrossAmountMinor, statement.Currency),
+		CommissionAmount: common.FromMinorUnits(statement.CommissionAmountMinor, statement.Currency),
+		NetAmount:        common.FromMinorUnits(statement.NetAmountMinor, statement.Currency),
+		EntryCount:       statement.EntryCount,
+		PayoutStatus:     statement.PayoutStatus,
+		PayoutReference:  statement.PayoutReference,
+		PayoutNote:       statement.PayoutNote,
+		PaidAt:           statement.PaidAt,
+		CreatedAt:        statement.StatementCreatedAt,
+	}
+	if withItems {
+		resp.Items = make([]dtopayouts.PayoutLedgerEntryResponse, 0, len(statement.Entries))
+		for _, entry := range statement.Entries {
+			resp.Items = append(resp.Items, dtopayouts.PayoutLedgerEntryResponse{
+				LedgerEntryID:        entry.LedgerEntryID.String(),
+				BookingID:            entry.BookingID.String(),
+				TransactionID:        entry.TransactionID.String(),
+				EntryType:            entry.EntryType,
+				GrossAmount:          common.FromMinorUnits(entry.GrossAmountMinor, entry.Currency),
+				CommissionPercentage: entry.CommissionPercentage,
+				CommissionAmount:     common.FromMinorUnits(entry.CommissionAmountMinor, entry.Currency),
+				NetAmount:            common.FromMinorUnits(entry.NetAmountMinor, entry.Currency),
+				Currency:             entry.Currency,
+				Description:          entry.Description,
+				CreatedAt:            entry.EntryCreatedAt,
+			})
+		}
+	}
+	return resp
+}
@@ -0,0 +1,75 @@
+package dtopromotions
+
+import "time"
+
+type CreateCouponRequest struct {
+	CouponCode        string     `json:"coupon_code" binding:"required"`
+	CampaignName      string     `json:"campaign_name"`
+	Description       *string    `json:"description,omitempty"`
+	DiscountType      string     `json:"discount_type" binding:"required"`
+	DiscountValue     float64    `json:"discount_value" binding:"required"`
+	MaxDiscountAmount *float64   `json:"max_discount_amount,omitempty"`
+	MinBookingAmount  *float64   `json:"min_booking_amount,omitempty"`
+	Currency          string     `json:"currency,omitempty"`
+	UsageLimitTotal   *int       `json:"usage_limit_total,omitempty"`
+	UsageLimitPerUser *int       `json:"usage_limit_per_user,omitempty"`
+	ExpertProfileIDs  []string   `json:"expert_profile_ids,omitempty"`
+	Specializations   []string   `json:"specializations,omitempty"`
+	ConsultationTypes []string   `json:"consultation_types,omitempty"`
+	ValidFrom         time.Time  `json:"valid_from" binding:"required"`
+	ValidUntil        *time.Time `json:"valid_until,omitempty"`
+	AdminUserID       string     `json:"-"`
+}
+
+// UpdateCouponRequest chỉ cập nhật các trường được gửi lên; mã coupon không đổi được vì đã gắn với các lượt dùng
+type UpdateCouponRequest struct {
+	CouponID          string     `json:"coupon_id" binding:"required"`
+	CampaignName      *string    `json:"campaign_name,omitempty"`
+	Description       *string    `json:"description,omitempty"`
+	MaxDiscountAmount *float64   `json:"max_discount_amount,omitempty"`
+	MinBookingAmount  *float64   `json:"min_booking_amount,omitempty"`
+	UsageLimitTotal   *int       `json:"usage_limit_total,omitempty"`
+	UsageLimitPerUser *int       `json:"usage_limit_per_user,omitempty"`
+	ExpertProfileIDs  []string   `json:"expert_profile_ids,omitempty"`
+	Specializations   []string   `json:"specializations,omitempty"`
+	ConsultationTypes []string   `json:"consultation_types,omitempty"`
+	ValidUntil        *time.Time `json:"valid_until,omitempty"`
+	IsActive          *bool      `json:"is_active,omitempty"`
+}
+
+type CouponResponse struct {
+	CouponID          string     `json:"coupon_id"`
+	CouponCode        string     `json:"coupon_code"`
+	CampaignName      string     `json:"campaign_name"`
+	Description       *string    `json:"description,omitempty"`
+	DiscountType      string     `json:"discount_type"`
+	DiscountValue     float64    `json:"discount_value"`
+	MaxDiscountAmount *float64   `json:"max_discount_amount,omitempty"`
+	MinBookingAmount  *float64   `json:"min_booking_amount,omitempty"`
+	Currency          string     `json:"currency"`
+	UsageLimitTotal   *int       `json:"usage_limit_total,omitempty"`
+	UsageLimitPerUser *int       `json:"usage_limit_per_user,omitempty"`
+	UsedCount         int        `json:"used_count"`
+	ExpertProfileIDs  []string   `json:"expert_profile_ids"`
+	Specializations   []string   `json:"specializations"`
+	ConsultationTypes []string   `json:"consultation_types"`
+	ValidFrom         time.Time  `json:"valid_from"`
+	ValidUntil        *time.Time `json:"valid_until,omitempty"`
+	IsActive          bool       `json:"is_active"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type GetCouponsRequest struct {
+	CampaignName string `form:"campaign_name"`
+	IsActive     *bool  `form:"is_active"`
+	Page         int    `form:"page"`
+	PageSize     int    `form:"page_size"`
+}
+
+type GetCouponsResponse struct {
+	Coupons     []CouponResponse `json:"coupons"`
+	TotalCount  int              `json:"total_count"`
+	CurrentPage int              `json:"current_page"`
+	PageSize    int              `json:"page_size"`
+	TotalPages  int              `json:"total_pages"`
+}
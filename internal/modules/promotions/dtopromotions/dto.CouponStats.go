@@ -0,0 +1,26 @@
+package dtopromotions
+
+type GetCouponStatsRequest struct {
+	CampaignName string `form:"campaign_name"`
+	CouponID     string `form:"coupon_id"`
+}
+
+// CouponStats là thống kê của một mã trong một tiền tệ booking
+type CouponStats struct {
+	CouponID             string  `json:"coupon_id"`
+	CouponCode           string  `json:"coupon_code"`
+	CampaignName         string  `json:"campaign_name"`
+	Currency             string  `json:"currency"`
+	AppliedCount         int64   `json:"applied_count"`  // Đã áp dụng, chưa thanh toán
+	RedeemedCount        int64   `json:"redeemed_count"` // Đã thanh toán
+	ReleasedCount        int64   `json:"released_count"` // Booking bị hủy trước khi thanh toán
+	UniqueUsers          int64   `json:"unique_users"`
+	TotalDiscount        float64 `json:"total_discount"`   // Tổng tiền giảm của các lượt đã thanh toán
+	RedeemedRevenue      float64 `json:"redeemed_revenue"` // Tổng tiền thu được từ các lượt đã thanh toán
+	TotalDiscountMinor   int64   `json:"total_discount_minor"`
+	RedeemedRevenueMinor int64   `json:"redeemed_revenue_minor"`
+}
+
+type GetCouponStatsResponse struct {
+	Coupons []CouponStats `json:"coupons"`
+}
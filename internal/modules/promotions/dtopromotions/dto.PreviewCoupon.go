@@ -0,0 +1,55 @@
+package dtopromotions
+
+import (
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PreviewCouponRequest struct {
+	CouponCode       string `json:"coupon_code" binding:"required"`
+	ExpertProfileID  string `json:"expert_profile_id" binding:"required"`
+	ConsultationType string `json:"consultation_type" binding:"required"`
+	DurationMinutes  int    `json:"duration_minutes" binding:"required"`
+	UserID           string `json:"-"`
+}
+
+type PreviewCouponResponse struct {
+	Coupon CouponEvaluation        `json:"coupon"`
+	Quote  *dtobookings.PriceQuote `json:"quote"`
+}
+
+// CheckCouponInput là ngữ cảnh đặt lịch dùng để kiểm tra điều kiện của mã
+type CheckCouponInput struct {
+	CouponCode       string
+	UserID           uuid.UUID
+	ExpertProfileID  uuid.UUID
+	ConsultationType string
+	AmountMinor      int64 // Đơn vị nhỏ nhất của Currency
+	Currency         string
+	At               time.Time
+}
+
+// CouponEvaluation là kết quả áp dụng mã lên một số tiền; các trường *Minor là giá trị gốc ở đơn vị nhỏ nhất
+type CouponEvaluation struct {
+	CouponID            uuid.UUID `json:"coupon_id"`
+	CouponCode          string    `json:"coupon_code"`
+	CampaignName        string    `json:"campaign_name"`
+	DiscountType        string    `json:"discount_type"`
+	DiscountValue       float64   `json:"discount_value"`
+	Currency            string    `json:"currency"`
+	OriginalAmount      float64   `json:"original_amount"`
+	DiscountAmount      float64   `json:"discount_amount"`
+	FinalAmount         float64   `json:"final_amount"`
+	OriginalAmountMinor int64     `json:"original_amount_minor"`
+	DiscountAmountMinor int64     `json:"discount_amount_minor"`
+	FinalAmountMinor    int64     `json:"final_amount_minor"`
+}
+
+type RedeemCouponInput struct {
+	Evaluation *CouponEvaluation
+	UserID     uuid.UUID
+	BookingID  uuid.UUID
+	Currency   string
+}
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Coupon represents tbl_coupons table
+// Các danh sách giới hạn (expert, chuyên môn, hình thức tư vấn) rỗng nghĩa là không giới hạn.
+type Coupon struct {
+	CouponID               uuid.UUID      `json:"coupon_id" db:"coupon_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	CouponCode             string         `json:"coupon_code" db:"coupon_code" gorm:"type:varchar(50);not null;uniqueIndex"`
+	CampaignName           string         `json:"campaign_name" db:"campaign_name" gorm:"type:varchar(100);index"`
+	Description            *string        `json:"description,omitempty" db:"description" gorm:"type:text"`
+	DiscountType           string         `json:"discount_type" db:"discount_type" gorm:"type:varchar(20);not null;check:discount_type IN ('percentage', 'fixed')"`
+	DiscountPercentage     *float64       `json:"discount_percentage,omitempty" db:"discount_percentage" gorm:"type:decimal(5,2)"`       // Chỉ với mã percentage
+	DiscountAmountMinor    *int64         `json:"discount_amount_minor,omitempty" db:"discount_amount_minor" gorm:"type:bigint"`         // Chỉ với mã fixed, đơn vị nhỏ nhất của Currency
+	MaxDiscountAmountMinor *int64         `json:"max_discount_amount_minor,omitempty" db:"max_discount_amount_minor" gorm:"type:bigint"` // Trần giảm cho mã phần trăm
+	MinBookingAmountMinor  *int64         `json:"min_booking_amount_minor,omitempty" db:"min_booking_amount_minor" gorm:"type:bigint"`
+	Currency               string         `json:"currency" db:"currency" gorm:"type:varchar(3);not null;default:'VND'"` // Tiền tệ của các mức tiền cố định ở trên
+	UsageLimitTotal        *int           `json:"usage_limit_total,omitempty" db:"usage_limit_total"`
+	UsageLimitPerUser      *int           `json:"usage_limit_per_user,omitempty" db:"usage_limit_per_user"`
+	UsedCount              int            `json:"used_count" db:"used_count" gorm:"default:0"`
+	ExpertProfileIDs       pq.StringArray `json:"expert_profile_ids" db:"expert_profile_ids" gorm:"type:text[]"`
+	Specializations        pq.StringArray `json:"specializations" db:"specializations" gorm:"type:text[]"`
+	ConsultationTypes      pq.StringArray `json:"consultation_types" db:"consultation_types" gorm:"type:text[]"`
+	ValidFrom              time.Time      `json:"valid_from" db:"valid_from" gorm:"not null"`
+	ValidUntil             *time.Time     `json:"valid_until,omitempty" db:"valid_until"`
+	IsActive               bool           `json:"is_active" db:"is_active" gorm:"default:true"`
+	CreatedByUserID        *uuid.UUID     `json:"created_by_user_id,omitempty" db:"created_by_user_id" gorm:"type:uuid"`
+	CouponCreatedAt        time.Time      `json:"coupon_created_at" db:"coupon_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	CouponUpdatedAt        time.Time      `json:"coupon_updated_at" db:"coupon_updated_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func (Coupon) TableName() string {
+	return "tbl_coupons"
+}
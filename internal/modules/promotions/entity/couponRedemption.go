@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"time"
+
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+
+	"github.com/google/uuid"
+)
+
+// CouponRedemption represents tbl_coupon_redemptions table
+// Mỗi booking dùng tối đa một mã; TransactionID trỏ tới PaymentTransaction đã thanh toán booking để đo hiệu quả campaign.
+type CouponRedemption struct {
+	RedemptionID        uuid.UUID  `json:"redemption_id" db:"redemption_id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	CouponID            uuid.UUID  `json:"coupon_id" db:"coupon_id" gorm:"type:uuid;not null;index;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserID              uuid.UUID  `json:"user_id" db:"user_id" gorm:"type:uuid;not null;index"`
+	BookingID           uuid.UUID  `json:"booking_id" db:"booking_id" gorm:"type:uuid;not null;uniqueIndex"`
+	TransactionID       *uuid.UUID `json:"transaction_id,omitempty" db:"transaction_id" gorm:"type:uuid;index"`
+	OriginalAmountMinor int64      `json:"original_amount_minor" db:"original_amount_minor" gorm:"type:bigint;not null;default:0"` // Đơn vị nhỏ nhất của Currency
+	DiscountAmountMinor int64      `json:"discount_amount_minor" db:"discount_amount_minor" gorm:"type:bigint;not null;default:0"`
+	FinalAmountMinor    int64      `json:"final_amount_minor" db:"final_amount_minor" gorm:"type:bigint;not null;default:0"`
+	Currency            string     `json:"currency" db:"currency" gorm:"type:varchar(3);default:'VND'"`
+	RedemptionStatus    string     `json:"redemption_status" db:"redemption_status" gorm:"type:varchar(20);default:'applied';check:redemption_status IN ('applied', 'redeemed', 'released')"`
+	RedeemedAt          *time.Time `json:"redeemed_at,omitempty" db:"redeemed_at"`
+	ReleasedAt          *time.Time `json:"released_at,omitempty" db:"released_at"`
+	RedemptionCreatedAt time.Time  `json:"redemption_created_at" db:"redemption_created_at" gorm:"default:CURRENT_TIMESTAMP"`
+
+	Coupon  *Coupon                            `json:"coupon,omitempty" gorm:"foreignKey:CouponID;references:CouponID"`
+	Booking *entityBooking.ConsultationBooking `json:"booking,omitempty" gorm:"foreignKey:BookingID;references:BookingID"`
+}
+
+func (CouponRedemption) TableName() string {
+	return "tbl_coupon_redemptions"
+}
@@ -0,0 +1,119 @@
+package promotions
+
+import (
+	"cbs_backend/internal/modules/promotions/dtopromotions"
+	"cbs_backend/pkg/response"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type PromotionController struct {
+	Logger *zap.Logger
+}
+
+func NewPromotionController(logger *zap.Logger) *PromotionController {
+	return &PromotionController{Logger: logger}
+}
+
+// getUserIDFromContext lấy userID đã được AuthMiddleware gắn vào context
+func getUserIDFromContext(c *gin.Context) (string, error) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		return "", response.NewAPIError(http.StatusUnauthorized, "Unauthorized", "UserID not found in context")
+	}
+	userID, ok := userIDValue.(uuid.UUID)
+	if !ok {
+		return "", response.NewAPIError(http.StatusInternalServerError, "Internal error", "Invalid userID type")
+	}
+	return userID.String(), nil
+}
+
+func (pc *PromotionController) PreviewCoupon(c *gin.Context) (res interface{}, err error) {
+	var req dtopromotions.PreviewCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.Logger.Error("Invalid preview coupon request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid preview coupon request", err)
+	}
+	if req.UserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Promotion().PreviewCoupon(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Preview coupon failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Preview coupon failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PromotionController) CreateCoupon(c *gin.Context) (res interface{}, err error) {
+	var req dtopromotions.CreateCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.Logger.Error("Invalid create coupon request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid create coupon request", err)
+	}
+	if req.AdminUserID, err = getUserIDFromContext(c); err != nil {
+		return nil, err
+	}
+
+	resp, err := Promotion().CreateCoupon(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Create coupon failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Create coupon failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PromotionController) UpdateCoupon(c *gin.Context) (res interface{}, err error) {
+	var req dtopromotions.UpdateCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		pc.Logger.Error("Invalid update coupon request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid update coupon request", err)
+	}
+
+	resp, err := Promotion().UpdateCoupon(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Update coupon failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Update coupon failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PromotionController) GetCoupons(c *gin.Context) (res interface{}, err error) {
+	var req dtopromotions.GetCouponsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		pc.Logger.Error("Invalid get coupons request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get coupons request", err)
+	}
+
+	resp, err := Promotion().GetCoupons(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Get coupons failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get coupons failed", err)
+	}
+
+	return resp, nil
+}
+
+func (pc *PromotionController) GetCouponStats(c *gin.Context) (res interface{}, err error) {
+	var req dtopromotions.GetCouponStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		pc.Logger.Error("Invalid get coupon stats request", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Invalid get coupon stats request", err)
+	}
+
+	resp, err := Promotion().GetCouponStats(context.Background(), req)
+	if err != nil {
+		pc.Logger.Error("Get coupon stats failed", zap.Error(err))
+		return nil, response.NewAPIError(http.StatusBadRequest, "Get coupon stats failed", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,43 @@
+package promotions
+
+import (
+	"cbs_backend/internal/modules/promotions/dtopromotions"
+	"context"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	iPromotionService IPromotions
+)
+
+func InitPromotionService(db *gorm.DB, logger *zap.Logger) {
+	iPromotionService = NewPromotionService(db, logger)
+}
+
+func Promotion() IPromotions {
+	if iPromotionService == nil {
+		panic("PromotionService not initialized. Call InitPromotionService(db, logger) first.")
+	}
+	return iPromotionService
+}
+
+type IPromotions interface {
+	// Admin
+	CreateCoupon(ctx context.Context, req dtopromotions.CreateCouponRequest) (*dtopromotions.CouponResponse, error)
+	UpdateCoupon(ctx context.Context, req dtopromotions.UpdateCouponRequest) (*dtopromotions.CouponResponse, error)
+	GetCoupons(ctx context.Context, req dtopromotions.GetCouponsRequest) (*dtopromotions.GetCouponsResponse, error)
+	GetCouponStats(ctx context.Context, req dtopromotions.GetCouponStatsRequest) (*dtopromotions.GetCouponStatsResponse, error)
+
+	// User
+	PreviewCoupon(ctx context.Context, req dtopromotions.PreviewCouponRequest) (*dtopromotions.PreviewCouponResponse, error)
+
+	// Dùng bởi booking/payment; các hàm nhận tx để lượt dùng mã commit cùng booking/giao dịch
+	EvaluateCoupon(ctx context.Context, input dtopromotions.CheckCouponInput) (*dtopromotions.CouponEvaluation, error)
+	RedeemCoupon(ctx context.Context, tx *gorm.DB, input dtopromotions.RedeemCouponInput) error
+	AttachTransaction(ctx context.Context, tx *gorm.DB, bookingID, transactionID uuid.UUID) error
+	ConfirmRedemption(ctx context.Context, tx *gorm.DB, bookingID, transactionID uuid.UUID) error
+	ReleaseRedemption(ctx context.Context, tx *gorm.DB, bookingID uuid.UUID) error
+}
@@ -0,0 +1,652 @@
+package promotions
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	entityExpert "cbs_backend/internal/modules/experts/entity"
+	"cbs_backend/internal/modules/promotions/dtopromotions"
+	entityPromotion "cbs_backend/internal/modules/promotions/entity"
+	"cbs_backend/utils/helper"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultCouponPageSize = 20
+	maxCouponPageSize     = 100
+)
+
+type promotionService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	helper *helper.HelperBooking
+}
+
+func NewPromotionService(db *gorm.DB, logger *zap.Logger) *promotionService {
+	return &promotionService{
+		db:     db,
+		logger: logger,
+		helper: helper.NewHelperBooking(db),
+	}
+}
+
+// normalizeCouponCode: mã không phân biệt hoa thường
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (ps *promotionService) CreateCoupon(ctx context.Context, req dtopromotions.CreateCouponRequest) (*dtopromotions.CouponResponse, error) {
+	code := normalizeCouponCode(req.CouponCode)
+	if code == "" {
+		return nil, fmt.Errorf("coupon code is required")
+	}
+	adminUserID, err := uuid.Parse(req.AdminUserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid admin user ID format: %w", err)
+	}
+
+	currency := common.NormalizeCurrency(req.Currency)
+	coupon := entityPromotion.Coupon{
+		CouponCode:             code,
+		CampaignName:           strings.TrimSpace(req.CampaignName),
+		Description:            req.Description,
+		DiscountType:           req.DiscountType,
+		MaxDiscountAmountMinor: amountToMinorUnits(req.MaxDiscountAmount, currency),
+		MinBookingAmountMinor:  amountToMinorUnits(req.MinBookingAmount, currency),
+		Currency:               currency,
+		UsageLimitTotal:        req.UsageLimitTotal,
+		UsageLimitPerUser:      req.UsageLimitPerUser,
+		ExpertProfileIDs:       pq.StringArray(req.ExpertProfileIDs),
+		Specializations:        pq.StringArray(req.Specializations),
+		ConsultationTypes:      pq.StringArray(req.ConsultationTypes),
+		ValidFrom:              req.ValidFrom,
+		ValidUntil:             req.ValidUntil,
+		IsActive:               true,
+		CreatedByUserID:        &adminUserID,
+	}
+	// Mã phần trăm lưu % giảm, mã cố định lưu số tiền giảm theo đơn vị nhỏ nhất của Currency
+	switch req.DiscountType {
+	case common.DiscountTypePercentage:
+		coupon.DiscountPercentage = &req.DiscountValue
+	case common.DiscountTypeFixed:
+		coupon.DiscountAmountMinor = amountToMinorUnits(&req.DiscountValue, currency)
+	}
+	if err := validateCoupon(&coupon); err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	if err := ps.db.WithContext(ctx).Model(&entityPromotion.Coupon{}).
+		Where("coupon_code = ?", code).Count(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to check coupon code: %w", err)
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("coupon code %s already exists", code)
+	}
+
+	if err := ps.db.WithContext(ctx).Create(&coupon).Error; err != nil {
+		return nil, fmt.Errorf("failed to create coupon: %w", err)
+	}
+
+	ps.logger.Info("🎟️ Coupon created",
+		zap.String("coupon_id", coupon.CouponID.String()),
+		zap.String("coupon_code", coupon.CouponCode),
+		zap.String("campaign", coupon.CampaignName))
+	return toCouponResponse(&coupon), nil
+}
+
+func (ps *promotionService) UpdateCoupon(ctx context.Context, req dtopromotions.UpdateCouponRequest) (*dtopromotions.CouponResponse, error) {
+	couponID, err := uuid.Parse(req.CouponID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid coupon ID format: %w", err)
+	}
+
+	var coupon entityPromotion.Coupon
+	err = ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&coupon, "coupon_id = ?", couponID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("coupon not found")
+			}
+			return fmt.Errorf("failed to get coupon: %w", err)
+		}
+
+		if req.CampaignName != nil {
+			coupon.CampaignName = strings.TrimSpace(*req.CampaignName)
+		}
+		if req.Description != nil {
+			coupon.Description = req.Description
+		}
+		if req.MaxDiscountAmount != nil {
+			coupon.MaxDiscountAmountMinor = amountToMinorUnits(req.MaxDiscountAmount, coupon.Currency)
+		}
+		if req.MinBookingAmount != nil {
+			coupon.MinBookingAmountMinor = amountToMinorUnits(req.MinBookingAmount, coupon.Currency)
+		}
+		if req.UsageLimitTotal != nil {
+			coupon.UsageLimitTotal = req.UsageLimitTotal
+		}
+		if req.UsageLimitPerUser != nil {
+			coupon.UsageLimitPerUser = req.UsageLimitPerUser
+		}
+		if req.ExpertProfileIDs != nil {
+			coupon.ExpertProfileIDs = pq.StringArray(req.ExpertProfileIDs)
+		}
+		if req.Specializations != nil {
+			coupon.Specializations = pq.StringArray(req.Specializations)
+		}
+		if req.ConsultationTypes != nil {
+			coupon.ConsultationTypes = pq.StringArray(req.ConsultationTypes)
+		}
+		if req.ValidUntil != nil {
+			coupon.ValidUntil = req.ValidUntil
+		}
+		if req.IsActive != nil {
+			coupon.IsActive = *req.IsActive
+		}
+		if err := validateCoupon(&coupon); err != nil {
+			return err
+		}
+
+		coupon.CouponUpdatedAt = time.Now()
+		if err := tx.Save(&coupon).Error; err != nil {
+			return fmt.Errorf("failed to update coupon: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toCouponResponse(&coupon), nil
+}
+
+func (ps *promotionService) GetCoupons(ctx context.Context, req dtopromotions.GetCouponsRequest) (*dtopromotions.GetCouponsResponse, error) {
+	query := ps.db.WithContext(ctx).Model(&entityPromotion.Coupon{})
+	if req.CampaignName != "" {
+		query = query.Where("campaign_name = ?", req.CampaignName)
+	}
+	if req.IsActive != nil {
+		query = query.Where("is_active = ?", *req.IsActive)
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 {
+		req.PageSize = defaultCouponPageSize
+	}
+	if req.PageSize > maxCouponPageSize {
+		req.PageSize = maxCouponPageSize
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, fmt.Errorf("failed to count coupons: %w", err)
+	}
+
+	var coupons []entityPromotion.Coupon
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Limit(req.PageSize).Offset(offset).Order("coupon_created_at DESC").Find(&coupons).Error; err != nil {
+		return nil, fmt.Errorf("failed to get coupons: %w", err)
+	}
+
+	resp := &dtopromotions.GetCouponsResponse{
+		Coupons:     make([]dtopromotions.CouponResponse, 0, len(coupons)),
+		TotalCount:  int(totalCount),
+		CurrentPage: req.Page,
+		PageSize:    req.PageSize,
+		TotalPages:  int((totalCount + int64(req.PageSize) - 1) / int64(req.PageSize)),
+	}
+	for i := range coupons {
+		resp.Coupons = append(resp.Coupons, *toCouponResponse(&coupons[i]))
+	}
+	return resp, nil
+}
+
+// GetCouponStats tổng hợp lượt dùng theo từng mã và tiền tệ của booking để marketing đo hiệu quả campaign
+func (ps *promotionService) GetCouponStats(ctx context.Context, req dtopromotions.GetCouponStatsRequest) (*dtopromotions.GetCouponStatsResponse, error) {
+	query := ps.db.WithContext(ctx).Table("tbl_coupons c").
+		Select(`c.coupon_id, c.coupon_code, c.campaign_name, COALESCE(r.currency, c.currency) AS currency,
+			COUNT(r.redemption_id) FILTER (WHERE r.redemption_status = ?) AS applied_count,
+			COUNT(r.redemption_id) FILTER (WHERE r.redemption_status = ?) AS redeemed_count,
+			COUNT(r.redemption_id) FILTER (WHERE r.redemption_status = ?) AS released_count,
+			COUNT(DISTINCT r.user_id) FILTER (WHERE r.redemption_status <> ?) AS unique_users,
+			COALESCE(SUM(r.discount_amount_minor) FILTER (WHERE r.redemption_status = ?), 0) AS total_discount_minor,
+			COALESCE(SUM(r.final_amount_minor) FILTER (WHERE r.redemption_status = ?), 0) AS redeemed_revenue_minor`,
+			common.RedemptionStatusApplied, common.RedemptionStatusRedeemed, common.RedemptionStatusReleased,
+			common.RedemptionStatusReleased, common.RedemptionStatusRedeemed, common.RedemptionStatusRedeemed).
+		Joins("LEFT JOIN tbl_coupon_redemptions r ON r.coupon_id = c.coupon_id").
+		Group("c.coupon_id, c.coupon_code, c.campaign_name, COALESCE(r.currency, c.currency)").
+		Order("c.campaign_name ASC, c.coupon_code ASC, currency ASC")
+	if req.CampaignName != "" {
+		query = query.Where("c.campaign_name = ?", req.CampaignName)
+	}
+	if req.CouponID != "" {
+		couponID, err := uuid.Parse(req.CouponID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coupon ID format: %w", err)
+		}
+		query = query.Where("c.coupon_id = ?", couponID)
+	}
+
+	var stats []dtopromotions.CouponStats
+	if err := query.Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to get coupon stats: %w", err)
+	}
+	if stats == nil {
+		stats = []dtopromotions.CouponStats{}
+	}
+	for i := range stats {
+		stats[i].TotalDiscount = common.FromMinorUnits(stats[i].TotalDiscountMinor, stats[i].Currency)
+		stats[i].RedeemedRevenue = common.FromMinorUnits(stats[i].RedeemedRevenueMinor, stats[i].Currency)
+	}
+	return &dtopromotions.GetCouponStatsResponse{Coupons: stats}, nil
+}
+
+func (ps *promotionService) PreviewCoupon(ctx context.Context, req dtopromotions.PreviewCouponRequest) (*dtopromotions.PreviewCouponResponse, error) {
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+	expertID, err := uuid.Parse(req.ExpertProfileID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expert profile ID format: %w", err)
+	}
+
+	now := time.Now()
+	quote, err := ps.helper.CalculateConsultationFee(ctx, expertID, req.ConsultationType, req.DurationMinutes, now)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluation, err := ps.EvaluateCoupon(ctx, dtopromotions.CheckCouponInput{
+		CouponCode:       req.CouponCode,
+		UserID:           userID,
+		ExpertProfileID:  expertID,
+		ConsultationType: req.ConsultationType,
+		AmountMinor:      quote.TotalAmountMinor,
+		Currency:         quote.Currency,
+		At:               now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ApplyToQuote(quote, evaluation)
+
+	return &dtopromotions.PreviewCouponResponse{
+		Coupon: *evaluation,
+		Quote:  quote,
+	}, nil
+}
+
+// EvaluateCoupon kiểm tra mã với ngữ cảnh đặt lịch và tính số tiền được giảm, không ghi nhận lượt dùng
+func (ps *promotionService) EvaluateCoupon(ctx context.Context, input dtopromotions.CheckCouponInput) (*dtopromotions.CouponEvaluation, error) {
+	code := normalizeCouponCode(input.CouponCode)
+	var coupon entityPromotion.Coupon
+	if err := ps.db.WithContext(ctx).First(&coupon, "coupon_code = ?", code).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("coupon %s not found", code)
+		}
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	if err := ps.checkCouponEligibility(ctx, ps.db, &coupon, input); err != nil {
+		return nil, err
+	}
+	return evaluateDiscount(&coupon, input.AmountMinor, common.NormalizeCurrency(input.Currency)), nil
+}
+
+// RedeemCoupon ghi nhận lượt dùng mã cho booking. Coupon được khoá FOR UPDATE và kiểm tra lại giới hạn
+// để hai booking đồng thời không vượt quá usage limit.
+func (ps *promotionService) RedeemCoupon(ctx context.Context, tx *gorm.DB, input dtopromotions.RedeemCouponInput) error {
+	if input.Evaluation == nil {
+		return fmt.Errorf("coupon evaluation is required")
+	}
+
+	var coupon entityPromotion.Coupon
+	if err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&coupon, "coupon_id = ?", input.Evaluation.CouponID).Error; err != nil {
+		return fmt.Errorf("failed to lock coupon: %w", err)
+	}
+	if err := ps.checkUsageLimits(ctx, tx, &coupon, input.UserID); err != nil {
+		return err
+	}
+
+	currency := input.Currency
+	if currency == "" {
+		currency = common.CurrencyVND
+	}
+	redemption := entityPromotion.CouponRedemption{
+		CouponID:            coupon.CouponID,
+		UserID:              input.UserID,
+		BookingID:           input.BookingID,
+		OriginalAmountMinor: input.Evaluation.OriginalAmountMinor,
+		DiscountAmountMinor: input.Evaluation.DiscountAmountMinor,
+		FinalAmountMinor:    input.Evaluation.FinalAmountMinor,
+		Currency:            currency,
+		RedemptionStatus:    common.RedemptionStatusApplied,
+	}
+	// Mã giảm hết phí thì không có giao dịch thanh toán, coi như đã dùng ngay
+	if redemption.FinalAmountMinor <= 0 {
+		now := time.Now()
+		redemption.RedemptionStatus = common.RedemptionStatusRedeemed
+		redemption.RedeemedAt = &now
+	}
+	if err := tx.WithContext(ctx).Create(&redemption).Error; err != nil {
+		return fmt.Errorf("failed to record coupon redemption: %w", err)
+	}
+	if err := tx.WithContext(ctx).Model(&entityPromotion.Coupon{}).
+		Where("coupon_id = ?", coupon.CouponID).
+		Update("used_count", gorm.Expr("used_count + 1")).Error; err != nil {
+		return fmt.Errorf("failed to update coupon usage: %w", err)
+	}
+	return nil
+}
+
+// AttachTransaction gắn giao dịch thanh toán mới nhất của booking vào lượt dùng mã
+func (ps *promotionService) AttachTransaction(ctx context.Context, tx *gorm.DB, bookingID, transactionID uuid.UUID) error {
+	if err := tx.WithContext(ctx).Model(&entityPromotion.CouponRedemption{}).
+		Where("booking_id = ? AND redemption_status = ?", bookingID, common.RedemptionStatusApplied).
+		Update("transaction_id", transactionID).Error; err != nil {
+		return fmt.Errorf("failed to attach transaction to coupon redemption: %w", err)
+	}
+	return nil
+}
+
+// ConfirmRedemption đánh dấu lượt dùng mã là đã thanh toán khi giao dịch hoàn tất
+func (ps *promotionService) ConfirmRedemption(ctx context.Context, tx *gorm.DB, bookingID, transactionID uuid.UUID) error {
+	if err := tx.WithContext(ctx).Model(&entityPromotion.CouponRedemption{}).
+		Where("booking_id = ? AND redemption_status = ?", bookingID, common.RedemptionStatusApplied).
+		Updates(map[string]interface{}{
+			"transaction_id":    transactionID,
+			"redemption_status": common.RedemptionStatusRedeemed,
+			"redeemed_at":       time.Now(),
+		}).Error; err != nil {
+		return fmt.Errorf("failed to confirm coupon redemption: %w", err)
+	}
+	return nil
+}
+
+// ReleaseRedemption trả lại lượt dùng mã khi booking bị hủy trước khi thanh toán
+func (ps *promotionService) ReleaseRedemption(ctx context.Context, tx *gorm.DB, bookingID uuid.UUID) error {
+	return tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var redemption entityPromotion.CouponRedemption
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ? AND redemption_status = ?", bookingID, common.RedemptionStatusApplied).
+			First(&redemption).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get coupon redemption: %w", err)
+		}
+
+		if err := tx.Model(&entityPromotion.CouponRedemption{}).
+			Where("redemption_id = ?", redemption.RedemptionID).
+			Updates(map[string]interface{}{
+				"redemption_status": common.RedemptionStatusReleased,
+				"released_at":       time.Now(),
+			}).Error; err != nil {
+			return fmt.Errorf("failed to release coupon redemption: %w", err)
+		}
+		if err := tx.Model(&entityPromotion.Coupon{}).
+			Where("coupon_id = ? AND used_count > 0", redemption.CouponID).
+			Update("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+			return fmt.Errorf("failed to update coupon usage: %w", err)
+		}
+		return nil
+	})
+}
+
+func (ps *promotionService) checkCouponEligibility(ctx context.Context, db *gorm.DB, coupon *entityPromotion.Coupon, input dtopromotions.CheckCouponInput) error {
+	at := input.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if !coupon.IsActive {
+		return fmt.Errorf("coupon %s is no longer active", coupon.CouponCode)
+	}
+	if at.Before(coupon.ValidFrom) {
+		return fmt.Errorf("coupon %s is not valid until %s", coupon.CouponCode, coupon.ValidFrom.Format("02/01/2006 15:04"))
+	}
+	if coupon.ValidUntil != nil && at.After(*coupon.ValidUntil) {
+		return fmt.Errorf("coupon %s expired at %s", coupon.CouponCode, coupon.ValidUntil.Format("02/01/2006 15:04"))
+	}
+	// Mã có mức tiền cố định chỉ áp dụng cho booking cùng tiền tệ; mã phần trăm không giới hạn dùng được với mọi tiền tệ
+	if coupon.Currency != common.NormalizeCurrency(input.Currency) && couponHasFixedAmounts(coupon) {
+		return fmt.Errorf("coupon %s is only valid for %s bookings", coupon.CouponCode, coupon.Currency)
+	}
+	if coupon.MinBookingAmountMinor != nil && input.AmountMinor < *coupon.MinBookingAmountMinor {
+		return fmt.Errorf("coupon %s requires a minimum booking amount of %s %s", coupon.CouponCode,
+			common.FormatMinorUnits(*coupon.MinBookingAmountMinor, coupon.Currency), coupon.Currency)
+	}
+
+	if len(coupon.ConsultationTypes) > 0 && !containsFold(coupon.ConsultationTypes, input.ConsultationType) {
+		return fmt.Errorf("coupon %s is not valid for %s consultations", coupon.CouponCode, input.ConsultationType)
+	}
+	if len(coupon.ExpertProfileIDs) > 0 && !containsFold(coupon.ExpertProfileIDs, input.ExpertProfileID.String()) {
+		return fmt.Errorf("coupon %s is not valid for this expert", coupon.CouponCode)
+	}
+	if len(coupon.Specializations) > 0 {
+		matched, err := ps.expertHasSpecialization(ctx, db, input.ExpertProfileID, coupon.Specializations)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			return fmt.Errorf("coupon %s is not valid for this expert's specialization", coupon.CouponCode)
+		}
+	}
+
+	return ps.checkUsageLimits(ctx, db, coupon, input.UserID)
+}
+
+func (ps *promotionService) checkUsageLimits(ctx context.Context, db *gorm.DB, coupon *entityPromotion.Coupon, userID uuid.UUID) error {
+	if coupon.UsageLimitTotal != nil && coupon.UsedCount >= *coupon.UsageLimitTotal {
+		return fmt.Errorf("coupon %s has reached its usage limit", coupon.CouponCode)
+	}
+	if coupon.UsageLimitPerUser != nil {
+		var userCount int64
+		if err := db.WithContext(ctx).Model(&entityPromotion.CouponRedemption{}).
+			Where("coupon_id = ? AND user_id = ? AND redemption_status <> ?", coupon.CouponID, userID, common.RedemptionStatusReleased).
+			Count(&userCount).Error; err != nil {
+			return fmt.Errorf("failed to check coupon usage: %w", err)
+		}
+		if userCount >= int64(*coupon.UsageLimitPerUser) {
+			return fmt.Errorf("you have already used coupon %s the maximum number of times", coupon.CouponCode)
+		}
+	}
+	return nil
+}
+
+// expertHasSpecialization so khớp theo tên chuyên môn trong specialization_list và tbl_expert_specializations
+func (ps *promotionService) expertHasSpecialization(ctx context.Context, db *gorm.DB, expertID uuid.UUID, specializations []string) (bool, error) {
+	var expert entityExpert.ExpertProfile
+	if err := db.WithContext(ctx).Select("expert_profile_id", "specialization_list").
+		Preload("Specializations").
+		First(&expert, "expert_profile_id = ?", expertID).Error; err != nil {
+		return false, fmt.Errorf("expert not found")
+	}
+
+	for _, name := range expert.SpecializationList {
+		if containsFold(specializations, name) {
+			return true, nil
+		}
+	}
+	for _, spec := range expert.Specializations {
+		if containsFold(specializations, spec.SpecializationName) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// evaluateDiscount tính tiền giảm: mã phần trăm bị chặn bởi MaxDiscountAmountMinor, không mã nào giảm quá số tiền gốc
+func evaluateDiscount(coupon *entityPromotion.Coupon, amountMinor int64, currency string) *dtopromotions.CouponEvaluation {
+	var discountMinor int64
+	switch coupon.DiscountType {
+	case common.DiscountTypePercentage:
+		if coupon.DiscountPercentage != nil {
+			discountMinor = common.PercentOfMinor(amountMinor, *coupon.DiscountPercentage)
+		}
+		if coupon.MaxDiscountAmountMinor != nil {
+			discountMinor = min(discountMinor, *coupon.MaxDiscountAmountMinor)
+		}
+	case common.DiscountTypeFixed:
+		if coupon.DiscountAmountMinor != nil {
+			discountMinor = *coupon.DiscountAmountMinor
+		}
+	}
+	discountMinor = min(discountMinor, amountMinor)
+	finalMinor := amountMinor - discountMinor
+
+	return &dtopromotions.CouponEvaluation{
+		CouponID:            coupon.CouponID,
+		CouponCode:          coupon.CouponCode,
+		CampaignName:        coupon.CampaignName,
+		DiscountType:        coupon.DiscountType,
+		DiscountValue:       couponDiscountValue(coupon),
+		Currency:            currency,
+		OriginalAmount:      common.FromMinorUnits(amountMinor, currency),
+		DiscountAmount:      common.FromMinorUnits(discountMinor, currency),
+		FinalAmount:         common.FromMinorUnits(finalMinor, currency),
+		OriginalAmountMinor: amountMinor,
+		DiscountAmountMinor: discountMinor,
+		FinalAmountMinor:    finalMinor,
+	}
+}
+
+// ApplyToQuote trừ tiền giảm của mã vào báo giá và thêm dòng chi tiết tương ứng
+func ApplyToQuote(quote *dtobookings.PriceQuote, evaluation *dtopromotions.CouponEvaluation) {
+	if quote == nil || evaluation == nil {
+		return
+	}
+	quote.CouponCode = evaluation.CouponCode
+	quote.CouponDiscount = evaluation.DiscountAmount
+	quote.TotalAmount = evaluation.FinalAmount
+	quote.TotalAmountMinor = evaluation.FinalAmountMinor
+	quote.Items = append(quote.Items, dtobookings.PriceQuoteItem{
+		Label:  fmt.Sprintf("Mã khuyến mãi %s", evaluation.CouponCode),
+		Amount: -evaluation.DiscountAmount,
+	})
+}
+
+func validateCoupon(coupon *entityPromotion.Coupon) error {
+	switch coupon.DiscountType {
+	case common.DiscountTypePercentage:
+		if coupon.DiscountPercentage == nil || *coupon.DiscountPercentage <= 0 || *coupon.DiscountPercentage > 100 {
+			return fmt.Errorf("percentage discount must be between 0 and 100")
+		}
+	case common.DiscountTypeFixed:
+		if coupon.DiscountAmountMinor == nil || *coupon.DiscountAmountMinor <= 0 {
+			return fmt.Errorf("fixed discount must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("invalid discount type: %s", coupon.DiscountType)
+	}
+	if coupon.MaxDiscountAmountMinor != nil && *coupon.MaxDiscountAmountMinor <= 0 {
+		return fmt.Errorf("max_discount_amount must be greater than 0")
+	}
+	if coupon.MinBookingAmountMinor != nil && *coupon.MinBookingAmountMinor < 0 {
+		return fmt.Errorf("min_booking_amount cannot be negative")
+	}
+	if !common.IsSupportedCurrency(coupon.Currency) {
+		return fmt.Errorf("unsupported currency: %s", coupon.Currency)
+	}
+	if coupon.UsageLimitTotal != nil && *coupon.UsageLimitTotal <= 0 {
+		return fmt.Errorf("usage_limit_total must be greater than 0")
+	}
+	if coupon.UsageLimitPerUser != nil && *coupon.UsageLimitPerUser <= 0 {
+		return fmt.Errorf("usage_limit_per_user must be greater than 0")
+	}
+	if coupon.ValidUntil != nil && !coupon.ValidUntil.After(coupon.ValidFrom) {
+		return fmt.Errorf("valid_until must be after valid_from")
+	}
+	for _, id := range coupon.ExpertProfileIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("invalid expert profile ID in restrictions: %s", id)
+		}
+	}
+	for _, t := range coupon.ConsultationTypes {
+		if t != "online" && t != "offline" {
+			return fmt.Errorf("invalid consultation type in restrictions: %s", t)
+		}
+	}
+	return nil
+}
+
+func toCouponResponse(coupon *entityPromotion.Coupon) *dtopromotions.CouponResponse {
+	return &dtopromotions.CouponResponse{
+		CouponID:          coupon.CouponID.String(),
+		CouponCode:        coupon.CouponCode,
+		CampaignName:      coupon.CampaignName,
+		Description:       coupon.Description,
+		DiscountType:      coupon.DiscountType,
+		DiscountValue:     couponDiscountValue(coupon),
+		MaxDiscountAmount: minorUnitsToAmount(coupon.MaxDiscountAmountMinor, coupon.Currency),
+		MinBookingAmount:  minorUnitsToAmount(coupon.MinBookingAmountMinor, coupon.Currency),
+		Currency:          coupon.Currency,
+		UsageLimitTotal:   coupon.UsageLimitTotal,
+		UsageLimitPerUser: coupon.UsageLimitPerUser,
+		UsedCount:         coupon.UsedCount,
+		ExpertProfileIDs:  []string(coupon.ExpertProfileIDs),
+		Specializations:   []string(coupon.Specializations),
+		ConsultationTypes: []string(coupon.ConsultationTypes),
+		ValidFrom:         coupon.ValidFrom,
+		ValidUntil:        coupon.ValidUntil,
+		IsActive:          coupon.IsActive,
+		CreatedAt:         coupon.CouponCreatedAt,
+	}
+}
+
+func containsFold(values []string, target string) bool {
+	target = strings.TrimSpace(target)
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// couponHasFixedAmounts cho biết mã có mức tiền tuyệt đối (giảm cố định, trần giảm, mức tối thiểu) gắn với tiền tệ của mã
+func couponHasFixedAmounts(coupon *entityPromotion.Coupon) bool {
+	return coupon.DiscountType == common.DiscountTypeFixed || coupon.MaxDiscountAmountMinor != nil || coupon.MinBookingAmountMinor != nil
+}
+
+// couponDiscountValue trả về giá trị giảm hiển thị: % với mã phần trăm, số tiền theo Currency với mã cố định
+func couponDiscountValue(coupon *entityPromotion.Coupon) float64 {
+	switch {
+	case coupon.DiscountPercentage != nil:
+		return *coupon.DiscountPercentage
+	case coupon.DiscountAmountMinor != nil:
+		return common.FromMinorUnits(*coupon.DiscountAmountMinor, coupon.Currency)
+	}
+	return 0
+}
+
+// amountToMinorUnits đổi số tiền (có thể nil) sang đơn vị tiền nhỏ nhất
+func amountToMinorUnits(amount *float64, currency string) *int64 {
+	if amount == nil {
+		return nil
+	}
+	minor := common.ToMinorUnits(*amount, currency)
+	return &minor
+}
+
+// minorUnitsToAmount đổi số tiền ở đơn vị nhỏ nhất (có thể nil) về dạng thập phân
+func minorUnitsToAmount(minor *int64, currency string) *float64 {
+	if minor == nil {
+		return nil
+	}
+	amount := common.FromMinorUnits(*minor, currency)
+	return &amount
+}
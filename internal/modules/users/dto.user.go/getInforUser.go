@@ -18,6 +18,7 @@ type UserProfileResponse struct {
 	AvatarURL      string    `json:"avartar_url"`
 	Gender         string    `json:"gender"`
 	BioDescription string    `json:"bio_description"`
+	TimeZone       string    `json:"time_zone"`
 	UserCreatedAt  time.Time `json:"create_at"`
 	UserUpdatedAt  time.Time `json:"user_updated_at"`
 }
@@ -7,4 +7,5 @@ type InforUserUpdate struct {
 	AvatarURL      *string `json:"avatar_url"`
 	Gender         *string `json:"gender"`
 	BioDescription *string `json:"bio_description"`
+	TimeZone       *string `json:"time_zone"` // Múi giờ IANA, vd "Asia/Ho_Chi_Minh"
 }
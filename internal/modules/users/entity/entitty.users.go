@@ -3,6 +3,8 @@ package entity
 import (
 	"time"
 
+	"cbs_backend/internal/common"
+
 	"github.com/google/uuid"
 )
 
@@ -21,6 +23,7 @@ type User struct {
 	BioDescription *string   `json:"bio_description,omitempty" db:"bio_description" gorm:"type:text"`
 	IsActive       bool      `json:"is_active" db:"is_active" gorm:"default:true"`
 	EmailVerified  bool      `json:"email_verified" db:"email_verified" gorm:"default:false"`
+	TimeZone       string    `json:"time_zone" db:"time_zone" gorm:"type:varchar(64);not null;default:'Asia/Ho_Chi_Minh'"` // Múi giờ IANA để hiển thị lịch
 	// NotificationSettings common.JSONB `json:"notification_settings" db:"notification_settings" gorm:"type:jsonb;default:'{\"email\": true, \"push\": true, \"telegram\": false}'"`
 	UserCreatedAt time.Time `json:"user_created_at" db:"user_created_at" gorm:"default:CURRENT_TIMESTAMP"`
 	UserUpdatedAt time.Time `json:"user_updated_at" db:"user_updated_at" gorm:"default:CURRENT_TIMESTAMP"`
@@ -37,3 +40,8 @@ type User struct {
 func (User) TableName() string {
 	return "tbl_users"
 }
+
+// Location trả về múi giờ của user, dùng khi hiển thị giờ lịch hẹn cho user
+func (u *User) Location() *time.Location {
+	return common.LocationOrDefault(u.TimeZone)
+}
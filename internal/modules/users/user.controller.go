@@ -1,6 +1,7 @@
 package users
 
 import (
+	"cbs_backend/internal/common"
 	dtousergo "cbs_backend/internal/modules/users/dto.user.go"
 	"cbs_backend/pkg/response"
 	"fmt"
@@ -82,6 +83,7 @@ func (uc *UserController) GetInfor(ctx *gin.Context) (res interface{}, err error
 		AvatarURL:      user.AvatarURL,
 		Gender:         user.Gender,
 		BioDescription: user.BioDescription,
+		TimeZone:       user.TimeZone,
 		UserCreatedAt:  user.UserCreatedAt,
 		UserUpdatedAt:  user.UserUpdatedAt,
 	}
@@ -114,6 +116,12 @@ func (uc *UserController) UpdateInforUser(ctx *gin.Context) (res interface{}, er
 	// 	return
 	// }
 
+	if req.TimeZone != nil {
+		if err := common.ValidateTimeZone(*req.TimeZone); err != nil {
+			return nil, response.NewAPIError(http.StatusBadRequest, "Invalid time zone", err.Error())
+		}
+	}
+
 	userUpdate, err := User().UpdateInforUser(ctx, req, userID)
 	if err != nil {
 		response.ErrorResponse(ctx, http.StatusNotFound, "User not found", err.Error())
@@ -127,6 +135,7 @@ func (uc *UserController) UpdateInforUser(ctx *gin.Context) (res interface{}, er
 		AvatarURL:      userUpdate.AvatarURL,
 		Gender:         userUpdate.Gender,
 		BioDescription: userUpdate.BioDescription,
+		TimeZone:       userUpdate.TimeZone,
 		UserCreatedAt:  userUpdate.UserCreatedAt,
 		UserUpdatedAt:  userUpdate.UserUpdatedAt,
 	}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"cbs_backend/global"
+	"cbs_backend/internal/common"
 	"cbs_backend/internal/kafka"
 	dtousergo "cbs_backend/internal/modules/users/dto.user.go"
 	"cbs_backend/internal/modules/users/entity"
@@ -225,6 +226,7 @@ func (us *userService) GetUserByID(ctx context.Context, userid uuid.UUID) (*dtou
 		UserID:        user.UserID,
 		FullName:      user.FullName,
 		UserEmail:     user.UserEmail,
+		TimeZone:      user.TimeZone,
 		UserCreatedAt: user.UserCreatedAt,
 		UserUpdatedAt: user.UserUpdatedAt,
 	}
@@ -272,6 +274,12 @@ func (us *userService) UpdateInforUser(ctx context.Context, req dtousergo.InforU
 	if req.BioDescription != nil {
 		user.BioDescription = req.BioDescription
 	}
+	if req.TimeZone != nil {
+		if err := common.ValidateTimeZone(*req.TimeZone); err != nil {
+			return nil, err
+		}
+		user.TimeZone = strings.TrimSpace(*req.TimeZone)
+	}
 
 	user.UserUpdatedAt = time.Now()
 
@@ -284,6 +292,7 @@ func (us *userService) UpdateInforUser(ctx context.Context, req dtousergo.InforU
 		UserID:        user.UserID,
 		FullName:      user.FullName,
 		UserEmail:     user.UserEmail,
+		TimeZone:      user.TimeZone,
 		UserCreatedAt: user.UserCreatedAt,
 		UserUpdatedAt: user.UserUpdatedAt,
 	}
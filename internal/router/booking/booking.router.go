@@ -6,6 +6,7 @@ import (
 
 	"cbs_backend/internal/middleware"
 	PkgBooking "cbs_backend/internal/modules/bookings"
+	"cbs_backend/internal/modules/invoices"
 	"cbs_backend/internal/modules/users"
 
 	"github.com/gin-gonic/gin"
@@ -15,11 +16,15 @@ type BookingRouter struct{}
 
 func (br *BookingRouter) InitBookingRouter(router *gin.RouterGroup) {
 	bookingCtr := PkgBooking.NewBookingController(global.Log)
+	invoiceCtr := invoices.NewInvoiceController(global.Log)
 
 	// Public group: không cần đăng nhập
 	bookingPublic := router.Group("/booking/v1")
 	{
 		bookingPublic.GET("/available-slots", response.Wrap(bookingCtr.GetAvailableSlots))
+		bookingPublic.GET("/quote", response.Wrap(bookingCtr.GetPriceQuote))
+		bookingPublic.GET("/group-sessions", response.Wrap(bookingCtr.ListGroupSessions))
+		bookingPublic.GET("/calendar/:token", bookingCtr.GetCalendarFeed)
 		// Nếu /upcoming chỉ trả thông tin public, có thể để ở đây.
 		// bookingPublic.GET("/upcoming", response.Wrap(bookingCtr.GetUpcomingBookingsForExpert))
 	}
@@ -33,6 +38,7 @@ func (br *BookingRouter) InitBookingRouter(router *gin.RouterGroup) {
 		bookingPrivate.GET("/upcoming", response.Wrap(bookingCtr.GetUpcomingBookingsForExpert))
 		bookingPrivate.POST("/cancel/:bookingID", middleware.CancelBookingLimiter.Middleware(), response.Wrap(bookingCtr.CancelBooking))
 		bookingPrivate.POST("/confirm", response.Wrap(bookingCtr.ConfirmBooking))
+		bookingPrivate.POST("/reject", response.Wrap(bookingCtr.RejectBooking))
 		bookingPrivate.PUT("/update-notes", response.Wrap(bookingCtr.UpdateBookingNotes))
 		bookingPrivate.GET("/status-history", response.Wrap(bookingCtr.GetBookingStatusHistory))
 
@@ -43,6 +49,36 @@ func (br *BookingRouter) InitBookingRouter(router *gin.RouterGroup) {
 		bookingPrivate.POST("/complete", response.Wrap(bookingCtr.CompleteBooking))
 		bookingPrivate.GET("/stats", response.Wrap(bookingCtr.GetBookingStats))
 		bookingPrivate.GET("/search", response.Wrap(bookingCtr.SearchBookings))
+
+		// Chuỗi lịch định kỳ
+		bookingPrivate.POST("/series", response.Wrap(bookingCtr.CreateBookingSeries))
+		bookingPrivate.GET("/series", response.Wrap(bookingCtr.GetBookingSeries))
+		bookingPrivate.POST("/series/cancel", response.Wrap(bookingCtr.CancelSeriesOccurrences))
+		bookingPrivate.POST("/series/reschedule", response.Wrap(bookingCtr.RescheduleSeriesOccurrences))
+
+		// Waitlist khi expert kín lịch
+		bookingPrivate.POST("/waitlist", response.Wrap(bookingCtr.JoinWaitlist))
+		bookingPrivate.GET("/waitlist", response.Wrap(bookingCtr.GetMyWaitlist))
+		bookingPrivate.POST("/waitlist/leave", response.Wrap(bookingCtr.LeaveWaitlist))
+		bookingPrivate.POST("/waitlist/claim", response.Wrap(bookingCtr.ClaimSlotHold))
+
+		// Buổi tư vấn nhóm
+		bookingPrivate.POST("/group-session", response.Wrap(bookingCtr.CreateGroupSession))
+		bookingPrivate.POST("/group-session/seat", response.Wrap(bookingCtr.BookGroupSeat))
+		bookingPrivate.GET("/group-session/attendees", response.Wrap(bookingCtr.GetGroupSessionAttendees))
+		bookingPrivate.POST("/group-session/cancel", response.Wrap(bookingCtr.CancelGroupSession))
+		bookingPrivate.POST("/group-session/complete", response.Wrap(bookingCtr.CompleteGroupSession))
+
+		// Lịch làm việc cuối cùng của expert (giờ theo ngày, thời gian bận, ngày nghỉ, lịch hẹn)
+		bookingPrivate.GET("/expert-schedule", response.Wrap(bookingCtr.GetExpertSchedule))
+
+		// Feed lịch .ics
+		bookingPrivate.POST("/calendar-feed", response.Wrap(bookingCtr.CreateCalendarFeed))
+		bookingPrivate.DELETE("/calendar-feed", response.Wrap(bookingCtr.RevokeCalendarFeed))
+
+		// Hoá đơn của booking (chỉ người đặt lịch)
+		bookingPrivate.GET("/invoices", response.Wrap(invoiceCtr.GetBookingInvoices))
+		bookingPrivate.GET("/invoice/download", invoiceCtr.DownloadInvoice)
 	}
 
 	// bookingAdmin := router.Group("/v3")
@@ -0,0 +1,32 @@
+package currency
+
+import (
+	"cbs_backend/global"
+	"cbs_backend/internal/middleware"
+	PkgExchangeRate "cbs_backend/internal/modules/exchange_rates"
+	PkgUser "cbs_backend/internal/modules/users"
+	"cbs_backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CurrencyRouter struct{}
+
+func (cr *CurrencyRouter) InitCurrencyRouter(router *gin.RouterGroup) {
+	exchangeRateCtr := PkgExchangeRate.NewExchangeRateController(global.Log)
+
+	// Public group: xem bảng tỷ giá
+	currencyPublic := router.Group("/currency/v1")
+	{
+		currencyPublic.GET("/rates", response.Wrap(exchangeRateCtr.GetRates))
+	}
+
+	// Admin group: quản lý bảng tỷ giá
+	currencyAdmin := router.Group("/currency/v3")
+	currencyAdmin.Use(middleware.AuthMiddleware(PkgUser.User()))
+	currencyAdmin.Use(middleware.AdminMiddleware())
+	{
+		currencyAdmin.POST("/rate", response.Wrap(exchangeRateCtr.UpsertRate))
+		currencyAdmin.DELETE("/rate", response.Wrap(exchangeRateCtr.DeleteRate))
+	}
+}
@@ -0,0 +1,5 @@
+package currency
+
+type RouterCurrencyGroup struct {
+	CurrencyRouter
+}
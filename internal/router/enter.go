@@ -2,8 +2,12 @@ package router
 
 import (
 	"cbs_backend/internal/router/booking"
+	"cbs_backend/internal/router/currency"
 	"cbs_backend/internal/router/dashboard"
 	"cbs_backend/internal/router/expert"
+	"cbs_backend/internal/router/payment"
+	"cbs_backend/internal/router/payout"
+	"cbs_backend/internal/router/promotion"
 	"cbs_backend/internal/router/user"
 )
 
@@ -12,6 +16,10 @@ type RouterGroup struct {
 	Expert    expert.RouterExpertGroup
 	Booking   booking.RouterBookingGroup
 	Dashboard dashboard.RouterDashBoardGroup
+	Payment   payment.RouterPaymentGroup
+	Payout    payout.RouterPayoutGroup
+	Promotion promotion.RouterPromotionGroup
+	Currency  currency.RouterCurrencyGroup
 }
 
 var RouterGroupApp = new(RouterGroup)
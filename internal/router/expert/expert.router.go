@@ -20,8 +20,11 @@ func (er *ExpertRouter) InitExpertRouter(router *gin.RouterGroup) {
 		public.GET("/getAllExpert", response.Wrap(expertCtrl.GetAllExpert))
 		public.GET("/getDetail/:id", response.Wrap(expertCtrl.GetExpertProfileDetails))
 		public.GET("/workHour/:expertId", response.Wrap(expertCtrl.GetAllWorkHourByExpertID))
+		public.GET("/workHourOverride/:expertId", response.Wrap(expertCtrl.GetWorkingHourOverrides))
 		public.GET("/unavailableTime/:expertId", response.Wrap(expertCtrl.GetAllUnavailableTimeByExpertID))
 		public.GET("/price/:expertId", response.Wrap(expertCtrl.GetAllPriceByExpertID))
+		public.GET("/bookingRules/:expertId", response.Wrap(expertCtrl.GetBookingRules))
+		public.GET("/holidays", response.Wrap(expertCtrl.ListHolidays))
 		// 🆕 GET danh sách chuyên môn của chuyên gia
 		public.GET("/specialization/:expertId", response.Wrap(expertCtrl.GetAllExpertSpecializationByExpertID))
 	}
@@ -38,13 +41,24 @@ func (er *ExpertRouter) InitExpertRouter(router *gin.RouterGroup) {
 		// Working Hours Management
 		private.POST("/workHour", response.Wrap(expertCtrl.CreateWorkHour))
 		private.PUT("/workHour", response.Wrap(expertCtrl.UpdateWorkHour))
+		private.PUT("/workHour/weekly", response.Wrap(expertCtrl.ReplaceWeeklySchedule))
 		private.DELETE("/workHour/:workingHourId", response.Wrap(expertCtrl.DeleteWorkHour))
+		private.PUT("/workHourOverride", response.Wrap(expertCtrl.SetWorkingHourOverride))
+		private.DELETE("/workHourOverride", response.Wrap(expertCtrl.DeleteWorkingHourOverride))
+
+		// Booking Rules Management
+		private.PUT("/bookingRules", response.Wrap(expertCtrl.UpdateBookingRules))
 
 		// Unavailable Time Management
 		private.POST("/unavailableTime", response.Wrap(expertCtrl.CreateUnavailableTime))
 		private.PUT("/unavailableTime", response.Wrap(expertCtrl.UpdateUnavailableTime))
+		private.POST("/unavailableTime/skip", response.Wrap(expertCtrl.SkipUnavailableOccurrence))
 		private.DELETE("/unavailableTime/:unavailableTimeId", response.Wrap(expertCtrl.DeleteUnavailableTime))
 
+		// Làm việc vào ngày nghỉ toàn hệ thống
+		private.POST("/holiday/optIn", response.Wrap(expertCtrl.OptInHoliday))
+		private.DELETE("/holiday/optIn", response.Wrap(expertCtrl.OptOutHoliday))
+
 		// Specialization Management
 		private.POST("/specialization", response.Wrap(expertCtrl.CreateExpertSpecialization))
 		private.PUT("/specialization", response.Wrap(expertCtrl.UpdateExpertSpecialization))
@@ -54,4 +68,14 @@ func (er *ExpertRouter) InitExpertRouter(router *gin.RouterGroup) {
 		private.PUT("/price", response.Wrap(expertCtrl.UpdatePrice))
 		private.DELETE("/price/:pricingId", response.Wrap(expertCtrl.DeletePrice))
 	}
+
+	// Admin routes - ngày nghỉ lễ/ngày công ty nghỉ áp dụng cho mọi expert
+	admin := router.Group("/expert/v3")
+	admin.Use(middleware.AuthMiddleware(users.User()))
+	admin.Use(middleware.AdminMiddleware())
+	{
+		admin.POST("/holidays/import", response.Wrap(expertCtrl.ImportHolidayCalendar))
+		admin.POST("/holidays/closure", response.Wrap(expertCtrl.CreateClosureDays))
+		admin.DELETE("/holidays/:holidayId", response.Wrap(expertCtrl.DeleteHoliday))
+	}
 }
@@ -0,0 +1,5 @@
+package payment
+
+type RouterPaymentGroup struct {
+	PaymentRouter
+}
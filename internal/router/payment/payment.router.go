@@ -0,0 +1,45 @@
+package payment
+
+import (
+	"cbs_backend/global"
+	"cbs_backend/internal/middleware"
+	PkgPayment "cbs_backend/internal/modules/payment_transactions"
+	PkgUser "cbs_backend/internal/modules/users"
+	"cbs_backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentRouter struct{}
+
+func (pr *PaymentRouter) InitPaymentRouter(router *gin.RouterGroup) {
+	paymentCtr := PkgPayment.NewPaymentController(global.Log)
+
+	// Public group: gateway gọi về, xác thực bằng chữ ký HMAC thay vì JWT
+	paymentPublic := router.Group("/payment/v1")
+	{
+		paymentPublic.GET("/refund-policy", response.Wrap(paymentCtr.GetRefundPolicy))
+		paymentPublic.POST("/webhook/:gateway", middleware.PaymentWebhookLimiter.Middleware(), response.Wrap(paymentCtr.HandleWebhook))
+	}
+
+	// Private group: cần đăng nhập
+	paymentPrivate := router.Group("/payment/v2")
+	paymentPrivate.Use(middleware.AuthMiddleware(PkgUser.User()))
+	{
+		paymentPrivate.POST("/", middleware.CreatePaymentLimiter.Middleware(), response.Wrap(paymentCtr.CreatePayment))
+		paymentPrivate.GET("/detail", response.Wrap(paymentCtr.GetPaymentByID))
+		paymentPrivate.GET("/booking", response.Wrap(paymentCtr.GetPaymentsByBooking))
+		paymentPrivate.POST("/cancel", response.Wrap(paymentCtr.CancelPayment))
+		paymentPrivate.POST("/verify", response.Wrap(paymentCtr.VerifyPayment))
+	}
+
+	// Admin group: đối soát thủ công của bộ phận tài chính
+	paymentAdmin := router.Group("/payment/v3")
+	paymentAdmin.Use(middleware.AuthMiddleware(PkgUser.User()))
+	paymentAdmin.Use(middleware.AdminMiddleware())
+	{
+		paymentAdmin.PUT("/status", response.Wrap(paymentCtr.UpdatePaymentStatus))
+		paymentAdmin.POST("/refund", response.Wrap(paymentCtr.RefundPayment))
+		paymentAdmin.PUT("/refund-policy", response.Wrap(paymentCtr.UpdateRefundPolicy))
+	}
+}
@@ -0,0 +1,5 @@
+package payout
+
+type RouterPayoutGroup struct {
+	PayoutRouter
+}
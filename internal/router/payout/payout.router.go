@@ -0,0 +1,38 @@
+package payout
+
+import (
+	"cbs_backend/global"
+	"cbs_backend/internal/middleware"
+	PkgPayout "cbs_backend/internal/modules/payouts"
+	PkgUser "cbs_backend/internal/modules/users"
+	"cbs_backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayoutRouter struct{}
+
+func (pr *PayoutRouter) InitPayoutRouter(router *gin.RouterGroup) {
+	payoutCtr := PkgPayout.NewPayoutController(global.Log)
+
+	// Private group: expert xem và tải bảng kê của chính mình
+	payoutPrivate := router.Group("/payout/v2")
+	payoutPrivate.Use(middleware.AuthMiddleware(PkgUser.User()))
+	{
+		payoutPrivate.GET("/statements", response.Wrap(payoutCtr.GetMyStatements))
+		payoutPrivate.GET("/statement", response.Wrap(payoutCtr.GetMyStatement))
+		payoutPrivate.GET("/statement/download", payoutCtr.DownloadMyStatement)
+	}
+
+	// Admin group: bộ phận tài chính quyết toán và xác nhận đã chuyển khoản
+	payoutAdmin := router.Group("/payout/v3")
+	payoutAdmin.Use(middleware.AuthMiddleware(PkgUser.User()))
+	payoutAdmin.Use(middleware.AdminMiddleware())
+	{
+		payoutAdmin.GET("/statements", response.Wrap(payoutCtr.GetStatements))
+		payoutAdmin.GET("/statement", response.Wrap(payoutCtr.GetStatement))
+		payoutAdmin.GET("/statement/download", payoutCtr.DownloadStatement)
+		payoutAdmin.PUT("/statement/paid", response.Wrap(payoutCtr.MarkStatementPaid))
+		payoutAdmin.POST("/settle", response.Wrap(payoutCtr.GenerateStatements))
+	}
+}
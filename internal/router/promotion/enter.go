@@ -0,0 +1,5 @@
+package promotion
+
+type RouterPromotionGroup struct {
+	PromotionRouter
+}
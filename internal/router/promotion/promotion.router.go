@@ -0,0 +1,35 @@
+package promotion
+
+import (
+	"cbs_backend/global"
+	"cbs_backend/internal/middleware"
+	PkgPromotion "cbs_backend/internal/modules/promotions"
+	PkgUser "cbs_backend/internal/modules/users"
+	"cbs_backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromotionRouter struct{}
+
+func (pr *PromotionRouter) InitPromotionRouter(router *gin.RouterGroup) {
+	promotionCtr := PkgPromotion.NewPromotionController(global.Log)
+
+	// Private group: user xem trước mã trước khi đặt lịch
+	promotionPrivate := router.Group("/promotion/v2")
+	promotionPrivate.Use(middleware.AuthMiddleware(PkgUser.User()))
+	{
+		promotionPrivate.POST("/preview", response.Wrap(promotionCtr.PreviewCoupon))
+	}
+
+	// Admin group: quản lý campaign, mã khuyến mãi
+	promotionAdmin := router.Group("/promotion/v3")
+	promotionAdmin.Use(middleware.AuthMiddleware(PkgUser.User()))
+	promotionAdmin.Use(middleware.AdminMiddleware())
+	{
+		promotionAdmin.POST("/coupon", response.Wrap(promotionCtr.CreateCoupon))
+		promotionAdmin.PUT("/coupon", response.Wrap(promotionCtr.UpdateCoupon))
+		promotionAdmin.GET("/coupons", response.Wrap(promotionCtr.GetCoupons))
+		promotionAdmin.GET("/stats", response.Wrap(promotionCtr.GetCouponStats))
+	}
+}
@@ -0,0 +1,75 @@
+package email
+
+import (
+	"fmt"
+
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/pkg/ical"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const calendarProdID = "-//CBS//Consultation Booking//VI"
+
+// CalendarResolver dựng lời mời .ics của booking để đính kèm email; UID trùng với feed lịch
+// nên ứng dụng lịch gộp lời mời và feed thành cùng một sự kiện.
+type CalendarResolver struct {
+	db        *gorm.DB
+	logger    *zap.Logger
+	organizer string
+}
+
+func NewCalendarResolver(db *gorm.DB, logger *zap.Logger, organizer string) *CalendarResolver {
+	return &CalendarResolver{
+		db:        db,
+		logger:    logger,
+		organizer: organizer,
+	}
+}
+
+// GetBookingInvite trả về nil khi không nạp được booking; method là ical.MethodRequest hoặc ical.MethodCancel
+func (cr *CalendarResolver) GetBookingInvite(bookingID, attendee, method string) *EmailAttachment {
+	parsedID, err := uuid.Parse(bookingID)
+	if err != nil {
+		cr.logger.Error("Invalid UUID format", zap.String("bookingID", bookingID), zap.Error(err))
+		return nil
+	}
+
+	var booking entityBooking.ConsultationBooking
+	if err := cr.db.First(&booking, "booking_id = ?", parsedID).Error; err != nil {
+		cr.logger.Error("Failed to get booking for calendar invite", zap.Error(err), zap.String("bookingID", bookingID))
+		return nil
+	}
+
+	var expertName string
+	if err := cr.db.Table("tbl_expert_profiles ep").
+		Select("u.full_name").
+		Joins("JOIN tbl_users u ON u.user_id = ep.user_id").
+		Where("ep.expert_profile_id = ?", booking.ExpertProfileID).
+		Scan(&expertName).Error; err != nil {
+		cr.logger.Warn("Failed to get expert name for calendar invite", zap.Error(err), zap.String("bookingID", bookingID))
+	}
+
+	description := fmt.Sprintf("Mã booking: %s\nHình thức: %s", booking.BookingID, booking.ConsultationType)
+	event := booking.CalendarEvent(fmt.Sprintf("Tư vấn với %s", expertName), description)
+	if method == ical.MethodCancel {
+		event.Status = ical.StatusCancelled
+	}
+	event.Organizer = cr.organizer
+	if attendee != "" {
+		event.Attendees = []string{attendee}
+	}
+
+	calendar := ical.Calendar{
+		ProdID: calendarProdID,
+		Method: method,
+		Events: []ical.Event{event},
+	}
+	return &EmailAttachment{
+		FileName:    "invite.ics",
+		ContentType: fmt.Sprintf("text/calendar; method=%s; charset=UTF-8", method),
+		Content:     calendar.Bytes(),
+	}
+}
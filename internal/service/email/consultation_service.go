@@ -4,31 +4,39 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"cbs_backend/global"
 	"cbs_backend/internal/service/interfaces"
+	"cbs_backend/pkg/ical"
 
 	"go.uber.org/zap"
 )
 
 type ConsultationEmailService struct {
-	sender          *EmailSender
-	templateManager *TemplateManager
-	userResolver    *UserResolver
-	baseURL         string
+	sender           *EmailSender
+	templateManager  *TemplateManager
+	userResolver     *UserResolver
+	invoiceResolver  *InvoiceResolver
+	calendarResolver *CalendarResolver
+	baseURL          string
 }
 
 func NewConsultationEmailService(
 	sender *EmailSender,
 	templateManager *TemplateManager,
 	userResolver *UserResolver,
+	invoiceResolver *InvoiceResolver,
+	calendarResolver *CalendarResolver,
 	baseURL string,
 ) *ConsultationEmailService {
 	return &ConsultationEmailService{
-		sender:          sender,
-		templateManager: templateManager,
-		userResolver:    userResolver,
-		baseURL:         baseURL,
+		sender:           sender,
+		templateManager:  templateManager,
+		userResolver:     userResolver,
+		invoiceResolver:  invoiceResolver,
+		calendarResolver: calendarResolver,
+		baseURL:          baseURL,
 	}
 }
 
@@ -71,7 +79,7 @@ func (ces *ConsultationEmailService) SendBookingApprove(ctx context.Context, use
 		return ces.sendBookingConfirmationFallback(email, data)
 	}
 
-	return ces.sender.Send(email, subject, body)
+	return ces.sendWithBookingAttachments(email, subject, body, data.BookingID)
 }
 
 func (ces *ConsultationEmailService) SendBookingConfirmation(ctx context.Context, userID string, data interfaces.ConsultationBookingData) error {
@@ -106,7 +114,7 @@ func (ces *ConsultationEmailService) SendBookingConfirmation(ctx context.Context
 		return ces.sendBookingConfirmationFallback(email, data)
 	}
 
-	return ces.sender.Send(email, subject, body)
+	return ces.sendWithBookingAttachments(email, subject, body, data.BookingID)
 }
 func (ces *ConsultationEmailService) SendBookingCancelledForUser(ctx context.Context, userID string, data interfaces.ConsultationCancellationDataForUser) error {
 	email := ces.userResolver.GetUserEmail(userID)
@@ -136,6 +144,117 @@ func (ces *ConsultationEmailService) SendBookingCancelledForUser(ctx context.Con
 		// return ces.sendBookingConfirmationFallback(email, data)
 	}
 
+	return ces.sendWithCancelInvite(email, subject, body, data.BookingID)
+}
+
+// SendBookingRejected báo cho người dùng booking bị expert từ chối, kèm các khung giờ trống gợi ý để đặt lại
+func (ces *ConsultationEmailService) SendBookingRejected(ctx context.Context, userID string, data interfaces.ConsultationRejectionData) error {
+	email := ces.userResolver.GetUserEmail(userID)
+	if email == "" {
+		global.Log.Error("failed to resolve user email", zap.String("userID", userID))
+		return errors.New("user email not found")
+	}
+
+	var slotsHTML strings.Builder
+	for _, slot := range data.AlternativeSlots {
+		slotsHTML.WriteString(fmt.Sprintf("<li>%s: %s - %s</li>", slot.Date, slot.StartTime, slot.EndTime))
+	}
+
+	template, err := ces.templateManager.GetTemplate("booking_rejected")
+	if err != nil {
+		return ces.sendBookingRejectedFallback(email, data, slotsHTML.String())
+	}
+
+	templateData := map[string]interface{}{
+		"BookingID":         data.BookingID,
+		"expert_name":       data.DoctorName,
+		"booking_datetime":  data.ConsultationDate,
+		"booking_time":      data.ConsultationTime,
+		"RejectionReason":   data.RejectionReason,
+		"RefundAmount":      FormatAmount(data.RefundAmount),
+		"RefundProcessDays": data.RefundProcessDays,
+		"AlternativeSlots":  slotsHTML.String(),
+		"BookingURL":        fmt.Sprintf("%s/bookings/%s", ces.baseURL, data.BookingID),
+	}
+
+	subject, body, err := ces.templateManager.RenderTemplate(template, templateData)
+	if err != nil {
+		return ces.sendBookingRejectedFallback(email, data, slotsHTML.String())
+	}
+
+	return ces.sender.Send(email, subject, body)
+}
+
+func (ces *ConsultationEmailService) sendBookingRejectedFallback(email string, data interfaces.ConsultationRejectionData, slotsHTML string) error {
+	subject := "❌ Your Consultation Booking was Declined"
+
+	alternatives := "<p style=\"font-size: 16px;\">The expert has no other open slots in the coming days.</p>"
+	if slotsHTML != "" {
+		alternatives = fmt.Sprintf(`<p style="font-size: 16px;">You can book one of these open slots instead:</p><ul style="font-size: 16px;">%s</ul>`, slotsHTML)
+	}
+
+	body := fmt.Sprintf(`
+		<div style="font-family: Arial, sans-serif; max-width: 600px; margin: auto; padding: 20px; border: 1px solid #eee; border-radius: 8px;">
+			<h2 style="color: #c0392b;">Booking Declined</h2>
+			<p style="font-size: 16px;">Hello,</p>
+			<p style="font-size: 16px;">Unfortunately <strong>%s</strong> could not accept your consultation on <strong>%s %s</strong> (booking %s).</p>
+			<p style="font-size: 16px;"><strong>Reason:</strong> %s</p>
+			<p style="font-size: 16px;"><strong>Refund:</strong> %s, processed within %d days.</p>
+			%s
+			<p style="margin-top: 30px; font-size: 15px; color: #555;">Thank you for choosing our service!</p>
+		</div>
+	`, data.DoctorName, data.ConsultationDate, data.ConsultationTime, data.BookingID, data.RejectionReason,
+		FormatAmount(data.RefundAmount), data.RefundProcessDays, alternatives)
+
+	return ces.sender.Send(email, subject, body)
+}
+
+// SendWaitlistSlotOffered báo cho người trong waitlist có slot đang được giữ, cần đặt trước ExpiresAt
+func (ces *ConsultationEmailService) SendWaitlistSlotOffered(ctx context.Context, userID string, data interfaces.WaitlistSlotOfferData) error {
+	email := ces.userResolver.GetUserEmail(userID)
+	if email == "" {
+		global.Log.Error("failed to resolve user email", zap.String("userID", userID))
+		return errors.New("user email not found")
+	}
+
+	claimURL := fmt.Sprintf("%s/waitlist/holds/%s", ces.baseURL, data.HoldID)
+	template, err := ces.templateManager.GetTemplate("waitlist_slot_offered")
+	if err != nil {
+		return ces.sendWaitlistSlotOfferedFallback(email, data, claimURL)
+	}
+
+	templateData := map[string]interface{}{
+		"HoldID":           data.HoldID,
+		"expert_name":      data.DoctorName,
+		"booking_datetime": data.ConsultationDate,
+		"booking_time":     data.ConsultationTime,
+		"Duration":         data.Duration,
+		"ExpiresAt":        data.ExpiresAt,
+		"ClaimURL":         claimURL,
+	}
+
+	subject, body, err := ces.templateManager.RenderTemplate(template, templateData)
+	if err != nil {
+		return ces.sendWaitlistSlotOfferedFallback(email, data, claimURL)
+	}
+
+	return ces.sender.Send(email, subject, body)
+}
+
+func (ces *ConsultationEmailService) sendWaitlistSlotOfferedFallback(email string, data interfaces.WaitlistSlotOfferData, claimURL string) error {
+	subject := "⏳ A Consultation Slot is Being Held for You"
+
+	body := fmt.Sprintf(`
+		<div style="font-family: Arial, sans-serif; max-width: 600px; margin: auto; padding: 20px; border: 1px solid #eee; border-radius: 8px;">
+			<h2 style="color: #2c3e50;">A Slot Opened Up</h2>
+			<p style="font-size: 16px;">Hello,</p>
+			<p style="font-size: 16px;">A slot with <strong>%s</strong> on <strong>%s %s</strong> (%d minutes) just became available and is being held for you.</p>
+			<p style="font-size: 16px;">Please book it before <strong>%s</strong>, after that it will be offered to the next person on the waitlist.</p>
+			<p style="font-size: 16px;"><a href="%s">Book this slot</a></p>
+			<p style="margin-top: 30px; font-size: 15px; color: #555;">Thank you for choosing our service!</p>
+		</div>
+	`, data.DoctorName, data.ConsultationDate, data.ConsultationTime, data.Duration, data.ExpiresAt, claimURL)
+
 	return ces.sender.Send(email, subject, body)
 }
 
@@ -256,5 +375,33 @@ func (ces *ConsultationEmailService) sendBookingConfirmationFallback(email strin
 		</div>
 	`, data.BookingID, data.DoctorName, data.ConsultationDate, data.ConsultationTime, data.ConsultationType)
 
-	return ces.sender.Send(email, subject, body)
+	return ces.sendWithBookingAttachments(email, subject, body, data.BookingID)
+}
+
+// sendWithBookingAttachments đính kèm lời mời .ics và PDF hoá đơn của booking (nếu đã có) vào email xác nhận
+func (ces *ConsultationEmailService) sendWithBookingAttachments(email, subject, body, bookingID string) error {
+	var attachments []EmailAttachment
+	if ces.calendarResolver != nil {
+		if invite := ces.calendarResolver.GetBookingInvite(bookingID, email, ical.MethodRequest); invite != nil {
+			attachments = append(attachments, *invite)
+		}
+	}
+	if ces.invoiceResolver != nil {
+		if invoice := ces.invoiceResolver.GetBookingInvoice(bookingID); invoice != nil {
+			attachments = append(attachments, *invoice)
+		}
+	}
+	return ces.sender.SendWithAttachments(email, subject, body, attachments)
+}
+
+// sendWithCancelInvite đính kèm .ics METHOD:CANCEL cùng UID để ứng dụng lịch gỡ sự kiện đã thêm từ lời mời
+func (ces *ConsultationEmailService) sendWithCancelInvite(email, subject, body, bookingID string) error {
+	if ces.calendarResolver == nil {
+		return ces.sender.Send(email, subject, body)
+	}
+	invite := ces.calendarResolver.GetBookingInvite(bookingID, email, ical.MethodCancel)
+	if invite == nil {
+		return ces.sender.Send(email, subject, body)
+	}
+	return ces.sender.SendWithAttachments(email, subject, body, []EmailAttachment{*invite})
 }
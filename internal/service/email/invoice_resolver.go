@@ -0,0 +1,60 @@
+package email
+
+import (
+	"os"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// InvoiceResolver tìm file PDF hoá đơn mới nhất của booking để đính kèm email
+type InvoiceResolver struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func NewInvoiceResolver(db *gorm.DB, logger *zap.Logger) *InvoiceResolver {
+	return &InvoiceResolver{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetBookingInvoice trả về nil khi booking chưa có hoá đơn hoặc PDF chưa được render
+func (ir *InvoiceResolver) GetBookingInvoice(bookingID string) *EmailAttachment {
+	parsedID, err := uuid.Parse(bookingID)
+	if err != nil {
+		ir.logger.Error("Invalid UUID format", zap.String("bookingID", bookingID), zap.Error(err))
+		return nil
+	}
+
+	var invoice struct {
+		InvoiceNumber string
+		FilePath      string
+	}
+	err = ir.db.Table("tbl_invoices").
+		Select("invoice_number, file_path").
+		Where("booking_id = ? AND file_path IS NOT NULL", parsedID).
+		Order("issued_at DESC").
+		Limit(1).
+		Scan(&invoice).Error
+	if err != nil {
+		ir.logger.Error("Failed to get booking invoice", zap.Error(err), zap.String("bookingID", bookingID))
+		return nil
+	}
+	if invoice.FilePath == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(invoice.FilePath)
+	if err != nil {
+		ir.logger.Warn("Failed to read invoice file", zap.Error(err), zap.String("path", invoice.FilePath))
+		return nil
+	}
+	return &EmailAttachment{
+		FileName:    invoice.InvoiceNumber + ".pdf",
+		ContentType: "application/pdf",
+		Content:     content,
+	}
+}
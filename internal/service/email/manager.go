@@ -25,10 +25,12 @@ func NewEmailManager(db *gorm.DB, logger *zap.Logger) interfaces.EmailService {
 	sender := NewEmailSender(config, logger)
 	templateManager := NewTemplateManager(db, logger)
 	userResolver := NewUserResolver(db, logger)
+	invoiceResolver := NewInvoiceResolver(db, logger)
+	calendarResolver := NewCalendarResolver(db, logger, config.FromEmail)
 
 	// Initialize domain services
 	authService := NewAuthEmailService(sender, templateManager, userResolver, config.BaseURL)
-	consultationService := NewConsultationEmailService(sender, templateManager, userResolver, config.BaseURL)
+	consultationService := NewConsultationEmailService(sender, templateManager, userResolver, invoiceResolver, calendarResolver, config.BaseURL)
 
 	return &EmailManager{
 		authService:         authService,
@@ -59,6 +61,12 @@ func (em *EmailManager) SendConsultationBookingApprove(ctx context.Context, user
 func (em *EmailManager) SendConsultationBookingCancelledForUser(ctx context.Context, userID string, data interfaces.ConsultationCancellationDataForUser) error {
 	return em.consultationService.SendBookingCancelledForUser(ctx, userID, data)
 }
+func (em *EmailManager) SendConsultationBookingRejected(ctx context.Context, userID string, data interfaces.ConsultationRejectionData) error {
+	return em.consultationService.SendBookingRejected(ctx, userID, data)
+}
+func (em *EmailManager) SendWaitlistSlotOffered(ctx context.Context, userID string, data interfaces.WaitlistSlotOfferData) error {
+	return em.consultationService.SendWaitlistSlotOffered(ctx, userID, data)
+}
 func (em *EmailManager) SendConsultationBookingCancelledForExpert(ctx context.Context, userID string, data interfaces.ConsultationCancellationDataForExpert) error {
 	return em.consultationService.SendBookingCancelledForExpert(ctx, userID, data)
 }
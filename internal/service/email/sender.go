@@ -1,7 +1,9 @@
 package email
 
 import (
+	"encoding/base64"
 	"fmt"
+	"mime"
 	"net/smtp"
 	"strings"
 	"time"
@@ -58,6 +60,82 @@ func (es *EmailSender) Send(to, subject, body string) error {
 	return nil
 }
 
+// EmailAttachment là file đính kèm email (ví dụ PDF hoá đơn)
+type EmailAttachment struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// SendWithAttachments gửi email HTML kèm file dạng multipart/mixed; không có file thì gửi như Send
+func (es *EmailSender) SendWithAttachments(to, subject, body string, attachments []EmailAttachment) error {
+	if len(attachments) == 0 {
+		return es.Send(to, subject, body)
+	}
+	if to == "" {
+		es.logger.Error("Recipient email is empty")
+		return fmt.Errorf("recipient email is required")
+	}
+
+	es.logger.Info("Sending email with attachments",
+		zap.String("to", to),
+		zap.String("subject", subject),
+		zap.Int("attachments", len(attachments)),
+	)
+
+	auth := smtp.PlainAuth("", es.config.SMTPUsername, es.config.SMTPPassword, es.config.SMTPHost)
+	boundary := fmt.Sprintf("cbs-%d", time.Now().UnixNano())
+
+	msg := []string{
+		fmt.Sprintf("From: %s <%s>", es.config.FromName, es.config.FromEmail),
+		fmt.Sprintf("To: %s", to),
+		fmt.Sprintf("Subject: %s", subject),
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q", boundary),
+		"",
+		"--" + boundary,
+		"Content-Type: text/html; charset=UTF-8",
+		"",
+		body,
+	}
+	for _, attachment := range attachments {
+		fileName := mime.QEncoding.Encode("UTF-8", attachment.FileName)
+		msg = append(msg,
+			"--"+boundary,
+			fmt.Sprintf("Content-Type: %s; name=%q", attachment.ContentType, fileName),
+			"Content-Transfer-Encoding: base64",
+			fmt.Sprintf("Content-Disposition: attachment; filename=%q", fileName),
+			"",
+			wrapBase64(base64.StdEncoding.EncodeToString(attachment.Content)),
+		)
+	}
+	msg = append(msg, "--"+boundary+"--", "")
+
+	message := []byte(strings.Join(msg, "\r\n"))
+	addr := fmt.Sprintf("%s:%s", es.config.SMTPHost, es.config.SMTPPort)
+
+	if err := smtp.SendMail(addr, auth, es.config.FromEmail, []string{to}, message); err != nil {
+		es.logger.Error("Failed to send email", zap.Error(err), zap.String("to", to))
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	es.logger.Info("Email sent successfully", zap.String("to", to))
+	return nil
+}
+
+// wrapBase64 ngắt dòng base64 ở 76 ký tự theo RFC 2045
+func wrapBase64(encoded string) string {
+	const lineLength = 76
+	var b strings.Builder
+	for len(encoded) > lineLength {
+		b.WriteString(encoded[:lineLength])
+		b.WriteString("\r\n")
+		encoded = encoded[lineLength:]
+	}
+	b.WriteString(encoded)
+	return b.String()
+}
+
 func (es *EmailSender) SendBulk(emails []string, subject, body string) error {
 	for _, email := range emails {
 		if err := es.Send(email, subject, body); err != nil {
@@ -45,6 +45,29 @@ type ConsultationCancellationDataForUser struct {
 	RefundAmount      float64
 	RefundProcessDays int
 }
+type ConsultationRejectionData struct {
+	BookingID         string
+	DoctorName        string
+	ConsultationDate  string
+	ConsultationTime  string
+	RejectionReason   string
+	RefundAmount      float64
+	RefundProcessDays int
+	AlternativeSlots  []SuggestedSlotData
+}
+type SuggestedSlotData struct {
+	Date      string
+	StartTime string
+	EndTime   string
+}
+type WaitlistSlotOfferData struct {
+	HoldID           string
+	DoctorName       string
+	ConsultationDate string
+	ConsultationTime string
+	Duration         int    // minutes
+	ExpiresAt        string // Hạn giữ slot (HH:mm dd/MM/yyyy)
+}
 type ConsultationCancellationDataForExpert struct {
 	BookingID         string
 	UserName          string
@@ -15,6 +15,8 @@ type EmailService interface {
 	SendConsultationBookingApprove(ctx context.Context, userID string, data ConsultationBookingData) error
 	SendConsultationBookingCancelledForUser(ctx context.Context, userID string, data ConsultationCancellationDataForUser) error
 	SendConsultationBookingCancelledForExpert(ctx context.Context, expertID string, data ConsultationCancellationDataForExpert) error
+	SendConsultationBookingRejected(ctx context.Context, userID string, data ConsultationRejectionData) error
+	SendWaitlistSlotOffered(ctx context.Context, userID string, data WaitlistSlotOfferData) error
 	SendConsultationBookingRemindersToUser(ctx context.Context, userID string, data ConsultationReminderData) error
 	SendConsultationBookingRemindersToExpert(ctx context.Context, userID string, data ConsultationReminderData) error
 	// SendConsultationReminder(ctx context.Context, userID		 string, data ConsultationReminderData) error
@@ -0,0 +1,52 @@
+package interfaces
+
+// Các data structures trao đổi giữa hệ thống và cổng thanh toán.
+// Trạng thái luôn được quy đổi về common.TransactionStatus*.
+
+type GatewayInitiateRequest struct {
+	TransactionID string
+	BookingID     string
+	UserID        string
+	Amount        float64
+	Currency      string
+	Description   string
+	ReturnURL     string
+}
+
+type GatewayInitiateResult struct {
+	ExternalTransactionID string
+	PaymentURL            string // Link để user thực hiện thanh toán (nếu có)
+	Status                string
+	RawResponse           map[string]interface{}
+}
+
+type GatewayPaymentResult struct {
+	ExternalTransactionID string
+	Status                string
+	Amount                float64
+	Currency              string
+	RawResponse           map[string]interface{}
+}
+
+type GatewayRefundRequest struct {
+	ExternalTransactionID string
+	Amount                float64
+	Currency              string
+	Reason                string
+}
+
+type GatewayRefundResult struct {
+	RefundID    string
+	Status      string
+	Amount      float64
+	RawResponse map[string]interface{}
+}
+
+type GatewayWebhookEvent struct {
+	EventID               string // ID sự kiện phía gateway, dùng để chống xử lý trùng
+	ExternalTransactionID string
+	Status                string
+	Amount                float64
+	Currency              string
+	RawPayload            map[string]interface{}
+}
@@ -0,0 +1,20 @@
+// internal/service/interfaces/payment_gateway.go
+package interfaces
+
+import "context"
+
+// PaymentGateway là adapter cho một nhà cung cấp thanh toán (Momo, ZaloPay, chuyển khoản...).
+// Mỗi provider mới chỉ cần implement interface này và đăng ký khi khởi tạo service.
+type PaymentGateway interface {
+	// Name trả về mã gateway, được lưu vào cột payment_gateway
+	Name() string
+
+	// InitiatePayment tạo phiên thanh toán phía gateway
+	InitiatePayment(ctx context.Context, req GatewayInitiateRequest) (*GatewayInitiateResult, error)
+	// VerifyPayment hỏi lại gateway trạng thái thực tế của một giao dịch
+	VerifyPayment(ctx context.Context, externalTransactionID string) (*GatewayPaymentResult, error)
+	// RefundPayment hoàn tiền (toàn bộ hoặc một phần) cho giao dịch đã thanh toán
+	RefundPayment(ctx context.Context, req GatewayRefundRequest) (*GatewayRefundResult, error)
+	// ParseWebhook chuyển body webhook của gateway về dạng chung
+	ParseWebhook(ctx context.Context, body []byte) (*GatewayWebhookEvent, error)
+}
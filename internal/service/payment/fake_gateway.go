@@ -0,0 +1,214 @@
+// internal/service/payment/fake_gateway.go
+package payment
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/service/interfaces"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const FakeGatewayName = "fake"
+
+// FakeGateway là cổng thanh toán giả chạy trong process, dùng cho môi trường dev
+// và integration test để chạy trọn luồng thanh toán -> xác nhận mà không cần provider thật.
+type FakeGateway struct {
+	mu           sync.Mutex
+	payments     map[string]*fakePayment
+	baseURL      string
+	autoComplete bool
+}
+
+type fakePayment struct {
+	ExternalTransactionID string
+	TransactionID         string
+	Amount                float64
+	RefundedAmount        float64
+	Currency              string
+	Status                string
+	UpdatedAt             time.Time
+}
+
+func NewFakeGateway(baseURL string, autoComplete bool) *FakeGateway {
+	return &FakeGateway{
+		payments:     make(map[string]*fakePayment),
+		baseURL:      baseURL,
+		autoComplete: autoComplete,
+	}
+}
+
+func (fg *FakeGateway) Name() string {
+	return FakeGatewayName
+}
+
+func (fg *FakeGateway) InitiatePayment(ctx context.Context, req interfaces.GatewayInitiateRequest) (*interfaces.GatewayInitiateResult, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("fake gateway: amount must be greater than 0")
+	}
+
+	externalID := "FAKE-" + uuid.NewString()
+	p := &fakePayment{
+		ExternalTransactionID: externalID,
+		TransactionID:         req.TransactionID,
+		Amount:                req.Amount,
+		Currency:              req.Currency,
+		Status:                common.TransactionStatusProcessing,
+		UpdatedAt:             time.Now(),
+	}
+
+	fg.mu.Lock()
+	fg.payments[externalID] = p
+	fg.mu.Unlock()
+
+	paymentURL := fmt.Sprintf("%s/fake-pay/%s", fg.baseURL, externalID)
+	return &interfaces.GatewayInitiateResult{
+		ExternalTransactionID: externalID,
+		PaymentURL:            paymentURL,
+		Status:                p.Status,
+		RawResponse: map[string]interface{}{
+			"gateway":                 FakeGatewayName,
+			"external_transaction_id": externalID,
+			"payment_url":             paymentURL,
+			"status":                  p.Status,
+			"amount":                  p.Amount,
+			"currency":                p.Currency,
+		},
+	}, nil
+}
+
+func (fg *FakeGateway) VerifyPayment(ctx context.Context, externalTransactionID string) (*interfaces.GatewayPaymentResult, error) {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+
+	p, ok := fg.payments[externalTransactionID]
+	if !ok {
+		return nil, fmt.Errorf("fake gateway: transaction %s not found", externalTransactionID)
+	}
+	if fg.autoComplete && p.Status == common.TransactionStatusProcessing {
+		p.Status = common.TransactionStatusCompleted
+		p.UpdatedAt = time.Now()
+	}
+
+	return &interfaces.GatewayPaymentResult{
+		ExternalTransactionID: p.ExternalTransactionID,
+		Status:                p.Status,
+		Amount:                p.Amount,
+		Currency:              p.Currency,
+		RawResponse:           p.toMap(),
+	}, nil
+}
+
+func (fg *FakeGateway) RefundPayment(ctx context.Context, req interfaces.GatewayRefundRequest) (*interfaces.GatewayRefundResult, error) {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+
+	p, ok := fg.payments[req.ExternalTransactionID]
+	if !ok {
+		return nil, fmt.Errorf("fake gateway: transaction %s not found", req.ExternalTransactionID)
+	}
+	if p.Status != common.TransactionStatusCompleted {
+		return nil, fmt.Errorf("fake gateway: cannot refund a %s transaction", p.Status)
+	}
+	if req.Amount <= 0 || p.RefundedAmount+req.Amount > p.Amount {
+		return nil, fmt.Errorf("fake gateway: invalid refund amount %.2f", req.Amount)
+	}
+
+	p.RefundedAmount += req.Amount
+	if p.RefundedAmount >= p.Amount {
+		p.Status = common.TransactionStatusRefunded
+	}
+	p.UpdatedAt = time.Now()
+
+	refundID := "FAKE-RF-" + uuid.NewString()
+	raw := p.toMap()
+	raw["refund_id"] = refundID
+	raw["refund_amount"] = req.Amount
+	raw["refund_reason"] = req.Reason
+
+	return &interfaces.GatewayRefundResult{
+		RefundID:    refundID,
+		Status:      common.TransactionStatusRefunded,
+		Amount:      req.Amount,
+		RawResponse: raw,
+	}, nil
+}
+
+// fakeWebhookBody là format webhook mà fake gateway gửi về
+type fakeWebhookBody struct {
+	EventID               string  `json:"event_id"`
+	ExternalTransactionID string  `json:"external_transaction_id"`
+	Status                string  `json:"status"`
+	Amount                float64 `json:"amount"`
+	Currency              string  `json:"currency"`
+}
+
+func (fg *FakeGateway) ParseWebhook(ctx context.Context, body []byte) (*interfaces.GatewayWebhookEvent, error) {
+	var wb fakeWebhookBody
+	if err := json.Unmarshal(body, &wb); err != nil {
+		return nil, fmt.Errorf("fake gateway: invalid webhook body: %w", err)
+	}
+	if wb.EventID == "" || wb.ExternalTransactionID == "" || wb.Status == "" {
+		return nil, fmt.Errorf("fake gateway: webhook is missing event_id, external_transaction_id or status")
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("fake gateway: invalid webhook body: %w", err)
+	}
+
+	return &interfaces.GatewayWebhookEvent{
+		EventID:               wb.EventID,
+		ExternalTransactionID: wb.ExternalTransactionID,
+		Status:                wb.Status,
+		Amount:                wb.Amount,
+		Currency:              wb.Currency,
+		RawPayload:            raw,
+	}, nil
+}
+
+// SimulateResult đặt kết quả cho một giao dịch (completed/failed/cancelled) như thể
+// user đã thao tác trên trang thanh toán, và trả về body webhook tương ứng để test gửi lại cho hệ thống.
+func (fg *FakeGateway) SimulateResult(externalTransactionID, status string) ([]byte, error) {
+	switch status {
+	case common.TransactionStatusCompleted, common.TransactionStatusFailed, common.TransactionStatusCancelled:
+	default:
+		return nil, fmt.Errorf("fake gateway: unsupported simulated status %s", status)
+	}
+
+	fg.mu.Lock()
+	p, ok := fg.payments[externalTransactionID]
+	if !ok {
+		fg.mu.Unlock()
+		return nil, fmt.Errorf("fake gateway: transaction %s not found", externalTransactionID)
+	}
+	p.Status = status
+	p.UpdatedAt = time.Now()
+	body := fakeWebhookBody{
+		EventID:               "FAKE-EV-" + uuid.NewString(),
+		ExternalTransactionID: p.ExternalTransactionID,
+		Status:                p.Status,
+		Amount:                p.Amount,
+		Currency:              p.Currency,
+	}
+	fg.mu.Unlock()
+
+	return json.Marshal(body)
+}
+
+func (p *fakePayment) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"gateway":                 FakeGatewayName,
+		"external_transaction_id": p.ExternalTransactionID,
+		"transaction_id":          p.TransactionID,
+		"status":                  p.Status,
+		"amount":                  p.Amount,
+		"refunded_amount":         p.RefundedAmount,
+		"currency":                p.Currency,
+		"updated_at":              p.UpdatedAt.Format(time.RFC3339),
+	}
+}
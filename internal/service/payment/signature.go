@@ -0,0 +1,34 @@
+// internal/service/payment/signature.go
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// WebhookSignatureHeader là header chứa chữ ký HMAC-SHA256 (hex) của body webhook
+const WebhookSignatureHeader = "X-Signature"
+
+// SignWebhook tạo chữ ký HMAC-SHA256 dạng hex cho body webhook
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature so sánh chữ ký nhận được với chữ ký tính lại (constant-time).
+// Secret trống luôn trả về false để server chưa cấu hình PAYMENT_WEBHOOK_SECRET không nhận webhook giả mạo.
+func VerifyWebhookSignature(secret string, body []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	received, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	expected, _ := hex.DecodeString(SignWebhook(secret, body))
+	return hmac.Equal(received, expected)
+}
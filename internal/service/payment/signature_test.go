@@ -0,0 +1,31 @@
+package payment
+
+import "testing"
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event_id":"evt_1","status":"completed"}`)
+	signature := SignWebhook("secret", body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{"valid", "secret", body, signature, true},
+		{"valid with prefix", "secret", body, "sha256=" + signature, true},
+		{"wrong secret", "other", body, signature, false},
+		{"tampered body", "secret", []byte(`{"event_id":"evt_1","status":"failed"}`), signature, false},
+		{"missing signature", "secret", body, "", false},
+		{"not hex", "secret", body, "zz", false},
+		{"empty secret", "", body, SignWebhook("", body), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyWebhookSignature(tt.secret, tt.body, tt.signature); got != tt.want {
+				t.Errorf("VerifyWebhookSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package testutil
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisAddrEnv là biến môi trường chứa địa chỉ Redis dùng cho test (host:port)
+const RedisAddrEnv = "TEST_REDIS_ADDR"
+
+// OpenRedis kết nối Redis test; bỏ qua test nếu chưa cấu hình địa chỉ
+func OpenRedis(t testing.TB) *redis.Client {
+	t.Helper()
+	addr := os.Getenv(RedisAddrEnv)
+	if addr == "" {
+		t.Skipf("%s is not set, skipping redis test", RedisAddrEnv)
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("failed to connect to test redis: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
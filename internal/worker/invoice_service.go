@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"cbs_backend/internal/modules/invoices"
+	"context"
+	"fmt"
+	"log"
+)
+
+type InvoiceService struct{}
+
+func NewInvoiceService() *InvoiceService {
+	return &InvoiceService{}
+}
+
+// RenderPendingInvoices render PDF cho hoá đơn mới phát hành và gửi email xác nhận kèm hoá đơn
+func (is *InvoiceService) RenderPendingInvoices(limit int) error {
+	result, err := invoices.Invoice().RenderPendingInvoices(context.Background(), limit)
+	if err != nil {
+		return fmt.Errorf("failed to render pending invoices: %w", err)
+	}
+
+	if result.Rendered > 0 || result.Failed > 0 {
+		log.Printf("🧾 Rendered %d invoices, %d failed", result.Rendered, result.Failed)
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%d invoices failed to render", result.Failed)
+	}
+	return nil
+}
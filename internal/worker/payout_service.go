@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"cbs_backend/internal/modules/payouts"
+	"cbs_backend/internal/modules/payouts/dtopayouts"
+	"context"
+	"fmt"
+	"log"
+)
+
+type PayoutService struct{}
+
+func NewPayoutService() *PayoutService {
+	return &PayoutService{}
+}
+
+// SettleWeeklyPayouts quyết toán các bút toán payout chưa quyết toán đến 00:00 hôm nay
+func (ps *PayoutService) SettleWeeklyPayouts() error {
+	log.Println("🧾 Settling weekly expert payouts...")
+
+	result, err := payouts.Payout().GenerateStatements(context.Background(), dtopayouts.GenerateStatementsRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to settle expert payouts: %w", err)
+	}
+
+	log.Printf("✅ Generated %d payout statements for period ending %s, %d experts carried over",
+		len(result.Statements), result.PeriodEnd.Format("02/01/2006"), len(result.CarriedOverExperts))
+	return nil
+}
@@ -21,6 +21,7 @@ const (
 	NotificationTypeBookingCancelled = "booking_cancelled"
 	NotificationTypeBookingReminder  = "booking_reminder"
 	NotificationTypeBookingConfirmed = "booking_confirmed"
+	NotificationTypeWaitlistOffered  = "waitlist_slot_offered"
 
 	// Redis configuration
 	UserChannelPrefix   = "user:"
@@ -80,6 +81,10 @@ var notificationTemplates = map[string]NotificationTemplate{
 		Title:   "Lịch hẹn được xác nhận",
 		Message: "Lịch hẹn của bạn đã được xác nhận",
 	},
+	NotificationTypeWaitlistOffered: {
+		Title:   "Có slot trống cho bạn",
+		Message: "Một khung giờ bạn đang chờ vừa trống và đang được giữ cho bạn trong thời gian ngắn",
+	},
 }
 
 // Constructor
@@ -132,15 +137,29 @@ func (rs *RealtimeService) SendBookingNotification(bookingID, userID, expertID,
 		return nil
 	}
 
-	template := notificationTemplates[notificationType]
-	userUUID, _ := uuid.Parse(userID) // Already validated
-
 	// Prepare notification data
 	if data == nil {
 		data = make(map[string]interface{})
 	}
 	data["booking_id"] = bookingID
 	data["expert_id"] = expertID
+
+	return rs.SendUserNotification(userID, notificationType, data)
+}
+
+// SendUserNotification lưu notification, publish realtime và xử lý các kênh gửi khác cho thông báo không gắn với một booking cụ thể
+func (rs *RealtimeService) SendUserNotification(userID, notificationType string, data map[string]interface{}) error {
+	template, exists := notificationTemplates[notificationType]
+	if !exists {
+		return fmt.Errorf("invalid notification type: %s", notificationType)
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
 	data["user_id"] = userID
 
 	// Create notification record
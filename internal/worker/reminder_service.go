@@ -1,11 +1,19 @@
 package worker
 
 import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings"
 	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	exchangerates "cbs_backend/internal/modules/exchange_rates"
 	entityExpert "cbs_backend/internal/modules/experts/entity"
+	paymenttransactions "cbs_backend/internal/modules/payment_transactions"
+	"cbs_backend/internal/modules/payment_transactions/dtopayments"
 	entityNotify "cbs_backend/internal/modules/system_notification/entity"
 	entitySystem "cbs_backend/internal/modules/system_setting/entity"
 	"cbs_backend/internal/service/interfaces"
+	"cbs_backend/utils/cache"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -17,6 +25,7 @@ import (
 // BookingData represents the booking information structure
 type BookingData struct {
 	BookingID        string    `json:"booking_id"`
+	GroupSessionID   *string   `json:"group_session_id"`
 	UserID           string    `json:"user_id"`
 	ExpertProfileID  string    `json:"expert_profile_id"`
 	BookingDatetime  time.Time `json:"booking_datetime"`
@@ -27,6 +36,8 @@ type BookingData struct {
 	ConsultationType string    `json:"consultation_type"`
 	MeetingLink      string    `json:"meeting_link"`
 	MeetingAddress   string    `json:"meeting_address"`
+	UserTimeZone     string    `json:"user_time_zone"`   // Giờ gửi cho user format theo múi giờ này
+	ExpertTimeZone   string    `json:"expert_time_zone"` // Giờ gửi cho expert format theo múi giờ này
 }
 
 // WeeklyStats represents weekly statistics structure
@@ -55,14 +66,16 @@ type ReminderService struct {
 	db           *gorm.DB
 	dispatcher   NotificationDispatcher
 	emailService interfaces.EmailService
+	bookingCache cache.BookingCache
 }
 
 // NewReminderService creates a new instance of ReminderService
-func NewReminderService(db *gorm.DB, emailService interfaces.EmailService, dispatcher NotificationDispatcher) *ReminderService {
+func NewReminderService(db *gorm.DB, emailService interfaces.EmailService, dispatcher NotificationDispatcher, bookingCache cache.BookingCache) *ReminderService {
 	return &ReminderService{
 		db:           db,
 		emailService: emailService,
 		dispatcher:   dispatcher,
+		bookingCache: bookingCache,
 	}
 }
 
@@ -89,6 +102,54 @@ func (rs *ReminderService) SendBookingReminders() error {
 	}
 
 	log.Printf("✅ Completed sending reminders for %d/%d bookings", successCount, len(bookings))
+
+	if err := rs.sendGroupSessionReminders(); err != nil {
+		log.Printf("❌ Failed to send group session reminders: %v", err)
+	}
+	return nil
+}
+
+// sendGroupSessionReminders nhắc expert một lần cho mỗi buổi nhóm sắp diễn ra có người tham dự
+func (rs *ReminderService) sendGroupSessionReminders() error {
+	var sessions []BookingData
+	query := `
+		SELECT 
+			gs.session_id as booking_id,
+			gs.expert_profile_id,
+			gs.session_start as booking_datetime,
+			eu.user_email as expert_email,
+			eu.full_name as expert_full_name,
+			gs.title as user_full_name,
+			gs.consultation_type,
+			gs.meeting_link,
+			gs.meeting_address,
+			ep.time_zone as expert_time_zone
+		FROM tbl_group_sessions gs
+		JOIN tbl_expert_profiles ep ON gs.expert_profile_id = ep.expert_profile_id
+		JOIN tbl_users eu ON ep.user_id = eu.user_id
+		WHERE gs.session_status = 'scheduled'
+		AND gs.reminder_sent = false
+		AND gs.session_start BETWEEN NOW() AND NOW() + INTERVAL '1 hour'
+		AND EXISTS (
+			SELECT 1 FROM tbl_consultation_bookings cb
+			WHERE cb.group_session_id = gs.session_id AND cb.booking_status = 'confirmed'
+		)
+	`
+	if err := rs.db.Raw(query).Scan(&sessions).Error; err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if err := rs.sendReminderToExpert(session); err != nil {
+			log.Printf("❌ Failed to send group session reminder to expert %s: %v", session.ExpertProfileID, err)
+			continue
+		}
+		if err := rs.db.Model(&entityBooking.GroupSession{}).
+			Where("session_id = ?", session.BookingID).
+			Update("reminder_sent", true).Error; err != nil {
+			log.Printf("❌ Failed to update reminder_sent for group session %s: %v", session.BookingID, err)
+		}
+	}
 	return nil
 }
 
@@ -102,6 +163,7 @@ func (rs *ReminderService) getUpcomingBookings() ([]BookingData, error) {
 	query := `
 		SELECT 
 			cb.booking_id,
+			cb.group_session_id,
 			cb.user_id,
 			cb.expert_profile_id,
 			cb.booking_datetime,
@@ -111,7 +173,9 @@ func (rs *ReminderService) getUpcomingBookings() ([]BookingData, error) {
 			eu.full_name as expert_full_name,
 			cb.consultation_type,
 			cb.meeting_link,
-			cb.meeting_address
+			cb.meeting_address,
+			u.time_zone as user_time_zone,
+			ep.time_zone as expert_time_zone
 		FROM tbl_consultation_bookings cb
 		JOIN tbl_users u ON cb.user_id = u.user_id
 		JOIN tbl_expert_profiles ep ON cb.expert_profile_id = ep.expert_profile_id
@@ -134,10 +198,12 @@ func (rs *ReminderService) processBookingReminder(booking BookingData) bool {
 		return false
 	}
 
-	// Send reminder to expert
-	if err := rs.sendReminderToExpert(booking); err != nil {
-		log.Printf("❌ Failed to send reminder to expert %s: %v", booking.ExpertProfileID, err)
-		return false
+	// Send reminder to expert (buổi nhóm nhắc expert một lần cho cả buổi, xem sendGroupSessionReminders)
+	if booking.GroupSessionID == nil {
+		if err := rs.sendReminderToExpert(booking); err != nil {
+			log.Printf("❌ Failed to send reminder to expert %s: %v", booking.ExpertProfileID, err)
+			return false
+		}
 	}
 
 	// Mark reminder as sent
@@ -196,7 +262,7 @@ func (rs *ReminderService) createUserNotification(userUUID uuid.UUID, booking Bo
 	message := fmt.Sprintf(
 		"Bạn có lịch tư vấn với %s vào lúc %s. Loại tư vấn: %s",
 		booking.ExpertFullName,
-		booking.BookingDatetime.Format("15:04 02/01/2006"),
+		common.FormatInZone(booking.BookingDatetime, booking.UserTimeZone, "15:04 02/01/2006"),
 		booking.ConsultationType,
 	)
 
@@ -227,6 +293,7 @@ func (rs *ReminderService) createUserNotification(userUUID uuid.UUID, booking Bo
 // createUserEmailPayload creates email payload for user reminder - SYNCHRONIZED
 func (rs *ReminderService) createUserEmailPayload(booking BookingData) map[string]interface{} {
 	timeUntil := FormatTimeUntil(booking.BookingDatetime)
+	localStart := inZone(booking.BookingDatetime, booking.UserTimeZone)
 	return map[string]interface{}{
 		"from":      "user", // Thêm field "from" để sync với EnhancedNotificationService
 		"user_id":   booking.UserID,
@@ -241,8 +308,9 @@ func (rs *ReminderService) createUserEmailPayload(booking BookingData) map[strin
 			"expert_id":         booking.ExpertProfileID,
 			"expert_name":       booking.ExpertFullName,
 			"expert_email":      booking.ExpertEmail,
-			"consultation_date": booking.BookingDatetime.Format("02/01/2006"),
-			"consultation_time": booking.BookingDatetime.Format("15:04"),
+			"consultation_date": localStart.Format("02/01/2006"),
+			"consultation_time": localStart.Format("15:04"),
+			"time_zone":         localStart.Location().String(),
 			"meeting_link":      booking.MeetingLink,
 			"location":          booking.MeetingAddress,
 			"consultation_type": booking.ConsultationType,
@@ -256,7 +324,7 @@ func (rs *ReminderService) createUserEmailBody(booking BookingData) string {
 	body := fmt.Sprintf("Xin chào %s,\n\nBạn có lịch tư vấn với %s vào lúc %s.\n\nLoại tư vấn: %s\n",
 		booking.UserFullName,
 		booking.ExpertFullName,
-		booking.BookingDatetime.Format("15:04 02/01/2006"),
+		common.FormatInZone(booking.BookingDatetime, booking.UserTimeZone, "15:04 02/01/2006"),
 		booking.ConsultationType,
 	)
 	if booking.MeetingLink != "" {
@@ -322,7 +390,7 @@ func (rs *ReminderService) createExpertNotification(expertUserUUID uuid.UUID, bo
 	message := fmt.Sprintf(
 		"Bạn có lịch tư vấn với %s vào lúc %s. Loại tư vấn: %s",
 		booking.UserFullName,
-		booking.BookingDatetime.Format("15:04 02/01/2006"),
+		common.FormatInZone(booking.BookingDatetime, booking.ExpertTimeZone, "15:04 02/01/2006"),
 		booking.ConsultationType,
 	)
 
@@ -353,6 +421,7 @@ func (rs *ReminderService) createExpertNotification(expertUserUUID uuid.UUID, bo
 // createExpertEmailPayload creates email payload for expert reminder - SYNCHRONIZED
 func (rs *ReminderService) createExpertEmailPayload(booking BookingData, expertUserID string) map[string]interface{} {
 	timeUntil := FormatTimeUntil(booking.BookingDatetime)
+	localStart := inZone(booking.BookingDatetime, booking.ExpertTimeZone)
 	return map[string]interface{}{
 		"from":      "expert", // Thêm field "from" để sync với EnhancedNotificationService
 		"user_id":   expertUserID,
@@ -366,8 +435,9 @@ func (rs *ReminderService) createExpertEmailPayload(booking BookingData, expertU
 			"expert_id":         booking.ExpertProfileID,
 			"expert_name":       booking.ExpertFullName,
 			"expert_email":      booking.ExpertEmail,
-			"consultation_date": booking.BookingDatetime.Format("02/01/2006"),
-			"consultation_time": booking.BookingDatetime.Format("15:04"),
+			"consultation_date": localStart.Format("02/01/2006"),
+			"consultation_time": localStart.Format("15:04"),
+			"time_zone":         localStart.Location().String(),
 			"meeting_link":      booking.MeetingLink,
 			"location":          booking.MeetingAddress,
 			"consultation_type": booking.ConsultationType,
@@ -381,7 +451,7 @@ func (rs *ReminderService) createExpertEmailBody(booking BookingData) string {
 	body := fmt.Sprintf("Xin chào %s,\n\nBạn có lịch tư vấn với khách hàng %s vào lúc %s.\n\nLoại tư vấn: %s\n",
 		booking.ExpertFullName,
 		booking.UserFullName,
-		booking.BookingDatetime.Format("15:04 02/01/2006"),
+		common.FormatInZone(booking.BookingDatetime, booking.ExpertTimeZone, "15:04 02/01/2006"),
 		booking.ConsultationType,
 	)
 
@@ -404,18 +474,141 @@ func (rs *ReminderService) createExpertEmailBody(booking BookingData) string {
 func (rs *ReminderService) CheckMissedBookings() error {
 	log.Println("🔍 Checking missed bookings...")
 
-	result := rs.db.Model(&entityBooking.ConsultationBooking{}).
-		Where("booking_status = ? AND booking_datetime < NOW() - INTERVAL '15 minutes'", "confirmed").
-		Update("booking_status", "missed")
+	ctx := context.Background()
+	var bookingIDs []uuid.UUID
+	if err := rs.db.Model(&entityBooking.ConsultationBooking{}).
+		Where("booking_status = ? AND booking_datetime < NOW() - INTERVAL '15 minutes'", common.BookingStatusConfirmed).
+		Pluck("booking_id", &bookingIDs).Error; err != nil {
+		return fmt.Errorf("failed to find missed bookings: %w", err)
+	}
+
+	// State machine khoá và kiểm tra lại trạng thái nên booking vừa được hoàn thành sẽ không bị đánh dấu missed
+	missedCount := 0
+	for _, bookingID := range bookingIDs {
+		if _, err := bookings.TransitionBooking(ctx, rs.db, bookings.BookingTransition{
+			BookingID: bookingID,
+			Event:     bookings.BookingEventMarkMissed,
+			Actor:     bookings.BookingActorSystem,
+			ActorID:   bookings.SystemActorID,
+			Reason:    "Quá giờ hẹn 15 phút mà chưa được hoàn thành",
+		}); err != nil {
+			if !errors.Is(err, bookings.ErrInvalidBookingTransition) {
+				log.Printf("❌ Failed to mark booking %s as missed: %v", bookingID, err)
+			}
+			continue
+		}
+		missedCount++
+	}
+
+	log.Printf("✅ Updated %d missed bookings", missedCount)
+	return nil
+}
+
+// ExpireUnpaidBookings hủy các booking pending chưa thanh toán sau paymentWindow để giải phóng slot của expert
+func (rs *ReminderService) ExpireUnpaidBookings(paymentWindow time.Duration) error {
+	log.Printf("⌛ Expiring unpaid bookings older than %v...", paymentWindow)
+
+	ctx := context.Background()
+	deadline := time.Now().Add(-paymentWindow)
+	unpaidStatuses := []string{common.PaymentStatusPending, common.PaymentStatusFailed}
+
+	// Bỏ qua booking miễn phí và booking còn giao dịch đang mở (pending/processing) dù tạo lúc nào: tiền có thể đã
+	// trả ở gateway, hủy lúc này thì kết quả completed đến sau không áp dụng được vào giao dịch đã hủy.
+	// Giao dịch mở được giải phóng khi gateway báo failed/cancelled, lúc đó booking sẽ hết hạn ở lần chạy sau.
+	// Chỗ trong buổi nhóm được xác nhận ngay khi đặt nên cũng phải được giải phóng nếu không thanh toán.
+	var pendingBookings []entityBooking.ConsultationBooking
+	if err := rs.db.Where("(booking_status = ? OR (booking_status = ? AND group_session_id IS NOT NULL)) AND payment_status IN ? AND consultation_fee_minor > 0 AND booking_created_at < ?",
+		common.BookingStatusPending, common.BookingStatusConfirmed, unpaidStatuses, deadline).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM tbl_payment_transactions pt
+			WHERE pt.booking_id = tbl_consultation_bookings.booking_id
+			AND pt.transaction_type = ?
+			AND pt.transaction_status IN ?
+		)`, common.TransactionTypePayment, openTransactionStatuses).
+		Find(&pendingBookings).Error; err != nil {
+		return fmt.Errorf("failed to find unpaid bookings: %w", err)
+	}
+
+	reason := fmt.Sprintf("Tự động hủy do không thanh toán trong %d phút", int(paymentWindow.Minutes()))
+	expiredCount := 0
+	for _, booking := range pendingBookings {
+		// Guard kiểm tra lại trên bản ghi đã khoá để tránh hủy nhầm booking vừa được thanh toán
+		cancelled, err := bookings.TransitionBooking(ctx, rs.db, bookings.BookingTransition{
+			BookingID: booking.BookingID,
+			Event:     bookings.BookingEventCancel,
+			Actor:     bookings.BookingActorSystem,
+			ActorID:   bookings.SystemActorID,
+			Reason:    reason,
+			Guard: func(locked *entityBooking.ConsultationBooking) error {
+				if locked.PaymentStatus != common.PaymentStatusPending && locked.PaymentStatus != common.PaymentStatusFailed {
+					return fmt.Errorf("%w: booking payment is %s", bookings.ErrInvalidBookingTransition, locked.PaymentStatus)
+				}
+				// CreatePayment cũng khoá booking nên giao dịch tạo sau lượt quét ở trên đã commit khi tới đây
+				open, err := rs.hasOpenPaymentTransaction(locked.BookingID)
+				if err != nil {
+					return err
+				}
+				if open {
+					return fmt.Errorf("%w: booking has an open payment transaction", bookings.ErrInvalidBookingTransition)
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			if !errors.Is(err, bookings.ErrInvalidBookingTransition) {
+				log.Printf("❌ Failed to expire booking %s: %v", booking.BookingID, err)
+			}
+			continue
+		}
+		expiredCount++
+		now := *cancelled.CancelledAt
+
+		// Hủy các giao dịch còn mở của booking
+		if _, err := paymenttransactions.Payment().RefundBooking(ctx, dtopayments.RefundBookingRequest{
+			BookingID:   booking.BookingID.String(),
+			CancelledBy: common.CancelledBySystem,
+			Reason:      reason,
+			CancelledAt: now,
+		}); err != nil {
+			log.Printf("⚠️ Failed to cancel open transactions of booking %s: %v", booking.BookingID, err)
+		}
+
+		if rs.bookingCache != nil {
+			_ = rs.bookingCache.DeleteBooking(ctx, booking.BookingID.String())
+		}
+
+		// Slot vừa trống được giữ cho người kế tiếp trong waitlist, worker process_waitlist_holds sẽ gửi thông báo
+		if _, err := bookings.OfferFreedSlot(ctx, rs.db, booking.ExpertProfileID, booking.BookingDatetime, booking.DurationMinutes); err != nil {
+			log.Printf("⚠️ Failed to offer freed slot of booking %s to waitlist: %v", booking.BookingID, err)
+		}
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to update missed bookings: %w", result.Error)
+		if err := rs.sendCancellationNotification(
+			booking.UserID.String(),
+			booking.BookingID.String(),
+			"Lịch tư vấn đã bị hủy do chưa thanh toán đúng hạn. Vui lòng đặt lại lịch khác.",
+		); err != nil {
+			log.Printf("⚠️ Failed to notify user %s about expired booking: %v", booking.UserID, err)
+		}
 	}
 
-	log.Printf("✅ Updated %d missed bookings", result.RowsAffected)
+	log.Printf("✅ Expired %d/%d unpaid bookings", expiredCount, len(pendingBookings))
 	return nil
 }
 
+// openTransactionStatuses là trạng thái giao dịch thanh toán chưa có kết quả từ gateway
+var openTransactionStatuses = []string{common.TransactionStatusPending, common.TransactionStatusProcessing}
+
+// hasOpenPaymentTransaction kiểm tra booking còn giao dịch thanh toán chưa có kết quả
+func (rs *ReminderService) hasOpenPaymentTransaction(bookingID uuid.UUID) (bool, error) {
+	var count int64
+	if err := rs.db.Table("tbl_payment_transactions").
+		Where("booking_id = ? AND transaction_type = ? AND transaction_status IN ?", bookingID, common.TransactionTypePayment, openTransactionStatuses).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check open payment transactions: %w", err)
+	}
+	return count > 0, nil
+}
+
 // ===========================================
 // DUPLICATE BOOKING HANDLING
 // ===========================================
@@ -442,7 +635,8 @@ func (rs *ReminderService) HandleDuplicateBookings() error {
 	return nil
 }
 
-// findDuplicateBookings finds bookings with same expert and time
+// findDuplicateBookings finds bookings with same expert and time.
+// Chỗ ngồi của buổi nhóm dùng chung expert và giờ bắt đầu nên không tính là trùng lịch.
 func (rs *ReminderService) findDuplicateBookings() ([]DuplicateBooking, error) {
 	var duplicates []DuplicateBooking
 
@@ -452,7 +646,7 @@ func (rs *ReminderService) findDuplicateBookings() ([]DuplicateBooking, error) {
 			booking_datetime,
 			COUNT(*) as count
 		FROM tbl_consultation_bookings 
-		WHERE booking_status IN ('pending', 'confirmed')
+		WHERE booking_status IN ('pending', 'confirmed') AND group_session_id IS NULL
 		GROUP BY expert_profile_id, booking_datetime
 		HAVING COUNT(*) > 1
 	`
@@ -462,28 +656,54 @@ func (rs *ReminderService) findDuplicateBookings() ([]DuplicateBooking, error) {
 
 // resolveDuplicateBooking resolves conflicts by keeping first booking
 func (rs *ReminderService) resolveDuplicateBooking(expertProfileID string, bookingDatetime time.Time) error {
+	ctx := context.Background()
+
 	// Get all conflicting bookings, ordered by creation time
-	var bookings []entityBooking.ConsultationBooking
-	if err := rs.db.Where("expert_profile_id = ? AND booking_datetime = ? AND booking_status IN ('pending', 'confirmed')",
+	var conflicts []entityBooking.ConsultationBooking
+	if err := rs.db.Where("expert_profile_id = ? AND booking_datetime = ? AND booking_status IN ('pending', 'confirmed') AND group_session_id IS NULL",
 		expertProfileID, bookingDatetime).
 		Order("booking_created_at ASC").
-		Find(&bookings).Error; err != nil {
+		Find(&conflicts).Error; err != nil {
 		return err
 	}
 
 	// Keep first booking, cancel others
-	for i, booking := range bookings {
+	for i, booking := range conflicts {
 		if i == 0 {
 			// Confirm first booking if pending
-			if booking.BookingStatus == "pending" {
-				rs.db.Model(&booking).Update("booking_status", "confirmed")
+			if booking.BookingStatus == common.BookingStatusPending {
+				if _, err := bookings.TransitionBooking(ctx, rs.db, bookings.BookingTransition{
+					BookingID: booking.BookingID,
+					Event:     bookings.BookingEventConfirm,
+					Actor:     bookings.BookingActorSystem,
+					ActorID:   bookings.SystemActorID,
+					Reason:    "Tự động xác nhận khi xử lý trùng lịch",
+				}); err != nil {
+					log.Printf("❌ Failed to confirm booking %s: %v", booking.BookingID, err)
+				}
 			}
 		} else {
 			// Cancel other bookings
-			rs.db.Model(&booking).Updates(map[string]interface{}{
-				"booking_status":      "cancelled",
-				"cancellation_reason": "Tự động hủy do trùng lịch",
-			})
+			if _, err := bookings.TransitionBooking(ctx, rs.db, bookings.BookingTransition{
+				BookingID: booking.BookingID,
+				Event:     bookings.BookingEventCancel,
+				Actor:     bookings.BookingActorSystem,
+				ActorID:   bookings.SystemActorID,
+				Reason:    "Tự động hủy do trùng lịch",
+			}); err != nil {
+				log.Printf("❌ Failed to cancel duplicate booking %s: %v", booking.BookingID, err)
+				continue
+			}
+
+			// Hoàn tiền theo chính sách cho trường hợp hệ thống tự hủy
+			if _, err := paymenttransactions.Payment().RefundBooking(ctx, dtopayments.RefundBookingRequest{
+				BookingID:   booking.BookingID.String(),
+				CancelledBy: common.CancelledBySystem,
+				Reason:      "Tự động hủy do trùng lịch",
+				CancelledAt: time.Now(),
+			}); err != nil {
+				log.Printf("❌ Failed to refund auto-cancelled booking %s: %v", booking.BookingID, err)
+			}
 
 			// Send cancellation notification
 			rs.sendCancellationNotification(
@@ -573,13 +793,26 @@ func (rs *ReminderService) calculateWeeklyStats(weekStart time.Time) (*WeeklySta
 		return nil, err
 	}
 
-	// Total revenue
+	// Total revenue: cộng theo từng tiền tệ rồi quy đổi về VND
+	var revenueByCurrency []struct {
+		Currency   string
+		TotalMinor int64
+	}
 	if err := rs.db.Model(&entityBooking.ConsultationBooking{}).
 		Where("booking_created_at >= ? AND booking_status = ? AND payment_status = ?", weekStart, "completed", "paid").
-		Select("COALESCE(SUM(consultation_fee), 0)").
-		Scan(&stats.RevenueTotal).Error; err != nil {
+		Select("currency, COALESCE(SUM(consultation_fee_minor), 0) AS total_minor").
+		Group("currency").
+		Scan(&revenueByCurrency).Error; err != nil {
 		return nil, err
 	}
+	for _, rc := range revenueByCurrency {
+		converted, err := exchangerates.ExchangeRate().Convert(context.Background(),
+			common.FromMinorUnits(rc.TotalMinor, rc.Currency), rc.Currency, common.CurrencyVND, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		stats.RevenueTotal += converted
+	}
 
 	return stats, nil
 }
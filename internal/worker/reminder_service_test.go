@@ -0,0 +1,130 @@
+package worker_test
+
+import (
+	"testing"
+	"time"
+
+	"cbs_backend/internal/common"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	entityPayment "cbs_backend/internal/modules/payment_transactions/entity"
+	"cbs_backend/internal/testutil"
+	"cbs_backend/internal/worker"
+
+	"github.com/google/uuid"
+)
+
+func TestHandleDuplicateBookingsKeepsGroupSessionSeats(t *testing.T) {
+	db := testutil.OpenPostgres(t)
+	expert := testutil.CreateExpert(t, db, 0)
+
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	session := entityBooking.GroupSession{
+		SessionID:        uuid.New(),
+		ExpertProfileID:  expert.ExpertProfileID,
+		Title:            "Workshop",
+		SessionStart:     start,
+		DurationMinutes:  90,
+		Capacity:         5,
+		Currency:         "VND",
+		ConsultationType: "online",
+		SessionStatus:    common.GroupSessionStatusScheduled,
+	}
+	if err := db.Create(&session).Error; err != nil {
+		t.Fatalf("create group session: %v", err)
+	}
+
+	var seatIDs []uuid.UUID
+	for i := 0; i < 3; i++ {
+		user := testutil.CreateUser(t, db, "user")
+		seat := entityBooking.ConsultationBooking{
+			BookingID:        uuid.New(),
+			UserID:           user.UserID,
+			ExpertProfileID:  expert.ExpertProfileID,
+			BookingDatetime:  session.SessionStart,
+			DurationMinutes:  session.DurationMinutes,
+			ConsultationType: session.ConsultationType,
+			BookingStatus:    common.BookingStatusConfirmed,
+			Currency:         session.Currency,
+			PaymentStatus:    common.PaymentStatusPaid,
+			GroupSessionID:   &session.SessionID,
+		}
+		if err := db.Create(&seat).Error; err != nil {
+			t.Fatalf("create seat %d: %v", i, err)
+		}
+		seatIDs = append(seatIDs, seat.BookingID)
+	}
+
+	rs := worker.NewReminderService(db, nil, nil, nil)
+	if err := rs.HandleDuplicateBookings(); err != nil {
+		t.Fatalf("HandleDuplicateBookings: %v", err)
+	}
+
+	var seats []entityBooking.ConsultationBooking
+	if err := db.Where("booking_id IN ?", seatIDs).Find(&seats).Error; err != nil {
+		t.Fatalf("reload seats: %v", err)
+	}
+	if len(seats) != len(seatIDs) {
+		t.Fatalf("expected %d seats, got %d", len(seatIDs), len(seats))
+	}
+	for _, seat := range seats {
+		if seat.BookingStatus != common.BookingStatusConfirmed || seat.PaymentStatus != common.PaymentStatusPaid {
+			t.Errorf("seat %s changed to %s/%s, want confirmed/paid", seat.BookingID, seat.BookingStatus, seat.PaymentStatus)
+		}
+	}
+}
+
+func TestExpireUnpaidBookingsSkipsBookingWithOpenTransaction(t *testing.T) {
+	db := testutil.OpenPostgres(t)
+	fee := int64(400000)
+	expert := testutil.CreateExpert(t, db, fee)
+	user := testutil.CreateUser(t, db, "user")
+
+	// Booking và giao dịch đều đã quá hạn thanh toán nhưng gateway chưa trả kết quả
+	createdAt := time.Now().Add(-3 * time.Hour)
+	booking := entityBooking.ConsultationBooking{
+		BookingID:            uuid.New(),
+		UserID:               user.UserID,
+		ExpertProfileID:      expert.ExpertProfileID,
+		BookingDatetime:      time.Now().Add(72 * time.Hour).Truncate(time.Hour),
+		DurationMinutes:      60,
+		ConsultationType:     "online",
+		BookingStatus:        common.BookingStatusPending,
+		ConsultationFeeMinor: &fee,
+		Currency:             "VND",
+		PaymentStatus:        common.PaymentStatusPending,
+		BookingCreatedAt:     createdAt,
+	}
+	if err := db.Create(&booking).Error; err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	gateway := "fake"
+	externalID := "FAKE-" + uuid.NewString()
+	txn := entityPayment.PaymentTransaction{
+		TransactionType:       common.TransactionTypePayment,
+		BookingID:             booking.BookingID,
+		UserID:                user.UserID,
+		ExpertProfileID:       expert.ExpertProfileID,
+		AmountMinor:           400000,
+		Currency:              "VND",
+		TransactionStatus:     common.TransactionStatusProcessing,
+		PaymentGateway:        &gateway,
+		ExternalTransactionID: &externalID,
+		TransactionCreatedAt:  createdAt,
+	}
+	if err := db.Create(&txn).Error; err != nil {
+		t.Fatalf("create payment transaction: %v", err)
+	}
+
+	rs := worker.NewReminderService(db, nil, nil, nil)
+	if err := rs.ExpireUnpaidBookings(time.Hour); err != nil {
+		t.Fatalf("ExpireUnpaidBookings: %v", err)
+	}
+
+	var reloaded entityBooking.ConsultationBooking
+	if err := db.First(&reloaded, "booking_id = ?", booking.BookingID).Error; err != nil {
+		t.Fatalf("reload booking: %v", err)
+	}
+	if reloaded.BookingStatus != common.BookingStatusPending {
+		t.Errorf("booking with an open transaction was expired: status %s", reloaded.BookingStatus)
+	}
+}
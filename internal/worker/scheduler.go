@@ -2,6 +2,7 @@ package worker
 
 import (
 	"cbs_backend/internal/service/interfaces"
+	"cbs_backend/utils/cache"
 	"context"
 	"fmt"
 	"log"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -25,6 +27,8 @@ const (
 	RetryMultiplier  = 2
 	BaseRetryDelay   = 30 * time.Second
 	MaxRetryDelay    = 5 * time.Minute
+
+	DefaultPaymentWindow = 30 * time.Minute // Hạn thanh toán mặc định của booking pending
 )
 
 // =====================================================================
@@ -35,6 +39,7 @@ type WorkerConfig struct {
 	MaxWorkers      int
 	QueueSize       int
 	ShutdownTimeout time.Duration
+	PaymentWindow   time.Duration
 }
 
 type CronJobConfig struct {
@@ -194,16 +199,27 @@ type ServiceContainer struct {
 	CleanupService        *CleanupService
 	NotificationService   *NotificationService
 	EnhancedNotifyService *EnhancedNotificationService
+	PayoutService         *PayoutService
+	InvoiceService        *InvoiceService
+	WaitlistService       *WaitlistService
 }
 
 func NewServiceContainer(db *gorm.DB, emailService interfaces.EmailService, redisClient *redis.Client) *ServiceContainer {
 	enhancedNotifyService := NewEnhancedNotificationService(db, redisClient, emailService)
 
+	var bookingCache cache.BookingCache
+	if redisClient != nil {
+		bookingCache = cache.NewRedisBookingCache(cache.NewRedisCache(redisClient), zap.L())
+	}
+
 	return &ServiceContainer{
-		ReminderService:       NewReminderService(db, emailService, enhancedNotifyService),
+		ReminderService:       NewReminderService(db, emailService, enhancedNotifyService, bookingCache),
 		CleanupService:        NewCleanupService(db),
 		NotificationService:   NewNotificationService(db),
 		EnhancedNotifyService: enhancedNotifyService,
+		PayoutService:         NewPayoutService(),
+		InvoiceService:        NewInvoiceService(),
+		WaitlistService:       NewWaitlistService(db, redisClient, emailService),
 	}
 }
 
@@ -218,6 +234,7 @@ func NewWorkerScheduler(db *gorm.DB, maxWorkers int, emailService interfaces.Ema
 		MaxWorkers:      maxWorkers,
 		QueueSize:       DefaultQueueSize,
 		ShutdownTimeout: ShutdownTimeout,
+		PaymentWindow:   DefaultPaymentWindow,
 	}
 
 	// Configure cron with better options
@@ -245,6 +262,13 @@ func NewWorkerScheduler(db *gorm.DB, maxWorkers int, emailService interfaces.Ema
 	return ws
 }
 
+// SetPaymentWindow cấu hình hạn thanh toán cho job expire_unpaid_bookings, phải gọi trước Start()
+func (ws *WorkerScheduler) SetPaymentWindow(window time.Duration) {
+	if window > 0 {
+		ws.config.PaymentWindow = window
+	}
+}
+
 // =====================================================================
 // LIFECYCLE MANAGEMENT
 // =====================================================================
@@ -433,9 +457,13 @@ func (ws *WorkerScheduler) getCronJobConfigs() []CronJobConfig {
 		{Name: "process_notifications", Schedule: "* * * * *", JobType: "process_notifications", Priority: 1, Retries: 3},
 		{Name: "booking_reminder", Schedule: "*/2 * * * *", JobType: "booking_reminder", Priority: 1, Retries: 3},
 		{Name: "check_missed_bookings", Schedule: "*/15 * * * *", JobType: "check_missed_bookings", Priority: 2, Retries: 3},
+		{Name: "expire_unpaid_bookings", Schedule: "*/5 * * * *", JobType: "expire_unpaid_bookings", Payload: map[string]interface{}{"payment_window_minutes": int(ws.config.PaymentWindow.Minutes())}, Priority: 2, Retries: 3},
 		{Name: "handle_duplicate_bookings", Schedule: "*/30 * * * *", JobType: "handle_duplicate_bookings", Priority: 2, Retries: 3},
 		{Name: "cleanup_old_data", Schedule: "0 2 * * *", JobType: "cleanup_old_data", Payload: map[string]interface{}{"days": 30}, Priority: 3, Retries: 2},
 		{Name: "weekly_statistics", Schedule: "0 6 * * 0", JobType: "weekly_statistics", Priority: 2, Retries: 3},
+		{Name: "settle_expert_payouts", Schedule: "0 3 * * 1", JobType: "settle_expert_payouts", Priority: 2, Retries: 3},
+		{Name: "render_invoices", Schedule: "* * * * *", JobType: "render_invoices", Payload: map[string]interface{}{"limit": 50}, Priority: 2, Retries: 3},
+		{Name: "process_waitlist_holds", Schedule: "* * * * *", JobType: "process_waitlist_holds", Priority: 1, Retries: 3},
 	}
 }
 
@@ -530,6 +558,9 @@ func (je *JobExecutorImpl) Execute(job Job) error {
 		return je.services.ReminderService.SendBookingReminders()
 	case "check_missed_bookings":
 		return je.services.ReminderService.CheckMissedBookings()
+	case "expire_unpaid_bookings":
+		window := je.extractPaymentWindow(job.Payload)
+		return je.services.ReminderService.ExpireUnpaidBookings(window)
 	case "handle_duplicate_bookings":
 		return je.services.ReminderService.HandleDuplicateBookings()
 	case "cleanup_old_data":
@@ -537,6 +568,12 @@ func (je *JobExecutorImpl) Execute(job Job) error {
 		return je.services.CleanupService.CleanupOldData(days)
 	case "weekly_statistics":
 		return je.services.ReminderService.GenerateWeeklyStatistics()
+	case "settle_expert_payouts":
+		return je.services.PayoutService.SettleWeeklyPayouts()
+	case "render_invoices":
+		return je.services.InvoiceService.RenderPendingInvoices(je.extractInvoiceBatchSize(job.Payload))
+	case "process_waitlist_holds":
+		return je.services.WaitlistService.ProcessSlotHolds()
 	case "send_email_batch":
 		return je.services.NotificationService.ProcessEmailBatch(job.Payload)
 	case "send_email", "send_telegram", "send_sms":
@@ -560,3 +597,32 @@ func (je *JobExecutorImpl) extractCleanupDays(payload interface{}) int {
 	}
 	return defaultDays
 }
+
+func (je *JobExecutorImpl) extractInvoiceBatchSize(payload interface{}) int {
+	defaultLimit := 50
+	payloadMap, ok := payload.(map[string]interface{})
+	if !ok {
+		return defaultLimit
+	}
+
+	if limit, exists := payloadMap["limit"]; exists {
+		if limitInt, ok := limit.(int); ok {
+			return limitInt
+		}
+	}
+	return defaultLimit
+}
+
+func (je *JobExecutorImpl) extractPaymentWindow(payload interface{}) time.Duration {
+	payloadMap, ok := payload.(map[string]interface{})
+	if !ok {
+		return DefaultPaymentWindow
+	}
+
+	if minutes, exists := payloadMap["payment_window_minutes"]; exists {
+		if minutesInt, ok := minutes.(int); ok && minutesInt > 0 {
+			return time.Duration(minutesInt) * time.Minute
+		}
+	}
+	return DefaultPaymentWindow
+}
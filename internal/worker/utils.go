@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	"cbs_backend/internal/common"
+
 	"github.com/google/uuid"
 )
 
@@ -11,16 +13,9 @@ func generateJobID() string {
 	return uuid.New().String()
 }
 
-// Helper function to get current time in Vietnam timezone
-func getCurrentVietnamTime() time.Time {
-	loc, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
-	return time.Now().In(loc)
-}
-
-// Helper function to format time for Vietnamese locale
-func formatVietnameseTime(t time.Time) string {
-	loc, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
-	return t.In(loc).Format("15:04 ngày 02/01/2006")
+// inZone đổi t sang múi giờ IANA của người nhận, rỗng/không hợp lệ thì dùng múi giờ mặc định
+func inZone(t time.Time, timeZone string) time.Time {
+	return t.In(common.LocationOrDefault(timeZone))
 }
 
 func getString(m map[string]interface{}, key string) string {
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings"
+	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	"cbs_backend/internal/modules/experts/entity"
+	entityUser "cbs_backend/internal/modules/users/entity"
+	"cbs_backend/internal/service/interfaces"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+type WaitlistService struct {
+	db           *gorm.DB
+	realtimeSvc  *RealtimeService
+	emailService interfaces.EmailService
+}
+
+func NewWaitlistService(db *gorm.DB, redisClient *redis.Client, emailService interfaces.EmailService) *WaitlistService {
+	return &WaitlistService{
+		db:           db,
+		realtimeSvc:  NewRealtimeService(db, redisClient, nil),
+		emailService: emailService,
+	}
+}
+
+// ProcessSlotHolds chuyển các hold quá hạn cho người kế tiếp trong waitlist và thông báo cho người vừa được giữ slot
+func (ws *WaitlistService) ProcessSlotHolds() error {
+	ctx := context.Background()
+	now := time.Now()
+
+	passed, err := bookings.ExpireSlotHolds(ctx, ws.db, now)
+	if err != nil {
+		return fmt.Errorf("failed to expire slot holds: %w", err)
+	}
+	if passed > 0 {
+		log.Printf("⏳ Passed %d expired slot holds to the next waitlisted user", passed)
+	}
+
+	var holds []entityBooking.SlotHold
+	if err := ws.db.Where("hold_status = ? AND notified_at IS NULL AND expires_at > ?", common.SlotHoldStatusActive, now).
+		Order("hold_created_at ASC").
+		Find(&holds).Error; err != nil {
+		return fmt.Errorf("failed to find slot holds to notify: %w", err)
+	}
+
+	notified := 0
+	for _, hold := range holds {
+		if err := ws.notifyHold(ctx, hold); err != nil {
+			log.Printf("❌ Failed to notify slot hold %s: %v", hold.HoldID, err)
+			continue
+		}
+		notified++
+	}
+	if notified > 0 {
+		log.Printf("✅ Notified %d waitlisted users about held slots", notified)
+	}
+	return nil
+}
+
+func (ws *WaitlistService) notifyHold(ctx context.Context, hold entityBooking.SlotHold) error {
+	var expert entity.ExpertProfile
+	if err := ws.db.Preload("User").First(&expert, "expert_profile_id = ?", hold.ExpertProfileID).Error; err != nil {
+		return fmt.Errorf("failed to get expert info: %w", err)
+	}
+	doctorName := ""
+	if expert.User != nil {
+		doctorName = expert.User.FullName
+	}
+	userID := hold.UserID.String()
+	// Giờ trong email theo múi giờ của user được giữ slot
+	var userTimeZone string
+	if err := ws.db.Model(&entityUser.User{}).Where("user_id = ?", hold.UserID).Select("time_zone").Scan(&userTimeZone).Error; err != nil {
+		log.Printf("⚠️ Failed to get time zone of user %s, using default: %v", userID, err)
+	}
+	slotStart := inZone(hold.SlotStart, userTimeZone)
+
+	if err := ws.realtimeSvc.SendUserNotification(userID, NotificationTypeWaitlistOffered, map[string]interface{}{
+		"hold_id":          hold.HoldID.String(),
+		"expert_id":        hold.ExpertProfileID.String(),
+		"slot_start":       hold.SlotStart,
+		"duration_minutes": hold.DurationMinutes,
+		"expires_at":       hold.ExpiresAt,
+	}); err != nil {
+		return fmt.Errorf("failed to send realtime notification: %w", err)
+	}
+
+	// Email lỗi chỉ log, người dùng vẫn nhận được thông báo trong app
+	if ws.emailService != nil {
+		if err := ws.emailService.SendWaitlistSlotOffered(ctx, userID, interfaces.WaitlistSlotOfferData{
+			HoldID:           hold.HoldID.String(),
+			DoctorName:       doctorName,
+			ConsultationDate: slotStart.Format("02-01-2006"),
+			ConsultationTime: slotStart.Format("15:04"),
+			Duration:         hold.DurationMinutes,
+			ExpiresAt:        common.FormatInZone(hold.ExpiresAt, userTimeZone, "15:04 02/01/2006"),
+		}); err != nil {
+			log.Printf("⚠️ Failed to email user %s about held slot: %v", userID, err)
+		}
+	}
+
+	return ws.db.Model(&entityBooking.SlotHold{}).
+		Where("hold_id = ?", hold.HoldID).
+		Update("notified_at", time.Now()).Error
+}
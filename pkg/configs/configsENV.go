@@ -19,6 +19,7 @@ type ConectionConfigs struct {
 	SMTPCF     *STMPConfig
 	SMSCF      *SMSConfig
 	TLGCF      *TelegramConfig
+	PaymentCF  *PaymentConfig
 }
 type STMPConfig struct {
 	SmtpHost     string
@@ -43,6 +44,19 @@ type SMSConfig struct {
 	SMSApiURL string
 }
 
+type PaymentConfig struct {
+	DefaultGateway       string
+	ReturnURL            string
+	WebhookSecret        string        `json:"-"` // Không ghi ra log khi log cả config
+	PaymentWindow        time.Duration // Thời gian tối đa để thanh toán trước khi booking pending bị hủy
+	EnableFakeGateway    bool          // Chỉ bật ở dev/test: đăng ký fake gateway chạy trong process
+	FakeAutoComplete     bool          // Fake gateway tự chuyển giao dịch sang completed khi verify
+	CommissionPercentage float64       // % hoa hồng nền tảng trừ vào doanh thu của expert
+	InvoiceTaxPercentage float64       // % thuế GTGT đã bao gồm trong giá, dùng để tách thuế trên hoá đơn
+	InvoiceStorageDir    string        // Thư mục lưu file PDF hoá đơn
+	InvoiceIssuerName    string        // Tên đơn vị phát hành hoá đơn
+}
+
 type TelegramConfig struct {
 	TELEGRAM_BOT_TOKEN string
 }
@@ -130,6 +144,18 @@ func LoadConfigs() (*ConectionConfigs, error) { // Try to load .env file from mu
 		TLGCF: &TelegramConfig{
 			TELEGRAM_BOT_TOKEN: getEnv("TELEGRAM_BOT_TOKEN", "23456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"),
 		},
+		PaymentCF: &PaymentConfig{
+			DefaultGateway:       getEnv("PAYMENT_DEFAULT_GATEWAY", ""),
+			ReturnURL:            getEnv("PAYMENT_RETURN_URL", "http://localhost:8899/payment/result"),
+			WebhookSecret:        getEnv("PAYMENT_WEBHOOK_SECRET", ""), // Không có mặc định: secret trống thì mọi webhook bị từ chối
+			PaymentWindow:        getEnvDuration("PAYMENT_WINDOW", 30*time.Minute),
+			EnableFakeGateway:    getEnv("PAYMENT_ENABLE_FAKE_GATEWAY", "false") == "true",
+			FakeAutoComplete:     getEnv("PAYMENT_FAKE_AUTO_COMPLETE", "false") == "true",
+			CommissionPercentage: getEnvFloat("PLATFORM_COMMISSION_PERCENTAGE", 20),
+			InvoiceTaxPercentage: getEnvFloat("INVOICE_TAX_PERCENTAGE", 10),
+			InvoiceStorageDir:    getEnv("INVOICE_STORAGE_DIR", "storage/invoices"),
+			InvoiceIssuerName:    getEnv("INVOICE_ISSUER_NAME", "Consultation Booking System"),
+		},
 		PostgresCF: &DataBasePostgresConfig{
 			Host:     getEnv("DB_HOST_POSTGRES", "localhost"),
 			Port:     getEnv("DB_PORT_POSTGRES", "5432"),
@@ -212,6 +238,19 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	return duration
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	var f float64
+	if _, err := fmt.Sscanf(value, "%g", &f); err != nil {
+		log.Printf("⚠️ Invalid number for %s: %s, using default: %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return f
+}
+
 // func getEnvInt(key string, defaultValue int) int {
 // 	value := strings.TrimSpace(os.Getenv(key))
 // 	if value == "" {
@@ -0,0 +1,134 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Các giá trị METHOD của iTIP (RFC 5546): feed không đặt METHOD, lời mời qua email dùng REQUEST/CANCEL
+const (
+	MethodRequest = "REQUEST"
+	MethodCancel  = "CANCEL"
+)
+
+// Các giá trị STATUS của VEVENT
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+const (
+	timeLayout = "20060102T150405Z"
+	maxLineLen = 75 // RFC 5545 3.1: mỗi dòng tối đa 75 octet, dài hơn thì gấp dòng
+)
+
+// Event là một VEVENT. UID phải ổn định để ứng dụng lịch cập nhật đúng sự kiện khi đổi lịch/huỷ;
+// Sequence tăng mỗi khi sự kiện thay đổi để bản mới được ưu tiên hơn bản cũ.
+type Event struct {
+	UID         string
+	Sequence    int
+	Stamp       time.Time
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Status      string
+	Organizer   string // Email người tổ chức, rỗng thì bỏ qua
+	Attendees   []string
+}
+
+// Calendar là một VCALENDAR gồm nhiều Event
+type Calendar struct {
+	ProdID string
+	Name   string // X-WR-CALNAME, tên lịch hiển thị khi đăng ký feed
+	Method string
+	Events []Event
+}
+
+// Bytes trả về nội dung .ics (CRLF, đã escape và gấp dòng), thời gian ghi theo UTC
+func (c Calendar) Bytes() []byte {
+	var b strings.Builder
+	w := func(name, value string) {
+		writeLine(&b, name+":"+value)
+	}
+
+	w("BEGIN", "VCALENDAR")
+	w("VERSION", "2.0")
+	w("PRODID", c.ProdID)
+	w("CALSCALE", "GREGORIAN")
+	if c.Method != "" {
+		w("METHOD", c.Method)
+	}
+	if c.Name != "" {
+		w("X-WR-CALNAME", escapeText(c.Name))
+	}
+	for _, e := range c.Events {
+		w("BEGIN", "VEVENT")
+		w("UID", e.UID)
+		w("SEQUENCE", fmt.Sprintf("%d", e.Sequence))
+		w("DTSTAMP", formatTime(e.Stamp))
+		w("DTSTART", formatTime(e.Start))
+		w("DTEND", formatTime(e.End))
+		w("SUMMARY", escapeText(e.Summary))
+		if e.Description != "" {
+			w("DESCRIPTION", escapeText(e.Description))
+		}
+		if e.Location != "" {
+			w("LOCATION", escapeText(e.Location))
+		}
+		if e.URL != "" {
+			w("URL", e.URL)
+		}
+		if e.Status != "" {
+			w("STATUS", e.Status)
+		}
+		if e.Organizer != "" {
+			w("ORGANIZER", "mailto:"+e.Organizer)
+		}
+		for _, attendee := range e.Attendees {
+			writeLine(&b, "ATTENDEE;ROLE=REQ-PARTICIPANT:mailto:"+attendee)
+		}
+		w("END", "VEVENT")
+	}
+	w("END", "VCALENDAR")
+	return []byte(b.String())
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// escapeText escape TEXT theo RFC 5545 3.3.11
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, ";", `\;`)
+	s = strings.ReplaceAll(s, ",", `\,`)
+	s = strings.ReplaceAll(s, "\r\n", `\n`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return s
+}
+
+// writeLine ghi một dòng, gấp ở 75 octet mà không cắt giữa ký tự UTF-8
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineLen
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineLen - 1 // Dòng gấp bắt đầu bằng một dấu cách
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,156 @@
+package ical
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// ics dựng nội dung .ics từ các dòng, nối bằng CRLF như RFC 5545
+func ics(lines ...string) string {
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func TestParse(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []ParsedEvent
+		wantErr string
+	}{
+		{
+			name: "utc event",
+			input: ics("BEGIN:VCALENDAR", "BEGIN:VEVENT", "UID:a@test", "SUMMARY:Meeting",
+				"DTSTART:20260105T030000Z", "DTEND:20260105T040000Z", "END:VEVENT", "END:VCALENDAR"),
+			want: []ParsedEvent{{UID: "a@test", Summary: "Meeting",
+				Start: time.Date(2026, 1, 5, 3, 0, 0, 0, time.UTC), End: time.Date(2026, 1, 5, 4, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name: "folded lines and escaped text",
+			input: ics("BEGIN:VEVENT", "UID:folded", "SUMMARY:Tư vấn\\, ", " buổi đầu\\;", "\tghi chú\\nhai dòng",
+				"DTSTART:20260105T030000Z", "END:VEVENT"),
+			want: []ParsedEvent{{UID: "folded", Summary: "Tư vấn, buổi đầu;ghi chú\nhai dòng",
+				Start: time.Date(2026, 1, 5, 3, 0, 0, 0, time.UTC), End: time.Date(2026, 1, 5, 3, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:  "all-day event with exclusive end",
+			input: ics("BEGIN:VEVENT", "UID:holiday", "DTSTART;VALUE=DATE:20260101", "DTEND;VALUE=DATE:20260103", "END:VEVENT"),
+			want: []ParsedEvent{{UID: "holiday", AllDay: true,
+				Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:  "all-day event without end lasts one day",
+			input: ics("BEGIN:VEVENT", "UID:day", "DTSTART;VALUE=DATE:20260430", "END:VEVENT"),
+			want: []ParsedEvent{{UID: "day", AllDay: true,
+				Start: time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC), End: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name: "tzid parameter, quoted and across dst",
+			input: ics("BEGIN:VEVENT", "UID:tz", `DTSTART;TZID="America/New_York":20260307T090000`,
+				"DTEND;TZID=America/New_York:20260308T090000", "END:VEVENT"),
+			want: []ParsedEvent{{UID: "tz",
+				Start: time.Date(2026, 3, 7, 9, 0, 0, 0, newYork), End: time.Date(2026, 3, 8, 9, 0, 0, 0, newYork)}},
+		},
+		{
+			name:  "floating local time is treated as utc",
+			input: ics("BEGIN:VEVENT", "UID:floating", "DTSTART:20260105T090000", "END:VEVENT"),
+			want: []ParsedEvent{{UID: "floating",
+				Start: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC), End: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name: "lowercase names, recurrence and properties outside events",
+			input: ics("BEGIN:VCALENDAR", "BEGIN:VTIMEZONE", "BEGIN:STANDARD", "DTSTART:19701101T020000", "END:STANDARD", "END:VTIMEZONE",
+				"begin:VEVENT", "uid:weekly", "dtstart:20260105T030000Z", "rrule:FREQ=WEEKLY", "end:VEVENT", "END:VCALENDAR"),
+			want: []ParsedEvent{{UID: "weekly", Recurring: true,
+				Start: time.Date(2026, 1, 5, 3, 0, 0, 0, time.UTC), End: time.Date(2026, 1, 5, 3, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name:  "empty calendar",
+			input: ics("BEGIN:VCALENDAR", "END:VCALENDAR"),
+		},
+		{
+			name:    "end without begin",
+			input:   ics("END:VEVENT"),
+			wantErr: "END:VEVENT without BEGIN",
+		},
+		{
+			name:    "missing dtstart",
+			input:   ics("BEGIN:VEVENT", "UID:nostart", "END:VEVENT"),
+			wantErr: "has no DTSTART",
+		},
+		{
+			name:    "unterminated event",
+			input:   ics("BEGIN:VEVENT", "UID:open", "DTSTART:20260105T030000Z"),
+			wantErr: "unterminated VEVENT",
+		},
+		{
+			name:    "malformed dtstart",
+			input:   ics("BEGIN:VEVENT", "DTSTART:2026-01-05T03:00:00Z", "END:VEVENT"),
+			wantErr: "invalid DTSTART",
+		},
+		{
+			name:    "malformed dtend",
+			input:   ics("BEGIN:VEVENT", "DTSTART:20260105T030000Z", "DTEND;VALUE=DATE:2026010", "END:VEVENT"),
+			wantErr: "invalid DTEND",
+		},
+		{
+			name:    "unknown tzid",
+			input:   ics("BEGIN:VEVENT", "DTSTART;TZID=Mars/Olympus:20260105T090000", "END:VEVENT"),
+			wantErr: "unknown TZID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Parse() returned %d events, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !got[i].Start.Equal(tt.want[i].Start) || !got[i].End.Equal(tt.want[i].End) {
+					t.Errorf("event %d spans %v - %v, want %v - %v", i, got[i].Start, got[i].End, tt.want[i].Start, tt.want[i].End)
+				}
+				got[i].Start, got[i].End = tt.want[i].Start, tt.want[i].End
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("event %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestParseReadsCalendarBytes: nội dung do Calendar.Bytes ghi ra (đã escape và gấp dòng) đọc lại được bằng Parse
+func TestParseReadsCalendarBytes(t *testing.T) {
+	start := time.Date(2026, 1, 5, 3, 0, 0, 0, time.UTC)
+	summary := strings.Repeat("Tư vấn tâm lý, buổi dài; ", 8)
+	calendar := Calendar{ProdID: "-//test//EN", Events: []Event{{
+		UID: "roundtrip@test", Stamp: start, Start: start, End: start.Add(time.Hour), Summary: summary,
+	}}}
+
+	events, err := Parse(strings.NewReader(string(calendar.Bytes())))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Parse() returned %d events, want 1", len(events))
+	}
+	if events[0].Summary != summary || !events[0].Start.Equal(start) || !events[0].End.Equal(start.Add(time.Hour)) {
+		t.Errorf("Parse() = %+v, want summary %q from %v to %v", events[0], summary, start, start.Add(time.Hour))
+	}
+}
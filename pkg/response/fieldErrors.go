@@ -0,0 +1,26 @@
+package response
+
+import "strings"
+
+// FieldError là một lỗi kiểm tra dữ liệu gắn với một trường trong request (vd "entries[2].end_time")
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// FieldErrors gom mọi lỗi của một request để client sửa một lần; Wrap trả nguyên danh sách trong "error" thay vì một chuỗi
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fe := range e {
+		messages = append(messages, fe.Field+": "+fe.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add thêm một lỗi vào danh sách
+func (e *FieldErrors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
@@ -15,6 +15,10 @@ func Wrap(handler HandlerFunc) func(ctx *gin.Context) {
 		res, err := handler(ctx)
 		if err != nil {
 			if apiErr, ok := err.(*APIError); ok {
+				if fieldErrs, ok := apiErr.Err.(FieldErrors); ok {
+					ErrorResponse(ctx, apiErr.StatusCode, apiErr.Message, fieldErrs)
+					return
+				}
 				ErrorResponse(ctx, apiErr.StatusCode, apiErr.Message, apiErr.Error())
 			} else {
 				ErrorResponse(ctx, http.StatusInternalServerError, "Internal Server Error", err.Error())
@@ -40,6 +40,13 @@ type BookingCache interface {
 	GetExpertBookingsForDay(ctx context.Context, expertID string, date time.Time) ([]*BookingCacheData, error)
 	// Lấy tất cả booking của user trong ngày
 	GetUserBookingsForDay(ctx context.Context, userID string, date time.Time) ([]*BookingCacheData, error)
+	// Lấy lưới slot đã tính của expert theo từng ngày (YYYY-MM-DD theo múi giờ expert); ngày chưa có trong cache không nằm trong map.
+	// Trả kèm thế hệ của từng ngày để truyền lại cho SetSlotGrids.
+	GetSlotGrids(ctx context.Context, expertID string, days []string, slotDuration int) (map[string][]byte, map[string]string, error)
+	// Lưu lưới slot theo ngày, hết hạn sau ttl; bỏ qua ngày đã bị xoá (đổi thế hệ) kể từ lúc GetSlotGrids
+	SetSlotGrids(ctx context.Context, expertID string, slotDuration int, grids map[string]interface{}, generations map[string]string, ttl time.Duration) error
+	// Xoá lưới slot của các ngày; expertID rỗng là mọi expert, days rỗng là mọi ngày
+	InvalidateSlotGrids(ctx context.Context, expertID string, days []string) error
 }
 
 type redisBookingCache struct {
@@ -60,6 +67,8 @@ const (
 	expertScheduleKeyPrefix = "expert_schedule:"
 	userScheduleKeyPrefix   = "user_schedule:"
 	dailyBookingKeyPrefix   = "daily_bookings:"
+	slotGridKeyPrefix       = "slot_grid:"
+	slotGridGenKeyPrefix    = "slot_grid_gen:"
 )
 
 func (r *redisBookingCache) IsExpertAvailable(ctx context.Context, expertID string, startTime, endTime time.Time) (bool, error) {
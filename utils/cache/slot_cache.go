@@ -0,0 +1,206 @@
+// utils/cache/slot_cache.go
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxSlotGridDays: khoảng xoá rộng hơn thì xoá luôn mọi ngày của expert
+	maxSlotGridDays = 400
+	// slotGridGenerationTTL phải dài hơn thời gian tính một lưới để bộ đếm không hết hạn giữa lúc đọc và lúc ghi
+	slotGridGenerationTTL = 24 * time.Hour
+	slotGridAllExperts    = "_all"
+)
+
+// Lưới slot của một expert trong một ngày nằm ở hash slot_grid:{expertID}:{YYYY-MM-DD},
+// mỗi field là một thời lượng slot (phút) nên xoá một ngày là xoá mọi thời lượng của ngày đó.
+func slotGridKey(expertID, day string) string {
+	return fmt.Sprintf("%s%s:%s", slotGridKeyPrefix, expertID, day)
+}
+
+// Mỗi lần xoá lưới tăng bộ đếm thế hệ tương ứng: slot_grid_gen:_all (mọi expert), slot_grid_gen:{expertID}
+// (mọi ngày của expert) hoặc slot_grid_gen:{expertID}:{YYYY-MM-DD}. Thế hệ của một ngày ghép từ ba bộ đếm này.
+func slotGridGenerationKeys(expertID, day string) []string {
+	return []string{
+		slotGridGenKeyPrefix + slotGridAllExperts,
+		slotGridGenKeyPrefix + expertID,
+		fmt.Sprintf("%s%s:%s", slotGridGenKeyPrefix, expertID, day),
+	}
+}
+
+// setSlotGridScript chỉ ghi lưới khi thế hệ của ngày chưa đổi kể từ lúc đọc, nên lưới tính từ dữ liệu cũ
+// không ghi đè được lần xoá xảy ra trong lúc đang tính.
+// KEYS: 3 bộ đếm thế hệ, key lưới; ARGV: thế hệ đã đọc, field, dữ liệu, ttl (ms)
+var setSlotGridScript = redis.NewScript(`
+local gen = (redis.call('GET', KEYS[1]) or '0') .. ':' .. (redis.call('GET', KEYS[2]) or '0') .. ':' .. (redis.call('GET', KEYS[3]) or '0')
+if gen ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[4], ARGV[2], ARGV[3])
+redis.call('PEXPIRE', KEYS[4], ARGV[4])
+return 1
+`)
+
+func (r *redisBookingCache) GetSlotGrids(ctx context.Context, expertID string, days []string, slotDuration int) (map[string][]byte, map[string]string, error) {
+	field := strconv.Itoa(slotDuration)
+	pipe := r.redis.Client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(days))
+	genCmds := make([]*redis.SliceCmd, len(days))
+	for i, day := range days {
+		// Đọc thế hệ trước lưới: lần xoá sau thời điểm này sẽ chặn lần ghi lại của request hiện tại
+		genCmds[i] = pipe.MGet(ctx, slotGridGenerationKeys(expertID, day)...)
+		cmds[i] = pipe.HGet(ctx, slotGridKey(expertID, day), field)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		r.logger.Error("Failed to get slot grids", zap.String("expertID", expertID), zap.Error(err))
+		return nil, nil, err
+	}
+
+	grids := make(map[string][]byte, len(days))
+	generations := make(map[string]string, len(days))
+	for i, cmd := range cmds {
+		generations[days[i]] = slotGridGeneration(genCmds[i].Val())
+		data, err := cmd.Bytes()
+		if err != nil {
+			continue // redis.Nil: ngày chưa được cache
+		}
+		grids[days[i]] = data
+	}
+	return grids, generations, nil
+}
+
+// slotGridGeneration ghép giá trị các bộ đếm thế hệ theo đúng cách của setSlotGridScript; bộ đếm chưa có là 0
+func slotGridGeneration(counters []interface{}) string {
+	parts := make([]string, len(counters))
+	for i, c := range counters {
+		parts[i] = "0"
+		if v, ok := c.(string); ok {
+			parts[i] = v
+		}
+	}
+	return strings.Join(parts, ":")
+}
+
+func (r *redisBookingCache) SetSlotGrids(ctx context.Context, expertID string, slotDuration int, grids map[string]interface{}, generations map[string]string, ttl time.Duration) error {
+	field := strconv.Itoa(slotDuration)
+	pipe := r.redis.Client.Pipeline()
+	for day, grid := range grids {
+		generation, ok := generations[day]
+		if !ok {
+			continue // Không biết thế hệ lúc đọc thì không ghi để tránh đè lên lần xoá
+		}
+		data, err := json.Marshal(grid)
+		if err != nil {
+			return err
+		}
+		keys := append(slotGridGenerationKeys(expertID, day), slotGridKey(expertID, day))
+		// EVAL thay vì Run: trong pipeline không biết lỗi NOSCRIPT để gửi lại script sau EVALSHA
+		setSlotGridScript.Eval(ctx, pipe, keys, generation, field, data, ttl.Milliseconds())
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Error("Failed to cache slot grids", zap.String("expertID", expertID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *redisBookingCache) InvalidateSlotGrids(ctx context.Context, expertID string, days []string) error {
+	expert := expertID
+	if expert == "" {
+		expert = "*"
+	}
+	if err := r.bumpSlotGridGenerations(ctx, expertID, days); err != nil {
+		r.logger.Error("Failed to bump slot grid generations", zap.String("expertID", expertID), zap.Error(err))
+		return err
+	}
+	if len(days) == 0 || len(days) > maxSlotGridDays {
+		return r.deleteSlotGridPattern(ctx, slotGridKey(expert, "*"))
+	}
+	if expertID == "" {
+		for _, day := range days {
+			if err := r.deleteSlotGridPattern(ctx, slotGridKey(expert, day)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	keys := make([]string, len(days))
+	for i, day := range days {
+		keys[i] = slotGridKey(expertID, day)
+	}
+	if err := r.redis.Client.Del(ctx, keys...).Err(); err != nil {
+		r.logger.Error("Failed to invalidate slot grids", zap.String("expertID", expertID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// bumpSlotGridGenerations tăng bộ đếm thế hệ của phạm vi sắp xoá, trước khi xoá lưới
+func (r *redisBookingCache) bumpSlotGridGenerations(ctx context.Context, expertID string, days []string) error {
+	var keys []string
+	switch {
+	case expertID == "":
+		keys = []string{slotGridGenKeyPrefix + slotGridAllExperts}
+	case len(days) == 0 || len(days) > maxSlotGridDays:
+		keys = []string{slotGridGenKeyPrefix + expertID}
+	default:
+		for _, day := range days {
+			keys = append(keys, slotGridGenerationKeys(expertID, day)[2])
+		}
+	}
+	pipe := r.redis.Client.Pipeline()
+	for _, key := range keys {
+		pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, slotGridGenerationTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// deleteSlotGridPattern xoá các key khớp pattern bằng SCAN để không chặn Redis như KEYS
+func (r *redisBookingCache) deleteSlotGridPattern(ctx context.Context, pattern string) error {
+	iter := r.redis.Client.Scan(ctx, 0, pattern, 500).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 500 {
+			if err := r.redis.Client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		r.logger.Error("Failed to scan slot grids", zap.String("pattern", pattern), zap.Error(err))
+		return err
+	}
+	if len(keys) > 0 {
+		return r.redis.Client.Del(ctx, keys...).Err()
+	}
+	return nil
+}
+
+// SlotGridDaysAround trả về các ngày YYYY-MM-DD có thể chứa [from, to) theo bất kỳ múi giờ nào (nới một ngày mỗi phía),
+// dùng khi bên xoá cache không biết múi giờ của expert
+func SlotGridDaysAround(from, to time.Time) []string {
+	start := from.UTC().AddDate(0, 0, -1)
+	end := to.UTC().AddDate(0, 0, 1)
+	var days []string
+	for d := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC); !d.After(end); d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format("2006-01-02"))
+		if len(days) > maxSlotGridDays {
+			return nil // Quá rộng: xoá mọi ngày
+		}
+	}
+	return days
+}
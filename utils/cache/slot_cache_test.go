@@ -0,0 +1,66 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cbs_backend/internal/testutil"
+	"cbs_backend/utils/cache"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// TestSetSlotGridsSkipsInvalidatedDays: lưới tính từ dữ liệu đọc trước một lần xoá không được ghi đè lần xoá đó
+func TestSetSlotGridsSkipsInvalidatedDays(t *testing.T) {
+	client := testutil.OpenRedis(t)
+	bookingCache := cache.NewRedisBookingCache(cache.NewRedisCache(client), zap.NewNop())
+	ctx := context.Background()
+	expertID := uuid.NewString()
+	days := []string{"2030-01-01", "2030-01-02"}
+
+	tests := []struct {
+		name       string
+		invalidate func() error
+		wantCached []string
+	}{
+		{"no invalidation", func() error { return nil }, days},
+		{"one day invalidated", func() error { return bookingCache.InvalidateSlotGrids(ctx, expertID, days[:1]) }, days[1:]},
+		{"all days of expert invalidated", func() error { return bookingCache.InvalidateSlotGrids(ctx, expertID, nil) }, nil},
+		{"all experts invalidated", func() error { return bookingCache.InvalidateSlotGrids(ctx, "", days) }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := bookingCache.InvalidateSlotGrids(ctx, expertID, nil); err != nil {
+				t.Fatalf("InvalidateSlotGrids: %v", err)
+			}
+			_, generations, err := bookingCache.GetSlotGrids(ctx, expertID, days, 60)
+			if err != nil {
+				t.Fatalf("GetSlotGrids: %v", err)
+			}
+
+			// Lịch đổi trong lúc request đang tính lưới
+			if err := tt.invalidate(); err != nil {
+				t.Fatalf("invalidate: %v", err)
+			}
+
+			grids := map[string]interface{}{days[0]: []string{"stale"}, days[1]: []string{"stale"}}
+			if err := bookingCache.SetSlotGrids(ctx, expertID, 60, grids, generations, time.Minute); err != nil {
+				t.Fatalf("SetSlotGrids: %v", err)
+			}
+			cached, _, err := bookingCache.GetSlotGrids(ctx, expertID, days, 60)
+			if err != nil {
+				t.Fatalf("GetSlotGrids: %v", err)
+			}
+			if len(cached) != len(tt.wantCached) {
+				t.Fatalf("cached %d days, want %v", len(cached), tt.wantCached)
+			}
+			for _, day := range tt.wantCached {
+				if _, ok := cached[day]; !ok {
+					t.Errorf("day %s was not cached", day)
+				}
+			}
+		})
+	}
+}
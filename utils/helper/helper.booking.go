@@ -3,6 +3,7 @@ package helper
 import (
 	"cbs_backend/internal/modules/bookings/dtobookings"
 	entityBooking "cbs_backend/internal/modules/bookings/entity"
+	entityExpert "cbs_backend/internal/modules/experts/entity"
 	"context"
 	"fmt"
 	"time"
@@ -23,11 +24,17 @@ func NewHelperBooking(db *gorm.DB) *HelperBooking {
 	}
 }
 
-func (hb *HelperBooking) CheckExpertAvailabilityDB(ctx context.Context, expertID string, startTime, endTime time.Time) (int64, error) {
+// excludeBookingIDs bỏ qua chính các booking đang được dời lịch để không tự xung đột với khung giờ cũ
+func (hb *HelperBooking) CheckExpertAvailabilityDB(ctx context.Context, expertID string, startTime, endTime time.Time, excludeBookingIDs ...uuid.UUID) (int64, error) {
 	var count int64
-	err := hb.db.WithContext(ctx).Model(&entityBooking.ConsultationBooking{}).
+	query := hb.db.WithContext(ctx).Model(&entityBooking.ConsultationBooking{})
+	if len(excludeBookingIDs) > 0 {
+		query = query.Where("booking_id NOT IN ?", excludeBookingIDs)
+	}
+	err := query.
 		Where(`
 			expert_profile_id = ?
+			AND group_session_id IS NULL
 			AND booking_status NOT IN (?)
 			AND (
 				(booking_datetime <= ? AND booking_datetime + (duration_minutes || ' minutes')::interval > ?)
@@ -36,17 +43,56 @@ func (hb *HelperBooking) CheckExpertAvailabilityDB(ctx context.Context, expertID
 			)
 		`,
 			expertID,
-			[]string{"cancelled", "completed"},
+			[]string{"cancelled", "completed", "rejected"}, // slot của booking bị từ chối được trả lại cho người khác/waitlist
 			startTime, startTime, endTime, endTime).
 		Count(&count).Error
+	if err != nil {
+		return count, err
+	}
+
+	// Chỗ ngồi trong buổi nhóm không chiếm lịch riêng, thay vào đó cả buổi nhóm chiếm lịch của expert
+	var sessions int64
+	err = hb.db.WithContext(ctx).
+		Model(&entityBooking.GroupSession{}).
+		Where("expert_profile_id = ? AND session_status = ?", expertID, "scheduled").
+		Where("session_start < ? AND session_start + (duration_minutes || ' minutes')::interval > ?", endTime, startTime).
+		Count(&sessions).Error
+
+	return count + sessions, err
+}
 
-	return count, err
+// CountExpertSessionsBetween đếm số buổi của expert bắt đầu trong [from, to): booking riêng còn hiệu lực hoặc đã hoàn thành
+// và buổi nhóm (mỗi buổi nhóm tính là một buổi dù có nhiều người tham dự)
+func (hb *HelperBooking) CountExpertSessionsBetween(ctx context.Context, expertID string, from, to time.Time, excludeBookingIDs ...uuid.UUID) (int64, error) {
+	var bookings int64
+	query := hb.db.WithContext(ctx).Model(&entityBooking.ConsultationBooking{})
+	if len(excludeBookingIDs) > 0 {
+		query = query.Where("booking_id NOT IN ?", excludeBookingIDs)
+	}
+	if err := query.
+		Where("expert_profile_id = ? AND group_session_id IS NULL AND booking_status IN (?) AND booking_datetime >= ? AND booking_datetime < ?",
+			expertID, []string{"pending", "confirmed", "completed"}, from, to).
+		Count(&bookings).Error; err != nil {
+		return 0, err
+	}
+
+	var sessions int64
+	err := hb.db.WithContext(ctx).
+		Model(&entityBooking.GroupSession{}).
+		Where("expert_profile_id = ? AND session_status IN (?) AND session_start >= ? AND session_start < ?",
+			expertID, []string{"scheduled", "completed"}, from, to).
+		Count(&sessions).Error
+	return bookings + sessions, err
 }
 
 // CheckUserConflictDB kiểm tra conflict booking của user trong database
-func (hb *HelperBooking) CheckUserConflictDB(ctx context.Context, userID string, startTime, endTime time.Time) (bool, error) {
+func (hb *HelperBooking) CheckUserConflictDB(ctx context.Context, userID string, startTime, endTime time.Time, excludeBookingIDs ...uuid.UUID) (bool, error) {
 	var count int64
-	err := hb.db.WithContext(ctx).Model(&entityBooking.ConsultationBooking{}).
+	query := hb.db.WithContext(ctx).Model(&entityBooking.ConsultationBooking{})
+	if len(excludeBookingIDs) > 0 {
+		query = query.Where("booking_id NOT IN ?", excludeBookingIDs)
+	}
+	err := query.
 		Where(`
 			user_id = ? 
 			AND booking_status NOT IN (?) 
@@ -113,49 +159,68 @@ func (hb *HelperBooking) IsTimeSlotAvailable(ctx context.Context, expertID strin
 	return count == 0, nil
 }
 
-func (hb *HelperBooking) GenerateAvailableSlots(
-	workingHours []dtobookings.WorkingHourRow,
+// FilterBookableSlots giữ các slot bắt đầu trong [windowStart, windowEnd) và trong khoảng [now + MinNotice, now + MaxAdvance]
+func FilterBookableSlots(slots []dtobookings.TimeSlot, windowStart, windowEnd time.Time, rules entityExpert.BookingRules, now time.Time) []dtobookings.TimeSlot {
+	earliest := now.Add(rules.MinNotice)
+	latest := now.Add(rules.MaxAdvance)
+	result := make([]dtobookings.TimeSlot, 0, len(slots))
+	for _, slot := range slots {
+		if slot.StartTime.Before(windowStart) || !slot.StartTime.Before(windowEnd) || slot.StartTime.Before(earliest) || slot.StartTime.After(latest) {
+			continue
+		}
+		result = append(result, slot)
+	}
+	return result
+}
+
+// GenerateSlotGrid sinh lưới slot trống của các ngày giao với [windowStart, windowEnd) theo giờ làm việc và BookingRules
+// của expert: các slot cách nhau đúng buffer sau + buffer trước, booking có sẵn được nới thêm buffer khi so trùng,
+// bỏ qua ngày đã đủ MaxSessionsPerDay. Giờ làm việc được dựng theo múi giờ của expert (rules.Location) từng ngày một
+// nên slot luôn đúng giờ địa phương kể cả ngày chuyển giờ mùa hè/đông.
+// Lưới không lọc theo thời điểm hiện tại (việc đó của FilterBookableSlots), nên lưới của một ngày dùng lại được (cache)
+// cho tới khi lịch của expert đổi.
+func (hb *HelperBooking) GenerateSlotGrid(
+	workingSchedule dtobookings.WorkingSchedule,
 	existingBookings []entityBooking.ConsultationBooking,
 	unavailableTimes []dtobookings.UnavailableTime,
-	fromDate, toDate time.Time,
+	windowStart, windowEnd time.Time,
 	slotDuration int,
+	rules entityExpert.BookingRules,
 ) []dtobookings.TimeSlot {
 	var slots []dtobookings.TimeSlot
 	slotDurationTime := time.Duration(slotDuration) * time.Minute
+	padding := rules.Padding()
 
-	// Loop through each day from fromDate to toDate (inclusive)
-	for d := fromDate; !d.After(toDate); d = d.Add(24 * time.Hour) {
-		dayOfWeek := int(d.Weekday())
-
-		// Find working hours for this day
-		var dayWorkingHours []dtobookings.WorkingHourRow
-		for _, wh := range workingHours {
-			if wh.DayOfWeek == dayOfWeek {
-				dayWorkingHours = append(dayWorkingHours, wh)
-			}
+	// Duyệt từng ngày theo lịch của expert; AddDate giữ 00:00 địa phương kể cả ngày dài 23h/25h
+	for d := rules.DayStart(windowStart); d.Before(windowEnd); d = d.AddDate(0, 0, 1) {
+		// Ngày đã đủ số buổi tối đa thì không còn slot
+		if rules.MaxSessionsPerDay > 0 && countSessionsOnDay(existingBookings, d) >= rules.MaxSessionsPerDay {
+			continue
 		}
 
-		// Generate slots for each working hour period
-		for _, wh := range dayWorkingHours {
+		// Generate slots for each working hour period of this day (giờ riêng của ngày ưu tiên hơn lịch tuần)
+		workingHours, _ := workingSchedule.On(d)
+		for _, wh := range workingHours {
 			// Convert TimeOfDay to full datetime
 			startDateTime := wh.StartTime.ToTime(d)
 			endDateTime := wh.EndTime.ToTime(d)
 
 			// Generate slots within working hours
-			for slotStart := startDateTime; slotStart.Add(slotDurationTime).Before(endDateTime) || slotStart.Add(slotDurationTime).Equal(endDateTime); slotStart = slotStart.Add(slotDurationTime) {
+			for slotStart := startDateTime; slotStart.Add(slotDurationTime).Before(endDateTime) || slotStart.Add(slotDurationTime).Equal(endDateTime); slotStart = slotStart.Add(slotDurationTime + padding) {
 				slotEnd := slotStart.Add(slotDurationTime)
 
-				// Skip past slots with 15-minute buffer
-				if slotStart.Before(time.Now().Add(15 * time.Minute)) {
+				// Skip slots outside the requested window
+				if slotStart.Before(windowStart) || !slotStart.Before(windowEnd) {
 					continue
 				}
 
-				// Check conflict with existing bookings
+				// Check conflict with existing bookings (kể cả buffer quanh mỗi buổi)
 				isConflict := false
 				for _, booking := range existingBookings {
-					bookingEnd := booking.BookingDatetime.Add(time.Duration(booking.DurationMinutes) * time.Minute)
+					bookingStart := booking.BookingDatetime.Add(-padding)
+					bookingEnd := booking.BookingDatetime.Add(time.Duration(booking.DurationMinutes)*time.Minute + padding)
 					// Check if slot overlaps with booking
-					if slotStart.Before(bookingEnd) && slotEnd.After(booking.BookingDatetime) {
+					if slotStart.Before(bookingEnd) && slotEnd.After(bookingStart) {
 						isConflict = true
 						break
 					}
@@ -188,6 +253,19 @@ func (hb *HelperBooking) GenerateAvailableSlots(
 	return slots
 }
 
+// countSessionsOnDay đếm số buổi trong existingBookings bắt đầu cùng ngày với day
+func countSessionsOnDay(bookings []entityBooking.ConsultationBooking, day time.Time) int {
+	y, m, d := day.Date()
+	count := 0
+	for _, booking := range bookings {
+		by, bm, bd := booking.BookingDatetime.In(day.Location()).Date()
+		if by == y && bm == m && bd == d {
+			count++
+		}
+	}
+	return count
+}
+
 func (hb *HelperBooking) updateExpertRating(tx *gorm.DB, expertProfileID uuid.UUID) error {
 	var avgRating float64
 	var totalReviews int64
@@ -212,43 +290,33 @@ func (hb *HelperBooking) updateExpertRating(tx *gorm.DB, expertProfileID uuid.UU
 		}).Error
 }
 
-// Helper function để log status change (có thể dùng cho các functions khác)
-func (hb *HelperBooking) logStatusChange(ctx context.Context, bookingID uuid.UUID, oldStatus, newStatus string, changedByUserID uuid.UUID, reason string) error {
-	statusHistory := struct {
-		BookingID       uuid.UUID `gorm:"column:booking_id"`
-		OldStatus       *string   `gorm:"column:old_status"`
-		NewStatus       string    `gorm:"column:new_status"`
-		ChangedByUserID uuid.UUID `gorm:"column:changed_by_user_id"`
-		ChangeReason    *string   `gorm:"column:change_reason"`
-		StatusChangedAt time.Time `gorm:"column:status_changed_at"`
-	}{
-		BookingID:       bookingID,
-		NewStatus:       newStatus,
-		ChangedByUserID: changedByUserID,
-		StatusChangedAt: time.Now(),
-	}
-
-	if oldStatus != "" {
-		statusHistory.OldStatus = &oldStatus
-	}
+// Thêm vào helper booking
 
-	if reason != "" {
-		statusHistory.ChangeReason = &reason
+// CheckSlotHeldByOthers kiểm tra slot có đang được giữ cho người khác trong waitlist không (hold active, chưa hết hạn)
+func (h *HelperBooking) CheckSlotHeldByOthers(ctx context.Context, expertID, userID string, startTime, endTime time.Time) (bool, error) {
+	var count int64
+	query := h.db.WithContext(ctx).
+		Model(&entityBooking.SlotHold{}).
+		Where("expert_profile_id = ? AND hold_status = ? AND expires_at > ?", expertID, "active", time.Now()).
+		Where("slot_start < ? AND slot_start + (duration_minutes || ' minutes')::interval > ?", endTime, startTime)
+	if userID != "" {
+		query = query.Where("user_id <> ?", userID)
 	}
-
-	return hb.db.WithContext(ctx).Table("tbl_booking_status_history").Create(&statusHistory).Error
+	err := query.Count(&count).Error
+	return count > 0, err
 }
 
-// Thêm vào helper booking
-
 // CheckDuplicateBooking kiểm tra xem user đã có booking với expert trong cùng thời gian chưa
-func (h *HelperBooking) CheckDuplicateBooking(ctx context.Context, userID, expertID string, startTime, endTime time.Time) (bool, error) {
+func (h *HelperBooking) CheckDuplicateBooking(ctx context.Context, userID, expertID string, startTime, endTime time.Time, excludeBookingIDs ...uuid.UUID) (bool, error) {
 	var count int64
-	err := h.db.WithContext(ctx).
+	query := h.db.WithContext(ctx).
 		Model(&entityBooking.ConsultationBooking{}).
 		Where("user_id = ? AND expert_profile_id = ? AND booking_datetime >= ? AND booking_datetime < ? AND booking_status IN (?)",
-			userID, expertID, startTime, endTime, []string{"pending", "confirmed"}).
-		Count(&count).Error
+			userID, expertID, startTime, endTime, []string{"pending", "confirmed"})
+	if len(excludeBookingIDs) > 0 {
+		query = query.Where("booking_id NOT IN ?", excludeBookingIDs)
+	}
+	err := query.Count(&count).Error
 
 	if err != nil {
 		return false, err
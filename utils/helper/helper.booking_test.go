@@ -0,0 +1,168 @@
+package helper
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"time"
+	_ "time/tzdata"
+
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	entityExpert "cbs_backend/internal/modules/experts/entity"
+)
+
+var slotGridTestZones = []string{"UTC", "Asia/Ho_Chi_Minh", "America/New_York", "Europe/London", "Australia/Sydney", "Pacific/Apia", "Pacific/Kiritimati", "America/St_Johns"}
+
+// slotGridCase là một lịch làm việc, múi giờ và cửa sổ ngày sinh ngẫu nhiên
+type slotGridCase struct {
+	schedule     dtobookings.WorkingSchedule
+	rules        entityExpert.BookingRules
+	windowStart  time.Time
+	windowEnd    time.Time
+	slotDuration int
+}
+
+func randomSlotGridCase(t *testing.T, r *rand.Rand) slotGridCase {
+	loc, err := time.LoadLocation(slotGridTestZones[r.Intn(len(slotGridTestZones))])
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+
+	var c slotGridCase
+	for i := r.Intn(10); i > 0; i-- {
+		start := r.Intn(23*60 - 15)
+		end := start + 15 + r.Intn(23*60+59-start-15)
+		c.schedule.Weekly = append(c.schedule.Weekly, dtobookings.WorkingHourRow{
+			DayOfWeek: common.Weekday(r.Intn(7)),
+			StartTime: dtobookings.TimeOfDay{Hour: start / 60, Minute: start % 60},
+			EndTime:   dtobookings.TimeOfDay{Hour: end / 60, Minute: end % 60},
+		})
+	}
+
+	// Ngày bất kỳ trong 2000..2040, gồm cả các ngày chuyển giờ mùa hè/đông
+	day := time.Date(2000, 1, 1, 0, 0, 0, 0, loc).AddDate(0, 0, r.Intn(41*365))
+	for i := r.Intn(3); i > 0; i-- {
+		start := r.Intn(22 * 60)
+		end := start + 30 + r.Intn(23*60+59-start-30)
+		c.schedule.Overrides = append(c.schedule.Overrides, dtobookings.WorkingHourOverrideRow{
+			OverrideDate: day.AddDate(0, 0, r.Intn(7)),
+			IsDayOff:     r.Intn(3) == 0,
+			StartTime:    dtobookings.TimeOfDay{Hour: start / 60, Minute: start % 60},
+			EndTime:      dtobookings.TimeOfDay{Hour: end / 60, Minute: end % 60},
+		})
+	}
+
+	c.rules = entityExpert.BookingRules{
+		BufferBefore: time.Duration(r.Intn(3)*5) * time.Minute,
+		BufferAfter:  time.Duration(r.Intn(3)*5) * time.Minute,
+		Location:     loc,
+	}
+	c.windowStart = c.rules.DayStart(day)
+	c.windowEnd = c.windowStart.AddDate(0, 0, 1+r.Intn(7))
+	c.slotDuration = 15 * (1 + r.Intn(8))
+	return c
+}
+
+// TestSlotGridAgreesWithWorkingSchedule kiểm tra lưới slot (GenerateSlotGrid) và bước kiểm tra khi đặt lịch
+// (WorkingSchedule.Covers, dùng bởi expertWorksDuring) luôn cho cùng kết quả, trên ngày và múi giờ ngẫu nhiên.
+func TestSlotGridAgreesWithWorkingSchedule(t *testing.T) {
+	hb := NewHelperBooking(nil)
+	property := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		c := randomSlotGridCase(t, r)
+		loc := c.rules.Location
+		slotDuration := time.Duration(c.slotDuration) * time.Minute
+		grid := hb.GenerateSlotGrid(c.schedule, nil, nil, c.windowStart, c.windowEnd, c.slotDuration, c.rules)
+
+		inGrid := make(map[int64]bool, len(grid))
+		for _, slot := range grid {
+			start, end := slot.StartTime.In(loc), slot.EndTime.In(loc)
+			inGrid[start.UnixNano()] = true
+
+			// Mỗi slot sinh ra phải qua được bước kiểm tra giờ làm việc khi đặt lịch
+			if !c.schedule.Covers(start, end) {
+				t.Logf("seed %d: slot %v-%v (%s) is not covered by the schedule", seed, start, end, loc)
+				return false
+			}
+			// Ngày trong tuần của slot theo múi giờ expert phải có khung giờ tương ứng
+			rows, overridden := c.schedule.On(start)
+			if !overridden && len(dtobookings.WorkingHoursOn(c.schedule.Weekly, start)) != len(rows) {
+				return false
+			}
+			for _, row := range rows {
+				if row.DayOfWeek != common.WeekdayOf(start) {
+					t.Logf("seed %d: slot %v has weekday %v, row has %v", seed, start, common.WeekdayOf(start), row.DayOfWeek)
+					return false
+				}
+			}
+		}
+
+		// Ngược lại: mọi mốc trên bước slot của từng khung giờ mà slot nằm gọn trong khung đó
+		// thì phải được Covers chấp nhận và có trong lưới
+		step := slotDuration + c.rules.Padding()
+		for d := c.windowStart; d.Before(c.windowEnd); d = d.AddDate(0, 0, 1) {
+			rows, _ := c.schedule.On(d)
+			for _, wh := range rows {
+				periodEnd := wh.EndTime.ToTime(d)
+				for start := wh.StartTime.ToTime(d); !start.Add(slotDuration).After(periodEnd); start = start.Add(step) {
+					if start.Before(c.windowStart) || !start.Before(c.windowEnd) {
+						continue
+					}
+					if !c.schedule.Covers(start, start.Add(slotDuration)) || !inGrid[start.UnixNano()] {
+						t.Logf("seed %d: %v (%s) fits a working period but covered=%v inGrid=%v",
+							seed, start, loc, c.schedule.Covers(start, start.Add(slotDuration)), inGrid[start.UnixNano()])
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSlotGridAcrossDSTTransitions cố định các ngày chuyển giờ: slot vẫn bắt đầu đúng giờ địa phương của khung làm việc
+func TestSlotGridAcrossDSTTransitions(t *testing.T) {
+	tests := []struct {
+		zone string
+		day  string
+	}{
+		{"America/New_York", "2024-03-10"},
+		{"America/New_York", "2024-11-03"},
+		{"Europe/London", "2024-03-31"},
+		{"Europe/London", "2024-10-27"},
+		{"Australia/Sydney", "2024-04-07"},
+		{"Australia/Sydney", "2024-10-06"},
+	}
+	hb := NewHelperBooking(nil)
+	for _, tt := range tests {
+		t.Run(tt.zone+" "+tt.day, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.zone)
+			if err != nil {
+				t.Fatalf("LoadLocation: %v", err)
+			}
+			day, _ := time.ParseInLocation("2006-01-02", tt.day, loc)
+			schedule := dtobookings.WorkingSchedule{Weekly: []dtobookings.WorkingHourRow{{
+				DayOfWeek: common.WeekdayOf(day),
+				StartTime: dtobookings.TimeOfDay{Hour: 9},
+				EndTime:   dtobookings.TimeOfDay{Hour: 12},
+			}}}
+			rules := entityExpert.BookingRules{Location: loc}
+			grid := hb.GenerateSlotGrid(schedule, nil, nil, day, day.AddDate(0, 0, 1), 60, rules)
+			if len(grid) != 3 {
+				t.Fatalf("got %d slots, want 3", len(grid))
+			}
+			for i, slot := range grid {
+				if got := slot.StartTime.In(loc).Hour(); got != 9+i {
+					t.Errorf("slot %d starts at %02d:00 local, want %02d:00", i, got, 9+i)
+				}
+				if !schedule.Covers(slot.StartTime.In(loc), slot.EndTime.In(loc)) {
+					t.Errorf("slot %d is not covered by the schedule", i)
+				}
+			}
+		})
+	}
+}
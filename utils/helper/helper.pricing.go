@@ -0,0 +1,84 @@
+package helper
+
+import (
+	"cbs_backend/internal/common"
+	"cbs_backend/internal/modules/bookings/dtobookings"
+	entityExpert "cbs_backend/internal/modules/experts/entity"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalculateConsultationFee tính phí tư vấn phía server.
+// Ưu tiên PricingConfig đang active khớp consultation_type + duration_minutes và còn hiệu lực tại thời điểm `at`
+// (cấu hình riêng của expert được ưu tiên hơn cấu hình chung), sau đó áp dụng DiscountPercentage.
+// Không có cấu hình phù hợp thì dùng ExpertProfile.ConsultationFeeMinor. Báo giá dùng tiền tệ của nguồn giá.
+func (hb *HelperBooking) CalculateConsultationFee(
+	ctx context.Context,
+	expertID uuid.UUID,
+	consultationType string,
+	durationMinutes int,
+	at time.Time,
+) (*dtobookings.PriceQuote, error) {
+	var pricing entityExpert.PricingConfig
+	err := hb.db.WithContext(ctx).
+		Where("(expert_profile_id = ? OR expert_profile_id IS NULL)", expertID).
+		Where("consultation_type = ? AND duration_minutes = ? AND is_active = true", consultationType, durationMinutes).
+		Where("valid_from <= ? AND (valid_until IS NULL OR valid_until >= ?)", at, at).
+		Order("expert_profile_id IS NULL ASC, valid_from DESC").
+		First(&pricing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get pricing config: %w", err)
+	}
+
+	quote := &dtobookings.PriceQuote{
+		ConsultationType: consultationType,
+		DurationMinutes:  durationMinutes,
+	}
+
+	// Tính toán trên đơn vị nhỏ nhất để không lệch tiền do làm tròn số thực
+	var baseMinor, discountMinor int64
+	if err == nil {
+		quote.Source = dtobookings.PriceSourcePricingConfig
+		quote.PricingID = pricing.PricingID.String()
+		quote.Currency = common.NormalizeCurrency(pricing.Currency)
+		baseMinor = pricing.BasePriceMinor
+		if pricing.DiscountPercentage > 0 {
+			discount := math.Min(pricing.DiscountPercentage, 100)
+			quote.DiscountPercentage = discount
+			discountMinor = int64(math.Round(float64(baseMinor) * discount / 100))
+		}
+	} else {
+		var expert entityExpert.ExpertProfile
+		if err := hb.db.WithContext(ctx).Select("expert_profile_id", "consultation_fee_minor", "currency").
+			First(&expert, "expert_profile_id = ?", expertID).Error; err != nil {
+			return nil, fmt.Errorf("expert not found")
+		}
+		if expert.ConsultationFeeMinor == nil || *expert.ConsultationFeeMinor <= 0 {
+			return nil, fmt.Errorf("no pricing available for %s consultation of %d minutes", consultationType, durationMinutes)
+		}
+		quote.Source = dtobookings.PriceSourceExpertProfile
+		quote.Currency = common.NormalizeCurrency(expert.Currency)
+		baseMinor = *expert.ConsultationFeeMinor
+	}
+
+	quote.TotalAmountMinor = baseMinor - discountMinor
+	quote.BasePrice = common.FromMinorUnits(baseMinor, quote.Currency)
+	quote.DiscountAmount = common.FromMinorUnits(discountMinor, quote.Currency)
+	quote.TotalAmount = common.FromMinorUnits(quote.TotalAmountMinor, quote.Currency)
+	quote.Items = []dtobookings.PriceQuoteItem{
+		{Label: fmt.Sprintf("Phí tư vấn %s %d phút", consultationType, durationMinutes), Amount: quote.BasePrice},
+	}
+	if quote.DiscountAmount > 0 {
+		quote.Items = append(quote.Items, dtobookings.PriceQuoteItem{
+			Label:  fmt.Sprintf("Giảm giá %.2f%%", quote.DiscountPercentage),
+			Amount: -quote.DiscountAmount,
+		})
+	}
+	return quote, nil
+}